@@ -120,9 +120,16 @@ type InitArgs struct {
 	Force   bool
 }
 
+func (pa *PluginsRequiredArgs) AddFlagSets(flags *flag.FlagSet) {
+	flags.BoolVar(&pa.PruneUnreferenced, "prune-unreferenced", false, "remove the installed plugins no requirement matches.")
+
+	pa.MetaArgs.AddFlagSets(flags)
+}
+
 // PluginsRequiredArgs represents a parsed cli line for a `packer plugins required <path>`
 type PluginsRequiredArgs struct {
 	MetaArgs
+	PruneUnreferenced bool
 }
 
 // ConsoleArgs represents a parsed cli line for a `packer console`
@@ -15,8 +15,6 @@ import (
 	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
 	"github.com/hashicorp/packer/packer"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
-	"github.com/hashicorp/packer/packer/plugin-getter/github"
-	"github.com/hashicorp/packer/version"
 	"github.com/posener/complete"
 )
 
@@ -75,8 +73,17 @@ https://www.packer.io/docs/templates/hcl_templates/blocks/packer
 for more info.`)
 	}
 
+	// A plugin required in several required_plugins blocks is installed once,
+	// at a version satisfying all of them.
+	reqs, err := plugingetter.MergeRequirements(reqs)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
 	opts := plugingetter.ListInstallationsOptions{
 		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		FromFolders:     c.Meta.CoreConfig.Components.PluginConfig.FromFolders,
 		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
 			OS:              runtime.GOOS,
 			ARCH:            runtime.GOARCH,
@@ -94,19 +101,6 @@ for more info.`)
 
 	log.Printf("[TRACE] init: %#v", opts)
 
-	getters := []plugingetter.Getter{
-		&github.Getter{
-			// In the past some terraform plugins downloads were blocked from a
-			// specific aws region by s3. Changing the user agent unblocked the
-			// downloads so having one user agent per version will help mitigate
-			// that a little more. Especially in the case someone forks this
-			// code to make it more aggressive or something.
-			// TODO: allow to set this from the config file or an environment
-			// variable.
-			UserAgent: "packer-getter-github-" + version.String(),
-		},
-	}
-
 	ui := &packer.ColoredUi{
 		Color: packer.UiColorCyan,
 		Ui:    c.Ui,
@@ -121,7 +115,9 @@ for more info.`)
 			return 1
 		}
 
+		previousVersion := ""
 		if len(installs) > 0 {
+			previousVersion = installs[len(installs)-1].Version
 			if !cla.Force && !cla.Upgrade {
 				continue
 			}
@@ -131,25 +127,73 @@ for more info.`)
 			}
 		}
 
-		newInstall, err := pluginRequirement.InstallLatest(plugingetter.InstallOptions{
+		getters, err := c.Meta.PluginGettersFor(pluginRequirement.Identifier.String())
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		// Also installs the dependencies the plugin release may declare.
+		newInstalls, err := plugingetter.Requirements{pluginRequirement}.InstallAllContext(buildCtx, plugingetter.InstallOptions{
 			PluginDirectory:           opts.PluginDirectory,
 			BinaryInstallationOptions: opts.BinaryInstallationOptions,
 			Getters:                   getters,
 			Force:                     cla.Force,
+			ProvenanceVerifier:        c.Meta.ProvenanceVerifier(),
+			SigstoreVerifier:          c.Meta.SigstoreVerifier(),
+			TagSignatureVerifier:      c.Meta.TagSignatureVerifier(),
 		})
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf("Failed getting the %q plugin:", pluginRequirement.Identifier))
 			c.Ui.Error(err.Error())
+			if msg := noCompatibleVersionMessage(err); msg != "" {
+				c.Ui.Error(msg)
+			}
 			ret = 1
 		}
-		if newInstall != nil {
+		for i, newInstall := range newInstalls {
 			msg := fmt.Sprintf("Installed plugin %s %s in %q", pluginRequirement.Identifier, newInstall.Version, newInstall.BinaryPath)
+			if i > 0 {
+				msg = fmt.Sprintf("Installed dependency %s %s in %q", newInstall.Source, newInstall.Version, newInstall.BinaryPath)
+			}
 			ui.Say(msg)
+			if i == 0 && cla.Upgrade {
+				if notes := upgradeNotes(getters, pluginRequirement, previousVersion, newInstall.Version); notes != "" {
+					c.Ui.Say(notes)
+				}
+			}
 		}
 	}
 	return ret
 }
 
+// maxUpgradeNotes is the number of releases upgradeNotes shows the notes of.
+const maxUpgradeNotes = 5
+
+// upgradeNotes returns what changed when upgrading the plugin from the from
+// version to the to version: the release notes of every release since from,
+// newest first and up to maxUpgradeNotes of them, or an empty string when
+// there are none or nothing was upgraded.
+func upgradeNotes(getters []plugingetter.Getter, pr *plugingetter.Requirement, from, to string) string {
+	if from == "" || from == to {
+		return ""
+	}
+	releases := pr.UpgradeNotes(getters, from, to)
+	if len(releases) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changes of %s from %s to %s:", pr.Identifier, from, to)
+	for i, release := range releases {
+		if i == maxUpgradeNotes {
+			fmt.Fprintf(&b, "\n\n…and %d more", len(releases)-maxUpgradeNotes)
+			break
+		}
+		fmt.Fprintf(&b, "\n\n%s:\n%s", release.Version, release.Notes)
+	}
+	return b.String()
+}
+
 func (*InitCommand) Help() string {
 	helpText := `
 Usage: packer init [options] TEMPLATE
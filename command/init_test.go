@@ -7,7 +7,9 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"runtime"
@@ -18,6 +20,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/go-getter/v2"
 	"github.com/hashicorp/packer-plugin-sdk/acctest"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 	"golang.org/x/mod/sumdb/dirhash"
 )
 
@@ -374,3 +378,60 @@ func TestInitCmd(t *testing.T) {
 		})
 	}
 }
+
+// notesGetter only knows the releases of plugins and their notes.
+type notesGetter map[string]string
+
+func (g notesGetter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
+	if what != "releases" {
+		return nil, errors.New("not implemented")
+	}
+	var releases []string
+	for version := range g {
+		releases = append(releases, fmt.Sprintf(`{"version": %q}`, version))
+	}
+	return io.NopCloser(strings.NewReader("[" + strings.Join(releases, ",") + "]")), nil
+}
+
+func (g notesGetter) ReleaseNotes(pr *plugingetter.Requirement, version string) (string, error) {
+	return g[version], nil
+}
+
+func TestUpgradeNotes(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/hashicups")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &plugingetter.Requirement{Identifier: identifier}
+	getters := []plugingetter.Getter{notesGetter{
+		"v1.0.2": "* Fix ordering coffee.",
+		"v1.0.3": "* Add tea.",
+		"v1.0.4": "* Add cookies.",
+		"v1.0.5": "* Add milk.",
+		"v1.0.6": "* Add sugar.",
+		"v1.0.7": "* Add cups.",
+		"v1.0.8": "* Add spoons.",
+	}}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{"upgrade", "v1.0.1", "v1.0.2", "Changes of github.com/hashicorp/hashicups from v1.0.1 to v1.0.2:\n\nv1.0.2:\n* Fix ordering coffee."},
+		{"upgrade-several-releases", "v1.0.1", "v1.0.3", "Changes of github.com/hashicorp/hashicups from v1.0.1 to v1.0.3:\n\nv1.0.3:\n* Add tea.\n\nv1.0.2:\n* Fix ordering coffee."},
+		{"upgrade-many-releases", "v1.0.1", "v1.0.8", "Changes of github.com/hashicorp/hashicups from v1.0.1 to v1.0.8:" +
+			"\n\nv1.0.8:\n* Add spoons.\n\nv1.0.7:\n* Add cups.\n\nv1.0.6:\n* Add sugar.\n\nv1.0.5:\n* Add milk.\n\nv1.0.4:\n* Add cookies." +
+			"\n\n…and 2 more"},
+		{"first-install", "", "v1.0.2", ""},
+		{"reinstall", "v1.0.2", "v1.0.2", ""},
+		{"no-notes", "v1.0.0", "v1.0.1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upgradeNotes(getters, pr, tt.from, tt.to); got != tt.want {
+				t.Errorf("upgradeNotes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
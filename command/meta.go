@@ -80,6 +80,14 @@ func (m *Meta) FlagSet(n string) *flag.FlagSet {
 	return f
 }
 
+// QuietUi makes m.Ui drop informational messages, keeping errors, until the
+// returned function is called.
+func (m *Meta) QuietUi() (restore func()) {
+	ui := m.Ui
+	m.Ui = &packer.QuietUi{Ui: ui}
+	return func() { m.Ui = ui }
+}
+
 // ValidateFlags should be called after parsing flags to validate the
 // given flags
 func (m *Meta) ValidateFlags() error {
@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/hashicorp/packer/packer/plugin-getter/github"
+	"github.com/hashicorp/packer/packer/plugin-getter/ipfs"
+	"github.com/hashicorp/packer/packer/plugin-getter/pgp"
+	"github.com/hashicorp/packer/packer/plugin-getter/sigstore"
+	"github.com/hashicorp/packer/packer/plugin-getter/slsa"
+	pkrversion "github.com/hashicorp/packer/version"
+)
+
+// proxyEnvVars are the env vars Go's http package reads the proxy from.
+var proxyEnvVars = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"}
+
+// PluginGetters returns the getters used to download plugins, configured
+// from the plugin_getters section of the Packer config file.
+//
+// Env vars take precedence over the config file: a config file token or
+// proxy is only used when the matching env var is not set.
+func (m *Meta) PluginGetters() []plugingetter.Getter {
+	return []plugingetter.Getter{m.withContentStore(m.githubGetter())}
+}
+
+// GetterRegistry returns the registry inferring the getter of a plugin source
+// from its scheme or hostname.
+func (m *Meta) GetterRegistry() *plugingetter.GetterRegistry {
+	registry := &plugingetter.GetterRegistry{}
+	registry.RegisterHost("github.com", func(string) (plugingetter.Getter, error) {
+		return m.withContentStore(m.githubGetter()), nil
+	})
+	return registry
+}
+
+// PluginGettersFor returns the getters of the plugins of source: the one its
+// scheme or hostname is registered for in GetterRegistry, or PluginGetters
+// when none is.
+func (m *Meta) PluginGettersFor(source string) ([]plugingetter.Getter, error) {
+	getter, err := m.GetterRegistry().Resolve(source)
+	if errors.Is(err, plugingetter.ErrNoGetterForSource) {
+		return m.PluginGetters(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []plugingetter.Getter{getter}, nil
+}
+
+func (m *Meta) githubGetter() *github.Getter {
+	cfg := m.CoreConfig.Components.PluginConfig.Getters.GitHub
+
+	gh := &github.Getter{
+		// In the past some terraform plugins downloads were blocked from a
+		// specific aws region by s3. Changing the user agent unblocked the
+		// downloads so having one user agent per version will help mitigate
+		// that a little more. Especially in the case someone forks this
+		// code to make it more aggressive or something.
+		UserAgent:       "packer-getter-github-" + pkrversion.String(),
+		APIBaseURL:      cfg.APIBaseURL,
+		DownloadBaseURL: cfg.DownloadBaseURL,
+		CACertFile:      cfg.CACertFile,
+		MaxReleases:     cfg.MaxReleases,
+
+		ZipAssetTemplate:      cfg.ZipAssetTemplate,
+		ChecksumAssetTemplate: cfg.ChecksumAssetTemplate,
+		BinaryAssetTemplate:   cfg.BinaryAssetTemplate,
+
+		DisableHTTP2:        cfg.DisableHTTP2,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+
+		TLSMinVersion:   cfg.TLSMinVersion,
+		TLSCipherSuites: cfg.TLSCipherSuites,
+
+		MaxRetries: m.CoreConfig.Components.PluginConfig.Getters.MaxRetries,
+	}
+	gh.IdleConnTimeout = githubGetterDuration("idle_conn_timeout", cfg.IdleConnTimeout)
+	gh.MetadataTimeout = githubGetterDuration("metadata_timeout", cfg.MetadataTimeout)
+	gh.DownloadTimeout = githubGetterDuration("download_timeout", cfg.DownloadTimeout)
+	if cfg.UserAgent != "" {
+		gh.UserAgent = cfg.UserAgent
+	}
+	if os.Getenv("PACKER_GITHUB_API_TOKEN") == "" {
+		gh.Token = cfg.Token
+	}
+	if !anyEnvSet(proxyEnvVars) {
+		gh.ProxyURL = cfg.Proxy
+	}
+
+	return gh
+}
+
+// githubGetterDuration parses the duration of the name setting of the github
+// getter, zero when unset or invalid.
+func githubGetterDuration(name, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("[WARNING] ignoring invalid plugin_getters.github.%s %q: %s", name, value, err)
+		return 0
+	}
+	return d
+}
+
+// defaultIPFSGatewayURL is the gateway of a local IPFS node.
+const defaultIPFSGatewayURL = "http://127.0.0.1:8080/"
+
+// withContentStore returns a getter downloading zips from IPFS, falling back
+// to getter, when the plugin_getters.content_addressed section of the Packer
+// config file enables it. Otherwise getter is returned.
+func (m *Meta) withContentStore(getter plugingetter.Getter) plugingetter.Getter {
+	cfg := m.CoreConfig.Components.PluginConfig.Getters.ContentAddressed
+	if !cfg.Enabled {
+		return getter
+	}
+	gatewayURL := cfg.IPFSGatewayURL
+	if gatewayURL == "" {
+		gatewayURL = defaultIPFSGatewayURL
+	}
+	return &plugingetter.ContentAddressedGetter{
+		Store: &ipfs.Gateway{
+			BaseURL:    gatewayURL,
+			MaxRetries: m.CoreConfig.Components.PluginConfig.Getters.MaxRetries,
+		},
+		Getter: getter,
+	}
+}
+
+// ProvenanceVerifier returns the verifier of plugin provenance attestations
+// configured in the plugin_provenance section of the Packer config file, or
+// nil when it is not enabled.
+func (m *Meta) ProvenanceVerifier() plugingetter.ProvenanceVerifier {
+	cfg := m.CoreConfig.Components.PluginConfig.Provenance
+	if !cfg.Enabled {
+		return nil
+	}
+	return &slsa.Verifier{BuilderID: cfg.BuilderID}
+}
+
+// SigstoreVerifier returns the verifier of the keyless signatures of plugins
+// configured in the plugin_sigstore section of the Packer config file, or nil
+// when it is not enabled.
+func (m *Meta) SigstoreVerifier() plugingetter.SigstoreVerifier {
+	cfg := m.CoreConfig.Components.PluginConfig.Sigstore
+	if !cfg.Enabled {
+		return nil
+	}
+	return &sigstore.Verifier{
+		Identity:           cfg.Identity,
+		Issuer:             cfg.Issuer,
+		FulcioRootsFile:    cfg.FulcioRootsFile,
+		RekorPublicKeyFile: cfg.RekorPublicKeyFile,
+	}
+}
+
+// TagSignatureVerifier returns the verifier of the signatures of the git tags
+// of plugin releases configured in the plugin_tag_signature section of the
+// Packer config file, or nil when it is not enabled.
+func (m *Meta) TagSignatureVerifier() plugingetter.TagSignatureVerifier {
+	cfg := m.CoreConfig.Components.PluginConfig.TagSignature
+	if !cfg.Enabled {
+		return nil
+	}
+	return &pgp.Verifier{
+		PublicKeyFile:       cfg.PublicKeyFile,
+		KeyServer:           cfg.KeyServer,
+		Fingerprint:         cfg.Fingerprint,
+		TrustedFingerprints: cfg.TrustedFingerprints,
+	}
+}
+
+func anyEnvSet(names []string) bool {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/packer/packer"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/hashicorp/packer/packer/plugin-getter/github"
+	"github.com/hashicorp/packer/packer/plugin-getter/ipfs"
+	"github.com/hashicorp/packer/packer/plugin-getter/sigstore"
+	"github.com/hashicorp/packer/packer/plugin-getter/slsa"
+)
+
+func TestMeta_PluginGetters(t *testing.T) {
+	cfg := packer.GitHubGetterConfig{
+		Token:           "config-token",
+		APIBaseURL:      "https://github-api.mirror.internal/",
+		DownloadBaseURL: "https://github-releases.mirror.internal/",
+		Proxy:           "http://proxy.internal:3128",
+		CACertFile:      "/etc/ssl/internal-ca.pem",
+		UserAgent:       "internal-packer",
+		MaxReleases:     100,
+
+		DisableHTTP2:        true,
+		MaxIdleConns:        20,
+		MaxIdleConnsPerHost: 5,
+		IdleConnTimeout:     "30s",
+		MetadataTimeout:     "15s",
+		DownloadTimeout:     "10m",
+
+		TLSMinVersion:   "1.3",
+		TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want *github.Getter
+	}{
+		{
+			name: "from-config-file",
+			want: &github.Getter{
+				UserAgent:       "internal-packer",
+				Token:           "config-token",
+				APIBaseURL:      "https://github-api.mirror.internal/",
+				DownloadBaseURL: "https://github-releases.mirror.internal/",
+				ProxyURL:        "http://proxy.internal:3128",
+				CACertFile:      "/etc/ssl/internal-ca.pem",
+				MaxReleases:     100,
+
+				DisableHTTP2:        true,
+				MaxIdleConns:        20,
+				MaxIdleConnsPerHost: 5,
+				IdleConnTimeout:     30 * time.Second,
+				MetadataTimeout:     15 * time.Second,
+				DownloadTimeout:     10 * time.Minute,
+
+				TLSMinVersion:   "1.3",
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+
+				MaxRetries: 2,
+			},
+		},
+		{
+			name: "env-takes-precedence",
+			env: map[string]string{
+				"PACKER_GITHUB_API_TOKEN": "env-token",
+				"HTTPS_PROXY":             "http://env-proxy:3128",
+			},
+			want: &github.Getter{
+				UserAgent:       "internal-packer",
+				APIBaseURL:      "https://github-api.mirror.internal/",
+				DownloadBaseURL: "https://github-releases.mirror.internal/",
+				CACertFile:      "/etc/ssl/internal-ca.pem",
+				MaxReleases:     100,
+
+				DisableHTTP2:        true,
+				MaxIdleConns:        20,
+				MaxIdleConnsPerHost: 5,
+				IdleConnTimeout:     30 * time.Second,
+				MetadataTimeout:     15 * time.Second,
+				DownloadTimeout:     10 * time.Minute,
+
+				TLSMinVersion:   "1.3",
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+
+				MaxRetries: 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range append([]string{"PACKER_GITHUB_API_TOKEN"}, proxyEnvVars...) {
+				t.Setenv(name, tt.env[name])
+			}
+
+			meta := TestMetaFile(t)
+			meta.CoreConfig.Components.PluginConfig.Getters.GitHub = cfg
+			meta.CoreConfig.Components.PluginConfig.Getters.MaxRetries = 2
+
+			getters := meta.PluginGetters()
+			if len(getters) != 1 {
+				t.Fatalf("expected a single getter, got %d", len(getters))
+			}
+			got, ok := getters[0].(*github.Getter)
+			if !ok {
+				t.Fatalf("expected a github getter, got %T", getters[0])
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(github.Getter{})); diff != "" {
+				t.Errorf("unexpected getter: %s", diff)
+			}
+		})
+	}
+}
+
+func TestMeta_ProvenanceVerifier(t *testing.T) {
+	m := TestMetaFile(t)
+	if v := m.ProvenanceVerifier(); v != nil {
+		t.Errorf("expected no provenance verifier by default, got %#v", v)
+	}
+
+	m.CoreConfig.Components.PluginConfig.Provenance = packer.PluginProvenanceConfig{
+		Enabled:   true,
+		BuilderID: "https://example.com/builder",
+	}
+	want := &slsa.Verifier{BuilderID: "https://example.com/builder"}
+	if diff := cmp.Diff(want, m.ProvenanceVerifier()); diff != "" {
+		t.Errorf("unexpected provenance verifier: %s", diff)
+	}
+}
+
+func TestMeta_SigstoreVerifier(t *testing.T) {
+	m := TestMetaFile(t)
+	if v := m.SigstoreVerifier(); v != nil {
+		t.Errorf("expected no sigstore verifier by default, got %#v", v)
+	}
+
+	m.CoreConfig.Components.PluginConfig.Sigstore = packer.PluginSigstoreConfig{
+		Enabled:            true,
+		Identity:           "https://github.com/hashicorp/packer-plugin-amazon/.github/workflows/release.yml@refs/tags/v1.0.0",
+		Issuer:             "https://token.actions.githubusercontent.com",
+		FulcioRootsFile:    "/etc/sigstore/fulcio.pem",
+		RekorPublicKeyFile: "/etc/sigstore/rekor.pub",
+	}
+	want := &sigstore.Verifier{
+		Identity:           "https://github.com/hashicorp/packer-plugin-amazon/.github/workflows/release.yml@refs/tags/v1.0.0",
+		Issuer:             "https://token.actions.githubusercontent.com",
+		FulcioRootsFile:    "/etc/sigstore/fulcio.pem",
+		RekorPublicKeyFile: "/etc/sigstore/rekor.pub",
+	}
+	if diff := cmp.Diff(want, m.SigstoreVerifier(), cmpopts.IgnoreUnexported(sigstore.Verifier{})); diff != "" {
+		t.Errorf("unexpected sigstore verifier: %s", diff)
+	}
+}
+
+func TestMeta_PluginGettersFor(t *testing.T) {
+	m := TestMetaFile(t)
+
+	getters, err := m.PluginGettersFor("github.com/hashicorp/happycloud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(getters) != 1 {
+		t.Fatalf("expected the github getter only, got %#v", getters)
+	}
+	if _, ok := getters[0].(*github.Getter); !ok {
+		t.Errorf("expected a github getter, got %T", getters[0])
+	}
+
+	// sources without registered getter fall back to PluginGetters
+	getters, err = m.PluginGettersFor("example.com/hashicorp/happycloud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(getters) != len(m.PluginGetters()) {
+		t.Errorf("expected the default getters, got %#v", getters)
+	}
+}
+
+func TestMeta_PluginGetters_contentAddressed(t *testing.T) {
+	m := TestMetaFile(t)
+	if _, ok := m.PluginGetters()[0].(*github.Getter); !ok {
+		t.Fatalf("expected zips to be got from github by default, got %T", m.PluginGetters()[0])
+	}
+
+	m.CoreConfig.Components.PluginConfig.Getters.ContentAddressed = packer.ContentAddressedGetterConfig{
+		Enabled: true,
+	}
+	m.CoreConfig.Components.PluginConfig.Getters.MaxRetries = 2
+	for _, getters := range [][]plugingetter.Getter{m.PluginGetters(), mustPluginGettersFor(t, m, "github.com/hashicorp/happycloud")} {
+		got, ok := getters[0].(*plugingetter.ContentAddressedGetter)
+		if !ok {
+			t.Fatalf("expected a content addressed getter, got %T", getters[0])
+		}
+		if diff := cmp.Diff(&ipfs.Gateway{BaseURL: defaultIPFSGatewayURL, MaxRetries: 2}, got.Store); diff != "" {
+			t.Errorf("unexpected content store: %s", diff)
+		}
+		if _, ok := got.Getter.(*github.Getter); !ok {
+			t.Errorf("expected metadata to be got from github, got %T", got.Getter)
+		}
+	}
+}
+
+func mustPluginGettersFor(t *testing.T, m Meta, source string) []plugingetter.Getter {
+	getters, err := m.PluginGettersFor(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return getters
+}
@@ -4,8 +4,12 @@
 package command
 
 import (
+	"fmt"
 	"strings"
 
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 	"github.com/mitchellh/cli"
 )
 
@@ -33,3 +37,64 @@ Related but not under the "plugins" command :
 func (c *PluginsCommand) Run(args []string) int {
 	return cli.RunResultHelp
 }
+
+// checkPluginSourceArg rejects a plugin source argument that is obviously
+// wrong, before any file or remote plugin source is looked at.
+func checkPluginSourceArg(source string) error {
+	if strings.TrimSpace(source) == "" {
+		return fmt.Errorf("Invalid arguments: the plugin source cannot be empty, expected something like github.com/hashicorp/happycloud")
+	}
+	if strings.TrimSpace(source) != source {
+		return fmt.Errorf("Invalid arguments: the plugin source %q cannot start or end with spaces", source)
+	}
+	return nil
+}
+
+// isLatestVersionArg tells whether constraint is a keyword asking for the
+// newest compatible version, like no constraint at all: "latest" or "*".
+func isLatestVersionArg(constraint string) bool {
+	return constraint == "latest" || constraint == "*"
+}
+
+// checkVersionArg rejects an empty version constraint argument.
+func checkVersionArg(constraint string) error {
+	if strings.TrimSpace(constraint) == "" {
+		return fmt.Errorf("Invalid arguments: the version constraint cannot be empty, omit it to match every version or use something like \">= 1.2.3\"")
+	}
+	return nil
+}
+
+// writeMetricsTextfile writes the metrics observed by textfile. Failing to do
+// so is reported without failing the command.
+func writeMetricsTextfile(ui packersdk.Ui, textfile *plugingetter.PrometheusTextfile) {
+	if err := textfile.WriteFile(); err != nil {
+		ui.Error(fmt.Sprintf("failed to write the metrics textfile: %s", err))
+	}
+}
+
+// installProgresses notifies every one of its InstallProgress.
+type installProgresses []plugingetter.InstallProgress
+
+func (p installProgresses) ObserveInstall(result plugingetter.InstallResult) {
+	for _, progress := range p {
+		progress.ObserveInstall(result)
+	}
+}
+
+// uiLineWriter writes every line it is given as a message of Ui, bypassing
+// -quiet: machine readable output must not be dropped with the
+// informational messages.
+type uiLineWriter struct {
+	Ui packersdk.Ui
+}
+
+func (w uiLineWriter) Write(p []byte) (int, error) {
+	ui := w.Ui
+	if quiet, ok := ui.(*packer.QuietUi); ok {
+		ui = quiet.Ui
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		ui.Message(line)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/mitchellh/cli"
+)
+
+type PluginsCheckRemoteCommand struct {
+	Meta
+}
+
+func (c *PluginsCheckRemoteCommand) Synopsis() string {
+	return "Check installed Packer plugins still match their published release"
+}
+
+func (c *PluginsCheckRemoteCommand) Help() string {
+	helpText := `
+Usage: packer plugins check-remote [options] [<plugin> [<version constraint>]]
+
+  This command downloads the checksum file of the release of every installed
+  Packer plugin for the current OS and architecture, and reports the ones
+  whose zip is now published with another checksum than the one it was
+  installed with, ex: because the release was re-tagged. Zips are not
+  downloaded.
+  When the plugin is omitted all installed plugins are checked.
+
+  Ex: packer plugins check-remote github.com/hashicorp/happycloud v1.2.3
+
+Options:
+  -quiet                        Only output errors.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginsCheckRemoteCommand) Run(args []string) int {
+	ctx, cleanup := handleTermInterrupt(c.Ui)
+	defer cleanup()
+
+	flags := c.Meta.FlagSet("plugins check-remote")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	var quiet bool
+	flags.BoolVar(&quiet, "quiet", false, "only output errors.")
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse options: %s", err))
+		return 1
+	}
+	if quiet {
+		defer c.QuietUi()()
+	}
+
+	return c.RunContext(ctx, flags.Args())
+}
+
+func (c *PluginsCheckRemoteCommand) RunContext(buildCtx context.Context, args []string) int {
+	if len(args) > 2 {
+		return cli.RunResultHelp
+	}
+
+	opts := plugingetter.ListInstallationsOptions{
+		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			OS:              runtime.GOOS,
+			ARCH:            runtime.GOARCH,
+			APIVersionMajor: pluginsdk.APIVersionMajor,
+			APIVersionMinor: pluginsdk.APIVersionMinor,
+			Checksummers: []plugingetter.Checksummer{
+				{Type: "sha256", Hash: sha256.New()},
+			},
+		},
+	}
+	if runtime.GOOS == "windows" {
+		opts.BinaryInstallationOptions.Ext = ".exe"
+	}
+
+	getters := c.Meta.PluginGetters()
+	pluginRequirement := plugingetter.Requirement{}
+	if len(args) > 0 {
+		plugin, diags := c.Meta.CoreConfig.Components.PluginConfig.NamespaceHostnames.ParsePluginSourceString(args[0])
+		if diags.HasErrors() {
+			c.Ui.Error(diags.Error())
+			return 1
+		}
+		pluginRequirement.Identifier = plugin
+
+		var err error
+		getters, err = c.Meta.PluginGettersFor(plugin.String())
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+	if len(args) > 1 {
+		constraints, err := version.NewConstraint(args[1])
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		pluginRequirement.VersionConstraints = constraints
+	}
+
+	checks, err := pluginRequirement.CheckPublishedChecksums(opts, plugingetter.InstallOptions{
+		Getters:                   getters,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if len(checks) == 0 {
+		c.Ui.Message("No installed plugin found")
+		return 0
+	}
+
+	ret := 0
+	for _, check := range checks {
+		var drift *plugingetter.PublishedChecksumDriftError
+		switch {
+		case check.Err == nil:
+			c.Ui.Message(fmt.Sprintf("%s %s matches its published release", check.Identifier, check.Version))
+		case errors.As(check.Err, &drift):
+			c.Ui.Error(fmt.Sprintf("%s %s drifted: %s", check.Identifier, check.Version, drift))
+			ret = 1
+		case errors.Is(check.Err, plugingetter.ErrNoStoredZipChecksum):
+			c.Ui.Message(fmt.Sprintf("%s %s cannot be checked: %s", check.Identifier, check.Version, check.Err))
+		default:
+			c.Ui.Error(fmt.Sprintf("Failed to check %s %s: %s", check.Identifier, check.Version, check.Err))
+			ret = 1
+		}
+	}
+	return ret
+}
@@ -0,0 +1,42 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestPluginsCheckRemoteCommand_Run_notRecorded(t *testing.T) {
+	pluginDir := t.TempDir()
+	// installed without recording the checksum of its zip.
+	createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	c := &PluginsCheckRemoteCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"github.com/hashicorp/hashicups"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsCheckRemoteCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stdout, "github.com/hashicorp/hashicups v1.0.1 cannot be checked") {
+		t.Errorf("unexpected stdout: %s", stdout)
+	}
+}
+
+func TestPluginsCheckRemoteCommand_Run_invalidArgs(t *testing.T) {
+	c := &PluginsCheckRemoteCommand{
+		Meta: TestMetaFile(t),
+	}
+	if got := c.Run([]string{"github.com/hashicorp/hashicups", "v1.0.1", "extra"}); got != cli.RunResultHelp {
+		t.Errorf("PluginsCheckRemoteCommand.Run() = %d, want %d", got, cli.RunResultHelp)
+	}
+}
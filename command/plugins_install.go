@@ -8,6 +8,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -21,11 +22,10 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/packer-plugin-sdk/plugin"
 	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	sliceflag "github.com/hashicorp/packer/command/flag-slice"
 	"github.com/hashicorp/packer/hcl2template/addrs"
 	"github.com/hashicorp/packer/packer"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
-	"github.com/hashicorp/packer/packer/plugin-getter/github"
-	pkrversion "github.com/hashicorp/packer/version"
 )
 
 type PluginsInstallCommand struct {
@@ -42,10 +42,14 @@ Usage: packer plugins install [OPTIONS...] <plugin> [<version constraint>]
 
   This command will install the most recent compatible Packer plugin matching
   version constraint.
-  When the version constraint is omitted, the most recent version will be
-  installed.
+  When the version constraint is omitted, or is "latest" or "*", the most
+  recent compatible version will be installed.
+  A version of "commit:<sha>" installs the release tagged on that git commit
+  of the plugin sources; commits that were not released cannot be installed.
 
   Ex: packer plugins install github.com/hashicorp/happycloud v1.2.3
+      packer plugins install github.com/hashicorp/happycloud latest
+      packer plugins install github.com/hashicorp/happycloud commit:0a1b2c3
       packer plugins install --path ./packer-plugin-happycloud "github.com/hashicorp/happycloud"
 
 Options:
@@ -55,6 +59,29 @@ Options:
                                 install the binary in the Packer plugins path. This option cannot
                                 be specified with a version constraint.
   -force                        Forces reinstallation of plugins, even if already installed.
+  -fail-if-installed            Fail instead of doing nothing when a version matching the
+                                version constraint is already installed.
+  -max-version <version>        Never install a version higher than this one, even if the
+                                version constraint allows it.
+  -platform <os>/<arch>         Install the plugin for this platform instead of the current
+                                one. Can be repeated or comma separated to install for
+                                several platforms at once, ex: linux/amd64,darwin/arm64.
+  -skip-missing-platforms       With -platform, skip the platforms the plugin was not released
+                                for instead of failing, as long as one of them is installed.
+  -audit-log <path>             Append a JSON record of every installed plugin to this file.
+  -metrics-textfile <path>      Write the outcome of the install to this file in the Prometheus
+                                text format, ex: for the node exporter textfile collector.
+                                Counts add up to the ones already in the file.
+  -describe                     Once installed, start the plugin and print its version and
+                                the components it supports. Fails when the plugin cannot
+                                describe itself. Cannot be used with -platform.
+  -check-version                Once installed, start the plugin to make sure it reports the
+                                version its release is tagged with. Fails, removing the
+                                plugin, when it does not.
+  -json                         Output the result of every plugin install as a JSON line,
+                                ex: {"source":"github.com/hashicorp/happycloud","reason":"installed",...}.
+                                The JSON lines are output even with -quiet.
+  -quiet                        Only output errors.
 `
 
 	return strings.TrimSpace(helpText)
@@ -68,6 +95,9 @@ func (c *PluginsInstallCommand) Run(args []string) int {
 	if ret != 0 {
 		return ret
 	}
+	if cmdArgs.Quiet {
+		defer c.QuietUi()()
+	}
 
 	return c.RunContext(ctx, cmdArgs)
 }
@@ -77,15 +107,85 @@ type PluginsInstallArgs struct {
 	PluginIdentifier string
 	PluginPath       string
 	Version          string
+	Commit           string // SHA of a "commit:" version argument
+	MaxVersion       string
+	Platforms        []string
+	SkipMissing      bool
 	Force            bool
+	FailIfInstalled  bool
+	Describe         bool
+	CheckVersion     bool
+	AuditLogPath     string
+	MetricsTextfile  string
+	JSON             bool
+	Quiet            bool
 }
 
 func (pa *PluginsInstallArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.StringVar(&pa.PluginPath, "path", "", "install the binary specified by path as a Packer plugin.")
 	flags.BoolVar(&pa.Force, "force", false, "force installation of the specified plugin, even if already installed.")
+	flags.BoolVar(&pa.FailIfInstalled, "fail-if-installed", false, "fail if a version of the plugin matching the constraint is already installed.")
+	flags.StringVar(&pa.MaxVersion, "max-version", "", "highest version of the plugin that can be installed.")
+	flags.Var((*sliceflag.StringFlag)(&pa.Platforms), "platform", "os/arch platforms to install the plugin for.")
+	flags.BoolVar(&pa.SkipMissing, "skip-missing-platforms", false, "skip the platforms the plugin was not released for.")
+	flags.StringVar(&pa.AuditLogPath, "audit-log", "", "file to append a JSON record of every installed plugin to.")
+	flags.StringVar(&pa.MetricsTextfile, "metrics-textfile", "", "file to write the install metrics to, in the Prometheus text format.")
+	flags.BoolVar(&pa.Describe, "describe", false, "print the describe output of the installed plugin.")
+	flags.BoolVar(&pa.CheckVersion, "check-version", false, "fail when the installed plugin reports another version than its release.")
+	flags.BoolVar(&pa.JSON, "json", false, "output the result of every plugin install as a JSON line, even with -quiet.")
+	flags.BoolVar(&pa.Quiet, "quiet", false, "only output errors.")
 	pa.MetaArgs.AddFlagSets(flags)
 }
 
+// VersionConstraints returns the constraints the installed version must
+// match: the version constraint argument, capped by the max version if set.
+// The "latest" and "*" keywords, and "commit:" versions, which are resolved
+// by getters, do not constrain the version.
+func (pa *PluginsInstallArgs) VersionConstraints() (version.Constraints, error) {
+	var constraints version.Constraints
+	if pa.Version != "" && !isLatestVersionArg(pa.Version) && pa.Commit == "" {
+		cts, err := version.NewConstraint(pa.Version)
+		if err != nil {
+			return nil, err
+		}
+		constraints = cts
+	}
+
+	if pa.MaxVersion != "" {
+		maxVersion, err := version.NewVersion(pa.MaxVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max version %q: %s", pa.MaxVersion, err)
+		}
+		maxConstraint, err := version.NewConstraint("<= " + maxVersion.String())
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, maxConstraint...)
+	}
+
+	return constraints, nil
+}
+
+// PlatformOptions returns one copy of base per platform to install for, with
+// the OS, ARCH and Ext set from the platform.
+func (pa *PluginsInstallArgs) PlatformOptions(base plugingetter.BinaryInstallationOptions) ([]plugingetter.BinaryInstallationOptions, error) {
+	var platforms []plugingetter.BinaryInstallationOptions
+	for _, platform := range pa.Platforms {
+		parts := strings.Split(platform, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid platform %q, expected something like linux/amd64", platform)
+		}
+		opts := base
+		opts.OS, opts.ARCH = parts[0], parts[1]
+		opts.Ext = ""
+		if opts.OS == "windows" {
+			opts.Ext = ".exe"
+		}
+		platforms = append(platforms, opts)
+	}
+	return platforms, nil
+}
+
 func (c *PluginsInstallCommand) ParseArgs(args []string) (*PluginsInstallArgs, int) {
 	pa := &PluginsInstallArgs{}
 
@@ -105,7 +205,27 @@ func (c *PluginsInstallCommand) ParseArgs(args []string) (*PluginsInstallArgs, i
 		return pa, 1
 	}
 
+	if err := checkPluginSourceArg(args[0]); err != nil {
+		c.Ui.Error(err.Error())
+		return pa, 1
+	}
+
 	if len(args) == 2 {
+		if err := checkVersionArg(args[1]); err != nil {
+			c.Ui.Error(err.Error())
+			return pa, 1
+		}
+		sha, isCommit, err := plugingetter.ParseCommitVersion(args[1])
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid arguments: %s", err))
+			return pa, 1
+		}
+		if isCommit {
+			pa.Commit = sha
+		} else if _, err := version.NewConstraint(args[1]); err != nil && !isLatestVersionArg(args[1]) {
+			c.Ui.Error(fmt.Sprintf("Invalid arguments: %s. Expected a version constraint like \">= 1.2.3\" or \"v1.2.3\"", err))
+			return pa, 1
+		}
 		pa.Version = args[1]
 	}
 
@@ -115,6 +235,55 @@ func (c *PluginsInstallCommand) ParseArgs(args []string) (*PluginsInstallArgs, i
 		return pa, 1
 	}
 
+	if pa.PluginPath != "" && pa.MaxVersion != "" {
+		c.Ui.Error("Invalid arguments: a maximum version cannot be specified when using --path to install a local plugin binary")
+		flags.Usage()
+		return pa, 1
+	}
+
+	if pa.Force && pa.FailIfInstalled {
+		c.Ui.Error("Invalid arguments: --force and --fail-if-installed cannot be used together")
+		flags.Usage()
+		return pa, 1
+	}
+
+	if pa.PluginPath != "" && len(pa.Platforms) > 0 {
+		c.Ui.Error("Invalid arguments: platforms cannot be specified when using --path to install a local plugin binary")
+		flags.Usage()
+		return pa, 1
+	}
+
+	if pa.Describe && len(pa.Platforms) > 0 {
+		c.Ui.Error("Invalid arguments: --describe cannot be used with --platform, the plugin may not run on this platform")
+		flags.Usage()
+		return pa, 1
+	}
+
+	if pa.PluginPath != "" && pa.MetricsTextfile != "" {
+		c.Ui.Error("Invalid arguments: --metrics-textfile cannot be used with --path")
+		flags.Usage()
+		return pa, 1
+	}
+
+	if pa.PluginPath != "" && pa.JSON {
+		c.Ui.Error("Invalid arguments: --json cannot be used with --path")
+		flags.Usage()
+		return pa, 1
+	}
+
+	if pa.SkipMissing && len(pa.Platforms) == 0 {
+		c.Ui.Error("Invalid arguments: --skip-missing-platforms can only be used with --platform")
+		flags.Usage()
+		return pa, 1
+	}
+
+	if pa.MaxVersion != "" {
+		if _, err := version.NewVersion(pa.MaxVersion); err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid arguments: invalid max version %q: %s. Expected a version like \"1.2.3\"", pa.MaxVersion, err))
+			return pa, 1
+		}
+	}
+
 	pa.PluginIdentifier = args[0]
 	return pa, 0
 }
@@ -122,6 +291,7 @@ func (c *PluginsInstallCommand) ParseArgs(args []string) (*PluginsInstallArgs, i
 func (c *PluginsInstallCommand) RunContext(buildCtx context.Context, args *PluginsInstallArgs) int {
 	opts := plugingetter.ListInstallationsOptions{
 		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		FromFolders:     c.Meta.CoreConfig.Components.PluginConfig.FromFolders,
 		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
 			OS:              runtime.GOOS,
 			ARCH:            runtime.GOARCH,
@@ -136,7 +306,7 @@ func (c *PluginsInstallCommand) RunContext(buildCtx context.Context, args *Plugi
 		opts.BinaryInstallationOptions.Ext = ".exe"
 	}
 
-	plugin, diags := addrs.ParsePluginSourceString(args.PluginIdentifier)
+	plugin, diags := c.Meta.CoreConfig.Components.PluginConfig.NamespaceHostnames.ParsePluginSourceString(args.PluginIdentifier)
 	if diags.HasErrors() {
 		c.Ui.Error(diags.Error())
 		return 1
@@ -153,53 +323,174 @@ func (c *PluginsInstallCommand) RunContext(buildCtx context.Context, args *Plugi
 		Identifier: plugin,
 	}
 
-	if args.Version != "" {
-		constraints, err := version.NewConstraint(args.Version)
+	constraints, err := args.VersionConstraints()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	pluginRequirement.VersionConstraints = constraints
+	pluginRequirement.SkipMissingPlatforms = args.SkipMissing
+
+	getters, err := c.Meta.PluginGettersFor(pluginRequirement.Identifier.String())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if args.Commit != "" {
+		v, err := pluginRequirement.ResolveCommit(getters, args.Commit)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to resolve commit %s of %s: %s", args.Commit, pluginRequirement.Identifier, err))
+			return 1
+		}
+		c.Ui.Say(fmt.Sprintf("Commit %s of %s was released as %s", args.Commit, pluginRequirement.Identifier, v.Original()))
+		pinned, err := version.NewConstraint("= " + v.String())
 		if err != nil {
 			c.Ui.Error(err.Error())
 			return 1
 		}
-		pluginRequirement.VersionConstraints = constraints
-	}
-
-	getters := []plugingetter.Getter{
-		&github.Getter{
-			// In the past some terraform plugins downloads were blocked from a
-			// specific aws region by s3. Changing the user agent unblocked the
-			// downloads so having one user agent per version will help mitigate
-			// that a little more. Especially in the case someone forks this
-			// code to make it more aggressive or something.
-			// TODO: allow to set this from the config file or an environment
-			// variable.
-			UserAgent: "packer-getter-github-" + pkrversion.String(),
-		},
+		pluginRequirement.VersionConstraints = append(pluginRequirement.VersionConstraints, pinned...)
 	}
 
-	newInstall, err := pluginRequirement.InstallLatest(plugingetter.InstallOptions{
+	installOpts := plugingetter.InstallOptions{
 		PluginDirectory:           opts.PluginDirectory,
 		BinaryInstallationOptions: opts.BinaryInstallationOptions,
 		Getters:                   getters,
 		Force:                     args.Force,
-	})
+		FailIfInstalled:           args.FailIfInstalled,
+		ProvenanceVerifier:        c.Meta.ProvenanceVerifier(),
+		SigstoreVerifier:          c.Meta.SigstoreVerifier(),
+		TagSignatureVerifier:      c.Meta.TagSignatureVerifier(),
+		AuditLogPath:              args.AuditLogPath,
+	}
+	if args.CheckVersion {
+		installOpts.EmbeddedVersionCheck = plugingetter.EmbeddedVersionFail
+	}
+	var progress installProgresses
+	if args.MetricsTextfile != "" {
+		textfile := &plugingetter.PrometheusTextfile{Path: args.MetricsTextfile}
+		progress = append(progress, textfile)
+		defer writeMetricsTextfile(c.Ui, textfile)
+	}
+	if args.JSON {
+		progress = append(progress, &plugingetter.JSONLinesProgress{W: uiLineWriter{Ui: c.Ui}})
+	}
+	if len(progress) > 0 {
+		installOpts.Progress = progress
+	}
 
-	if err != nil {
-		c.Ui.Error(err.Error())
-		return 1
+	var newInstalls []*plugingetter.Installation
+	if len(args.Platforms) > 0 {
+		platforms, err := args.PlatformOptions(opts.BinaryInstallationOptions)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		newInstalls, err = pluginRequirement.InstallLatestForPlatformsContext(buildCtx, installOpts, platforms)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			if msg := noCompatibleVersionMessage(err); msg != "" {
+				c.Ui.Error(msg)
+			}
+			return 1
+		}
+	} else {
+		// Also installs the dependencies the plugin release may declare.
+		newInstalls, err = plugingetter.Requirements{&pluginRequirement}.InstallAllContext(buildCtx, installOpts)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			if msg := noCompatibleVersionMessage(err); msg != "" {
+				c.Ui.Error(msg)
+			}
+			return 1
+		}
 	}
 
-	if newInstall != nil {
+	ui := &packer.ColoredUi{
+		Color: packer.UiColorCyan,
+		Ui:    c.Ui,
+	}
+	for i, newInstall := range newInstalls {
 		msg := fmt.Sprintf("Installed plugin %s %s in %q", pluginRequirement.Identifier, newInstall.Version, newInstall.BinaryPath)
-		ui := &packer.ColoredUi{
-			Color: packer.UiColorCyan,
-			Ui:    c.Ui,
+		if i > 0 && len(args.Platforms) == 0 {
+			msg = fmt.Sprintf("Installed dependency %s %s in %q", newInstall.Source, newInstall.Version, newInstall.BinaryPath)
 		}
 		ui.Say(msg)
-		return 0
 	}
 
+	if args.Describe {
+		for _, newInstall := range newInstalls {
+			if ret := c.describeInstalled(newInstall.BinaryPath); ret != 0 {
+				return ret
+			}
+		}
+	}
+
+	return 0
+}
+
+// describeInstalled runs the describe command of an installed plugin binary
+// and prints what it supports.
+func (c *PluginsInstallCommand) describeInstalled(binaryPath string) int {
+	desc, err := describePlugin(binaryPath)
+	if err != nil {
+		return writeDiags(c.Ui, nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Installed plugin failed to start",
+			Detail:   fmt.Sprintf("The plugin installed in %q could not describe itself: %s", binaryPath, err),
+		}})
+	}
+
+	c.Ui.Say(fmt.Sprintf("%s: version %s, API version %s, SDK version %s", binaryPath, desc.Version, desc.APIVersion, desc.SDKVersion))
+	for _, components := range []struct {
+		kind  string
+		names []string
+	}{
+		{"builders", desc.Builders},
+		{"provisioners", desc.Provisioners},
+		{"post-processors", desc.PostProcessors},
+		{"data sources", desc.Datasources},
+	} {
+		if len(components.names) > 0 {
+			c.Ui.Say(fmt.Sprintf("  %s: %s", components.kind, strings.Join(components.names, ", ")))
+		}
+	}
 	return 0
 }
 
+// describePlugin runs the describe command of a plugin binary and decodes its
+// output.
+func describePlugin(binaryPath string) (plugin.SetDescription, error) {
+	var desc plugin.SetDescription
+	out, err := exec.Command(binaryPath, "describe").Output()
+	if err != nil {
+		return desc, fmt.Errorf("Packer failed to run %s describe: %s", binaryPath, err)
+	}
+	if err := json.Unmarshal(out, &desc); err != nil {
+		return desc, fmt.Errorf("'%s describe' produced information that Packer couldn't decode: %s", binaryPath, err)
+	}
+	return desc, nil
+}
+
+// noCompatibleVersionMessage explains what was released when err tells that
+// no version of a plugin is compatible with Packer, and returns an empty
+// string otherwise.
+func noCompatibleVersionMessage(err error) string {
+	var noCompatible *plugingetter.NoCompatibleVersionError
+	if !errors.As(err, &noCompatible) {
+		return ""
+	}
+
+	msg := &strings.Builder{}
+	fmt.Fprintf(msg, "No release of %s can be used by this version of Packer, which supports plugins with the protocol version %s on %s_%s.\n",
+		noCompatible.Plugin, noCompatible.APIVersion, noCompatible.OS, noCompatible.ARCH)
+	fmt.Fprintf(msg, "Available versions and their protocol versions:")
+	for _, release := range noCompatible.Releases {
+		fmt.Fprintf(msg, "\n  %s", release)
+	}
+	return msg.String()
+}
+
 func (c *PluginsInstallCommand) InstallFromBinary(opts plugingetter.ListInstallationsOptions, pluginIdentifier *addrs.Plugin, args *PluginsInstallArgs) int {
 	pluginDir := opts.PluginDirectory
 
@@ -231,21 +522,12 @@ func (c *PluginsInstallCommand) InstallFromBinary(opts plugingetter.ListInstalla
 		}})
 	}
 
-	describeCmd, err := exec.Command(args.PluginPath, "describe").Output()
+	desc, err := describePlugin(args.PluginPath)
 	if err != nil {
 		return writeDiags(c.Ui, nil, hcl.Diagnostics{&hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Failed to describe the plugin",
-			Detail:   fmt.Sprintf("Packer failed to run %s describe: %s", args.PluginPath, err),
-		}})
-	}
-
-	var desc plugin.SetDescription
-	if err := json.Unmarshal(describeCmd, &desc); err != nil {
-		return writeDiags(c.Ui, nil, hcl.Diagnostics{&hcl.Diagnostic{
-			Severity: hcl.DiagError,
-			Summary:  "Failed to decode plugin describe info",
-			Detail:   fmt.Sprintf("'%s describe' produced information that Packer couldn't decode: %s", args.PluginPath, err),
+			Detail:   err.Error(),
 		}})
 	}
 
@@ -311,6 +593,16 @@ func (c *PluginsInstallCommand) InstallFromBinary(opts plugingetter.ListInstalla
 		outputPrefix+opts.BinaryInstallationOptions.FilenameSuffix(),
 	)
 
+	if args.FailIfInstalled {
+		if _, err := os.Stat(binaryPath); err == nil {
+			return writeDiags(c.Ui, nil, hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Plugin already installed",
+				Detail:   fmt.Sprintf("%s: %s %s in %q", plugingetter.ErrAlreadyInstalled, args.PluginIdentifier, desc.Version, binaryPath),
+			}})
+		}
+	}
+
 	outputPlugin, err := os.OpenFile(binaryPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0755)
 	if err != nil {
 		return writeDiags(c.Ui, nil, hcl.Diagnostics{&hcl.Diagnostic{
@@ -349,5 +641,24 @@ func (c *PluginsInstallCommand) InstallFromBinary(opts plugingetter.ListInstalla
 	fmt.Fprintf(shaFile, "%x", shasum.Sum([]byte{}))
 	c.Ui.Say(fmt.Sprintf("Successfully installed plugin %s from %s to %s", args.PluginIdentifier, args.PluginPath, binaryPath))
 
+	if args.AuditLogPath != "" {
+		err := plugingetter.AppendAuditRecord(args.AuditLogPath, plugingetter.AuditRecord{
+			Operation:  plugingetter.AuditOperationInstall,
+			Source:     pluginIdentifier.String(),
+			Version:    "v" + desc.Version,
+			BinaryPath: binaryPath,
+			Checksum:   fmt.Sprintf("sha256:%x", shasum.Sum(nil)),
+			Outcome:    string(plugingetter.InstallReasonInstalled),
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to audit the installation of %s: %s", binaryPath, err))
+		}
+	}
+
+	if args.Describe {
+		// a binary still open for writing cannot be started.
+		_ = outputPlugin.Close()
+		return c.describeInstalled(binaryPath)
+	}
 	return 0
 }
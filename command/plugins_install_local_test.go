@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPluginsInstallCommand_Run_failIfInstalled(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"-fail-if-installed", "github.com/hashicorp/hashicups", ">= 1.0.0"}); got == 0 {
+		t.Fatal("PluginsInstallCommand.Run() = 0, want non-zero exit")
+	}
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "plugin already installed") || !strings.Contains(stderr, binary) {
+		t.Errorf("expected stderr to report %q as already installed, got: %s", binary, stderr)
+	}
+}
+
+func TestPluginsInstallCommand_Run_failIfInstalledWithForce(t *testing.T) {
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+
+	if got := c.Run([]string{"-fail-if-installed", "-force", "github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsInstallCommand.Run() = %d, want 1", got)
+	}
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "cannot be used together") {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+}
+
+func TestPluginsInstallCommand_Run_quiet(t *testing.T) {
+	binary := createFakePlugin(t, t.TempDir(), "github.com/hashicorp/hashicups", "v1.0.1")
+
+	for _, quiet := range []bool{false, true} {
+		c := &PluginsInstallCommand{
+			Meta: TestMetaFile(t),
+		}
+		c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+
+		args := []string{"-path", binary, "github.com/hashicorp/hashicups"}
+		if quiet {
+			args = append([]string{"-quiet"}, args...)
+		}
+		if got := c.Run(args); got != 0 {
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			t.Fatalf("PluginsInstallCommand.Run(%q) = %d, want 0. stderr: %s", args, got, stderr)
+		}
+		stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		if printed := strings.Contains(stdout, "Successfully installed"); printed == quiet {
+			t.Errorf("with quiet %t, unexpected stdout: %q", quiet, stdout)
+		}
+	}
+}
+
+func TestPluginsInstallCommand_Run_quietKeepsErrors(t *testing.T) {
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+
+	if got := c.Run([]string{"-quiet", "-path", "/does/not/exist", "github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsInstallCommand.Run() = %d, want 1", got)
+	}
+	stdout, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if stdout != "" {
+		t.Errorf("expected no output, got %q", stdout)
+	}
+	if stderr == "" {
+		t.Error("expected the error to be output")
+	}
+}
+
+func TestPluginsInstallCommand_Run_describe(t *testing.T) {
+	binary := createFakePlugin(t, t.TempDir(), "github.com/hashicorp/hashicups", "v1.0.1")
+
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+
+	if got := c.Run([]string{"-describe", "-path", binary, "github.com/hashicorp/hashicups"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsInstallCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stdout, "version 1.0.1, API version x5.0, SDK version 0.5.2") {
+		t.Errorf("expected the describe output to be printed, got: %s", stdout)
+	}
+}
+
+func TestPluginsInstallCommand_Run_describeFailure(t *testing.T) {
+	// a plugin that only starts from where it was built.
+	binary := filepath.Join(t.TempDir(), "packer-plugin-hashicups")
+	script := `#!/bin/sh
+[ "$0" = "` + binary + `" ] || exit 1
+echo '{"version":"1.0.1","sdk_version":"0.5.2","api_version":"x5.0"}'
+`
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+
+	if got := c.Run([]string{"-describe", "-path", binary, "github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsInstallCommand.Run() = %d, want 1", got)
+	}
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "Installed plugin failed to start") {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+}
@@ -6,13 +6,24 @@
 package command
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 	"golang.org/x/mod/sumdb/dirhash"
 )
 
@@ -178,3 +189,369 @@ func TestPluginsInstallCommand_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestPluginsInstallArgs_VersionConstraints(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       PluginsInstallArgs
+		allowed    []string
+		disallowed []string
+		wantErr    bool
+	}{
+		{
+			name:       "max-version-caps-open-ended-constraint",
+			args:       PluginsInstallArgs{Version: ">= v2", MaxVersion: "v2.1.0"},
+			allowed:    []string{"v2.0.0", "v2.1.0"},
+			disallowed: []string{"v1.9.0", "v2.1.1", "v2.10.0"},
+		},
+		{
+			name:       "max-version-without-constraint",
+			args:       PluginsInstallArgs{MaxVersion: "v1.2.3"},
+			allowed:    []string{"v0.0.1", "v1.2.3"},
+			disallowed: []string{"v1.2.4", "v2.0.0"},
+		},
+		{
+			name:    "latest",
+			args:    PluginsInstallArgs{Version: "latest"},
+			allowed: []string{"v0.0.1", "v2.0.0"},
+		},
+		{
+			name:       "latest-capped-by-max-version",
+			args:       PluginsInstallArgs{Version: "*", MaxVersion: "v1.2.3"},
+			allowed:    []string{"v1.2.3"},
+			disallowed: []string{"v1.2.4"},
+		},
+		{
+			name:    "invalid-max-version",
+			args:    PluginsInstallArgs{MaxVersion: "not-a-version"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraints, err := tt.args.VersionConstraints()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VersionConstraints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, v := range tt.allowed {
+				if !constraints.Check(version.Must(version.NewVersion(v))) {
+					t.Errorf("expected %s to satisfy %q", v, constraints)
+				}
+			}
+			for _, v := range tt.disallowed {
+				if constraints.Check(version.Must(version.NewVersion(v))) {
+					t.Errorf("expected %s to not satisfy %q", v, constraints)
+				}
+			}
+		})
+	}
+}
+
+func TestPluginsInstallArgs_PlatformOptions(t *testing.T) {
+	base := plugingetter.BinaryInstallationOptions{
+		APIVersionMajor: "5", APIVersionMinor: "0",
+		OS: "linux", ARCH: "amd64",
+	}
+
+	args := PluginsInstallArgs{Platforms: []string{"linux/arm64", "windows/amd64"}}
+	got, err := args.PlatformOptions(base)
+	if err != nil {
+		t.Fatalf("PlatformOptions: %v", err)
+	}
+	want := []plugingetter.BinaryInstallationOptions{
+		{APIVersionMajor: "5", APIVersionMinor: "0", OS: "linux", ARCH: "arm64"},
+		{APIVersionMajor: "5", APIVersionMinor: "0", OS: "windows", ARCH: "amd64", Ext: ".exe"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected platform options: %s", diff)
+	}
+
+	args = PluginsInstallArgs{Platforms: []string{"linux"}}
+	if _, err := args.PlatformOptions(base); err == nil {
+		t.Errorf("expected an error for a platform without an arch")
+	}
+}
+
+func TestPluginsInstallCommand_Run_skipMissingPlatformsWithoutPlatform(t *testing.T) {
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+
+	if got := c.Run([]string{"-skip-missing-platforms", "github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsInstallCommand.Run() = %d, want 1", got)
+	}
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "can only be used with --platform") {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+}
+
+func TestPluginsInstallCommand_Run_describeWithPlatform(t *testing.T) {
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+
+	if got := c.Run([]string{"-describe", "-platform", "linux/amd64", "github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsInstallCommand.Run() = %d, want 1", got)
+	}
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "--describe cannot be used with --platform") {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+}
+
+func TestPluginsInstallCommand_Run_noCompatibleVersion(t *testing.T) {
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/hashicorp/packer-plugin-hashicups/git/matching-refs/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"ref":"refs/tags/v1.0.0"},{"ref":"refs/tags/v2.0.0"}]`)
+	})
+	mux.HandleFunc("/hashicorp/packer-plugin-hashicups/releases/download/v2.0.0/packer-plugin-hashicups_v2.0.0_SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  packer-plugin-hashicups_v2.0.0_x6.0_%s.zip\n", platform)
+	})
+	mux.HandleFunc("/hashicorp/packer-plugin-hashicups/releases/download/v1.0.0/packer-plugin-hashicups_v1.0.0_SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  packer-plugin-hashicups_v1.0.0_x5.0_plan9_amd64.zip\n")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+	c.CoreConfig.Components.PluginConfig.Getters.GitHub.APIBaseURL = server.URL
+	c.CoreConfig.Components.PluginConfig.Getters.GitHub.DownloadBaseURL = server.URL
+
+	if got := c.Run([]string{"github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsInstallCommand.Run() = %d, want 1", got)
+	}
+
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	for _, want := range []string{
+		fmt.Sprintf("No release of github.com/hashicorp/hashicups can be used by this version of Packer, which supports plugins with the protocol version x5.0 on %s.", platform),
+		"  v2.0.0: x6.0",
+		"  v1.0.0: no binary for this platform",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected stderr to contain %q, got:\n%s", want, stderr)
+		}
+	}
+}
+
+// releaseServer serves the releases of the hashicups plugin like GitHub, for
+// the current platform. The zips contain a binary with the executable header
+// of the platform.
+func releaseServer(t *testing.T, versions ...string) *httptest.Server {
+	header := map[string]string{"linux": "\x7fELF", "darwin": "\xcf\xfa\xed\xfe", "windows": "MZ"}[runtime.GOOS]
+	ext := ""
+	if runtime.GOOS == "windows" {
+		ext = ".exe"
+	}
+
+	mux := http.NewServeMux()
+	var refs, tags []string
+	for _, v := range versions {
+		v := v
+		refs = append(refs, fmt.Sprintf(`{"ref":"refs/tags/%s"}`, v))
+		tags = append(tags, fmt.Sprintf(`{"name":%q,"commit":{"sha":"%x"}}`, v, releaseCommit(v)))
+		binary := fmt.Sprintf("packer-plugin-hashicups_%s_x5.0_%s_%s", v, runtime.GOOS, runtime.GOARCH)
+
+		buf := &bytes.Buffer{}
+		zw := zip.NewWriter(buf)
+		w, err := zw.Create(binary + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(header + v)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		zipContent := buf.Bytes()
+
+		mux.HandleFunc(fmt.Sprintf("/hashicorp/packer-plugin-hashicups/releases/download/%s/packer-plugin-hashicups_%s_SHA256SUMS", v, v), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%x  %s.zip\n", sha256.Sum256(zipContent), binary)
+		})
+		mux.HandleFunc(fmt.Sprintf("/hashicorp/packer-plugin-hashicups/releases/download/%s/%s.zip", v, binary), func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(zipContent)
+		})
+	}
+	mux.HandleFunc("/repos/hashicorp/packer-plugin-hashicups/git/matching-refs/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[%s]", strings.Join(refs, ","))
+	})
+	mux.HandleFunc("/repos/hashicorp/packer-plugin-hashicups/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[%s]", strings.Join(tags, ","))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// releaseCommit is the SHA of the commit releaseServer tags with version v.
+func releaseCommit(v string) [sha1.Size]byte {
+	return sha1.Sum([]byte(v))
+}
+
+func TestPluginsInstallCommand_Run_latest(t *testing.T) {
+	server := releaseServer(t, "v1.0.0", "v1.1.0")
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantVersion string
+	}{
+		{"latest", []string{"github.com/hashicorp/hashicups", "latest"}, "v1.1.0"},
+		{"star", []string{"github.com/hashicorp/hashicups", "*"}, "v1.1.0"},
+		{"latest-capped", []string{"-max-version", "1.0.0", "github.com/hashicorp/hashicups", "latest"}, "v1.0.0"},
+		{"commit", []string{"github.com/hashicorp/hashicups", fmt.Sprintf("commit:%x", releaseCommit("v1.0.0"))}, "v1.0.0"},
+		{"abbreviated-commit", []string{"github.com/hashicorp/hashicups", fmt.Sprintf("commit:%.8x", releaseCommit("v1.0.0"))}, "v1.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			c := &PluginsInstallCommand{
+				Meta: TestMetaFile(t),
+			}
+			c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+			c.CoreConfig.Components.PluginConfig.Getters.GitHub.APIBaseURL = server.URL
+			c.CoreConfig.Components.PluginConfig.Getters.GitHub.DownloadBaseURL = server.URL
+
+			if got := c.Run(tt.args); got != 0 {
+				_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+				t.Fatalf("PluginsInstallCommand.Run(%q) = %d, want 0. stderr: %s", tt.args, got, stderr)
+			}
+
+			matches, err := filepath.Glob(filepath.Join(pluginDir, "github.com", "hashicorp", "hashicups", "packer-plugin-hashicups_*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var binaries []string
+			for _, match := range matches {
+				if !strings.HasSuffix(match, "SUM") {
+					binaries = append(binaries, filepath.Base(match))
+				}
+			}
+			want := fmt.Sprintf("packer-plugin-hashicups_%s_x5.0_%s_%s", tt.wantVersion, runtime.GOOS, runtime.GOARCH)
+			if len(binaries) != 1 || !strings.HasPrefix(binaries[0], want) {
+				t.Errorf("expected only %s to be installed, got %v", want, binaries)
+			}
+		})
+	}
+}
+
+func TestPluginsInstallCommand_Run_metricsTextfile(t *testing.T) {
+	server := releaseServer(t, "v1.0.0")
+	textfile := filepath.Join(t.TempDir(), "packer_plugins.prom")
+
+	c := &PluginsInstallCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = t.TempDir()
+	c.CoreConfig.Components.PluginConfig.Getters.GitHub.APIBaseURL = server.URL
+	c.CoreConfig.Components.PluginConfig.Getters.GitHub.DownloadBaseURL = server.URL
+
+	args := []string{"-metrics-textfile", textfile, "github.com/hashicorp/hashicups"}
+	if got := c.Run(args); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsInstallCommand.Run(%q) = %d, want 0. stderr: %s", args, got, stderr)
+	}
+
+	content, err := os.ReadFile(textfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE packer_plugin_installs_total counter\n",
+		`packer_plugin_installs_total{outcome="installed",source="github.com/hashicorp/hashicups"} 1` + "\n",
+		`packer_plugin_install_duration_seconds_count{source="github.com/hashicorp/hashicups"} 1` + "\n",
+		`packer_plugin_last_success_timestamp_seconds{operation="install",source="github.com/hashicorp/hashicups"} `,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected the metrics textfile to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestPluginsInstallCommand_Run_json(t *testing.T) {
+	server := releaseServer(t, "v1.0.0")
+
+	for _, quiet := range []bool{false, true} {
+		pluginDir := t.TempDir()
+		c := &PluginsInstallCommand{
+			Meta: TestMetaFile(t),
+		}
+		c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+		c.CoreConfig.Components.PluginConfig.Getters.GitHub.APIBaseURL = server.URL
+		c.CoreConfig.Components.PluginConfig.Getters.GitHub.DownloadBaseURL = server.URL
+
+		args := []string{"-json", "github.com/hashicorp/hashicups"}
+		if quiet {
+			args = append([]string{"-quiet"}, args...)
+		}
+		if got := c.Run(args); got != 0 {
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			t.Fatalf("PluginsInstallCommand.Run(%q) = %d, want 0. stderr: %s", args, got, stderr)
+		}
+
+		stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		binaryPath := filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "hashicups", fmt.Sprintf("packer-plugin-hashicups_v1.0.0_x5.0_%s_%s", runtime.GOOS, runtime.GOARCH)))
+		if runtime.GOOS == "windows" {
+			binaryPath += ".exe"
+		}
+		wantJSON := fmt.Sprintf(`{"source":"github.com/hashicorp/hashicups","reason":"installed","version":"v1.0.0","binary_path":%q}`+"\n", binaryPath)
+		if !strings.HasPrefix(stdout, wantJSON) {
+			t.Errorf("with quiet %t, expected stdout to start with %q, got %q", quiet, wantJSON, stdout)
+		}
+		// only the JSON lines are output with -quiet.
+		if printed := strings.Contains(stdout, "Installed plugin"); printed == quiet {
+			t.Errorf("with quiet %t, unexpected stdout: %q", quiet, stdout)
+		}
+	}
+}
+
+func TestPluginsInstallCommand_Run_invalidArgs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{"empty-source", []string{""}, "the plugin source cannot be empty"},
+		{"blank-source", []string{"  ", ">= 1.0.0"}, "the plugin source cannot be empty"},
+		{"padded-source", []string{" github.com/hashicorp/hashicups"}, "cannot start or end with spaces"},
+		{"empty-version", []string{"github.com/hashicorp/hashicups", ""}, "the version constraint cannot be empty"},
+		{"malformed-version", []string{"github.com/hashicorp/hashicups", "latest-ish"}, `Malformed constraint: latest-ish. Expected a version constraint like ">= 1.2.3"`},
+		{"malformed-commit", []string{"github.com/hashicorp/hashicups", "commit:main"}, `invalid commit "main"`},
+		{"malformed-max-version", []string{"-max-version", "soon", "github.com/hashicorp/hashicups"}, `invalid max version "soon"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			c := &PluginsInstallCommand{
+				Meta: TestMetaFile(t),
+			}
+			c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+			c.CoreConfig.Components.PluginConfig.Getters.GitHub.APIBaseURL = server.URL
+			c.CoreConfig.Components.PluginConfig.Getters.GitHub.DownloadBaseURL = server.URL
+
+			if got := c.Run(tt.args); got != 1 {
+				t.Fatalf("PluginsInstallCommand.Run(%q) = %d, want 1", tt.args, got)
+			}
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got:\n%s", tt.wantStderr, stderr)
+			}
+			if entries, _ := os.ReadDir(pluginDir); len(entries) != 0 {
+				t.Errorf("expected the plugin directory to be left untouched, found %v", entries)
+			}
+		})
+	}
+}
@@ -6,9 +6,12 @@ package command
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"log"
 	"runtime"
 	"strings"
+	"time"
 
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 )
@@ -23,11 +26,14 @@ func (c *PluginsInstalledCommand) Synopsis() string {
 
 func (c *PluginsInstalledCommand) Help() string {
 	helpText := `
-Usage: packer plugins installed
+Usage: packer plugins installed [options]
 
   This command lists all installed plugin binaries that match with the current
   OS and architecture. Packer's API version will be ignored.
 
+Options:
+  -json                         Output the installed plugins as a JSON list,
+                                with their version, size and modification time.
 `
 
 	return strings.TrimSpace(helpText)
@@ -37,13 +43,36 @@ func (c *PluginsInstalledCommand) Run(args []string) int {
 	ctx, cleanup := handleTermInterrupt(c.Ui)
 	defer cleanup()
 
-	return c.RunContext(ctx)
+	flags := c.Meta.FlagSet("plugins installed")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	var jsonOutput bool
+	flags.BoolVar(&jsonOutput, "json", false, "output installed plugins as JSON.")
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse options: %s", err))
+		return 1
+	}
+	if len(flags.Args()) != 0 {
+		flags.Usage()
+		return 1
+	}
+
+	return c.RunContext(ctx, jsonOutput)
 }
 
-func (c *PluginsInstalledCommand) RunContext(buildCtx context.Context) int {
+// installedPluginJSON is how an installation is output with -json.
+type installedPluginJSON struct {
+	BinaryPath string    `json:"binary_path"`
+	Version    string    `json:"version"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+}
+
+func (c *PluginsInstalledCommand) RunContext(buildCtx context.Context, jsonOutput bool) int {
 
 	opts := plugingetter.ListInstallationsOptions{
 		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		FromFolders:     c.Meta.CoreConfig.Components.PluginConfig.FromFolders,
+		WithFileInfo:    jsonOutput,
 		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
 			OS:   runtime.GOOS,
 			ARCH: runtime.GOARCH,
@@ -72,6 +101,25 @@ func (c *PluginsInstalledCommand) RunContext(buildCtx context.Context) int {
 		return 1
 	}
 
+	if jsonOutput {
+		out := []installedPluginJSON{}
+		for _, installation := range installations {
+			out = append(out, installedPluginJSON{
+				BinaryPath: installation.BinaryPath,
+				Version:    installation.Version,
+				Size:       installation.Size,
+				ModTime:    installation.ModTime,
+			})
+		}
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Message(string(b))
+		return 0
+	}
+
 	for _, installation := range installations {
 		c.Ui.Message(installation.BinaryPath)
 	}
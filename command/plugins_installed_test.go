@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPluginsInstalledCommand_Run_json(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	modTime := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	if err := os.Chtimes(binary, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &PluginsInstalledCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"-json"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsInstalledCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+
+	stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	var got []installedPluginJSON
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("invalid json output %q: %v", stdout, err)
+	}
+
+	want := []installedPluginJSON{{
+		BinaryPath: binary,
+		Version:    "v1.0.1",
+		Size:       fi.Size(),
+		ModTime:    modTime,
+	}}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+		t.Errorf("unexpected output: %s", diff)
+	}
+}
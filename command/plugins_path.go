@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+type PluginsPathCommand struct {
+	Meta
+}
+
+func (c *PluginsPathCommand) Synopsis() string {
+	return "Show where Packer plugins are installed"
+}
+
+func (c *PluginsPathCommand) Help() string {
+	helpText := `
+Usage: packer plugins path
+
+  This command shows the directory Packer installs plugins in, then the
+  plugins installed there for the current OS and architecture, with the
+  number of installed versions of each. Other directories plugins are loaded
+  from, like the next entries of PACKER_PLUGIN_PATH, are listed afterwards.
+
+  Installed plugin binaries are not run: use "packer plugins installed" to
+  list the usable ones.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginsPathCommand) Run(args []string) int {
+	ctx, cleanup := handleTermInterrupt(c.Ui)
+	defer cleanup()
+
+	flags := c.Meta.FlagSet("plugins path")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse options: %s", err))
+		return 1
+	}
+	if len(flags.Args()) != 0 {
+		flags.Usage()
+		return 1
+	}
+
+	return c.RunContext(ctx)
+}
+
+func (c *PluginsPathCommand) RunContext(buildCtx context.Context) int {
+	pluginConfig := c.Meta.CoreConfig.Components.PluginConfig
+
+	c.Ui.Message(pluginConfig.PluginDirectory)
+	if err := c.summarizeDirectory(pluginConfig.PluginDirectory); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	for _, dir := range pluginConfig.FromFolders {
+		c.Ui.Message("")
+		c.Ui.Message(fmt.Sprintf("Also loading plugins from %s", dir))
+		if err := c.summarizeDirectory(dir); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+	return 0
+}
+
+// summarizeDirectory outputs the number of installed versions of each plugin
+// of dir, then the totals.
+func (c *PluginsPathCommand) summarizeDirectory(dir string) error {
+	opts := plugingetter.ListInstallationsOptions{
+		PluginDirectory: dir,
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			OS:   runtime.GOOS,
+			ARCH: runtime.GOARCH,
+		},
+	}
+	if runtime.GOOS == "windows" && opts.Ext == "" {
+		opts.BinaryInstallationOptions.Ext = ".exe"
+	}
+
+	// a plugin requirement that matches them all
+	allPlugins := plugingetter.Requirement{}
+	installed, err := allPlugins.ListInstalledVersions(opts)
+	if err != nil {
+		return err
+	}
+
+	// Each version is counted once, even when installed for several
+	// protocol versions.
+	var sources []string
+	versions := map[string][]string{}
+	for _, binary := range installed {
+		source, err := filepath.Rel(dir, filepath.Dir(binary.BinaryPath))
+		if err != nil {
+			return err
+		}
+		source = filepath.ToSlash(source)
+		if _, found := versions[source]; !found {
+			sources = append(sources, source)
+		}
+		if v := versions[source]; len(v) == 0 || v[len(v)-1] != binary.Version {
+			versions[source] = append(v, binary.Version)
+		}
+	}
+
+	total := 0
+	sort.Strings(sources)
+	for _, source := range sources {
+		count := len(versions[source])
+		total += count
+		c.Ui.Message(fmt.Sprintf("  %s: %s (%s)", source, plural(count, "version"), strings.Join(versions[source], ", ")))
+	}
+	c.Ui.Message(fmt.Sprintf("%s, %s installed", plural(len(sources), "plugin"), plural(total, "version")))
+	return nil
+}
+
+// plural returns count followed by noun, with an s when count is not 1.
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
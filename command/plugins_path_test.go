@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package command
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPluginsPathCommand_Run(t *testing.T) {
+	pluginDir := t.TempDir()
+	createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+	createFakePlugin(t, pluginDir, "github.com/hashicorp/amazon", "v1.2.3")
+	otherDir := t.TempDir()
+	createFakePlugin(t, otherDir, "github.com/hashicorp/docker", "v1.0.8")
+
+	c := &PluginsPathCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+	c.CoreConfig.Components.PluginConfig.FromFolders = []string{otherDir, filepath.Join(otherDir, "missing")}
+
+	if got := c.Run(nil); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsPathCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+
+	stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	want := strings.Join([]string{
+		pluginDir,
+		"  github.com/hashicorp/amazon: 1 version (v1.2.3)",
+		"  github.com/hashicorp/hashicups: 2 versions (v1.0.1, v1.0.2)",
+		"2 plugins, 3 versions installed",
+		"",
+		"Also loading plugins from " + otherDir,
+		"  github.com/hashicorp/docker: 1 version (v1.0.8)",
+		"1 plugin, 1 version installed",
+		"",
+		"Also loading plugins from " + filepath.Join(otherDir, "missing"),
+		"0 plugins, 0 versions installed",
+	}, "\n")
+	if strings.TrimSpace(stdout) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", stdout, want)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/mitchellh/cli"
+)
+
+// defaultPruneAge is how old temporary files must be to be pruned, so that
+// the ones of running installs are kept.
+const defaultPruneAge = time.Hour
+
+type PluginsPruneCommand struct {
+	Meta
+}
+
+func (c *PluginsPruneCommand) Synopsis() string {
+	return "Remove the temporary files left in the plugin directory by interrupted installs"
+}
+
+func (c *PluginsPruneCommand) Help() string {
+	helpText := `
+Usage: packer plugins prune [options]
+
+  This command removes, from the plugin directory, the temporary files
+  binaries are extracted to before being moved in place, when they are older
+  than -older-than. Those are left behind by installs that were interrupted.
+
+  Ex: packer plugins prune -older-than 24h
+      packer plugins prune -prefix .my-tool-tmp-
+
+Options:
+  -prefix <prefix>              Prefix of the temporary files, ".packer-tmp-" by default.
+  -older-than <duration>        Only remove the files last modified this long ago, "1h" by default.
+  -quiet                        Only output errors.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginsPruneCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("plugins prune")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	var quiet bool
+	var prefix string
+	var olderThan time.Duration
+	flags.BoolVar(&quiet, "quiet", false, "only output errors.")
+	flags.StringVar(&prefix, "prefix", plugingetter.DefaultTempFilePrefix, "prefix of the temporary files.")
+	flags.DurationVar(&olderThan, "older-than", defaultPruneAge, "only remove the files last modified this long ago.")
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse options: %s", err))
+		return 1
+	}
+	if quiet {
+		defer c.QuietUi()()
+	}
+	if flags.NArg() > 0 {
+		return cli.RunResultHelp
+	}
+	if prefix == "" {
+		c.Ui.Error("Invalid arguments: the prefix cannot be empty, every file of the plugin directory would be removed")
+		return 1
+	}
+
+	pluginDir := c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory
+	removed, err := plugingetter.RemoveStaleTempFiles(pluginDir, prefix, olderThan)
+	for _, path := range removed {
+		c.Ui.Message(path)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to remove some temporary files: %s", err))
+		return 1
+	}
+	if len(removed) == 0 {
+		c.Ui.Message("No stale temporary file found")
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/mitchellh/cli"
+)
+
+func TestPluginsPruneCommand_Run(t *testing.T) {
+	pluginDir := t.TempDir()
+	outputFolder := filepath.Join(pluginDir, "github.com", "hashicorp", "hashicups")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, age time.Duration) string {
+		path := filepath.Join(outputFolder, name)
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	stale := write(plugingetter.DefaultTempFilePrefix+"packer-plugin-hashicups_v1.0.1_x5.0_linux_amd64.123", 2*time.Hour)
+	recent := write(plugingetter.DefaultTempFilePrefix+"packer-plugin-hashicups_v1.0.2_x5.0_linux_amd64.456", 10*time.Minute)
+	otherTool := write(".my-tool-tmp-packer-plugin-hashicups_v1.0.1_x5.0_linux_amd64.789", 2*time.Hour)
+
+	newCommand := func() *PluginsPruneCommand {
+		c := &PluginsPruneCommand{
+			Meta: TestMetaFile(t),
+		}
+		c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+		return c
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	if got := newCommand().Run([]string{"-prefix", ""}); got != 1 {
+		t.Errorf("PluginsPruneCommand.Run() with an empty prefix = %d, want 1", got)
+	}
+	if got := newCommand().Run([]string{"github.com/hashicorp/hashicups"}); got != cli.RunResultHelp {
+		t.Errorf("PluginsPruneCommand.Run() with an argument = %d, want %d", got, cli.RunResultHelp)
+	}
+
+	c := newCommand()
+	if got := c.Run(nil); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsPruneCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	if exists(stale) || !exists(recent) || !exists(otherTool) {
+		t.Errorf("expected only %q to be pruned", stale)
+	}
+
+	c = newCommand()
+	if got := c.Run([]string{"-prefix", ".my-tool-tmp-", "-older-than", "1m"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsPruneCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	if exists(otherTool) || !exists(recent) {
+		t.Errorf("expected only %q to be pruned", otherTool)
+	}
+}
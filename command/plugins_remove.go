@@ -8,17 +8,20 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 
-	"github.com/hashicorp/go-version"
-	"github.com/hashicorp/packer/hcl2template/addrs"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 	"github.com/mitchellh/cli"
 )
 
 type PluginsRemoveCommand struct {
 	Meta
+
+	// textfile, when set, counts the removals to write them in the
+	// -metrics-textfile.
+	textfile *plugingetter.PrometheusTextfile
 }
 
 func (c *PluginsRemoveCommand) Synopsis() string {
@@ -27,13 +30,33 @@ func (c *PluginsRemoveCommand) Synopsis() string {
 
 func (c *PluginsRemoveCommand) Help() string {
 	helpText := `
-Usage: packer plugins remove <plugin> [<version constraint>]
+Usage: packer plugins remove [options] <plugin> [<version constraint>]
+       packer plugins remove [options] -path <binary path>
+       packer plugins remove [options] -checksum <checksum> [<plugin> [<version constraint>]]
 
   This command will remove all Packer plugins matching the version constraint
   for the current OS and architecture.
   When the version is omitted all installed versions will be removed.
+  When the version is not a valid constraint, it is matched as a glob
+  against the installed versions.
+  With -path, only the given plugin binary and its checksum file are
+  removed. The binary must be in a plugin directory.
+  With -checksum, only the plugin binaries with this sha256 checksum, or
+  installed from a zip with this checksum, are removed; of every plugin when
+  none is given.
 
   Ex: packer plugins remove github.com/hashicorp/happycloud v1.2.3
+      packer plugins remove github.com/hashicorp/happycloud 'v1.2.*'
+      packer plugins remove -checksum sha256:9f86d081884c7d65...
+
+Options:
+  -path <binary path>           Remove the plugin binary at this path.
+  -checksum <checksum>          Remove the plugin binaries with this checksum.
+  -audit-log <path>             Append a JSON record of every removal to this file.
+  -metrics-textfile <path>      Write the outcome of the removals to this file in the Prometheus
+                                text format, ex: for the node exporter textfile collector.
+                                Counts add up to the ones already in the file.
+  -quiet                        Only output errors.
 `
 
 	return strings.TrimSpace(helpText)
@@ -43,16 +66,125 @@ func (c *PluginsRemoveCommand) Run(args []string) int {
 	ctx, cleanup := handleTermInterrupt(c.Ui)
 	defer cleanup()
 
-	return c.RunContext(ctx, args)
+	flags := c.Meta.FlagSet("plugins remove")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	var quiet bool
+	var binaryPath, checksum, auditLogPath, metricsTextfile string
+	flags.BoolVar(&quiet, "quiet", false, "only output errors.")
+	flags.StringVar(&binaryPath, "path", "", "remove the plugin binary at this path.")
+	flags.StringVar(&checksum, "checksum", "", "remove the plugin binaries with this checksum.")
+	flags.StringVar(&auditLogPath, "audit-log", "", "file to append a JSON record of every removal to.")
+	flags.StringVar(&metricsTextfile, "metrics-textfile", "", "file to write the removal metrics to, in the Prometheus text format.")
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse options: %s", err))
+		return 1
+	}
+	if quiet {
+		defer c.QuietUi()()
+	}
+	if metricsTextfile != "" {
+		c.textfile = &plugingetter.PrometheusTextfile{Path: metricsTextfile}
+		defer writeMetricsTextfile(c.Ui, c.textfile)
+	}
+
+	if binaryPath != "" {
+		if flags.NArg() > 0 || checksum != "" {
+			return cli.RunResultHelp
+		}
+		return c.removeBinary(binaryPath, auditLogPath)
+	}
+	return c.RunContext(ctx, flags.Args(), auditLogPath, checksum)
+}
+
+// removeBinary removes the plugin binary in binaryPath and its sidecar
+// files, after making sure it is in one of the plugin directories.
+func (c *PluginsRemoveCommand) removeBinary(binaryPath, auditLogPath string) int {
+	pluginConfig := c.Meta.CoreConfig.Components.PluginConfig
+	pluginDirs := append([]string{pluginConfig.PluginDirectory}, pluginConfig.FromFolders...)
+	source, err := checkPathInPluginDirs(binaryPath, pluginDirs)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if err := plugingetter.CheckPluginDirWritable(filepath.Dir(binaryPath)); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if err := c.removeInstallation(auditLogPath, source, &plugingetter.Installation{BinaryPath: binaryPath}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	c.Ui.Message(binaryPath)
+	return 0
+}
+
+// checkPathInPluginDirs makes sure binaryPath is an existing plugin binary
+// below one of pluginDirs, once symlinks are resolved, and returns the plugin
+// source it is installed for, ex: github.com/hashicorp/happycloud.
+func checkPathInPluginDirs(binaryPath string, pluginDirs []string) (string, error) {
+	fi, err := os.Lstat(binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("Invalid arguments: %s", err)
+	}
+	if !fi.Mode().IsRegular() || !strings.HasPrefix(filepath.Base(binaryPath), "packer-plugin-") {
+		return "", fmt.Errorf("Invalid arguments: %q is not a plugin binary", binaryPath)
+	}
+	binaryDir, err := filepath.EvalSymlinks(filepath.Dir(binaryPath))
+	if err != nil {
+		return "", fmt.Errorf("Invalid arguments: %s", err)
+	}
+	binaryDir, err = filepath.Abs(binaryDir)
+	if err != nil {
+		return "", fmt.Errorf("Invalid arguments: %s", err)
+	}
+
+	for _, dir := range pluginDirs {
+		if dir == "" {
+			continue
+		}
+		dir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		if dir, err = filepath.Abs(dir); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(dir, binaryDir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if rel == "." {
+				return "", nil
+			}
+			return filepath.ToSlash(rel), nil
+		}
+	}
+	return "", fmt.Errorf("Invalid arguments: %q is not in a plugin directory (%s)", binaryPath, strings.Join(pluginDirs, ", "))
 }
 
-func (c *PluginsRemoveCommand) RunContext(buildCtx context.Context, args []string) int {
-	if len(args) < 1 || len(args) > 2 {
+// RunContext removes the installed plugins matching args, a plugin source and
+// an optional version constraint, and checksum when set. Without args, the
+// plugins matching checksum are removed, whatever their source.
+func (c *PluginsRemoveCommand) RunContext(buildCtx context.Context, args []string, auditLogPath, checksum string) int {
+	if len(args) > 2 || (len(args) < 1 && checksum == "") {
 		return cli.RunResultHelp
 	}
+	if len(args) > 0 {
+		if err := checkPluginSourceArg(args[0]); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+	if len(args) > 1 {
+		if err := checkVersionArg(args[1]); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
 
+	pluginDir := c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory
 	opts := plugingetter.ListInstallationsOptions{
-		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		PluginDirectory: pluginDir,
+		Checksum:        checksum,
 		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
 			OS:   runtime.GOOS,
 			ARCH: runtime.GOARCH,
@@ -66,24 +198,22 @@ func (c *PluginsRemoveCommand) RunContext(buildCtx context.Context, args []strin
 		opts.BinaryInstallationOptions.Ext = ".exe"
 	}
 
-	plugin, diags := addrs.ParsePluginSourceString(args[0])
-	if diags.HasErrors() {
-		c.Ui.Error(diags.Error())
-		return 1
-	}
-
 	// a plugin requirement that matches them all
-	pluginRequirement := plugingetter.Requirement{
-		Identifier: plugin,
+	pluginRequirement := plugingetter.Requirement{}
+	if len(args) > 0 {
+		plugin, diags := c.Meta.CoreConfig.Components.PluginConfig.NamespaceHostnames.ParsePluginSourceString(args[0])
+		if diags.HasErrors() {
+			c.Ui.Error(diags.Error())
+			return 1
+		}
+		pluginRequirement.Identifier = plugin
 	}
 
 	if len(args) > 1 {
-		constraints, err := version.NewConstraint(args[1])
-		if err != nil {
-			c.Ui.Error(err.Error())
+		if err := pluginRequirement.ParseVersionFilter(args[1]); err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid arguments: %s. Expected a version constraint like \">= 1.2.3\" or a version pattern like \"v1.2.*\"", err))
 			return 1
 		}
-		pluginRequirement.VersionConstraints = constraints
 	}
 
 	installations, err := pluginRequirement.ListInstallations(opts)
@@ -91,23 +221,40 @@ func (c *PluginsRemoveCommand) RunContext(buildCtx context.Context, args []strin
 		c.Ui.Error(err.Error())
 		return 1
 	}
+
+	// Make sure we can remove everything before removing anything.
+	checkedDirs := map[string]bool{}
 	for _, installation := range installations {
-		if err := os.Remove(installation.BinaryPath); err != nil {
+		dir := filepath.Dir(installation.BinaryPath)
+		if checkedDirs[dir] {
+			continue
+		}
+		if err := plugingetter.CheckPluginDirWritable(dir); err != nil {
 			c.Ui.Error(err.Error())
 			return 1
 		}
-		shasumFile := fmt.Sprintf("%s_SHA256SUM", installation.BinaryPath)
-		if err := os.Remove(shasumFile); err != nil {
-			c.Ui.Error(fmt.Sprintf("failed to remove %s: %s", shasumFile, err))
-			c.Ui.Error("You may need to remove it manually")
+		checkedDirs[dir] = true
+	}
+
+	for _, installation := range installations {
+		source := filepath.ToSlash(filepath.Dir(installation.BinaryPath))
+		if rel, err := filepath.Rel(pluginDir, filepath.Dir(installation.BinaryPath)); err == nil {
+			source = filepath.ToSlash(rel)
+		}
+		if err := c.removeInstallation(auditLogPath, source, installation); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
 		}
 		c.Ui.Message(installation.BinaryPath)
 	}
 
 	if len(installations) == 0 {
-		errMsg := fmt.Sprintf("No installed plugin found matching the plugin constraints %s", args[0])
-		if len(args) == 2 {
-			errMsg = fmt.Sprintf("%s %s", errMsg, args[1])
+		errMsg := "No installed plugin found"
+		if len(args) > 0 {
+			errMsg = fmt.Sprintf("%s matching the plugin constraints %s", errMsg, strings.Join(args, " "))
+		}
+		if checksum != "" {
+			errMsg = fmt.Sprintf("%s with checksum %s", errMsg, checksum)
 		}
 		c.Ui.Error(errMsg)
 		return 1
@@ -115,3 +262,46 @@ func (c *PluginsRemoveCommand) RunContext(buildCtx context.Context, args []strin
 
 	return 0
 }
+
+// removeInstallation removes the binary of installation with its sidecar
+// files, like plugins required -prune-unreferenced does, and reports it.
+func (c *PluginsRemoveCommand) removeInstallation(auditLogPath, source string, installation *plugingetter.Installation) error {
+	// the checksum file is gone once removed.
+	checksum, _ := installation.StoredChecksum()
+	err := plugingetter.RemoveInstallation(installation, []plugingetter.Checksummer{{Type: "sha256", Hash: sha256.New()}})
+	c.observeRemoval(auditLogPath, source, installation, checksum, err)
+	return err
+}
+
+// observeRemoval reports the removal of installation, whose stored checksum
+// was checksum, to the audit log and the metrics textfile, when set; err is
+// why the removal failed.
+func (c *PluginsRemoveCommand) observeRemoval(auditLogPath, source string, installation *plugingetter.Installation, checksum string, err error) {
+	c.auditRemoval(auditLogPath, source, installation, checksum, err)
+	if c.textfile != nil {
+		c.textfile.ObserveRemove(source, err)
+	}
+}
+
+// auditRemoval appends the removal of installation to the audit log in
+// auditLogPath, if set; err is why the removal failed.
+func (c *PluginsRemoveCommand) auditRemoval(auditLogPath, source string, installation *plugingetter.Installation, checksum string, err error) {
+	if auditLogPath == "" {
+		return
+	}
+	record := plugingetter.AuditRecord{
+		Operation:  plugingetter.AuditOperationRemove,
+		Source:     source,
+		Version:    installation.Version,
+		BinaryPath: installation.BinaryPath,
+		Checksum:   checksum,
+		Outcome:    plugingetter.AuditOutcomeRemoved,
+	}
+	if err != nil {
+		record.Outcome = string(plugingetter.InstallReasonFailed)
+		record.Error = err.Error()
+	}
+	if err := plugingetter.AppendAuditRecord(auditLogPath, record); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to audit the removal of %s: %s", installation.BinaryPath, err))
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// createFakePlugin writes a shell script answering to `describe` like a
+// plugin of the given version would, along with its checksum file, and
+// returns its path.
+func createFakePlugin(t *testing.T, pluginDir, source, version string) string {
+	parts := strings.Split(source, "/")
+	name := parts[len(parts)-1]
+	binaryPath := filepath.Join(pluginDir, source,
+		fmt.Sprintf("packer-plugin-%s_%s_x5.0_%s_%s", name, version, runtime.GOOS, runtime.GOARCH))
+
+	script := fmt.Sprintf("#!/bin/sh\necho '{\"version\":%q,\"sdk_version\":\"0.5.2\",\"api_version\":\"x5.0\"}'\n",
+		strings.TrimPrefix(version, "v"))
+
+	if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binaryPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(script))
+	if err := os.WriteFile(binaryPath+"_SHA256SUM", []byte(fmt.Sprintf("%x", sum)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return binaryPath
+}
+
+// addZipSidecars writes the checksum of the zip binaryPath was installed
+// from, as pinned on first use, and returns the paths of the files written.
+func addZipSidecars(t *testing.T, binaryPath string) []string {
+	zipName := filepath.Base(binaryPath) + ".zip"
+	files := map[string]string{
+		binaryPath + plugingetter.ZipChecksumFileExt:                      strings.Repeat("ab", sha256.Size) + "  " + zipName + "\n",
+		filepath.Join(filepath.Dir(binaryPath), zipName+"_SHA256SUM.pin"): strings.Repeat("ab", sha256.Size),
+	}
+	var paths []string
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestPluginsRemoveCommand_Run(t *testing.T) {
+	pluginDir := t.TempDir()
+	v101 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	v102 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+
+	c := &PluginsRemoveCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"github.com/hashicorp/hashicups", "v1.0.1"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+
+	for _, removed := range []string{v101, v101 + "_SHA256SUM"} {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be removed, stat returned: %v", removed, err)
+		}
+	}
+	for _, kept := range []string{v102, v102 + "_SHA256SUM"} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestPluginsRemoveCommand_Run_versionPattern(t *testing.T) {
+	pluginDir := t.TempDir()
+	v2100 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v2.10.0")
+	v2103 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v2.10.3")
+	v2110 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v2.11.0")
+
+	c := &PluginsRemoveCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"github.com/hashicorp/hashicups", "v2.10.*"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+
+	for _, removed := range []string{v2100, v2100 + "_SHA256SUM", v2103, v2103 + "_SHA256SUM"} {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be removed, stat returned: %v", removed, err)
+		}
+	}
+	for _, kept := range []string{v2110, v2110 + "_SHA256SUM"} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestPluginsRemoveCommand_Run_quiet(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	c := &PluginsRemoveCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"-quiet", "github.com/hashicorp/hashicups"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	if stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta); stdout != "" {
+		t.Errorf("expected no output, got %q", stdout)
+	}
+	if _, err := os.Stat(binary); !os.IsNotExist(err) {
+		t.Errorf("expected %q to be removed, stat returned: %v", binary, err)
+	}
+
+	// nothing is left to remove
+	if got := c.Run([]string{"-quiet", "github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 1", got)
+	}
+	stdout, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if stdout != "" {
+		t.Errorf("expected no output, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "No installed plugin found") {
+		t.Errorf("expected the error to be output, got %q", stderr)
+	}
+}
+
+func TestPluginsRemoveCommand_Run_invalidArgs(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{"empty-source", []string{""}, "the plugin source cannot be empty"},
+		{"blank-source", []string{" ", "v1.0.1"}, "the plugin source cannot be empty"},
+		{"padded-source", []string{"github.com/hashicorp/hashicups "}, "cannot start or end with spaces"},
+		{"empty-version", []string{"github.com/hashicorp/hashicups", " "}, "the version constraint cannot be empty"},
+		{"malformed-version", []string{"github.com/hashicorp/hashicups", "one"}, `or a version pattern like "v1.2.*"`},
+		{"malformed-pattern", []string{"github.com/hashicorp/hashicups", "v1.[0"}, "is neither a version constraint nor a version pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &PluginsRemoveCommand{
+				Meta: TestMetaFile(t),
+			}
+			c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+			if got := c.Run(tt.args); got != 1 {
+				t.Fatalf("PluginsRemoveCommand.Run(%q) = %d, want 1", tt.args, got)
+			}
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got:\n%s", tt.wantStderr, stderr)
+			}
+			if _, err := os.Stat(binary); err != nil {
+				t.Errorf("expected %q to be kept: %v", binary, err)
+			}
+		})
+	}
+}
+
+func TestPluginsRemoveCommand_Run_readOnlyPluginDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+
+	pluginDir := t.TempDir()
+	binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	installDir := filepath.Dir(binary)
+	if err := os.Chmod(installDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(installDir, 0755) })
+
+	c := &PluginsRemoveCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"github.com/hashicorp/hashicups"}); got != 1 {
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 1", got)
+	}
+
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "plugin directory is read-only") {
+		t.Errorf("expected a read-only error, got %q", stderr)
+	}
+	if _, err := os.Stat(binary); err != nil {
+		t.Errorf("expected binary to be kept: %v", err)
+	}
+}
+
+func TestPluginsRemoveCommand_Run_path(t *testing.T) {
+	pluginDir := t.TempDir()
+	v101 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	v102 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+
+	c := &PluginsRemoveCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	sidecars := addZipSidecars(t, v101)
+
+	if got := c.Run([]string{"-path", v101}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+
+	for _, removed := range append([]string{v101, v101 + "_SHA256SUM"}, sidecars...) {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be removed, stat returned: %v", removed, err)
+		}
+	}
+	for _, kept := range []string{v102, v102 + "_SHA256SUM"} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestPluginsRemoveCommand_Run_checksum(t *testing.T) {
+	pluginDir := t.TempDir()
+	v101 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	v102 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+	// same script, so same checksum, as the hashicorp v1.0.1
+	fork := createFakePlugin(t, pluginDir, "github.com/fork/hashicups", "v1.0.1")
+
+	checksum, err := os.ReadFile(v101 + "_SHA256SUM")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCommand := func() *PluginsRemoveCommand {
+		c := &PluginsRemoveCommand{
+			Meta: TestMetaFile(t),
+		}
+		c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+		return c
+	}
+
+	if got := newCommand().Run([]string{"-checksum", "sha256:invalid"}); got != 1 {
+		t.Errorf("PluginsRemoveCommand.Run() with an invalid checksum = %d, want 1", got)
+	}
+
+	c := newCommand()
+	if got := c.Run([]string{"-checksum", string(checksum), "github.com/fork/hashicups"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	if _, err := os.Stat(fork); !os.IsNotExist(err) {
+		t.Errorf("expected %q to be removed, stat returned: %v", fork, err)
+	}
+	if _, err := os.Stat(v101); err != nil {
+		t.Errorf("expected %q of another plugin to be kept: %v", v101, err)
+	}
+
+	c = newCommand()
+	if got := c.Run([]string{"-checksum", "sha256:" + string(checksum)}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	for _, removed := range []string{v101, v101 + "_SHA256SUM"} {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be removed, stat returned: %v", removed, err)
+		}
+	}
+	for _, kept := range []string{v102, v102 + "_SHA256SUM"} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+
+	if got := newCommand().Run([]string{"-checksum", string(checksum)}); got != 1 {
+		t.Errorf("PluginsRemoveCommand.Run() without matching plugin = %d, want 1", got)
+	}
+}
+
+func TestPluginsRemoveCommand_Run_pathOutOfTree(t *testing.T) {
+	pluginDir := t.TempDir()
+	otherDir := t.TempDir()
+	outside := createFakePlugin(t, otherDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	notAPlugin := filepath.Join(pluginDir, "github.com", "hashicorp", "hashicups", "notes.txt")
+	if err := os.MkdirAll(filepath.Dir(notAPlugin), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(notAPlugin, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStderr string
+	}{
+		{"other-directory", outside, "is not in a plugin directory"},
+		{"dot-dot", filepath.Join(pluginDir, "..", filepath.Base(otherDir), "github.com", "hashicorp", "hashicups", filepath.Base(outside)), "is not in a plugin directory"},
+		{"not-a-plugin", notAPlugin, "is not a plugin binary"},
+		{"missing", filepath.Join(pluginDir, "packer-plugin-hashicups_v9.9.9_x5.0_linux_amd64"), "no such file or directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &PluginsRemoveCommand{
+				Meta: TestMetaFile(t),
+			}
+			c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+			if got := c.Run([]string{"-path", tt.path}); got != 1 {
+				t.Fatalf("PluginsRemoveCommand.Run() = %d, want 1", got)
+			}
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got:\n%s", tt.wantStderr, stderr)
+			}
+		})
+	}
+	for _, kept := range []string{outside, outside + "_SHA256SUM", notAPlugin} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestPluginsRemoveCommand_Run_auditLog(t *testing.T) {
+	pluginDir := t.TempDir()
+	v101 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	v102 := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+
+	for _, args := range [][]string{
+		{"-audit-log", auditLog, "github.com/hashicorp/hashicups", "v1.0.1"},
+		{"-audit-log", auditLog, "-path", v102},
+	} {
+		c := &PluginsRemoveCommand{
+			Meta: TestMetaFile(t),
+		}
+		c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+		if got := c.Run(args); got != 0 {
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			t.Fatalf("PluginsRemoveCommand.Run(%q) = %d, want 0. stderr: %s", args, got, stderr)
+		}
+	}
+
+	content, err := os.ReadFile(auditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit records, got: %s", content)
+	}
+	for i, binaryPath := range []string{v101, v102} {
+		var record plugingetter.AuditRecord
+		if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+			t.Fatalf("malformed audit record %q: %v", lines[i], err)
+		}
+		if record.Operation != plugingetter.AuditOperationRemove || record.Outcome != plugingetter.AuditOutcomeRemoved ||
+			record.Source != "github.com/hashicorp/hashicups" || record.BinaryPath != binaryPath ||
+			!strings.HasPrefix(record.Checksum, "sha256:") {
+			t.Errorf("unexpected audit record %d: %#v", i, record)
+		}
+	}
+}
+
+func TestPluginsRemoveCommand_Run_metricsTextfile(t *testing.T) {
+	pluginDir := t.TempDir()
+	createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+	textfile := filepath.Join(t.TempDir(), "packer_plugins.prom")
+
+	c := &PluginsRemoveCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+	if got := c.Run([]string{"-metrics-textfile", textfile, "github.com/hashicorp/hashicups"}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRemoveCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+
+	content, err := os.ReadFile(textfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE packer_plugin_removals_total counter\n",
+		`packer_plugin_removals_total{outcome="removed",source="github.com/hashicorp/hashicups"} 2` + "\n",
+		`packer_plugin_last_success_timestamp_seconds{operation="remove",source="github.com/hashicorp/hashicups"} `,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected the metrics textfile to contain %q, got:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/mitchellh/cli"
+)
+
+type PluginsRepairCommand struct {
+	Meta
+}
+
+func (c *PluginsRepairCommand) Synopsis() string {
+	return "Re-install Packer plugins that fail checksum verification"
+}
+
+func (c *PluginsRepairCommand) Help() string {
+	helpText := `
+Usage: packer plugins repair [options] [<plugin> [<version constraint>]]
+
+  This command verifies the checksum of installed Packer plugins for the
+  current OS and architecture, and downloads again the same version of the
+  ones that don't match, replacing the corrupt binary.
+  When the plugin is omitted all installed plugins are verified.
+
+  Ex: packer plugins repair github.com/hashicorp/happycloud v1.2.3
+
+Options:
+  -quiet                        Only output errors.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginsRepairCommand) Run(args []string) int {
+	ctx, cleanup := handleTermInterrupt(c.Ui)
+	defer cleanup()
+
+	flags := c.Meta.FlagSet("plugins repair")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	var quiet bool
+	flags.BoolVar(&quiet, "quiet", false, "only output errors.")
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse options: %s", err))
+		return 1
+	}
+	if quiet {
+		defer c.QuietUi()()
+	}
+
+	return c.RunContext(ctx, flags.Args())
+}
+
+func (c *PluginsRepairCommand) RunContext(buildCtx context.Context, args []string) int {
+	if len(args) > 2 {
+		return cli.RunResultHelp
+	}
+
+	opts := plugingetter.ListInstallationsOptions{
+		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			OS:              runtime.GOOS,
+			ARCH:            runtime.GOARCH,
+			APIVersionMajor: pluginsdk.APIVersionMajor,
+			APIVersionMinor: pluginsdk.APIVersionMinor,
+			Checksummers: []plugingetter.Checksummer{
+				{Type: "sha256", Hash: sha256.New()},
+			},
+		},
+	}
+	if runtime.GOOS == "windows" {
+		opts.BinaryInstallationOptions.Ext = ".exe"
+	}
+
+	pluginRequirement := plugingetter.Requirement{}
+	if len(args) > 0 {
+		plugin, diags := c.Meta.CoreConfig.Components.PluginConfig.NamespaceHostnames.ParsePluginSourceString(args[0])
+		if diags.HasErrors() {
+			c.Ui.Error(diags.Error())
+			return 1
+		}
+		pluginRequirement.Identifier = plugin
+	}
+	if len(args) > 1 {
+		constraints, err := version.NewConstraint(args[1])
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		pluginRequirement.VersionConstraints = constraints
+	}
+
+	corrupt, err := pluginRequirement.ListCorruptInstallations(opts)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if len(corrupt) == 0 {
+		c.Ui.Message("No corrupt plugin installation found")
+		return 0
+	}
+
+	installOpts := plugingetter.InstallOptions{
+		PluginDirectory:           opts.PluginDirectory,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+		ProvenanceVerifier:        c.Meta.ProvenanceVerifier(),
+		SigstoreVerifier:          c.Meta.SigstoreVerifier(),
+		TagSignatureVerifier:      c.Meta.TagSignatureVerifier(),
+	}
+
+	ret := 0
+	for _, installation := range corrupt {
+		c.Ui.Message(fmt.Sprintf("%s is corrupt: %s", installation.BinaryPath, installation.Err))
+		getters, err := c.Meta.PluginGettersFor(installation.Identifier.String())
+		if err == nil {
+			installOpts.Getters = getters
+			err = installation.Repair(installOpts)
+		}
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to repair %s %s: %s", installation.Identifier, installation.Version, err))
+			ret = 1
+			continue
+		}
+		c.Ui.Say(fmt.Sprintf("Repaired %s %s in %q", installation.Identifier, installation.Version, installation.BinaryPath))
+	}
+
+	return ret
+}
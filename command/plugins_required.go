@@ -25,7 +25,7 @@ func (c *PluginsRequiredCommand) Synopsis() string {
 
 func (c *PluginsRequiredCommand) Help() string {
 	helpText := `
-Usage: packer plugins required <path>
+Usage: packer plugins required [options] <path>
 
   This command will list every Packer plugin required by a Packer config, in
   packer.required_plugins blocks. All binaries matching the required version
@@ -33,8 +33,18 @@ Usage: packer plugins required <path>
   version (and the first of the list) will be the one picked by Packer during a
   build.
 
+  The installed plugin binaries for the current OS and Architecture whose
+  version matches none of the requirements, including the ones of plugins
+  that are not required at all, are then listed as unreferenced: they are
+  candidates for cleanup. With -prune-unreferenced, they are removed from the
+  plugin directory.
+
   Ex: packer plugins required require.pkr.hcl
   Ex: packer plugins required path/to/folder/
+  Ex: packer plugins required -prune-unreferenced path/to/folder/
+
+Options:
+  -prune-unreferenced           Remove the installed plugins no requirement matches.
 `
 
 	return strings.TrimSpace(helpText)
@@ -85,6 +95,7 @@ func (c *PluginsRequiredCommand) RunContext(buildCtx context.Context, cla *Plugi
 
 	opts := plugingetter.ListInstallationsOptions{
 		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		FromFolders:     c.Meta.CoreConfig.Components.PluginConfig.FromFolders,
 		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
 			OS:              runtime.GOOS,
 			ARCH:            runtime.GOARCH,
@@ -109,6 +120,17 @@ func (c *PluginsRequiredCommand) RunContext(buildCtx context.Context, cla *Plugi
 		c.Ui.Message(s)
 	}
 
+	if cla.PruneUnreferenced && len(reqs) == 0 {
+		c.Ui.Error("No plugins requirement found, not removing every installed plugin")
+		return 1
+	}
+	if len(reqs) > 0 {
+		opts.FromFolders = nil
+		if ret := c.unreferenced(opts, reqs, cla.PruneUnreferenced); ret != 0 {
+			return ret
+		}
+	}
+
 	if len(reqs) == 0 {
 		c.Ui.Message(`
 No plugins requirement found, make sure you reference a Packer config
@@ -119,3 +141,33 @@ for more info.`)
 
 	return 0
 }
+
+// unreferenced lists the installations listed with opts that match none of
+// reqs, and removes them when prune is set.
+func (c *PluginsRequiredCommand) unreferenced(opts plugingetter.ListInstallationsOptions, reqs plugingetter.Requirements, prune bool) int {
+	installs, err := plugingetter.Requirement{}.ListInstallations(opts)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	unreferenced := plugingetter.FindUnreferenced(installs, reqs)
+	if !prune {
+		for _, install := range unreferenced {
+			c.Ui.Message(fmt.Sprintf("Unreferenced %s", install.BinaryPath))
+		}
+		if len(unreferenced) > 0 {
+			c.Ui.Message("Remove the unreferenced plugins with -prune-unreferenced")
+		}
+		return 0
+	}
+	ret := 0
+	for _, install := range unreferenced {
+		if err := plugingetter.RemoveInstallation(install, opts.Checksummers); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to remove %s: %s", install.BinaryPath, err))
+			ret = 1
+			continue
+		}
+		c.Ui.Message(fmt.Sprintf("Removed unreferenced %s", install.BinaryPath))
+	}
+	return ret
+}
@@ -0,0 +1,128 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPluginsRequiredCommand_Run_pruneUnreferenced(t *testing.T) {
+	pluginDir := t.TempDir()
+	old := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	required := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+	notRequired := createFakePlugin(t, pluginDir, "github.com/hashicorp/amazon", "v1.0.0")
+	sidecars := addZipSidecars(t, old)
+
+	configDir := t.TempDir()
+	config := `packer {
+  required_plugins {
+    hashicups = {
+      source  = "github.com/hashicorp/hashicups"
+      version = ">= 1.0.2"
+    }
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(configDir, "plugins.pkr.hcl"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &PluginsRequiredCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"-prune-unreferenced", configDir}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRequiredCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+
+	for _, removed := range append([]string{old, old + "_SHA256SUM", notRequired, notRequired + "_SHA256SUM"}, sidecars...) {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be removed, stat returned: %v", removed, err)
+		}
+	}
+	for _, kept := range []string{required, required + "_SHA256SUM"} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+	for _, want := range []string{"Removed unreferenced " + old, "Removed unreferenced " + notRequired} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestPluginsRequiredCommand_Run_listUnreferenced(t *testing.T) {
+	pluginDir := t.TempDir()
+	old := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	required := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+
+	configDir := t.TempDir()
+	config := `packer {
+  required_plugins {
+    hashicups = {
+      source  = "github.com/hashicorp/hashicups"
+      version = ">= 1.0.2"
+    }
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(configDir, "plugins.pkr.hcl"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &PluginsRequiredCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{configDir}); got != 0 {
+		_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+		t.Fatalf("PluginsRequiredCommand.Run() = %d, want 0. stderr: %s", got, stderr)
+	}
+	stdout, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+
+	// unreferenced plugins are reported, not removed.
+	for _, kept := range []string{old, old + "_SHA256SUM", required, required + "_SHA256SUM"} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+	if want := "Unreferenced " + old; !strings.Contains(stdout, want) {
+		t.Errorf("expected the output to contain %q, got:\n%s", want, stdout)
+	}
+	if strings.Contains(stdout, "Unreferenced "+required) {
+		t.Errorf("expected %s not to be reported, got:\n%s", required, stdout)
+	}
+}
+
+func TestPluginsRequiredCommand_Run_pruneUnreferencedWithoutRequirements(t *testing.T) {
+	pluginDir := t.TempDir()
+	installed := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	configDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(configDir, "empty.pkr.hcl"), []byte("packer {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &PluginsRequiredCommand{
+		Meta: TestMetaFile(t),
+	}
+	c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+	if got := c.Run([]string{"-prune-unreferenced", configDir}); got != 1 {
+		t.Fatalf("PluginsRequiredCommand.Run() = %d, want 1", got)
+	}
+	if _, err := os.Stat(installed); err != nil {
+		t.Errorf("expected %q to be kept: %v", installed, err)
+	}
+}
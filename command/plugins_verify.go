@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/hashicorp/packer/packer/plugin-getter/pgp"
+	"github.com/mitchellh/cli"
+)
+
+type PluginsVerifyCommand struct {
+	Meta
+}
+
+func (c *PluginsVerifyCommand) Synopsis() string {
+	return "Verify installed Packer plugins against a lockfile or their signatures"
+}
+
+func (c *PluginsVerifyCommand) Help() string {
+	helpText := `
+Usage: packer plugins verify [-lockfile <path>] [-public-key <path>]
+
+  This command verifies that the Packer plugins installed for the current OS
+  and architecture, in the plugin directory and the folders plugins are also
+  loaded from, are exactly the ones of a lockfile, at the locked version and
+  with the locked checksum. Missing, not locked, changed plugins and plugins
+  only installed for a protocol version Packer cannot use are reported.
+
+  With -public-key, it also re-verifies, offline, the signatures stored when
+  the plugins were installed with signature verification: the checksum file
+  of their release must be signed by the key, and list the zip each binary
+  was extracted from. Plugins without a stored signature are reported.
+
+  Ex: packer plugins verify -lockfile plugins.lock.json
+
+Options:
+  -lockfile <path>              The lockfile to verify installed plugins
+                                against.
+  -public-key <path>            The armored PGP public key to verify the
+                                stored signatures with.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginsVerifyCommand) Run(args []string) int {
+	ctx, cleanup := handleTermInterrupt(c.Ui)
+	defer cleanup()
+
+	flags := c.Meta.FlagSet("plugins verify")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	var lockfile, publicKeyFile string
+	flags.StringVar(&lockfile, "lockfile", "", "the lockfile to verify installed plugins against.")
+	flags.StringVar(&publicKeyFile, "public-key", "", "the PGP public key to verify stored signatures with.")
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse options: %s", err))
+		return 1
+	}
+	if (lockfile == "" && publicKeyFile == "") || flags.NArg() > 0 {
+		return cli.RunResultHelp
+	}
+
+	if lockfile != "" {
+		if ret := c.RunContext(ctx, lockfile); ret != 0 {
+			return ret
+		}
+	}
+	if publicKeyFile != "" {
+		return c.verifySignatures(publicKeyFile)
+	}
+	return 0
+}
+
+func (c *PluginsVerifyCommand) RunContext(buildCtx context.Context, lockfile string) int {
+	lock, err := plugingetter.ReadLockfile(lockfile)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	opts := plugingetter.ListInstallationsOptions{
+		PluginDirectory: c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory,
+		FromFolders:     c.Meta.CoreConfig.Components.PluginConfig.FromFolders,
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			OS:              runtime.GOOS,
+			ARCH:            runtime.GOARCH,
+			APIVersionMajor: pluginsdk.APIVersionMajor,
+			APIVersionMinor: pluginsdk.APIVersionMinor,
+		},
+	}
+	if runtime.GOOS == "windows" {
+		opts.BinaryInstallationOptions.Ext = ".exe"
+	}
+
+	err = plugingetter.VerifyLockfile(lock, opts)
+	var drift *plugingetter.LockfileDriftError
+	if errors.As(err, &drift) {
+		for _, plugin := range drift.Missing {
+			c.Ui.Error(fmt.Sprintf("%s is locked but not installed", plugin))
+		}
+		for _, plugin := range drift.Extra {
+			c.Ui.Error(fmt.Sprintf("%s is installed but not locked", plugin))
+		}
+		for _, plugin := range drift.Changed {
+			c.Ui.Error(fmt.Sprintf("%s does not match its locked checksum", plugin))
+		}
+		for _, plugin := range drift.Incompatible {
+			c.Ui.Error(fmt.Sprintf("%s is only installed for a protocol version this Packer cannot use", plugin))
+		}
+		return 1
+	}
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	c.Ui.Message(fmt.Sprintf("Installed plugins match %q", lockfile))
+	return 0
+}
+
+// verifySignatures re-verifies the stored signatures of the plugins installed
+// for the current OS and architecture with the public key of publicKeyFile.
+func (c *PluginsVerifyCommand) verifySignatures(publicKeyFile string) int {
+	publicKey, err := os.ReadFile(publicKeyFile)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to read the public key: %s", err))
+		return 1
+	}
+	verifier := &pgp.Verifier{PublicKey: string(publicKey)}
+
+	pluginDir := c.Meta.CoreConfig.Components.PluginConfig.PluginDirectory
+	opts := plugingetter.ListInstallationsOptions{
+		PluginDirectory: pluginDir,
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			OS:   runtime.GOOS,
+			ARCH: runtime.GOARCH,
+		},
+	}
+	if runtime.GOOS == "windows" {
+		opts.BinaryInstallationOptions.Ext = ".exe"
+	}
+	installed, err := plugingetter.Requirement{}.ListInstalledVersions(opts)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	ret := 0
+	for _, binary := range installed {
+		source, err := filepath.Rel(pluginDir, filepath.Dir(binary.BinaryPath))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		source = filepath.ToSlash(source)
+		identifier, diags := addrs.ParsePluginSourceString(source)
+		if diags.HasErrors() {
+			c.Ui.Error(fmt.Sprintf("%s: invalid plugin source %q: %s", binary.BinaryPath, source, diags.Error()))
+			ret = 1
+			continue
+		}
+
+		install := &plugingetter.Installation{BinaryPath: binary.BinaryPath, Version: binary.Version}
+		err = install.VerifyStoredSignature(&plugingetter.Requirement{Identifier: identifier}, verifier)
+		switch {
+		case errors.Is(err, plugingetter.ErrNoStoredSignature):
+			c.Ui.Error(fmt.Sprintf("%s %s has no stored signature", source, binary.Version))
+			ret = 1
+		case err != nil:
+			c.Ui.Error(fmt.Sprintf("%s %s: %s", source, binary.Version, err))
+			ret = 1
+		}
+	}
+	if ret == 0 {
+		c.Ui.Message(fmt.Sprintf("Stored signatures of the %d installed plugin binaries are valid", len(installed)))
+	}
+	return ret
+}
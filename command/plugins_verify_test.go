@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+func TestPluginsVerifyCommand_Run(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	checksum, err := os.ReadFile(binary + "_SHA256SUM")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeLockfile := func(version string) string {
+		path := filepath.Join(t.TempDir(), "plugins.lock.json")
+		content := fmt.Sprintf(`{"plugins": [{"source": "github.com/hashicorp/hashicups", "version": %q, "checksum": "sha256:%s"}]}`, version, checksum)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name       string
+		lockfile   string
+		want       int
+		wantStderr []string
+	}{
+		{"match", writeLockfile("v1.0.1"), 0, nil},
+		{"drift", writeLockfile("v1.0.2"), 1, []string{
+			"github.com/hashicorp/hashicups v1.0.2 is locked but not installed",
+			"github.com/hashicorp/hashicups v1.0.1 is installed but not locked",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &PluginsVerifyCommand{
+				Meta: TestMetaFile(t),
+			}
+			c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+			if got := c.Run([]string{"-lockfile", tt.lockfile}); got != tt.want {
+				_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+				t.Fatalf("PluginsVerifyCommand.Run() = %d, want %d. stderr: %s", got, tt.want, stderr)
+			}
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("expected stderr to contain %q, got:\n%s", want, stderr)
+				}
+			}
+		})
+	}
+}
+
+// signFakePlugin records that binaryPath was installed from a zip with the
+// aaa... checksum, and stores next to it a checksum file listing checksum for
+// that zip, signed by entity.
+func signFakePlugin(t *testing.T, entity *openpgp.Entity, binaryPath, checksum string) {
+	zipFilename := filepath.Base(binaryPath) + ".zip"
+	if err := os.WriteFile(binaryPath+"_ZIP_SHA256SUM", []byte(strings.Repeat("a", 64)+"  "+zipFilename+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	checksumFile := checksum + "  " + zipFilename + "\n"
+	if err := os.WriteFile(binaryPath+"_SHA256SUMS", []byte(checksumFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	signature := &bytes.Buffer{}
+	if err := openpgp.DetachSign(signature, entity, strings.NewReader(checksumFile), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binaryPath+"_SHA256SUMS.sig", signature.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPluginsVerifyCommand_Run_publicKey(t *testing.T) {
+	entity, err := openpgp.NewEntity("Packer test", "", "packer@example.com", &packet.Config{
+		Algorithm: packet.PubKeyAlgoEdDSA,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := &bytes.Buffer{}
+	w, err := armor.Encode(publicKey, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	publicKeyFile := filepath.Join(t.TempDir(), "key.asc")
+	if err := os.WriteFile(publicKeyFile, publicKey.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, pluginDir string)
+		want       int
+		wantStderr string
+	}{
+		{
+			name: "signed",
+			setup: func(t *testing.T, pluginDir string) {
+				binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+				signFakePlugin(t, entity, binary, strings.Repeat("a", 64))
+			},
+			want: 0,
+		},
+		{
+			name: "installed-from-another-zip",
+			setup: func(t *testing.T, pluginDir string) {
+				binary := createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+				signFakePlugin(t, entity, binary, strings.Repeat("b", 64))
+			},
+			want:       1,
+			wantStderr: "not part of the signed checksum file",
+		},
+		{
+			name: "no-signature",
+			setup: func(t *testing.T, pluginDir string) {
+				createFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+			},
+			want:       1,
+			wantStderr: "github.com/hashicorp/hashicups v1.0.1 has no stored signature",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			tt.setup(t, pluginDir)
+
+			c := &PluginsVerifyCommand{
+				Meta: TestMetaFile(t),
+			}
+			c.CoreConfig.Components.PluginConfig.PluginDirectory = pluginDir
+
+			got := c.Run([]string{"-public-key", publicKeyFile})
+			_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			if got != tt.want {
+				t.Fatalf("PluginsVerifyCommand.Run() = %d, want %d. stderr: %s", got, tt.want, stderr)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got:\n%s", tt.wantStderr, stderr)
+			}
+		})
+	}
+}
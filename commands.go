@@ -77,24 +77,54 @@ func init() {
 			}, nil
 		},
 
+		"plugins check-remote": func() (cli.Command, error) {
+			return &command.PluginsCheckRemoteCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"plugins install": func() (cli.Command, error) {
 			return &command.PluginsInstallCommand{
 				Meta: *CommandMeta,
 			}, nil
 		},
 
+		"plugins path": func() (cli.Command, error) {
+			return &command.PluginsPathCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
+		"plugins prune": func() (cli.Command, error) {
+			return &command.PluginsPruneCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"plugins remove": func() (cli.Command, error) {
 			return &command.PluginsRemoveCommand{
 				Meta: *CommandMeta,
 			}, nil
 		},
 
+		"plugins repair": func() (cli.Command, error) {
+			return &command.PluginsRepairCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"plugins required": func() (cli.Command, error) {
 			return &command.PluginsRequiredCommand{
 				Meta: *CommandMeta,
 			}, nil
 		},
 
+		"plugins verify": func() (cli.Command, error) {
+			return &command.PluginsVerifyCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"validate": func() (cli.Command, error) {
 			return &command.ValidateCommand{
 				Meta: *CommandMeta,
@@ -30,6 +30,12 @@ type config struct {
 	RawProvisioners            map[string]string `json:"provisioners"`
 	RawPostProcessors          map[string]string `json:"post-processors"`
 
+	PluginGetters      packer.PluginGettersConfig      `json:"plugin_getters"`
+	PluginHostnames    map[string]string               `json:"plugin_hostnames"`
+	PluginProvenance   packer.PluginProvenanceConfig   `json:"plugin_provenance"`
+	PluginSigstore     packer.PluginSigstoreConfig     `json:"plugin_sigstore"`
+	PluginTagSignature packer.PluginTagSignatureConfig `json:"plugin_tag_signature"`
+
 	Plugins *packer.PluginConfig
 }
 
@@ -224,3 +224,99 @@ func generateFakePackerConfigData() (packerConfigData string, cleanUpFunc func()
 
 	return
 }
+
+func TestLoadConfig_pluginGetters(t *testing.T) {
+	packerConfig := `
+	{
+		"disable_checkpoint": true,
+		"plugin_getters": {
+			"max_retries": 3,
+			"github": {
+				"token": "config-token",
+				"api_base_url": "https://github-api.mirror.internal/",
+				"download_base_url": "https://github-releases.mirror.internal/",
+				"proxy": "http://proxy.internal:3128",
+				"ca_cert_file": "/etc/ssl/internal-ca.pem",
+				"user_agent": "internal-packer"
+			},
+			"content_addressed": {
+				"enabled": true,
+				"ipfs_gateway_url": "https://ipfs.internal/"
+			}
+		},
+		"plugin_hostnames": {
+			"acme": "git.internal"
+		},
+		"plugin_provenance": {
+			"enabled": true,
+			"builder_id": "https://example.com/builder"
+		},
+		"plugin_sigstore": {
+			"enabled": true,
+			"identity": "https://github.com/hashicorp/packer-plugin-amazon/.github/workflows/release.yml@refs/tags/v1.0.0",
+			"issuer": "https://token.actions.githubusercontent.com",
+			"fulcio_roots_file": "/etc/sigstore/fulcio.pem",
+			"rekor_public_key_file": "/etc/sigstore/rekor.pub"
+		},
+		"plugin_tag_signature": {
+			"enabled": true,
+			"public_key_file": "/etc/packer/plugins.asc",
+			"trusted_fingerprints": ["0123456789ABCDEF0123456789ABCDEF01234567"]
+		}
+	}`
+
+	configFile := filepath.Join(t.TempDir(), "packerconfig")
+	if err := os.WriteFile(configFile, []byte(packerConfig), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("PACKER_CONFIG", configFile)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	expected := packer.PluginGettersConfig{
+		GitHub: packer.GitHubGetterConfig{
+			Token:           "config-token",
+			APIBaseURL:      "https://github-api.mirror.internal/",
+			DownloadBaseURL: "https://github-releases.mirror.internal/",
+			Proxy:           "http://proxy.internal:3128",
+			CACertFile:      "/etc/ssl/internal-ca.pem",
+			UserAgent:       "internal-packer",
+		},
+		ContentAddressed: packer.ContentAddressedGetterConfig{
+			Enabled:        true,
+			IPFSGatewayURL: "https://ipfs.internal/",
+		},
+		MaxRetries: 3,
+	}
+	if !reflect.DeepEqual(cfg.Plugins.Getters, expected) {
+		t.Errorf("plugin getters config not loaded; expected %#v got %#v", expected, cfg.Plugins.Getters)
+	}
+	if got := cfg.Plugins.NamespaceHostnames["acme"]; got != "git.internal" {
+		t.Errorf("plugin hostnames config not loaded; expected %q got %q", "git.internal", got)
+	}
+	expectedProvenance := packer.PluginProvenanceConfig{Enabled: true, BuilderID: "https://example.com/builder"}
+	if cfg.Plugins.Provenance != expectedProvenance {
+		t.Errorf("plugin provenance config not loaded; expected %#v got %#v", expectedProvenance, cfg.Plugins.Provenance)
+	}
+	expectedSigstore := packer.PluginSigstoreConfig{
+		Enabled:            true,
+		Identity:           "https://github.com/hashicorp/packer-plugin-amazon/.github/workflows/release.yml@refs/tags/v1.0.0",
+		Issuer:             "https://token.actions.githubusercontent.com",
+		FulcioRootsFile:    "/etc/sigstore/fulcio.pem",
+		RekorPublicKeyFile: "/etc/sigstore/rekor.pub",
+	}
+	if cfg.Plugins.Sigstore != expectedSigstore {
+		t.Errorf("plugin sigstore config not loaded; expected %#v got %#v", expectedSigstore, cfg.Plugins.Sigstore)
+	}
+	expectedTagSignature := packer.PluginTagSignatureConfig{
+		Enabled:             true,
+		PublicKeyFile:       "/etc/packer/plugins.asc",
+		TrustedFingerprints: []string{"0123456789ABCDEF0123456789ABCDEF01234567"},
+	}
+	if !reflect.DeepEqual(cfg.Plugins.TagSignature, expectedTagSignature) {
+		t.Errorf("plugin tag signature config not loaded; expected %#v got %#v", expectedTagSignature, cfg.Plugins.TagSignature)
+	}
+}
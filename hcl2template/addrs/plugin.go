@@ -93,15 +93,32 @@ func IsPluginPartNormalized(str string) (bool, error) {
 	return false, nil
 }
 
+// DefaultPluginHostname is the hostname of "namespace/name" sources whose
+// namespace has no entry in NamespaceHostnames.
+const DefaultPluginHostname = "github.com"
+
+// NamespaceHostnames maps plugin namespaces to the hostname of the forge
+// hosting them, ex: {"acme": "git.internal"}, so that the "acme/foo" source
+// resolves to "git.internal/acme/foo".
+//
+// Namespaces are matched after normalization, see ParsePluginPart.
+type NamespaceHostnames map[string]string
+
 // ParsePluginSourceString parses the source attribute and returns a plugin.
 // This is intended primarily to parse the FQN-like strings
 //
 // The following are valid source string formats:
 //
-//	name
-//	namespace/name
 //	hostname/namespace/name
 func ParsePluginSourceString(str string) (*Plugin, hcl.Diagnostics) {
+	return NamespaceHostnames(nil).ParsePluginSourceString(str)
+}
+
+// ParsePluginSourceString parses the source attribute like the
+// ParsePluginSourceString function does. When h is not empty,
+// "namespace/name" sources are accepted too: their hostname is the one of
+// their namespace in h, or DefaultPluginHostname.
+func (h NamespaceHostnames) ParsePluginSourceString(str string) (*Plugin, hcl.Diagnostics) {
 	ret := &Plugin{
 		Hostname:  "",
 		Namespace: "",
@@ -110,6 +127,11 @@ func ParsePluginSourceString(str string) (*Plugin, hcl.Diagnostics) {
 
 	// split the source string into individual components
 	parts := strings.Split(str, "/")
+	if len(parts) == 2 && len(h) > 0 {
+		// an invalid namespace is reported below
+		namespace, _ := ParsePluginPart(parts[0])
+		parts = append([]string{h.hostname(namespace)}, parts...)
+	}
 	if len(parts) != 3 {
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
@@ -210,3 +232,13 @@ func ParsePluginSourceString(str string) (*Plugin, hcl.Diagnostics) {
 
 	return ret, diags
 }
+
+// hostname returns the hostname of the normalized namespace.
+func (h NamespaceHostnames) hostname(namespace string) string {
+	for ns, hostname := range h {
+		if normalized, err := ParsePluginPart(ns); err == nil && normalized == namespace {
+			return hostname
+		}
+	}
+	return DefaultPluginHostname
+}
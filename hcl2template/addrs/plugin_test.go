@@ -33,3 +33,35 @@ func TestParsePluginSourceString(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceHostnames_ParsePluginSourceString(t *testing.T) {
+	hostnames := NamespaceHostnames{
+		"acme":   "git.internal",
+		"Globex": "forge.globex.example",
+	}
+	tests := []struct {
+		str       string
+		want      *Plugin
+		wantDiags bool
+	}{
+		{"acme/foo", &Plugin{"git.internal", "acme", "foo"}, false},
+		{"ACME/foo", &Plugin{"git.internal", "acme", "foo"}, false},
+		{"globex/foo", &Plugin{"forge.globex.example", "globex", "foo"}, false},
+		{"hashicorp/bar", &Plugin{"github.com", "hashicorp", "bar"}, false},
+		{"github.com/acme/foo", &Plugin{"github.com", "acme", "foo"}, false},
+		{"acme/packer-plugin-foo", nil, true},
+		{"ac..me/foo", nil, true},
+		{"foo", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, gotDiags := hostnames.ParsePluginSourceString(tt.str)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePluginSourceString() got = %v, want %v", got, tt.want)
+			}
+			if tt.wantDiags == (len(gotDiags) == 0) {
+				t.Errorf("Unexpected diags %s", gotDiags)
+			}
+		})
+	}
+}
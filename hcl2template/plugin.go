@@ -68,6 +68,7 @@ func (cfg *PackerConfig) DetectPluginBinaries() hcl.Diagnostics {
 	// Then we can apply any constraint from the template, if any
 	opts := plugingetter.ListInstallationsOptions{
 		PluginDirectory: cfg.parser.PluginConfig.PluginDirectory,
+		FromFolders:     cfg.parser.PluginConfig.FromFolders,
 		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
 			OS:              runtime.GOOS,
 			ARCH:            runtime.GOARCH,
@@ -30,7 +30,7 @@ func (cfg *PackerConfig) decodeRequiredPluginsBlock(f *hcl.File) hcl.Diagnostics
 			for _, innerBlock := range content.Blocks {
 				switch innerBlock.Type {
 				case "required_plugins":
-					reqs, reqsDiags := decodeRequiredPluginsBlock(innerBlock)
+					reqs, reqsDiags := decodeRequiredPluginsBlock(innerBlock, cfg.namespaceHostnames())
 					diags = append(diags, reqsDiags...)
 					cfg.Packer.RequiredPlugins = append(cfg.Packer.RequiredPlugins, reqs)
 				default:
@@ -62,7 +62,15 @@ type RequiredPlugins struct {
 	DeclRange       hcl.Range
 }
 
-func decodeRequiredPluginsBlock(block *hcl.Block) (*RequiredPlugins, hcl.Diagnostics) {
+// namespaceHostnames returns the hostnames of "namespace/name" plugin sources.
+func (cfg *PackerConfig) namespaceHostnames() addrs.NamespaceHostnames {
+	if cfg.parser == nil || cfg.parser.PluginConfig == nil {
+		return nil
+	}
+	return cfg.parser.PluginConfig.NamespaceHostnames
+}
+
+func decodeRequiredPluginsBlock(block *hcl.Block, hostnames addrs.NamespaceHostnames) (*RequiredPlugins, hcl.Diagnostics) {
 	attrs, diags := block.Body.JustAttributes()
 	ret := &RequiredPlugins{
 		RequiredPlugins: nil,
@@ -166,7 +174,7 @@ func decodeRequiredPluginsBlock(block *hcl.Block) (*RequiredPlugins, hcl.Diagnos
 			}
 
 			rp.Source = source.AsString()
-			p, sourceDiags := addrs.ParsePluginSourceString(rp.Source)
+			p, sourceDiags := hostnames.ParsePluginSourceString(rp.Source)
 
 			if sourceDiags.HasErrors() {
 				for _, diag := range sourceDiags {
@@ -329,7 +329,7 @@ func extractMachineReadable(args []string) ([]string, bool) {
 }
 
 func loadConfig() (*config, error) {
-	pluginDir, err := packer.PluginFolder()
+	pluginDirs, err := packer.PluginFolders()
 	if err != nil {
 		return nil, err
 	}
@@ -338,7 +338,8 @@ func loadConfig() (*config, error) {
 	config.Plugins = &packer.PluginConfig{
 		PluginMinPort:   10000,
 		PluginMaxPort:   25000,
-		PluginDirectory: pluginDir,
+		PluginDirectory: pluginDirs[0],
+		FromFolders:     pluginDirs[1:],
 		Builders:        packer.MapOfBuilder{},
 		Provisioners:    packer.MapOfProvisioner{},
 		PostProcessors:  packer.MapOfPostProcessor{},
@@ -381,6 +382,12 @@ func loadConfig() (*config, error) {
 		return nil, err
 	}
 
+	config.Plugins.Getters = config.PluginGetters
+	config.Plugins.NamespaceHostnames = config.PluginHostnames
+	config.Plugins.Provenance = config.PluginProvenance
+	config.Plugins.Sigstore = config.PluginSigstore
+	config.Plugins.TagSignature = config.PluginTagSignature
+
 	config.LoadExternalComponentsFromConfig()
 
 	return &config, nil
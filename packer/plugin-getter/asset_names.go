@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Default templates of the names of release files, the ones produced by the
+// plugin scaffolding release workflow, ex:
+//
+//	packer-plugin-amazon_v1.2.3_x5.0_linux_amd64.zip
+//	packer-plugin-amazon_v1.2.3_SHA256SUMS
+//
+// Templates can use the following tokens:
+//
+//   - {prefix}: the filename prefix of the plugin, ex: "packer-plugin-amazon_"
+//   - {version}: the version of the release, ex: "v1.2.3"
+//   - {api}: the plugin API version of the binary, ex: "x5.0"
+//   - {os} and {arch}: the platform of the binary, ex: "linux" and "amd64"
+const (
+	DefaultZipAssetTemplate      = "{prefix}{version}_{api}_{os}_{arch}.zip"
+	DefaultChecksumAssetTemplate = "{prefix}{version}_SHA256SUMS"
+)
+
+// AssetNames are the templates of the names of the files of a release. Empty
+// templates use the default ones.
+type AssetNames struct {
+	// Zip is the name of a zip listed in the checksum file. It must contain
+	// the {version}, {os} and {arch} tokens, without {api} the binary is
+	// expected to be built for the plugin API version of Packer.
+	Zip string
+	// Checksum is the name of the checksum file.
+	Checksum string
+	// Binary is the name of the plugin binary in a zip, without the .exe
+	// extension of Windows binaries, when it is not named like the binaries
+	// Packer installs, ex: "{prefix}{version}". It is installed renamed.
+	// When empty, a zip without such a binary has its only executable
+	// installed.
+	Binary string
+}
+
+// AssetNamer is implemented by getters whose release files are not named
+// like the default templates.
+type AssetNamer interface {
+	AssetNames() AssetNames
+}
+
+// assetNames returns the templates of the release files of getter.
+func assetNames(getter Getter) AssetNames {
+	var names AssetNames
+	if namer, ok := getter.(AssetNamer); ok {
+		names = namer.AssetNames()
+	}
+	if names.Zip == "" {
+		names.Zip = DefaultZipAssetTemplate
+	}
+	if names.Checksum == "" {
+		names.Checksum = DefaultChecksumAssetTemplate
+	}
+	return names
+}
+
+var assetTokenRegex = regexp.MustCompile(`\{(prefix|version|api|os|arch)\}`)
+
+// RenderAssetName returns the name described by tmpl for the release of
+// opts. {api} is the plugin API version of Packer, while {os} and {arch} are
+// the platform of opts.
+func (gp *GetOptions) RenderAssetName(tmpl string) string {
+	vars := map[string]string{
+		"prefix":  gp.PluginRequirement.FilenamePrefix(),
+		"version": gp.Version(),
+		"api":     "x" + gp.APIVersionMajor + "." + gp.APIVersionMinor,
+		"os":      gp.OS,
+		"arch":    gp.ARCH,
+	}
+	return assetTokenRegex.ReplaceAllStringFunc(tmpl, func(token string) string {
+		return vars[strings.Trim(token, "{}")]
+	})
+}
+
+// parseAssetName returns the value of every token of tmpl in name.
+func parseAssetName(tmpl, prefix, name string) (map[string]string, error) {
+	expr := &strings.Builder{}
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range assetTokenRegex.FindAllStringSubmatchIndex(tmpl, -1) {
+		expr.WriteString(regexp.QuoteMeta(tmpl[last:loc[0]]))
+		token := tmpl[loc[2]:loc[3]]
+		if token == "prefix" {
+			fmt.Fprintf(expr, "(?P<prefix>%s)", regexp.QuoteMeta(prefix))
+		} else {
+			fmt.Fprintf(expr, "(?P<%s>[^/]+?)", token)
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(tmpl[last:]))
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid asset name template %q: %s", tmpl, err)
+	}
+	match := re.FindStringSubmatch(name)
+	if match == nil {
+		return nil, fmt.Errorf("%q is not named like %s", name, tmpl)
+	}
+	vars := map[string]string{}
+	for i, token := range re.SubexpNames() {
+		if token == "" {
+			continue
+		}
+		if previous, found := vars[token]; found && previous != match[i] {
+			return nil, fmt.Errorf("%q has different {%s} values", name, token)
+		}
+		vars[token] = match[i]
+	}
+	return vars, nil
+}
+
+// initFromTemplate is init for zips named like tmpl. The binary in the zip is
+// expected to be named like the binaries Packer installs.
+func (e *ChecksumFileEntry) initFromTemplate(req *Requirement, tmpl string, opts BinaryInstallationOptions) error {
+	vars, err := parseAssetName(tmpl, req.FilenamePrefix(), e.Filename)
+	if err != nil {
+		return err
+	}
+	for _, token := range []string{"version", "os", "arch"} {
+		if _, found := vars[token]; !found {
+			return fmt.Errorf("invalid asset name template %q: no {%s}", tmpl, token)
+		}
+	}
+	if _, found := vars["api"]; !found {
+		vars["api"] = "x" + opts.APIVersionMajor + "." + opts.APIVersionMinor
+	}
+
+	e.ext = filepath.Ext(e.Filename)
+	e.binVersion, e.protVersion, e.os, e.arch = vars["version"], vars["api"], vars["os"], vars["arch"]
+	e.versionCore = versionCore(e.binVersion)
+	e.zipName = e.Filename
+	e.binaryName = req.FilenamePrefix() + strings.Join([]string{e.binVersion, e.protVersion, e.os, e.arch}, "_")
+	return nil
+}
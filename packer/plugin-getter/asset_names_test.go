@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+// namedAssetsGetter is a mockPluginGetter naming its release files like
+// Names.
+type namedAssetsGetter struct {
+	*mockPluginGetter
+	Names AssetNames
+}
+
+func (g *namedAssetsGetter) AssetNames() AssetNames { return g.Names }
+
+var _ AssetNamer = &namedAssetsGetter{}
+
+func TestGetOptions_RenderAssetName(t *testing.T) {
+	opts := GetOptions{
+		PluginRequirement: mustRequirement(t, "github.com/hashicorp/amazon", ""),
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "amd64",
+		},
+		version: version.Must(version.NewVersion("1.2.3")),
+	}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{DefaultZipAssetTemplate, "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64.zip"},
+		{DefaultChecksumAssetTemplate, "packer-plugin-amazon_v1.2.3_SHA256SUMS"},
+		{"amazon-{version}-{os}-{arch}.tar.zip", "amazon-v1.2.3-linux-amd64.tar.zip"},
+		{"{version}/checksums.txt", "v1.2.3/checksums.txt"},
+		{"{unknown}_{version}", "{unknown}_v1.2.3"},
+	}
+	for _, tt := range tests {
+		if got := opts.RenderAssetName(tt.tmpl); got != tt.want {
+			t.Errorf("RenderAssetName(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestRequirement_InstallLatest_assetNames(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name     string
+		template string
+		zipName  string
+		wantErr  string
+	}{
+		{
+			name:     "default",
+			template: "",
+			zipName:  binary + ".zip",
+		},
+		{
+			name:     "custom",
+			template: "amazon-{version}-{api}-{os}-{arch}.zip",
+			zipName:  "amazon-v1.0.0-x5.0-linux-amd64.zip",
+		},
+		{
+			name:     "custom-without-api",
+			template: "{prefix}{os}.{arch}.{version}.zip",
+			zipName:  "packer-plugin-amazon_linux.amd64.v1.0.0.zip",
+		},
+		{
+			name:     "default-template-for-custom-names",
+			template: "",
+			zipName:  "amazon-v1.0.0-x5.0-linux-amd64.zip",
+			wantErr:  "malformed filename",
+		},
+		{
+			name:     "template-without-os",
+			template: "amazon-{version}-{arch}.zip",
+			zipName:  "amazon-v1.0.0-amd64.zip",
+			wantErr:  "no {os}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zip, checksum := zipFileWithChecksum(map[string]string{
+				binary: elfHeader + "amazon",
+			})
+			getter := &namedAssetsGetter{
+				mockPluginGetter: &mockPluginGetter{
+					Releases: []Release{{Version: "v1.0.0"}},
+					ChecksumFileEntries: map[string][]ChecksumFileEntry{
+						"1.0.0": {{Filename: tt.zipName, Checksum: checksum}},
+					},
+					Zips: map[string]io.ReadCloser{
+						"github.com/hashicorp/packer-plugin-amazon/" + tt.zipName: zip,
+					},
+				},
+				Names: AssetNames{Zip: tt.template},
+			}
+
+			pluginDir := t.TempDir()
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, pluginDir))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+
+			wantPath := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary)
+			if install == nil || install.BinaryPath != filepath.ToSlash(wantPath) {
+				t.Fatalf("expected %s to be installed, got %#v", wantPath, install)
+			}
+			if _, err := os.Stat(wantPath + "_SHA256SUM"); err != nil {
+				t.Errorf("expected a checksum file: %v", err)
+			}
+		})
+	}
+}
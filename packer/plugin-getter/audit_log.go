@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"time"
+)
+
+// AuditOperation is what an AuditRecord is about.
+type AuditOperation string
+
+const (
+	AuditOperationInstall AuditOperation = "install"
+	AuditOperationRemove  AuditOperation = "remove"
+)
+
+// AuditOutcomeRemoved is the outcome of a successful removal. The outcome of
+// an install is its InstallReason.
+const AuditOutcomeRemoved = "removed"
+
+// AuditRecord is a line of an audit log, ex:
+//
+//	{"time":"2024-01-02T03:04:05Z","user":"ops","operation":"install","source":"github.com/hashicorp/amazon","version":"v1.2.3","binary_path":"/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.2.3_x5.0_linux_amd64","checksum":"sha256:9f86d081884c7d65...","outcome":"installed"}
+type AuditRecord struct {
+	Time       time.Time      `json:"time"`
+	User       string         `json:"user"`
+	Operation  AuditOperation `json:"operation"`
+	Source     string         `json:"source"`
+	Version    string         `json:"version,omitempty"`
+	BinaryPath string         `json:"binary_path,omitempty"`
+	// Checksum is the stored checksum of the binary, see
+	// Installation.StoredChecksum.
+	Checksum string `json:"checksum,omitempty"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AppendAuditRecord appends record as a JSON line to the audit log in path,
+// creating it if needed. The time and user of the record are set when empty.
+//
+// The line is written with a single append, so that records of concurrent
+// Packer processes are not interleaved.
+func AppendAuditRecord(path string, record AuditRecord) error {
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	if record.User == "" {
+		record.User = currentUser()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open the audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write the audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// currentUser returns the name of the user running Packer.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// auditInstall appends result to opts.AuditLogPath, if set. Failing to do so
+// does not fail the install.
+func (opts *InstallOptions) auditInstall(result InstallResult) {
+	if opts.AuditLogPath == "" {
+		return
+	}
+	record := AuditRecord{
+		Operation: AuditOperationInstall,
+		Source:    result.Source,
+		Outcome:   string(result.Reason),
+	}
+	if result.Installation != nil {
+		record.Version = result.Installation.Version
+		record.BinaryPath = result.Installation.BinaryPath
+		record.Checksum, _ = result.Installation.StoredChecksum()
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+	if err := AppendAuditRecord(opts.AuditLogPath, record); err != nil {
+		log.Printf("[WARNING] %s: %s", opts.AuditLogPath, err)
+	}
+}
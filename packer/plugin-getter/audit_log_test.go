@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readAuditLog returns the records of the audit log in path, failing on
+// malformed lines.
+func readAuditLog(t *testing.T, path string) []AuditRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("malformed audit record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestAppendAuditRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for _, source := range []string{"github.com/hashicorp/amazon", "github.com/hashicorp/docker"} {
+		err := AppendAuditRecord(path, AuditRecord{
+			Operation: AuditOperationRemove,
+			Source:    source,
+			Outcome:   AuditOutcomeRemoved,
+		})
+		if err != nil {
+			t.Fatalf("AppendAuditRecord: %v", err)
+		}
+	}
+
+	records := readAuditLog(t, path)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	for i, source := range []string{"github.com/hashicorp/amazon", "github.com/hashicorp/docker"} {
+		record := records[i]
+		if record.Source != source || record.Operation != AuditOperationRemove || record.Outcome != AuditOutcomeRemoved {
+			t.Errorf("unexpected record %d: %#v", i, record)
+		}
+		if record.Time.IsZero() || record.User == "" {
+			t.Errorf("expected the time and user of record %d to be set: %#v", i, record)
+		}
+	}
+}
+
+func TestRequirements_InstallAll_auditLog(t *testing.T) {
+	pluginDir := t.TempDir()
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon"),
+	}
+	opts := dependenciesInstallOptions(getter, pluginDir)
+	opts.AuditLogPath = auditLog
+
+	reqs := Requirements{mustRequirement(t, "github.com/hashicorp/amazon", "")}
+	installs, err := reqs.InstallAll(opts)
+	if err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+	// installing again appends to the log.
+	if _, err := reqs.InstallAll(opts); err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+
+	records := readAuditLog(t, auditLog)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	installed, upToDate := records[0], records[1]
+	if installed.Operation != AuditOperationInstall || installed.Outcome != string(InstallReasonInstalled) ||
+		installed.Source != "github.com/hashicorp/amazon" || installed.Version != "v1.0.0" ||
+		installed.BinaryPath != installs[0].BinaryPath || !strings.HasPrefix(installed.Checksum, "sha256:") {
+		t.Errorf("unexpected install record: %#v", installed)
+	}
+	if upToDate.Outcome != string(InstallReasonUpToDate) {
+		t.Errorf("unexpected up to date record: %#v", upToDate)
+	}
+}
+
+func TestRequirement_InstallLatestForPlatforms_auditLog(t *testing.T) {
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	opts := dependenciesInstallOptions(singleReleaseGetter("amazon"), t.TempDir())
+	opts.AuditLogPath = auditLog
+	linux := opts.BinaryInstallationOptions
+	// not released for this platform.
+	windows := linux
+	windows.OS = "windows"
+
+	installs, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatestForPlatforms(opts, []BinaryInstallationOptions{linux, windows})
+	if err == nil {
+		t.Fatal("InstallLatestForPlatforms: expected windows not to be installed")
+	}
+	if len(installs) != 1 {
+		t.Fatalf("expected linux to be installed, got %v", installs)
+	}
+
+	records := readAuditLog(t, auditLog)
+	if len(records) != 2 {
+		t.Fatalf("expected a record per platform, got %#v", records)
+	}
+	installed, failed := records[0], records[1]
+	if installed.Outcome != string(InstallReasonInstalled) || installed.BinaryPath != installs[0].BinaryPath ||
+		installed.Source != "github.com/hashicorp/amazon" || !strings.HasPrefix(installed.Checksum, "sha256:") {
+		t.Errorf("unexpected install record: %#v", installed)
+	}
+	if failed.Outcome != string(InstallReasonFailed) || !strings.Contains(failed.Error, "windows_amd64") {
+		t.Errorf("unexpected failure record: %#v", failed)
+	}
+}
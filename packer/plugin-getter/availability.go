@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+)
+
+// Platform is an OS and architecture plugins are released for.
+type Platform struct {
+	OS, ARCH string
+}
+
+func (p Platform) String() string { return p.OS + "_" + p.ARCH }
+
+// A ZipChecker is a Getter that can tell whether a zip exists without
+// downloading it, ex: with a HEAD request. Other getters are asked for the
+// zip, which is closed without being read.
+type ZipChecker interface {
+	ZipExists(opts GetOptions) (bool, error)
+}
+
+// MissingPlatforms returns the platforms that version v of pr has no zip for,
+// either because its checksum file does not list one or because the listed
+// zip does not exist. Nothing is downloaded but the checksum file.
+//
+// The checksum file is got from the first getter of opts that has it, the
+// zips are looked for with the same getter.
+func (pr *Requirement) MissingPlatforms(v *version.Version, platforms []Platform, opts InstallOptions) ([]Platform, error) {
+	release, err := pr.releaseChecksumFile(v, opts)
+	if err != nil {
+		return nil, err
+	}
+	return opts.missingPlatforms(release, platforms)
+}
+
+// releaseChecksumFile is the checksum file of a release, and the getter it
+// was got from.
+type releaseChecksumFile struct {
+	getter      Getter
+	getOpts     GetOptions
+	checksummer Checksummer
+	entries     []ChecksumFileEntry
+}
+
+// releaseChecksumFile gets the checksum file of version v of pr from the
+// first getter of opts that has it.
+func (pr *Requirement) releaseChecksumFile(v *version.Version, opts InstallOptions) (*releaseChecksumFile, error) {
+	var errs *multierror.Error
+	for _, getter := range opts.Getters {
+		for _, checksummer := range pr.checksummers(opts.BinaryInstallationOptions) {
+			getOpts := GetOptions{
+				PluginRequirement:         pr,
+				BinaryInstallationOptions: opts.BinaryInstallationOptions,
+				version:                   v,
+			}
+			checksumFile, err := opts.get(getter, checksummer.Type, getOpts)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("could not get %s checksum file for %s version %s: %w", checksummer.Type, pr.Identifier, v, err))
+				continue
+			}
+			entries, err := ParseChecksumFileEntries(checksumFile)
+			_ = checksumFile.Close()
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("could not parse %s checksum file for %s version %s: %w", checksummer.Type, pr.Identifier, v, err))
+				continue
+			}
+			return &releaseChecksumFile{getter: getter, getOpts: getOpts, checksummer: checksummer, entries: entries}, nil
+		}
+	}
+	if errs == nil {
+		return nil, fmt.Errorf("no getter to check %s %s with", pr.Identifier, v)
+	}
+	return nil, errs
+}
+
+// platformEntry returns the entry of the release zip for platform, and the
+// options to get it with. ok is false when the checksum file lists none.
+func (opts *InstallOptions) platformEntry(release *releaseChecksumFile, platform Platform) (entry ChecksumFileEntry, platformOpts GetOptions, ok bool) {
+	platformOpts = release.getOpts
+	platformOpts.OS, platformOpts.ARCH = platform.OS, platform.ARCH
+	for _, entry := range release.entries {
+		if err := opts.initChecksumFileEntry(release.getOpts.PluginRequirement, release.getter, &entry); err != nil {
+			continue
+		}
+		if entry.validate(release.getOpts.Version(), platformOpts.BinaryInstallationOptions) == nil {
+			platformOpts.expectedZipFilename = entry.zipName
+			return entry, platformOpts, true
+		}
+	}
+	log.Printf("[TRACE] the checksum file of %s %s lists no %s zip", release.getOpts.PluginRequirement.Identifier, release.getOpts.Version(), platform)
+	return ChecksumFileEntry{}, platformOpts, false
+}
+
+// missingPlatforms returns the platforms that the release has no existing
+// zip for.
+func (opts *InstallOptions) missingPlatforms(release *releaseChecksumFile, platforms []Platform) ([]Platform, error) {
+	var missing []Platform
+	for _, platform := range platforms {
+		_, platformOpts, ok := opts.platformEntry(release, platform)
+		if !ok {
+			missing = append(missing, platform)
+			continue
+		}
+
+		exists, err := opts.zipExists(release.getter, platformOpts)
+		if err != nil {
+			return nil, fmt.Errorf("could not check %s: %w", platformOpts.expectedZipFilename, err)
+		}
+		if !exists {
+			log.Printf("[TRACE] %s is listed but does not exist", platformOpts.expectedZipFilename)
+			missing = append(missing, platform)
+		}
+	}
+	return missing, nil
+}
+
+func (opts *InstallOptions) zipExists(getter Getter, getOpts GetOptions) (bool, error) {
+	if checker, ok := getter.(ZipChecker); ok {
+		if err := opts.waitRateLimit("zip"); err != nil {
+			return false, err
+		}
+		return checker.ZipExists(getOpts)
+	}
+	zip, err := opts.get(getter, "zip", getOpts)
+	if err != nil {
+		log.Printf("[TRACE] could not get %s: %s", getOpts.expectedZipFilename, err)
+		return false, nil
+	}
+	return true, zip.Close()
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+)
+
+// zipCheckingGetter is a mockPluginGetter that checks the zips it has
+// instead of panicking on the missing ones.
+type zipCheckingGetter struct {
+	mockPluginGetter
+	checked []string
+}
+
+func (g *zipCheckingGetter) ZipExists(opts GetOptions) (bool, error) {
+	g.checked = append(g.checked, opts.ExpectedZipFilename())
+	_, found := g.Zips["github.com/hashicorp/packer-plugin-amazon/"+opts.ExpectedZipFilename()]
+	return found, nil
+}
+
+func TestRequirement_MissingPlatforms(t *testing.T) {
+	getter := &zipCheckingGetter{mockPluginGetter: mockPluginGetter{
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{"1.0.0": {}},
+		Zips:                map[string]io.ReadCloser{},
+	}}
+	for _, platform := range []string{"linux_amd64", "darwin_arm64", "windows_amd64"} {
+		zipName := "packer-plugin-amazon_v1.0.0_x5.0_" + platform + ".zip"
+		zip, checksum := zipFileWithChecksum(map[string]string{"packer-plugin-amazon_v1.0.0_x5.0_" + platform: elfHeader})
+		getter.ChecksumFileEntries["1.0.0"] = append(getter.ChecksumFileEntries["1.0.0"], ChecksumFileEntry{Filename: zipName, Checksum: checksum})
+		if platform != "windows_amd64" {
+			getter.Zips["github.com/hashicorp/packer-plugin-amazon/"+zipName] = zip
+		}
+	}
+
+	platforms := []Platform{
+		{OS: "linux", ARCH: "amd64"},
+		{OS: "darwin", ARCH: "arm64"},
+		{OS: "windows", ARCH: "amd64"},
+		{OS: "freebsd", ARCH: "amd64"},
+	}
+	opts := dependenciesInstallOptions(getter, t.TempDir())
+	missing, err := mustRequirement(t, "github.com/hashicorp/amazon", "").MissingPlatforms(version.Must(version.NewVersion("1.0.0")), platforms, opts)
+	if err != nil {
+		t.Fatalf("MissingPlatforms: %v", err)
+	}
+	if diff := cmp.Diff([]Platform{{OS: "windows", ARCH: "amd64"}, {OS: "freebsd", ARCH: "amd64"}}, missing); diff != "" {
+		t.Errorf("unexpected missing platforms: %s", diff)
+	}
+	want := []string{
+		"packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip",
+		"packer-plugin-amazon_v1.0.0_x5.0_darwin_arm64.zip",
+		"packer-plugin-amazon_v1.0.0_x5.0_windows_amd64.zip",
+	}
+	if diff := cmp.Diff(want, getter.checked); diff != "" {
+		t.Errorf("unexpected checked zips: %s", diff)
+	}
+}
+
+func TestRequirement_MissingPlatforms_noChecksumFile(t *testing.T) {
+	getter := &mockPluginGetter{ChecksumFileEntries: map[string][]ChecksumFileEntry{}}
+	opts := dependenciesInstallOptions(getter, t.TempDir())
+	_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").MissingPlatforms(version.Must(version.NewVersion("1.0.0")), []Platform{{OS: "linux", ARCH: "amd64"}}, opts)
+	if err == nil {
+		t.Fatal("MissingPlatforms: expected an error without a checksum file")
+	}
+}
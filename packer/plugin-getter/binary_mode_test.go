@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRequirement_InstallLatest_binaryMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+
+	tests := []struct {
+		name    string
+		mode    os.FileMode
+		want    os.FileMode
+		wantErr string
+	}{
+		{name: "default", want: 0755},
+		{name: "group-only", mode: 0750, want: 0750},
+		{name: "not-executable", mode: 0644, wantErr: "must be executable by their owner"},
+		{name: "not-a-permission", mode: os.ModeSetuid | 0755, wantErr: "only permission bits can be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chowned []string
+			opts := dependenciesInstallOptions(singleReleaseGetter("amazon"), t.TempDir())
+			opts.BinaryMode = tt.mode
+			opts.ChownBinary = func(path string) error {
+				chowned = append(chowned, path)
+				return nil
+			}
+
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+
+			fi, err := os.Stat(install.BinaryPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm() != tt.want {
+				t.Errorf("expected the binary mode to be %#o, got %#o", tt.want, fi.Mode().Perm())
+			}
+			if len(chowned) != 1 {
+				t.Errorf("expected the binary to be chowned once, got %v", chowned)
+			}
+		})
+	}
+}
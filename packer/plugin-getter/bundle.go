@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+)
+
+// ErrBundleNotSupported can be returned by BundleGetter.GetBundle for
+// releases that have no bundle.
+var ErrBundleNotSupported = errors.New("no release bundle")
+
+// A BundleGetter is a Getter that can also serve all the files of a release
+// in a single archive, ex: a mirror bundling them to save round trips.
+//
+// The bundle is a tar archive, optionally gzip compressed, containing the
+// files other phases would return, named like release assets: the
+// SHA256SUMS checksum file, its ".sig" signature, the ".intoto.jsonl"
+// provenance and the zips. During an install, the bundle of a version is
+// only requested once; the files it contains are then verified in memory
+// like downloaded ones, and the phases it has no file for are got from the
+// getter as usual. When GetBundle fails, every phase is got separately.
+//
+// Bundles are cached by getter identity, so BundleGetters should be
+// pointers.
+type BundleGetter interface {
+	Getter
+	GetBundle(opts GetOptions) (io.ReadCloser, error)
+}
+
+// releaseBundle holds the files of a bundle, by name.
+type releaseBundle map[string][]byte
+
+// bundleCache holds the bundles got during an install. A nil bundle is a
+// bundle that could not be got.
+type bundleCache map[BundleGetter]map[string]releaseBundle
+
+// bundle returns the bundle of the release of getOpts, getting it from getter
+// the first time.
+func (opts *InstallOptions) bundle(getter BundleGetter, getOpts GetOptions) releaseBundle {
+	bundles := opts.bundles[getter]
+	if bundles == nil {
+		bundles = map[string]releaseBundle{}
+		opts.bundles[getter] = bundles
+	}
+	if bundle, found := bundles[getOpts.Version()]; found {
+		return bundle
+	}
+
+	bundle, err := opts.getBundle(getter, getOpts)
+	if err != nil {
+		log.Printf("[TRACE] getting the files of %s %s separately: %s", getOpts.PluginRequirement.Identifier, getOpts.Version(), err)
+	}
+	bundles[getOpts.Version()] = bundle
+	return bundle
+}
+
+func (opts *InstallOptions) getBundle(getter BundleGetter, getOpts GetOptions) (releaseBundle, error) {
+	rc, err := opts.observe(getter, "bundle", getOpts, func() (io.ReadCloser, error) {
+		return getter.GetBundle(getOpts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return readBundle(rc)
+}
+
+// maxBundleSize is how many bytes the files of a bundle, which are kept in
+// memory, can add up to once uncompressed.
+var maxBundleSize int64 = 1 << 30
+
+// readBundle reads the files of a tar archive, gzip compressed or not. It
+// fails when they add up to more than maxBundleSize.
+func readBundle(r io.Reader) (releaseBundle, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	bundle := releaseBundle{}
+	remaining := maxBundleSize
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return bundle, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > remaining {
+			return nil, fmt.Errorf("invalid bundle: its files are bigger than %d bytes", maxBundleSize)
+		}
+		// bounded whatever the header tells.
+		content, err := io.ReadAll(io.LimitReader(tr, remaining+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		if int64(len(content)) > remaining {
+			return nil, fmt.Errorf("invalid bundle: its files are bigger than %d bytes", maxBundleSize)
+		}
+		remaining -= int64(len(content))
+		bundle[path.Base(header.Name)] = content
+	}
+}
+
+// open returns the file of the bundle that the what phase of a getter
+// returns, if the bundle has it.
+func (b releaseBundle) open(what string, names AssetNames, getOpts GetOptions) (io.ReadCloser, bool) {
+	checksumFile := getOpts.RenderAssetName(names.Checksum)
+	var name string
+	switch what {
+	case "sha256", "sha256sums":
+		name = checksumFile
+	case "sha256sums.sig":
+		name = checksumFile + ".sig"
+	case "provenance":
+		name = getOpts.PluginRequirement.FilenamePrefix() + getOpts.Version() + ".intoto.jsonl"
+	case "zip":
+		name = getOpts.ExpectedZipFilename()
+	default:
+		return nil, false
+	}
+	content, found := b[name]
+	if !found {
+		return nil, false
+	}
+	if what == "sha256" {
+		content = checksumFileEntriesJSON(content)
+	}
+	return io.NopCloser(bytes.NewReader(content)), true
+}
+
+// checksumFileEntriesJSON converts a checksum file to the json list of
+// ChecksumFileEntry the checksum phases return.
+func checksumFileEntriesJSON(checksumFile []byte) []byte {
+	entries := []ChecksumFileEntry{}
+	for _, line := range strings.Split(string(checksumFile), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			continue
+		}
+		entries = append(entries, ChecksumFileEntry{Checksum: parts[0], Filename: parts[1]})
+	}
+	content, _ := json.Marshal(entries)
+	return content
+}
@@ -0,0 +1,219 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// bundleGetter serves the releases from its mockPluginGetter, and every
+// other file from Bundle. It records the phases it was asked for.
+type bundleGetter struct {
+	*mockPluginGetter
+	Bundle    func() (io.ReadCloser, error)
+	Requested []string
+}
+
+func (g *bundleGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	g.Requested = append(g.Requested, what)
+	return g.mockPluginGetter.Get(what, opts)
+}
+
+func (g *bundleGetter) GetBundle(opts GetOptions) (io.ReadCloser, error) {
+	g.Requested = append(g.Requested, "bundle")
+	return g.Bundle()
+}
+
+var _ BundleGetter = &bundleGetter{}
+
+// tarBundle returns a tar archive of files, gzip compressed with compress.
+func tarBundle(t *testing.T, files map[string]string, compress bool) []byte {
+	buf := &bytes.Buffer{}
+	var w io.Writer = buf
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(buf)
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: "bundle/" + name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestRequirement_InstallLatest_bundle(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	const checksumFilename = "packer-plugin-amazon_v1.0.0_SHA256SUMS"
+
+	zipContent := func() (string, string) {
+		zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader + "amazon"})
+		content, err := io.ReadAll(zip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content), checksum
+	}
+
+	tests := []struct {
+		name string
+		// bundle returns the bundle files, from the zip and its checksum.
+		bundle   func(zip, checksum string) map[string]string
+		compress bool
+		// noBundle makes GetBundle fail.
+		noBundle      bool
+		verify        bool
+		wantRequested []string
+		wantErr       string
+	}{
+		{
+			name: "bundle",
+			bundle: func(zip, checksum string) map[string]string {
+				return map[string]string{
+					checksumFilename: checksum + "  " + binary + ".zip\n",
+					binary + ".zip":  zip,
+				}
+			},
+			wantRequested: []string{"releases", "bundle"},
+		},
+		{
+			name: "gzip-bundle-with-signature",
+			bundle: func(zip, checksum string) map[string]string {
+				checksumFile := checksum + "  " + binary + ".zip\n"
+				return map[string]string{
+					checksumFilename:          checksumFile,
+					checksumFilename + ".sig": "signed(" + checksumFile + ")",
+					binary + ".zip":           zip,
+				}
+			},
+			compress:      true,
+			verify:        true,
+			wantRequested: []string{"releases", "bundle"},
+		},
+		{
+			name: "bad-signature-in-bundle",
+			bundle: func(zip, checksum string) map[string]string {
+				checksumFile := checksum + "  " + binary + ".zip\n"
+				return map[string]string{
+					checksumFilename:          checksumFile,
+					checksumFilename + ".sig": "forged",
+					binary + ".zip":           zip,
+				}
+			},
+			verify:  true,
+			wantErr: "bad signature",
+		},
+		{
+			name: "corrupt-zip-in-bundle",
+			bundle: func(zip, checksum string) map[string]string {
+				return map[string]string{
+					checksumFilename: checksum + "  " + binary + ".zip\n",
+					binary + ".zip":  zip[:len(zip)-1] + "!",
+				}
+			},
+			wantErr: "did not match",
+		},
+		{
+			name: "zip-missing-from-bundle",
+			bundle: func(zip, checksum string) map[string]string {
+				return map[string]string{
+					checksumFilename: checksum + "  " + binary + ".zip\n",
+				}
+			},
+			wantRequested: []string{"releases", "bundle", "zip"},
+		},
+		{
+			name:          "no-bundle",
+			noBundle:      true,
+			wantRequested: []string{"releases", "bundle", "sha256", "zip"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zip, checksum := zipContent()
+			getter := &bundleGetter{
+				mockPluginGetter: &mockPluginGetter{
+					Releases: []Release{{Version: "v1.0.0"}},
+					ChecksumFileEntries: map[string][]ChecksumFileEntry{
+						"1.0.0": {{Filename: binary + ".zip", Checksum: checksum}},
+					},
+					Zips: map[string]io.ReadCloser{
+						"github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip": io.NopCloser(strings.NewReader(zip)),
+					},
+				},
+				Bundle: func() (io.ReadCloser, error) {
+					if tt.noBundle {
+						return nil, ErrBundleNotSupported
+					}
+					return io.NopCloser(bytes.NewReader(tarBundle(t, tt.bundle(zip, checksum), tt.compress))), nil
+				},
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			if tt.verify {
+				opts.SignatureVerifier = signatureVerifier{}
+			}
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary)); err != nil {
+				t.Errorf("expected the plugin to be installed: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantRequested, getter.Requested); diff != "" {
+				t.Errorf("unexpected requests: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReadBundle_tooBig(t *testing.T) {
+	defer func(size int64) { maxBundleSize = size }(maxBundleSize)
+	maxBundleSize = 10
+
+	files := map[string]string{"SHA256SUMS": "0123456789"}
+	if _, err := readBundle(bytes.NewReader(tarBundle(t, files, true))); err != nil {
+		t.Fatalf("readBundle: %v", err)
+	}
+	files["SHA256SUMS.sig"] = "s"
+	_, err := readBundle(bytes.NewReader(tarBundle(t, files, true)))
+	if err == nil || !strings.Contains(err.Error(), "bigger than 10 bytes") {
+		t.Errorf("expected the bundle to be too big, got %v", err)
+	}
+}
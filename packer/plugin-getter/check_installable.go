@@ -0,0 +1,25 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import "errors"
+
+// CheckInstallable downloads the zip of the version InstallLatest would
+// install, and verifies it like InstallLatest does, without extracting nor
+// installing it, ex: to make sure a pinned version is installable before
+// using it in a template. Nothing is written to the plugin directory, and
+// installed versions are not looked at.
+func (pr *Requirement) CheckInstallable(opts InstallOptions) error {
+	opts.checkOnly = true
+	opts.Force = true
+	opts.FailIfInstalled = false
+	opts.attempts = &getterAttempts{}
+	if _, err := pr.installLatest(opts); err != nil {
+		if attemptsErr := opts.attempts.err(); attemptsErr != nil {
+			err = errors.Join(err, attemptsErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequirement_CheckInstallable(t *testing.T) {
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name          string
+		checksum      func(checksum string) string
+		wantIntegrity bool
+	}{
+		{
+			name:     "valid",
+			checksum: func(checksum string) string { return checksum },
+		},
+		{
+			name: "checksum-mismatch",
+			checksum: func(string) string {
+				_, other := zipFileWithChecksum(map[string]string{binary: elfHeader + "tampered"})
+				return other
+			},
+			wantIntegrity: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader})
+			getter := &mockPluginGetter{
+				Releases: []Release{{Version: "v1.0.0"}},
+				ChecksumFileEntries: map[string][]ChecksumFileEntry{
+					"1.0.0": {{Filename: binary + ".zip", Checksum: tt.checksum(checksum)}},
+				},
+				Zips: map[string]io.ReadCloser{
+					"github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip": zip,
+				},
+			}
+			// the plugin directory does not need to exist.
+			pluginDir := filepath.Join(t.TempDir(), "plugins")
+
+			err := mustRequirement(t, "github.com/hashicorp/amazon", "= 1.0.0").CheckInstallable(dependenciesInstallOptions(getter, pluginDir))
+			var integrityErr *IntegrityError
+			if tt.wantIntegrity {
+				if !errors.As(err, &integrityErr) {
+					t.Errorf("expected an IntegrityError, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("CheckInstallable: %v", err)
+			}
+
+			if _, err := os.Stat(pluginDir); !os.IsNotExist(err) {
+				t.Errorf("expected nothing to be written to the plugin directory, got %v", err)
+			}
+		})
+	}
+}
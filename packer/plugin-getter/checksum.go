@@ -5,7 +5,12 @@ package plugingetter
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -13,6 +18,9 @@ import (
 	"strings"
 )
 
+// ErrChecksumMismatch is matched, with errors.Is, by ChecksumErrors.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // A ChecksumError is returned when a checksum differs
 type ChecksumError struct {
 	Hash     hash.Hash
@@ -33,6 +41,10 @@ func (cerr *ChecksumError) Error() string {
 	)
 }
 
+func (cerr *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
 type Checksum []byte
 
 func (c Checksum) String() string { return hex.EncodeToString(c) }
@@ -54,6 +66,50 @@ func (c *Checksummer) FileExt() string {
 	return "_" + strings.ToUpper(c.Type) + "SUM"
 }
 
+// clone returns a Checksummer of the same type with its own Hash, so that
+// both can be used concurrently. ok is false for unknown hash types.
+func (c *Checksummer) clone() (clone Checksummer, ok bool) {
+	clone.Type = c.Type
+	switch strings.ToLower(c.Type) {
+	case "md5":
+		clone.Hash = md5.New()
+	case "sha1":
+		clone.Hash = sha1.New()
+	case "sha256":
+		clone.Hash = sha256.New()
+	case "sha512":
+		clone.Hash = sha512.New()
+	default:
+		return clone, false
+	}
+	return clone, clone.Hash.Size() == c.Hash.Size()
+}
+
+// knownChecksummers returns a checksummer of every hash type clone knows,
+// sha256 first, ex: to find the checksum files stored next to a binary.
+func knownChecksummers() []Checksummer {
+	return []Checksummer{
+		{Type: "sha256", Hash: sha256.New()},
+		{Type: "sha512", Hash: sha512.New()},
+		{Type: "sha1", Hash: sha1.New()},
+		{Type: "md5", Hash: md5.New()},
+	}
+}
+
+// cloneChecksummers clones every checksummer of checksummers, ok is false
+// when one of them cannot be cloned.
+func cloneChecksummers(checksummers []Checksummer) ([]Checksummer, bool) {
+	res := make([]Checksummer, len(checksummers))
+	for i := range checksummers {
+		clone, ok := checksummers[i].clone()
+		if !ok {
+			return nil, false
+		}
+		res[i] = clone
+	}
+	return res, true
+}
+
 // GetCacheChecksumOfFile will extract the checksum from file `filePath + c.FileExt()`.
 // It expects the checksum file to only contains the checksum and nothing else.
 func (c *Checksummer) GetCacheChecksumOfFile(filePath string) ([]byte, error) {
@@ -93,6 +149,8 @@ func (c *Checksummer) ChecksumFile(expected []byte, filePath string) error {
 	return err
 }
 
+// Sum returns the checksum of everything read from f. The Hash of c is reset
+// first, so a Checksummer must not be used concurrently.
 func (c *Checksummer) Sum(f io.Reader) ([]byte, error) {
 	c.Hash.Reset()
 	if _, err := io.Copy(c.Hash, f); err != nil {
@@ -117,3 +175,27 @@ func (c *Checksummer) Checksum(expected []byte, f io.Reader) error {
 
 	return nil
 }
+
+// SumHex works like Sum but returns the hex encoded checksum, as found in
+// checksum files.
+func (c *Checksummer) SumHex(f io.Reader) (string, error) {
+	sum, err := c.Sum(f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// VerifyFile checks that the file in filePath has the expected hex encoded
+// checksum, like the installer does for plugin binaries. A *ChecksumError is
+// returned when the checksums differ.
+func (c *Checksummer) VerifyFile(filePath, expected string) error {
+	expectedSum, err := hex.DecodeString(strings.TrimSpace(expected))
+	if err != nil {
+		return fmt.Errorf("invalid %s checksum %q: %s", c.Type, expected, err)
+	}
+	if len(expectedSum) != c.Hash.Size() {
+		return fmt.Errorf("invalid %s checksum %q: expected %d bytes, got %d", c.Type, expected, c.Hash.Size(), len(expectedSum))
+	}
+	return c.ChecksumFile(expectedSum, filePath)
+}
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ErrChecksumConflict is returned, wrapped in a *ChecksumConflictError, when
+// InstallOptions.CrossCheckChecksums is set and getters disagree on the
+// checksum of a zip.
+var ErrChecksumConflict = errors.New("checksum conflict")
+
+// ChecksumConflictError tells which checksums of a zip getters disagree on.
+type ChecksumConflictError struct {
+	Filename string
+	Type     string
+	// Checksum is the one listed by the getter the zip would be downloaded
+	// from, and Conflicting the one of the other getter.
+	Checksum, Conflicting string
+}
+
+func (e *ChecksumConflictError) Error() string {
+	return fmt.Sprintf("%s: getters disagree on the %s checksum of %s: %s and %s", ErrChecksumConflict, e.Type, e.Filename, e.Checksum, e.Conflicting)
+}
+
+func (e *ChecksumConflictError) Is(target error) bool { return target == ErrChecksumConflict }
+
+// crossCheckChecksum makes sure the getters other than opts.Getters[from]
+// that list entry in their checksum file list the same checksum. Getters
+// that fail or do not list entry are not taken into account.
+func (opts *InstallOptions) crossCheckChecksum(from int, getOpts GetOptions, checksummer Checksummer, entry ChecksumFileEntry) error {
+	for i, getter := range opts.Getters {
+		if i == from {
+			continue
+		}
+		rc, err := opts.get(getter, checksummer.Type, getOpts)
+		if err != nil {
+			log.Printf("[TRACE] not cross-checking the %s checksum of %s with getter %d: %s", checksummer.Type, entry.Filename, i, err)
+			continue
+		}
+		entries, err := ParseChecksumFileEntries(rc)
+		_ = rc.Close()
+		if err != nil {
+			log.Printf("[TRACE] not cross-checking the %s checksum of %s with getter %d: %s", checksummer.Type, entry.Filename, i, err)
+			continue
+		}
+		for _, other := range entries {
+			if !strings.EqualFold(other.Filename, entry.Filename) || strings.EqualFold(other.Checksum, entry.Checksum) {
+				continue
+			}
+			return &IntegrityError{Err: &ChecksumConflictError{
+				Filename:    entry.Filename,
+				Type:        checksummer.Type,
+				Checksum:    entry.Checksum,
+				Conflicting: other.Checksum,
+			}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequirement_InstallLatest_crossCheckChecksums(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	// tamperedChecksum makes getter list another checksum for the zip.
+	tamperedChecksum := func(getter *mockPluginGetter) *mockPluginGetter {
+		getter.ChecksumFileEntries["1.0.0"][0].Checksum = strings.Repeat("ab", 32)
+		return getter
+	}
+
+	tests := []struct {
+		name         string
+		crossCheck   bool
+		second       Getter
+		wantConflict bool
+	}{
+		{
+			name:   "first-wins-by-default",
+			second: tamperedChecksum(singleReleaseGetter("amazon")),
+		},
+		{
+			name:         "conflict",
+			crossCheck:   true,
+			second:       tamperedChecksum(singleReleaseGetter("amazon")),
+			wantConflict: true,
+		},
+		{
+			name:       "agreement",
+			crossCheck: true,
+			second:     singleReleaseGetter("amazon"),
+		},
+		{
+			name:       "other-getter-fails",
+			crossCheck: true,
+			second:     &recordingGetter{Err: errors.New("503 Service Unavailable")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := singleReleaseGetter("amazon")
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(first, pluginDir)
+			opts.Getters = append(opts.Getters, tt.second)
+			opts.CrossCheckChecksums = tt.crossCheck
+
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			_, statErr := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if !tt.wantConflict {
+				if err != nil {
+					t.Fatalf("InstallLatest: %v", err)
+				}
+				if statErr != nil {
+					t.Errorf("expected the plugin to be installed: %v", statErr)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrChecksumConflict) {
+				t.Fatalf("expected ErrChecksumConflict, got %v", err)
+			}
+			var conflictErr *ChecksumConflictError
+			if !errors.As(err, &conflictErr) {
+				t.Fatalf("expected a *ChecksumConflictError, got %T", err)
+			}
+			if conflictErr.Checksum != first.ChecksumFileEntries["1.0.0"][0].Checksum || conflictErr.Conflicting != strings.Repeat("ab", 32) {
+				t.Errorf("unexpected checksums in %v", conflictErr)
+			}
+			if !os.IsNotExist(statErr) {
+				t.Errorf("expected the plugin not to be installed, stat returned %v", statErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+)
+
+// checksumFileKey identifies the checksum file of a version.
+type checksumFileKey struct {
+	what    string
+	version string
+}
+
+// prefetchedChecksumFile is the outcome of getting a checksum file ahead of
+// time.
+type prefetchedChecksumFile struct {
+	content []byte
+	err     error
+}
+
+// checksumFileCache holds the checksum files prefetched by InstallLatest from
+// its first getter, or embedded in the releases it listed. Getters are not used as keys as they may not be
+// comparable.
+type checksumFileCache map[checksumFileKey]*prefetchedChecksumFile
+
+// prefetchChecksumFiles gets the checksum files of the next
+// opts.ChecksumFetchWorkers candidate versions from getter at the same time,
+// unless the ones of the first candidate were already got. Failures are kept
+// for when the version is tried, so that they only count for the version
+// that is selected.
+func (opts *InstallOptions) prefetchChecksumFiles(getter Getter, pr *Requirement, versions []*version.Version) {
+	if _, ok := getter.(BundleGetter); ok {
+		// checksum files are read from the release bundle.
+		return
+	}
+	if len(versions) == 0 || len(opts.Checksummers) == 0 {
+		return
+	}
+	if _, found := opts.checksumFiles[checksumFileKey{opts.Checksummers[0].Type, versions[0].String()}]; found {
+		return
+	}
+	if len(versions) > opts.ChecksumFetchWorkers {
+		versions = versions[:opts.ChecksumFetchWorkers]
+	}
+
+	type job struct {
+		key     checksumFileKey
+		getOpts GetOptions
+	}
+	var jobs []job
+	for _, v := range versions {
+		for _, checksummer := range opts.Checksummers {
+			key := checksumFileKey{checksummer.Type, v.String()}
+			if _, found := opts.checksumFiles[key]; found {
+				continue
+			}
+			jobs = append(jobs, job{key, GetOptions{
+				PluginRequirement:         pr,
+				BinaryInstallationOptions: opts.BinaryInstallationOptions,
+				version:                   v,
+			}})
+		}
+	}
+	if len(jobs) == 0 {
+		return
+	}
+	log.Printf("[TRACE] prefetching %d checksum files of %s", len(jobs), pr.Identifier)
+
+	results := make([]*prefetchedChecksumFile, len(jobs))
+	sem := make(chan struct{}, opts.ChecksumFetchWorkers)
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, j job) {
+			defer func() { <-sem; wg.Done() }()
+			result := &prefetchedChecksumFile{}
+			rc, err := opts.get(getter, j.key.what, j.getOpts)
+			if err == nil {
+				result.content, err = io.ReadAll(rc)
+				_ = rc.Close()
+			}
+			result.err = err
+			results[i] = result
+		}(i, j)
+	}
+	wg.Wait()
+
+	for i, j := range jobs {
+		opts.checksumFiles[j.key] = results[i]
+	}
+}
+
+// addReleaseChecksums keeps the checksum entries embedded in the release
+// metadata of v as prefetched checksum files, so that they are not got
+// separately. The checksum files of the checksummer types without entries
+// are still got.
+func (c checksumFileCache) addReleaseChecksums(v *version.Version, checksums map[string][]ChecksumFileEntry) {
+	for what, entries := range checksums {
+		if len(entries) == 0 {
+			continue
+		}
+		content, err := json.Marshal(entries)
+		if err != nil {
+			log.Printf("[TRACE] ignoring the %s checksums embedded in release %s: %s", what, v, err)
+			continue
+		}
+		log.Printf("[TRACE] using the %s checksums embedded in release %s", what, v)
+		c[checksumFileKey{what, v.String()}] = &prefetchedChecksumFile{content: content}
+	}
+}
+
+// getChecksumFile returns the what checksum file of getOpts from the
+// getterIdx getter, the prefetched one when there is one.
+func (opts *InstallOptions) getChecksumFile(getterIdx int, getter Getter, what string, getOpts GetOptions) (io.ReadCloser, error) {
+	if prefetched, found := opts.checksumFiles[checksumFileKey{what, getOpts.version.String()}]; found && getterIdx == 0 {
+		if prefetched.err != nil {
+			return nil, prefetched.err
+		}
+		return io.NopCloser(bytes.NewReader(prefetched.content)), nil
+	}
+	if asset, found := opts.asset(getterIdx, what, getOpts); found {
+		if asset.err != nil {
+			return nil, asset.err
+		}
+		return io.NopCloser(bytes.NewReader(asset.content)), nil
+	}
+	return opts.get(getter, what, getOpts)
+}
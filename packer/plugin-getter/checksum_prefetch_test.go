@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyCountingGetter is a mockPluginGetter that counts the checksum
+// files got at the same time. Every checksum fetch waits for up to wait for
+// allowedInFlight fetches to be running.
+type concurrencyCountingGetter struct {
+	mockPluginGetter
+	wait time.Duration
+
+	mu              sync.Mutex
+	inFlight        int
+	maxInFlight     int
+	checksumGets    int
+	allowedInFlight int
+}
+
+func (g *concurrencyCountingGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	if what != "sha256" {
+		return g.mockPluginGetter.Get(what, opts)
+	}
+	g.mu.Lock()
+	g.checksumGets++
+	g.inFlight++
+	if g.inFlight > g.maxInFlight {
+		g.maxInFlight = g.inFlight
+	}
+	g.mu.Unlock()
+
+	deadline := time.Now().Add(g.wait)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		done := g.maxInFlight >= g.allowedInFlight
+		g.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+
+	// unlike the pipe of the mockPluginGetter, the buffer can be read to
+	// the end.
+	entries, found := g.ChecksumFileEntries[opts.version.String()]
+	if !found {
+		return nil, fmt.Errorf("no checksum available for version %q", opts.version)
+	}
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(entries); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
+
+func TestRequirement_InstallLatest_checksumFetchWorkers(t *testing.T) {
+	tests := []struct {
+		name            string
+		workers         int
+		wantMaxInFlight int
+	}{
+		{"serial", 0, 1},
+		{"concurrent", 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &concurrencyCountingGetter{
+				mockPluginGetter: mockPluginGetter{
+					Releases:            []Release{{Version: "v1.0.0"}, {Version: "v1.1.0"}, {Version: "v1.2.0"}, {Version: "v1.3.0"}},
+					ChecksumFileEntries: map[string][]ChecksumFileEntry{},
+					Zips:                map[string]io.ReadCloser{},
+				},
+				wait:            time.Second,
+				allowedInFlight: tt.wantMaxInFlight,
+			}
+			// v1.3.0 has no checksum file and v1.2.0 no linux binary, only
+			// v1.1.0 and v1.0.0 can be installed.
+			for _, v := range []string{"v1.0.0", "v1.1.0"} {
+				binary := "packer-plugin-amazon_" + v + "_x5.0_linux_amd64"
+				zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader + v})
+				getter.ChecksumFileEntries[v[1:]] = []ChecksumFileEntry{{Filename: binary + ".zip", Checksum: checksum}}
+				getter.Zips["github.com/hashicorp/packer-plugin-amazon/"+binary+".zip"] = zip
+			}
+			_, checksum := zipFileWithChecksum(map[string]string{"packer-plugin-amazon_v1.2.0_x5.0_darwin_arm64": machoHeader})
+			getter.ChecksumFileEntries["1.2.0"] = []ChecksumFileEntry{{Filename: "packer-plugin-amazon_v1.2.0_x5.0_darwin_arm64.zip", Checksum: checksum}}
+
+			opts := dependenciesInstallOptions(getter, t.TempDir())
+			opts.ChecksumFetchWorkers = tt.workers
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if install == nil || install.Version != "v1.1.0" {
+				t.Fatalf("expected v1.1.0 to be installed, got %#v", install)
+			}
+			if getter.maxInFlight != tt.wantMaxInFlight {
+				t.Errorf("expected %d checksum files to be got at the same time, got %d", tt.wantMaxInFlight, getter.maxInFlight)
+			}
+			if getter.checksumGets != 3 {
+				t.Errorf("expected the 3 newest checksum files to be got once, got %d requests", getter.checksumGets)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_releaseChecksums(t *testing.T) {
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	in := `[{"version":"v1.0.0","checksums":{"sha256":[{"filename":"` + binary + `.zip","checksum":"%s"}]}},{"version":"v0.9.0"}]`
+
+	tests := []struct {
+		name             string
+		embedded         bool
+		wantChecksumGets int
+	}{
+		{"embedded", true, 0},
+		{"not-embedded", false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader})
+			releases, err := ParseReleases(io.NopCloser(strings.NewReader(fmt.Sprintf(in, checksum))))
+			if err != nil {
+				t.Fatalf("ParseReleases: %v", err)
+			}
+			getter := &concurrencyCountingGetter{
+				mockPluginGetter: mockPluginGetter{
+					Releases:            releases,
+					ChecksumFileEntries: map[string][]ChecksumFileEntry{},
+					Zips: map[string]io.ReadCloser{
+						"github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip": zip,
+					},
+				},
+				allowedInFlight: 1,
+			}
+			if !tt.embedded {
+				getter.Releases[0].Checksums = nil
+				getter.ChecksumFileEntries["1.0.0"] = []ChecksumFileEntry{{Filename: binary + ".zip", Checksum: checksum}}
+			}
+
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, t.TempDir()))
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if install == nil || install.Version != "v1.0.0" {
+				t.Fatalf("expected v1.0.0 to be installed, got %#v", install)
+			}
+			if getter.checksumGets != tt.wantChecksumGets {
+				t.Errorf("expected %d checksum file requests, got %d", tt.wantChecksumGets, getter.checksumGets)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_releaseChecksumsMismatch(t *testing.T) {
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	zip, _ := zipFileWithChecksum(map[string]string{binary: elfHeader})
+	_, otherChecksum := zipFileWithChecksum(map[string]string{binary: elfHeader + "tampered"})
+	getter := &mockPluginGetter{
+		Releases: []Release{{
+			Version:   "v1.0.0",
+			Checksums: map[string][]ChecksumFileEntry{"sha256": {{Filename: binary + ".zip", Checksum: otherChecksum}}},
+		}},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip": zip,
+		},
+	}
+
+	install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, t.TempDir()))
+	if err == nil || install != nil {
+		t.Fatalf("expected a zip not matching its embedded checksum not to be installed, got %#v", install)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sha256 of "packer"
+const packerSHA256 = "131db0b57a618771d4d791b8e065c3286ff3b0fd92afb2dcdd6119256688f94e"
+
+func TestChecksummer_SumHex(t *testing.T) {
+	c := &Checksummer{Type: "sha256", Hash: sha256.New()}
+
+	for i := 0; i < 2; i++ {
+		// the hash is reset between calls.
+		got, err := c.SumHex(strings.NewReader("packer"))
+		if err != nil {
+			t.Fatalf("SumHex: %v", err)
+		}
+		if got != packerSHA256 {
+			t.Errorf("SumHex = %s, want %s", got, packerSHA256)
+		}
+	}
+}
+
+func TestChecksummer_VerifyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packer-plugin-happycloud")
+	if err := os.WriteFile(path, []byte("packer"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		expected     string
+		wantErr      bool
+		wantMismatch bool
+	}{
+		{"correct", packerSHA256, false, false},
+		{"uppercase-and-newline", strings.ToUpper(packerSHA256) + "\n", false, false},
+		{"incorrect", strings.Repeat("0", 64), true, true},
+		{"not-hex", "not a checksum", true, false},
+		{"truncated", packerSHA256[:32], true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Checksummer{Type: "sha256", Hash: sha256.New()}
+			err := c.VerifyFile(path, tt.expected)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyFile error = %v, wantErr %t", err, tt.wantErr)
+			}
+			var cerr *ChecksumError
+			if errors.As(err, &cerr) != tt.wantMismatch {
+				t.Errorf("expected a *ChecksumError: %t, got %v", tt.wantMismatch, err)
+			}
+			if tt.wantMismatch && cerr.File != path {
+				t.Errorf("expected the error to name %q, got %q", path, cerr.File)
+			}
+		})
+	}
+
+	c := &Checksummer{Type: "sha256", Hash: sha256.New()}
+	if err := c.VerifyFile(filepath.Join(t.TempDir(), "missing"), packerSHA256); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
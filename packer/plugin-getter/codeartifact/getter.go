@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package codeartifact gets plugins from the generic packages of AWS
+// CodeArtifact repositories.
+package codeartifact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/codeartifact"
+	"github.com/aws/aws-sdk-go/service/codeartifact/codeartifactiface"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const (
+	// packageFormat is the format of the packages plugins are published as.
+	packageFormat = "generic"
+
+	defaultPackageNamespace = "packer"
+)
+
+// domainHostnameRegex matches the hostnames of CodeArtifact domains, ex:
+// my-domain-111122223333.d.codeartifact.us-east-1.amazonaws.com, with the
+// domain, its owner and its region in groups.
+var domainHostnameRegex = regexp.MustCompile(`^([a-z][a-z0-9-]*)-([0-9]{12})\.d\.codeartifact\.([a-z0-9-]+)\.amazonaws\.com$`)
+
+// Getter gets plugins from the generic packages of AWS CodeArtifact
+// repositories.
+//
+// The source of a plugin tells where it is published: its hostname is the
+// one of the domain, ex:
+// my-domain-111122223333.d.codeartifact.us-east-1.amazonaws.com, its
+// namespace is the repository, and the package is named packer-plugin-<type>.
+// Each plugin version is a published package version named like it, ex:
+// v1.2.3, with the release zips and checksum file as assets.
+type Getter struct {
+	// Client is the CodeArtifact client used for every domain. When nil, a
+	// client is created for the region of each domain, authenticated by the
+	// default AWS SDK credential chain: env vars, shared credentials and
+	// config files, then the role of the container or instance.
+	Client codeartifactiface.CodeArtifactAPI
+
+	// PackageNamespace is the namespace of the packages, "packer" when
+	// empty.
+	PackageNamespace string
+
+	// clients are the clients created by region.
+	clients   map[string]codeartifactiface.CodeArtifactAPI
+	clientsMu sync.Mutex
+}
+
+var _ plugingetter.Getter = &Getter{}
+
+// String names the getter in errors.
+func (g *Getter) String() string { return "codeartifact" }
+
+// packageVersion identifies the package version of a plugin version.
+type packageVersion struct {
+	domain, domainOwner, region string
+	repository, namespace, pkg  string
+	version                     string
+}
+
+// packageVersion returns where the version of opts is published, or an error
+// wrapping plugingetter.ErrUnsupportedSource when the plugin is not hosted on
+// CodeArtifact.
+func (g *Getter) packageVersion(opts plugingetter.GetOptions, what string) (packageVersion, error) {
+	pr := opts.PluginRequirement
+	matches := domainHostnameRegex.FindStringSubmatch(pr.Identifier.Hostname)
+	if matches == nil {
+		return packageVersion{}, fmt.Errorf("%w: %s is not hosted on a CodeArtifact domain, ex: <domain>-<account id>.d.codeartifact.<region>.amazonaws.com/<repository>/<name>", plugingetter.ErrUnsupportedSource, pr.Identifier)
+	}
+	pv := packageVersion{
+		domain:      matches[1],
+		domainOwner: matches[2],
+		region:      matches[3],
+		repository:  pr.Identifier.Namespace,
+		namespace:   g.PackageNamespace,
+		pkg:         "packer-plugin-" + pr.Identifier.Type,
+	}
+	if pv.namespace == "" {
+		pv.namespace = defaultPackageNamespace
+	}
+	if what != "releases" {
+		pv.version = opts.Version()
+	}
+	return pv, nil
+}
+
+// client returns the client of the domains of region.
+func (g *Getter) client(region string) (codeartifactiface.CodeArtifactAPI, error) {
+	if g.Client != nil {
+		return g.Client, nil
+	}
+	g.clientsMu.Lock()
+	defer g.clientsMu.Unlock()
+	if client, found := g.clients[region]; found {
+		return client, nil
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: aws.String(region)},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("codeartifact-getter: failed to create an AWS session: %w", err)
+	}
+	if g.clients == nil {
+		g.clients = map[string]codeartifactiface.CodeArtifactAPI{}
+	}
+	client := codeartifact.New(sess)
+	g.clients[region] = client
+	return client, nil
+}
+
+func (g *Getter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
+	pv, err := g.packageVersion(opts, what)
+	if err != nil {
+		return nil, err
+	}
+	client, err := g.client(pv.region)
+	if err != nil {
+		return nil, err
+	}
+
+	if what == "releases" {
+		return g.getReleases(client, pv)
+	}
+	checksumAsset := opts.RenderAssetName(plugingetter.DefaultChecksumAssetTemplate)
+	switch what {
+	case "sha256":
+		body, err := g.getAsset(client, pv, checksumAsset)
+		if err != nil {
+			return nil, err
+		}
+		return transformChecksumStream(body)
+	case "sha256sums":
+		return g.getAsset(client, pv, checksumAsset)
+	case "sha256sums.sig":
+		return g.getAsset(client, pv, checksumAsset+".sig")
+	case "zip":
+		return g.getAsset(client, pv, opts.ExpectedZipFilename())
+	default:
+		return nil, fmt.Errorf("%q not implemented", what)
+	}
+}
+
+// getReleases lists the published versions of the package, as a json list of
+// Release.
+func (g *Getter) getReleases(client codeartifactiface.CodeArtifactAPI, pv packageVersion) (io.ReadCloser, error) {
+	log.Printf("[DEBUG] codeartifact-getter: listing the versions of %s/%s in %s/%s", pv.namespace, pv.pkg, pv.domain, pv.repository)
+	out := []plugingetter.Release{}
+	err := client.ListPackageVersionsPagesWithContext(aws.BackgroundContext(), &codeartifact.ListPackageVersionsInput{
+		Domain:      aws.String(pv.domain),
+		DomainOwner: aws.String(pv.domainOwner),
+		Repository:  aws.String(pv.repository),
+		Format:      aws.String(packageFormat),
+		Namespace:   aws.String(pv.namespace),
+		Package:     aws.String(pv.pkg),
+		Status:      aws.String(codeartifact.PackageVersionStatusPublished),
+	}, func(page *codeartifact.ListPackageVersionsOutput, lastPage bool) bool {
+		for _, version := range page.Versions {
+			out = append(out, plugingetter.Release{Version: aws.StringValue(version.Version)})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, requestError(err, plugingetter.ErrPluginNotFound)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(out); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
+
+// getAsset downloads the asset of the package version.
+func (g *Getter) getAsset(client codeartifactiface.CodeArtifactAPI, pv packageVersion, asset string) (io.ReadCloser, error) {
+	log.Printf("[DEBUG] codeartifact-getter: getting %s of %s/%s %s in %s/%s", asset, pv.namespace, pv.pkg, pv.version, pv.domain, pv.repository)
+	resp, err := client.GetPackageVersionAssetWithContext(aws.BackgroundContext(), &codeartifact.GetPackageVersionAssetInput{
+		Domain:         aws.String(pv.domain),
+		DomainOwner:    aws.String(pv.domainOwner),
+		Repository:     aws.String(pv.repository),
+		Format:         aws.String(packageFormat),
+		Namespace:      aws.String(pv.namespace),
+		Package:        aws.String(pv.pkg),
+		PackageVersion: aws.String(pv.version),
+		Asset:          aws.String(asset),
+	})
+	if err != nil {
+		return nil, requestError(err, plugingetter.ErrReleaseFileNotFound)
+	}
+	return resp.Asset, nil
+}
+
+// requestError wraps notFound in the errors of the resources CodeArtifact
+// does not find.
+func requestError(err error, notFound error) error {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == codeartifact.ErrCodeResourceNotFoundException {
+		return fmt.Errorf("%w: %w", notFound, err)
+	}
+	log.Printf("[TRACE] codeartifact-getter: failed requesting: %T. %v", err, err)
+	return err
+}
+
+// transformChecksumStream converts a SHA256SUMS file to the json list of
+// ChecksumFileEntry Packer expects.
+func transformChecksumStream(in io.ReadCloser) (io.ReadCloser, error) {
+	defer in.Close()
+	entries := []plugingetter.ChecksumFileEntry{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		parts := bytes.Fields(scanner.Bytes())
+		if len(parts) != 2 {
+			continue
+		}
+		entries = append(entries, plugingetter.ChecksumFileEntry{
+			Checksum: string(parts[0]),
+			Filename: string(parts[1]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error reading checksum file: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(entries); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
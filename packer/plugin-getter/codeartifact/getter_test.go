@@ -0,0 +1,213 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package codeartifact
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/codeartifact"
+	"github.com/aws/aws-sdk-go/service/codeartifact/codeartifactiface"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const source = "my-domain-111122223333.d.codeartifact.us-east-1.amazonaws.com/my-repo/amazon"
+
+// mockCodeArtifact is a CodeArtifact API serving the generic packages of the
+// my-repo repository of the my-domain domain.
+type mockCodeArtifact struct {
+	codeartifactiface.CodeArtifactAPI
+
+	// packages are the assets by name, of the versions of the packages,
+	// ex: packages["packer/packer-plugin-amazon"]["v1.0.0"]["x.zip"].
+	packages map[string]map[string]map[string][]byte
+	// pageSize is the number of versions listed per page.
+	pageSize int
+}
+
+func (m *mockCodeArtifact) checkRepository(domain, domainOwner, repository, format *string) error {
+	if aws.StringValue(domain) != "my-domain" || aws.StringValue(domainOwner) != "111122223333" || aws.StringValue(repository) != "my-repo" {
+		return awserr.New(codeartifact.ErrCodeResourceNotFoundException, "repository not found", nil)
+	}
+	if aws.StringValue(format) != "generic" {
+		return awserr.New(codeartifact.ErrCodeValidationException, "unexpected format", nil)
+	}
+	return nil
+}
+
+func (m *mockCodeArtifact) ListPackageVersionsPagesWithContext(_ aws.Context, input *codeartifact.ListPackageVersionsInput, fn func(*codeartifact.ListPackageVersionsOutput, bool) bool, _ ...request.Option) error {
+	if err := m.checkRepository(input.Domain, input.DomainOwner, input.Repository, input.Format); err != nil {
+		return err
+	}
+	versions, found := m.packages[aws.StringValue(input.Namespace)+"/"+aws.StringValue(input.Package)]
+	if !found {
+		return awserr.New(codeartifact.ErrCodeResourceNotFoundException, "package not found", nil)
+	}
+	var summaries []*codeartifact.PackageVersionSummary
+	for version := range versions {
+		summaries = append(summaries, &codeartifact.PackageVersionSummary{
+			Version: aws.String(version),
+			Status:  aws.String(codeartifact.PackageVersionStatusPublished),
+		})
+	}
+	for len(summaries) > m.pageSize {
+		if !fn(&codeartifact.ListPackageVersionsOutput{Versions: summaries[:m.pageSize]}, false) {
+			return nil
+		}
+		summaries = summaries[m.pageSize:]
+	}
+	fn(&codeartifact.ListPackageVersionsOutput{Versions: summaries}, true)
+	return nil
+}
+
+func (m *mockCodeArtifact) GetPackageVersionAssetWithContext(_ aws.Context, input *codeartifact.GetPackageVersionAssetInput, _ ...request.Option) (*codeartifact.GetPackageVersionAssetOutput, error) {
+	if err := m.checkRepository(input.Domain, input.DomainOwner, input.Repository, input.Format); err != nil {
+		return nil, err
+	}
+	asset, found := m.packages[aws.StringValue(input.Namespace)+"/"+aws.StringValue(input.Package)][aws.StringValue(input.PackageVersion)][aws.StringValue(input.Asset)]
+	if !found {
+		return nil, awserr.New(codeartifact.ErrCodeResourceNotFoundException, "asset not found", nil)
+	}
+	return &codeartifact.GetPackageVersionAssetOutput{Asset: io.NopCloser(bytes.NewReader(asset))}, nil
+}
+
+// amazonPackage returns the assets of the v1.0.0 linux_amd64 release of the
+// amazon plugin.
+func amazonPackage(t *testing.T) map[string][]byte {
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	zipBuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zipBuf)
+	w, err := zw.Create(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("\x7fELF amazon")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(zipBuf.Bytes())
+	return map[string][]byte{
+		binary + ".zip":                          zipBuf.Bytes(),
+		"packer-plugin-amazon_v1.0.0_SHA256SUMS": []byte(fmt.Sprintf("%s  %s.zip\n", hex.EncodeToString(sum[:]), binary)),
+	}
+}
+
+func mustRequirement(t *testing.T, source string) *plugingetter.Requirement {
+	identifier, diags := addrs.ParsePluginSourceString(source)
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	return &plugingetter.Requirement{Identifier: identifier}
+}
+
+func TestGetter_InstallLatest(t *testing.T) {
+	client := &mockCodeArtifact{
+		packages: map[string]map[string]map[string][]byte{
+			"packer/packer-plugin-amazon": {
+				// without assets, v1.1.0 is skipped.
+				"v1.1.0": {},
+				"v1.0.0": amazonPackage(t),
+				"v0.8.0": {},
+			},
+		},
+		pageSize: 2,
+	}
+
+	installed, err := mustRequirement(t, source).InstallLatest(plugingetter.InstallOptions{
+		Getters:         []plugingetter.Getter{&Getter{Client: client}},
+		PluginDirectory: t.TempDir(),
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "amd64",
+			Checksummers: []plugingetter.Checksummer{{Type: "sha256", Hash: sha256.New()}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	if installed == nil || installed.Version != "v1.0.0" {
+		t.Errorf("expected v1.0.0 to be installed, got %#v", installed)
+	}
+}
+
+func TestGetter_Get_packageNamespace(t *testing.T) {
+	client := &mockCodeArtifact{
+		packages: map[string]map[string]map[string][]byte{
+			"plugins/packer-plugin-amazon": {"v1.0.0": {}},
+		},
+		pageSize: 10,
+	}
+	pr := mustRequirement(t, source)
+
+	g := &Getter{Client: client}
+	if _, err := g.Get("releases", plugingetter.GetOptions{PluginRequirement: pr}); !errors.Is(err, plugingetter.ErrPluginNotFound) {
+		t.Errorf("expected no package in the default namespace, got %v", err)
+	}
+
+	g = &Getter{Client: client, PackageNamespace: "plugins"}
+	rc, err := g.Get("releases", plugingetter.GetOptions{PluginRequirement: pr})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	releases, err := plugingetter.ParseReleases(rc)
+	if err != nil {
+		t.Fatalf("ParseReleases: %v", err)
+	}
+	if len(releases) != 1 || releases[0].Version != "v1.0.0" {
+		t.Errorf("expected the v1.0.0 release, got %#v", releases)
+	}
+}
+
+func TestGetter_Get_errorsIs(t *testing.T) {
+	client := &mockCodeArtifact{
+		packages: map[string]map[string]map[string][]byte{
+			"packer/packer-plugin-amazon": {"v1.0.0": {}},
+		},
+		pageSize: 10,
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   error
+	}{
+		{
+			name:   "unsupported source",
+			source: "github.com/hashicorp/amazon",
+			want:   plugingetter.ErrUnsupportedSource,
+		},
+		{
+			name:   "repository not found",
+			source: "my-domain-111122223333.d.codeartifact.us-east-1.amazonaws.com/other-repo/amazon",
+			want:   plugingetter.ErrPluginNotFound,
+		},
+		{
+			name:   "package not found",
+			source: "my-domain-111122223333.d.codeartifact.us-east-1.amazonaws.com/my-repo/googlecompute",
+			want:   plugingetter.ErrPluginNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&Getter{Client: client}).Get("releases", plugingetter.GetOptions{
+				PluginRequirement: mustRequirement(t, tt.source),
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected an error matching %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
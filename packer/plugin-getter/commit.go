@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+)
+
+// CommitVersionPrefix prefixes the version arguments pinning a plugin to the
+// release of a git commit, ex: "commit:0a1b2c3".
+const CommitVersionPrefix = "commit:"
+
+// ErrCommitNotReleased is matched when no release of a plugin was tagged on
+// a commit.
+var ErrCommitNotReleased = errors.New("commit not released")
+
+// commitSHARegex matches full and abbreviated git commit SHAs.
+var commitSHARegex = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// ParseCommitVersion returns the commit SHA of a version argument with the
+// CommitVersionPrefix, lowercased, and whether it has the prefix. An error is
+// returned when the SHA is not a hexadecimal SHA of 7 to 40 characters.
+func ParseCommitVersion(arg string) (string, bool, error) {
+	if !strings.HasPrefix(arg, CommitVersionPrefix) {
+		return "", false, nil
+	}
+	sha := strings.ToLower(strings.TrimPrefix(arg, CommitVersionPrefix))
+	if !commitSHARegex.MatchString(sha) {
+		return "", true, fmt.Errorf("invalid commit %q, expected a SHA of 7 to 40 hexadecimal characters", sha)
+	}
+	return sha, true, nil
+}
+
+// A CommitResolver is a Getter that can tell which release was tagged on a
+// git commit of the plugin sources, like GitHub does with the tags of its
+// repositories.
+type CommitResolver interface {
+	// ResolveCommit returns the versions, ex: "v1.2.3", of the tags of the
+	// commit with sha, which can be abbreviated. It returns an error
+	// matching ErrCommitNotReleased when the commit was not tagged.
+	ResolveCommit(pr *Requirement, sha string) ([]string, error)
+}
+
+// ResolveCommit returns the version of pr released from the commit with sha,
+// as told by the first of getters that is a CommitResolver and knows of it.
+// When the commit was tagged several times, the highest version is returned.
+// Getters that are not CommitResolvers are skipped.
+//
+// Only released commits can be installed: there is no getter building
+// plugins from their sources.
+func (pr *Requirement) ResolveCommit(getters []Getter, sha string) (*version.Version, error) {
+	var errs *multierror.Error
+	for _, getter := range getters {
+		resolver, ok := getter.(CommitResolver)
+		if !ok {
+			continue
+		}
+		tags, err := resolver.ResolveCommit(pr, sha)
+		if err != nil {
+			log.Printf("[TRACE] %s could not resolve commit %s of %s: %s", getterName(getter), sha, pr.Identifier, err)
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", getterName(getter), err))
+			continue
+		}
+		var resolved *version.Version
+		for _, tag := range tags {
+			v, err := version.NewVersion(tag)
+			if err != nil {
+				log.Printf("[TRACE] ignoring tag %q of commit %s: %s", tag, sha, err)
+				continue
+			}
+			if resolved == nil || v.GreaterThan(resolved) {
+				resolved = v
+			}
+		}
+		if resolved == nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w: no version tag on commit %s of %s", getterName(getter), ErrCommitNotReleased, sha, pr.Identifier))
+			continue
+		}
+		log.Printf("[INFO] commit %s of %s was released as %s", sha, pr.Identifier, resolved.Original())
+		return resolved, nil
+	}
+	if errs == nil {
+		return nil, fmt.Errorf("no getter of %s can resolve git commits", pr.Identifier)
+	}
+	return nil, errs
+}
@@ -0,0 +1,78 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// commitResolverGetter resolves the commits of tags, by commit SHA.
+type commitResolverGetter struct {
+	mockPluginGetter
+	tags map[string][]string
+}
+
+func (g *commitResolverGetter) ResolveCommit(pr *Requirement, sha string) ([]string, error) {
+	tags, found := g.tags[sha]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrCommitNotReleased, sha)
+	}
+	return tags, nil
+}
+
+func TestParseCommitVersion(t *testing.T) {
+	tests := []struct {
+		arg        string
+		wantSHA    string
+		wantCommit bool
+		wantErr    bool
+	}{
+		{arg: "v1.2.3"},
+		{arg: ">= 1.0.0"},
+		{arg: "commit:0a1b2c3", wantSHA: "0a1b2c3", wantCommit: true},
+		{arg: "commit:0A1B2C3D4E5F60718293A4B5C6D7E8F901234567", wantSHA: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", wantCommit: true},
+		{arg: "commit:0a1b2c", wantCommit: true, wantErr: true},
+		{arg: "commit:main", wantCommit: true, wantErr: true},
+		{arg: "commit:", wantCommit: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			sha, isCommit, err := ParseCommitVersion(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCommitVersion(%q) error = %v, wantErr %t", tt.arg, err, tt.wantErr)
+			}
+			if sha != tt.wantSHA || isCommit != tt.wantCommit {
+				t.Errorf("ParseCommitVersion(%q) = %q, %t, want %q, %t", tt.arg, sha, isCommit, tt.wantSHA, tt.wantCommit)
+			}
+		})
+	}
+}
+
+func TestRequirement_ResolveCommit(t *testing.T) {
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	resolver := &commitResolverGetter{tags: map[string][]string{
+		"0a1b2c3": {"nightly", "v1.0.0", "v1.0.1"},
+		"4d5e6f7": {"nightly"},
+	}}
+
+	v, err := pr.ResolveCommit([]Getter{&mockPluginGetter{}, resolver}, "0a1b2c3")
+	if err != nil {
+		t.Fatalf("ResolveCommit: %v", err)
+	}
+	if v.Original() != "v1.0.1" {
+		t.Errorf("expected the highest version tag v1.0.1, got %s", v.Original())
+	}
+
+	for _, sha := range []string{"4d5e6f7", "8a9b0c1"} {
+		if _, err := pr.ResolveCommit([]Getter{resolver}, sha); !errors.Is(err, ErrCommitNotReleased) {
+			t.Errorf("expected commit %s to not be released, got %v", sha, err)
+		}
+	}
+
+	if _, err := pr.ResolveCommit([]Getter{&mockPluginGetter{}}, "0a1b2c3"); err == nil {
+		t.Error("expected getters that cannot resolve commits to fail")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer-plugin-sdk/tmp"
+)
+
+// assetKey identifies an asset of a version, ex: "sha256sums.sig", fetched
+// ahead of time from the getterIdx getter. Getters are not used as keys as
+// they may not be comparable.
+type assetKey struct {
+	getterIdx int
+	what      string
+	version   string
+}
+
+// prefetchedAsset is the outcome of getting an asset ahead of time. Zips are
+// written to a temporary file, the others kept in memory.
+type prefetchedAsset struct {
+	content []byte
+	zipName string
+	zipFile *os.File
+	err     error
+}
+
+// assetCache holds the assets fetched concurrently by InstallLatest when
+// InstallOptions.ConcurrentAssetFetches is set.
+type assetCache map[assetKey]*prefetchedAsset
+
+// prefetchAssets gets, at the same time, the checksum files of version from
+// the getterIdx getter, their signed copies and signatures when a
+// SignatureVerifier is set, and the zip expected for the platform. Nothing is
+// trusted yet: checksum files and signatures are verified as if they were
+// got when needed, and the zip is only used once its name was read from the
+// checksum file, and goes through the same checksum verification.
+//
+// The zip name is predicted from the zip asset template of getter, with the
+// plugin API version of Packer, so the zip is not prefetched when a binary of
+// version is already installed, unless opts.Force is set. Getters that serve
+// release bundles are skipped.
+func (opts *InstallOptions) prefetchAssets(getterIdx int, getter Getter, pr *Requirement, v *version.Version, outputFolder, namePrefix string) {
+	if _, ok := getter.(BundleGetter); ok {
+		return
+	}
+	getOpts := GetOptions{
+		PluginRequirement:         pr,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+		version:                   v,
+	}
+
+	var whats []string
+	for _, checksummer := range opts.Checksummers {
+		// prefetched with ChecksumFetchWorkers, or embedded in the release.
+		if _, found := opts.checksumFiles[checksumFileKey{checksummer.Type, v.String()}]; !found || getterIdx != 0 {
+			whats = append(whats, checksummer.Type)
+		}
+		if opts.SignatureVerifier != nil {
+			whats = append(whats, checksummer.Type+"sums", checksummer.Type+"sums.sig")
+		}
+	}
+	zipName := ""
+	if opts.Force || !opts.versionInstalled(pr, v, outputFolder, namePrefix) {
+		zipName = getOpts.RenderAssetName(assetNames(getter).Zip)
+	}
+	if len(whats) == 0 && zipName == "" {
+		return
+	}
+	log.Printf("[TRACE] fetching %v and %q of %s %s at the same time", whats, zipName, pr.Identifier, v)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	store := func(what string, asset *prefetchedAsset) {
+		mu.Lock()
+		defer mu.Unlock()
+		opts.assets[assetKey{getterIdx, what, v.String()}] = asset
+	}
+	for _, what := range whats {
+		wg.Add(1)
+		go func(what string) {
+			defer wg.Done()
+			asset := &prefetchedAsset{}
+			asset.content, asset.err = opts.getAll(getter, what, getOpts)
+			store(what, asset)
+		}(what)
+	}
+	if zipName != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			zipGetOpts := getOpts
+			zipGetOpts.expectedZipFilename = zipName
+			store("zip", opts.prefetchZip(getter, zipGetOpts))
+		}()
+	}
+	wg.Wait()
+}
+
+// prefetchZip downloads the zip of getOpts to a temporary file.
+func (opts *InstallOptions) prefetchZip(getter Getter, getOpts GetOptions) *prefetchedAsset {
+	asset := &prefetchedAsset{zipName: getOpts.expectedZipFilename}
+	rc, err := opts.get(getter, "zip", getOpts)
+	if err != nil {
+		asset.err = err
+		return asset
+	}
+	defer rc.Close()
+	f, err := tmp.File("packer-plugin-*.zip")
+	if err != nil {
+		asset.err = err
+		return asset
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		asset.err = fmt.Errorf("could not download %s: %w", asset.zipName, err)
+		return asset
+	}
+	asset.zipFile = f
+	return asset
+}
+
+// versionInstalled tells whether a binary of version v of pr seems to be
+// installed in outputFolder.
+func (opts *InstallOptions) versionInstalled(pr *Requirement, v *version.Version, outputFolder, namePrefix string) bool {
+	matches, _ := filepath.Glob(filepath.Join(LongPath(outputFolder), namePrefix+pr.FilenamePrefix()+"v"+v.String()+"_*"))
+	return len(matches) > 0
+}
+
+// asset returns the what asset of getOpts prefetched from the getterIdx
+// getter, if any.
+func (opts *InstallOptions) asset(getterIdx int, what string, getOpts GetOptions) (*prefetchedAsset, bool) {
+	asset, found := opts.assets[assetKey{getterIdx, what, getOpts.version.String()}]
+	return asset, found
+}
+
+// getAsset returns the what asset of getOpts, the one prefetched from the
+// getterIdx getter when there is one.
+func (opts *InstallOptions) getAsset(getterIdx int, getter Getter, what string, getOpts GetOptions) ([]byte, error) {
+	if asset, found := opts.asset(getterIdx, what, getOpts); found {
+		return asset.content, asset.err
+	}
+	return opts.getAll(getter, what, getOpts)
+}
+
+// takeZip returns the zip of getOpts prefetched from the getterIdx getter,
+// rewound, when its name is the expected one. It is only returned once, and
+// removed when closed.
+func (opts *InstallOptions) takeZip(getterIdx int, getOpts GetOptions) (io.ReadCloser, bool) {
+	key := assetKey{getterIdx, "zip", getOpts.version.String()}
+	asset, found := opts.assets[key]
+	if !found || asset.zipFile == nil || asset.zipName != getOpts.expectedZipFilename {
+		return nil, false
+	}
+	delete(opts.assets, key)
+	if _, err := asset.zipFile.Seek(0, io.SeekStart); err != nil {
+		log.Printf("[TRACE] could not rewind the prefetched %s, getting it again: %s", asset.zipName, err)
+		asset.remove()
+		return nil, false
+	}
+	log.Printf("[TRACE] using the prefetched %s", asset.zipName)
+	return removeOnClose{asset.zipFile}, true
+}
+
+// removeOnClose removes its file once closed.
+type removeOnClose struct {
+	*os.File
+}
+
+func (f removeOnClose) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
+
+func (a *prefetchedAsset) remove() {
+	if a.zipFile != nil {
+		_ = a.zipFile.Close()
+		_ = os.Remove(a.zipFile.Name())
+	}
+}
+
+// cleanup removes the zips that were prefetched but not used.
+func (c assetCache) cleanup() {
+	for _, asset := range c {
+		asset.remove()
+	}
+}
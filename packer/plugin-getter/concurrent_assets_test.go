@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// inFlightGetter is a signedPluginGetter counting the assets got at the same
+// time. Every get waits for up to wait for allowedInFlight gets to be
+// running.
+type inFlightGetter struct {
+	*signedPluginGetter
+	wait            time.Duration
+	allowedInFlight int
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	gets        map[string]int
+}
+
+func (g *inFlightGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	if what == "releases" {
+		return g.signedPluginGetter.Get(what, opts)
+	}
+	g.mu.Lock()
+	g.gets[what]++
+	g.inFlight++
+	if g.inFlight > g.maxInFlight {
+		g.maxInFlight = g.inFlight
+	}
+	g.mu.Unlock()
+
+	deadline := time.Now().Add(g.wait)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		done := g.maxInFlight >= g.allowedInFlight
+		g.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+
+	if what == "sha256" {
+		// unlike the pipe of the mockPluginGetter, the buffer can be read
+		// to the end.
+		buf := &bytes.Buffer{}
+		if err := json.NewEncoder(buf).Encode(g.ChecksumFileEntries[opts.version.String()]); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(buf), nil
+	}
+	return g.signedPluginGetter.Get(what, opts)
+}
+
+func TestRequirement_InstallLatest_concurrentAssetFetches(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name            string
+		concurrent      bool
+		tampered        bool
+		wantMaxInFlight int
+	}{
+		{name: "serial", wantMaxInFlight: 1},
+		// the sha256 checksum file, its signed copy and signature, and the
+		// zip.
+		{name: "concurrent", concurrent: true, wantMaxInFlight: 4},
+		{name: "concurrent-tampered-zip", concurrent: true, tampered: true, wantMaxInFlight: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := singleReleaseGetter("amazon")
+			if tt.tampered {
+				zip, _ := zipFileWithChecksum(map[string]string{binary: elfHeader + "tampered"})
+				mock.Zips["github.com/hashicorp/packer-plugin-amazon/"+binary+".zip"] = zip
+			}
+			checksumFile := mock.ChecksumFileEntries["1.0.0"][0].Checksum + "  " + binary + ".zip\n"
+			getter := &inFlightGetter{
+				signedPluginGetter: &signedPluginGetter{
+					mockPluginGetter: mock,
+					ChecksumFile:     checksumFile,
+					Signature:        "signed(" + checksumFile + ")",
+				},
+				wait:            time.Second,
+				allowedInFlight: tt.wantMaxInFlight,
+				gets:            map[string]int{},
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.SignatureVerifier = signatureVerifier{}
+			opts.ConcurrentAssetFetches = tt.concurrent
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+
+			if getter.maxInFlight != tt.wantMaxInFlight {
+				t.Errorf("expected %d assets to be got at the same time, got %d", tt.wantMaxInFlight, getter.maxInFlight)
+			}
+			for _, what := range []string{"sha256", "sha256sums", "sha256sums.sig", "zip"} {
+				if getter.gets[what] != 1 {
+					t.Errorf("expected %s to be got once, got %d requests", what, getter.gets[what])
+				}
+			}
+
+			_, statErr := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if tt.tampered {
+				var integrityErr *IntegrityError
+				if !errors.As(err, &integrityErr) || !strings.Contains(err.Error(), "checksum") {
+					t.Fatalf("expected a checksum IntegrityError, got %v", err)
+				}
+				if !os.IsNotExist(statErr) {
+					t.Errorf("expected the plugin not to be installed, stat returned %v", statErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if install == nil || install.Version != "v1.0.0" || statErr != nil {
+				t.Errorf("expected v1.0.0 to be installed, got %#v: %v", install, statErr)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_concurrentAssetFetchesInstalled(t *testing.T) {
+	pluginDir := t.TempDir()
+	opts := dependenciesInstallOptions(singleReleaseGetter("amazon"), pluginDir)
+	if _, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts); err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	// the zip of an installed version is not prefetched, as it is not needed.
+	getter := &inFlightGetter{
+		signedPluginGetter: &signedPluginGetter{mockPluginGetter: singleReleaseGetter("amazon")},
+		allowedInFlight:    1,
+		gets:               map[string]int{},
+	}
+	opts = dependenciesInstallOptions(getter, pluginDir)
+	opts.ConcurrentAssetFetches = true
+	install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	if install != nil {
+		t.Errorf("expected nothing to be installed, got %#v", install)
+	}
+	if getter.gets["zip"] != 0 {
+		t.Errorf("expected the zip not to be got, got %d requests", getter.gets["zip"])
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"log"
+)
+
+// ErrContentNotFound is returned by ContentStores that do not have a file.
+var ErrContentNotFound = errors.New("content not found")
+
+// A ContentStore serves files by their checksum, ex: an IPFS gateway serving
+// the file whose CID is derived from its sha256 digest.
+type ContentStore interface {
+	// GetContent returns the file matching checksum, of type checksumType,
+	// ex: "sha256".
+	GetContent(checksumType string, checksum Checksum) (io.ReadCloser, error)
+}
+
+// ContentAddressedGetter is an experimental Getter downloading zips from a
+// ContentStore, by the checksum the checksum file lists for them, ex: to
+// spread the download of plugins over the nodes of a peer to peer network.
+// Every other file, along with the zips the store cannot serve, is got from
+// Getter.
+//
+// Zips from the store are verified against their checksum like any other,
+// the store does not need to be trusted. Zips trusted on first use have no
+// known checksum and are always got from Getter.
+type ContentAddressedGetter struct {
+	Store ContentStore
+
+	// Getter gets the releases and checksum files, ex: a *github.Getter.
+	Getter Getter
+}
+
+var _ AssetNamer = &ContentAddressedGetter{}
+
+func (g *ContentAddressedGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	if what == "zip" {
+		if checksumType, checksum := opts.ExpectedZipChecksum(); checksum != nil {
+			zip, err := g.Store.GetContent(checksumType, checksum)
+			if err == nil {
+				log.Printf("[TRACE] got %s from the content store, by its %s checksum %s", opts.ExpectedZipFilename(), checksumType, checksum)
+				return zip, nil
+			}
+			log.Printf("[TRACE] could not get %s from the content store, falling back: %s", opts.ExpectedZipFilename(), err)
+		}
+	}
+	return g.Getter.Get(what, opts)
+}
+
+// String names the getter in errors, after Getter.
+func (g *ContentAddressedGetter) String() string {
+	return getterName(g.Getter) + " with a content store"
+}
+
+// AssetNames names release files like Getter does, as it is the one listing
+// them.
+func (g *ContentAddressedGetter) AssetNames() AssetNames {
+	return assetNames(g.Getter)
+}
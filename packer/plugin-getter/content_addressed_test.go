@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// mockContentStore serves files by the hex of their checksum.
+type mockContentStore struct {
+	files    map[string][]byte
+	requests []string
+}
+
+func (s *mockContentStore) GetContent(checksumType string, checksum Checksum) (io.ReadCloser, error) {
+	key := checksumType + ":" + checksum.String()
+	s.requests = append(s.requests, key)
+	content, found := s.files[key]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrContentNotFound, key)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// contentAddressedGetter returns a getter of the amazon plugin, along with
+// the content of its zip and the key of the zip in a mockContentStore. The
+// zip of the HTTP getter is not a zip.
+func contentAddressedGetter(t *testing.T) (*mockPluginGetter, []byte, string) {
+	getter := singleReleaseGetter("amazon")
+	zipName := "github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip"
+	zip, err := io.ReadAll(getter.Zips[zipName])
+	if err != nil {
+		t.Fatalf("failed to read the zip: %s", err)
+	}
+	getter.Zips[zipName] = io.NopCloser(strings.NewReader("not a zip"))
+	return getter, zip, "sha256:" + getter.ChecksumFileEntries["1.0.0"][0].Checksum
+}
+
+func TestContentAddressedGetter(t *testing.T) {
+	metadata, zip, key := contentAddressedGetter(t)
+	store := &mockContentStore{files: map[string][]byte{key: zip}}
+	opts := dependenciesInstallOptions(&ContentAddressedGetter{Store: store, Getter: metadata}, t.TempDir())
+
+	install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+	if err != nil {
+		t.Fatalf("InstallLatest: %s", err)
+	}
+	if install == nil || install.Version != "v1.0.0" {
+		t.Fatalf("expected v1.0.0 to be installed, got %#v", install)
+	}
+	if len(store.requests) != 1 || store.requests[0] != key {
+		t.Errorf("expected the zip to be got by its checksum %s, got %v", key, store.requests)
+	}
+}
+
+func TestContentAddressedGetter_fallback(t *testing.T) {
+	metadata := singleReleaseGetter("amazon")
+	store := &mockContentStore{}
+	opts := dependenciesInstallOptions(&ContentAddressedGetter{Store: store, Getter: metadata}, t.TempDir())
+
+	install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+	if err != nil {
+		t.Fatalf("InstallLatest: %s", err)
+	}
+	if install == nil {
+		t.Fatal("expected the zip of the HTTP getter to be installed")
+	}
+	if len(store.requests) != 1 {
+		t.Errorf("expected the store to be asked for the zip once, got %v", store.requests)
+	}
+}
+
+func TestContentAddressedGetter_corruptedContent(t *testing.T) {
+	metadata, zip, key := contentAddressedGetter(t)
+	corrupted := append([]byte{}, zip...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	store := &mockContentStore{files: map[string][]byte{key: corrupted}}
+	opts := dependenciesInstallOptions(&ContentAddressedGetter{Store: store, Getter: metadata}, t.TempDir())
+
+	_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Fatalf("expected the content of the store to be verified, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Credentials authenticate the requests of a getter to a host.
+type Credentials struct {
+	// Token is sent as a bearer token.
+	Token string
+
+	// Username and Password are sent with HTTP basic authentication, when
+	// there is no Token.
+	Username string
+	Password string
+}
+
+// SetAuthHeader sets the Authorization header of req from c.
+func (c Credentials) SetAuthHeader(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		return
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+}
+
+// A CredentialResolver returns the credentials to get the plugins of a host,
+// the Hostname of their source address, ex: "github.com". It returns false
+// when it has none for the host. HTTP getters use it so that one install can
+// authenticate differently to each host.
+type CredentialResolver interface {
+	Credentials(hostname string) (Credentials, bool)
+}
+
+// HostCredentials is a CredentialResolver from a map of hostnames to their
+// credentials. Hostnames are matched case-insensitively.
+type HostCredentials map[string]Credentials
+
+var _ CredentialResolver = HostCredentials{}
+
+func (h HostCredentials) Credentials(hostname string) (Credentials, bool) {
+	if c, found := h[hostname]; found {
+		return c, true
+	}
+	for host, c := range h {
+		if strings.EqualFold(host, hostname) {
+			return c, true
+		}
+	}
+	return Credentials{}, false
+}
@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHostCredentials_Credentials(t *testing.T) {
+	creds := HostCredentials{
+		"github.com":               {Token: "github-token"},
+		"gitlab.example.com":       {Token: "gitlab-token"},
+		"plugins.internal.example": {Username: "packer", Password: "internal-secret"},
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		wantAuth string
+	}{
+		{"github", "github.com/hashicorp/amazon", "Bearer github-token"},
+		{"gitlab", "gitlab.example.com/hashicorp/amazon", "Bearer gitlab-token"},
+		{"internal", "plugins.internal.example/hashicorp/amazon", "Basic cGFja2VyOmludGVybmFsLXNlY3JldA=="},
+		{"case-insensitive-host", "GitHub.com/hashicorp/amazon", "Bearer github-token"},
+		{"unknown-host", "example.com/hashicorp/amazon", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := mustRequirement(t, tt.source, "")
+			c, found := creds.Credentials(pr.Identifier.Hostname)
+			if found != (tt.wantAuth != "") {
+				t.Fatalf("Credentials(%q) found = %t", pr.Identifier.Hostname, found)
+			}
+			if !found {
+				return
+			}
+			req, err := http.NewRequest("GET", "https://"+pr.Identifier.Hostname, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.SetAuthHeader(req)
+			if got := req.Header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// releaseDependency is how a dependency is declared in release metadata, ex:
+//
+//	{"source": "github.com/hashicorp/ansible", "version": ">= 1.1.0"}
+type releaseDependency struct {
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"`
+}
+
+type releaseJSON struct {
+	Version      string              `json:"version"`
+	Dependencies []releaseDependency `json:"dependencies,omitempty"`
+	PublishedAt  *time.Time          `json:"published_at,omitempty"`
+	// Checksums are the checksum entries of the release assets, by
+	// checksummer type, ex:
+	//
+	//	{"sha256": [{"filename": "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip", "checksum": "..."}]}
+	Checksums map[string][]ChecksumFileEntry `json:"checksums,omitempty"`
+}
+
+func (r Release) MarshalJSON() ([]byte, error) {
+	out := releaseJSON{Version: r.Version, Checksums: r.Checksums}
+	if !r.PublishedAt.IsZero() {
+		out.PublishedAt = &r.PublishedAt
+	}
+	for _, dep := range r.Dependencies {
+		if dep == nil || dep.Identifier == nil {
+			return nil, fmt.Errorf("release %s: dependency without source", r.Version)
+		}
+		out.Dependencies = append(out.Dependencies, releaseDependency{
+			Source:  dep.Identifier.String(),
+			Version: dep.VersionConstraints.String(),
+		})
+	}
+	return json.Marshal(out)
+}
+
+func (r *Release) UnmarshalJSON(b []byte) error {
+	var in releaseJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	r.Version = in.Version
+	r.PublishedAt = time.Time{}
+	if in.PublishedAt != nil {
+		r.PublishedAt = *in.PublishedAt
+	}
+	r.Checksums = in.Checksums
+	r.Dependencies = nil
+	for _, dep := range in.Dependencies {
+		identifier, diags := addrs.ParsePluginSourceString(dep.Source)
+		if diags.HasErrors() {
+			return fmt.Errorf("release %s: invalid dependency source %q: %s", in.Version, dep.Source, diags.Error())
+		}
+		var constraints version.Constraints
+		if dep.Version != "" {
+			var err error
+			constraints, err = version.NewConstraint(dep.Version)
+			if err != nil {
+				return fmt.Errorf("release %s: invalid version constraint %q for dependency %s: %w", in.Version, dep.Version, dep.Source, err)
+			}
+		}
+		r.Dependencies = append(r.Dependencies, &Requirement{
+			Identifier:         identifier,
+			VersionConstraints: constraints,
+		})
+	}
+	return nil
+}
+
+// InstallAllContext is InstallAll, with its waits for opts.RateLimiter
+// interrupted when ctx is done.
+func (reqs Requirements) InstallAllContext(ctx context.Context, opts InstallOptions) ([]*Installation, error) {
+	opts.ctx = ctx
+	return reqs.InstallAll(opts)
+}
+
+// InstallAll installs the latest version of every requirement, along with the
+// dependencies declared by the installed releases, transitively.
+//
+// A plugin is only installed once: when several requirements or dependencies
+// point to the same plugin, the first one wins. The dependencies of releases
+// that were already installed are installed too, ex: when they were removed
+// since. An error is returned when a release depends on one of the plugins
+// that led to it.
+//
+// With opts.Transactional, nothing is returned as installed on error: the
+// binaries written by this call are removed. Binaries installed by a previous
+// call and resumed from opts.StatePath are kept.
+//
+// Requirements resolving to the same zip, see ZipURLer, download and verify
+// it once.
+func (reqs Requirements) InstallAll(opts InstallOptions) ([]*Installation, error) {
+	opts.verifiedZips = verifiedZipCache{}
+	i := &dependencyInstaller{
+		opts: opts,
+		done: map[string]bool{},
+	}
+	if opts.StatePath != "" {
+		state, err := readInstallState(opts.StatePath)
+		if err != nil {
+			return nil, err
+		}
+		i.state = state
+	}
+	for _, req := range reqs {
+		i.install(req, nil)
+	}
+	if i.errs != nil && opts.Transactional {
+		i.rollback()
+		return nil, i.errs.ErrorOrNil()
+	}
+	if i.errs == nil && opts.StatePath != "" {
+		if err := os.Remove(opts.StatePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("[WARNING] failed to remove install state %q: %s", opts.StatePath, err)
+		}
+	}
+	return i.installs, i.errs.ErrorOrNil()
+}
+
+type dependencyInstaller struct {
+	opts InstallOptions
+
+	// done holds the plugins for which installation was attempted.
+	done     map[string]bool
+	installs []*Installation
+	errs     *multierror.Error
+
+	// created holds the binaries and checksum files that did not exist
+	// before being installed, which are the ones a rollback removes.
+	created []string
+
+	// state holds the plugins installed so far, when persisted.
+	state *installState
+}
+
+// install installs req then its dependencies; path is the chain of plugins
+// that required req.
+func (i *dependencyInstaller) install(req *Requirement, path []string) {
+	name := req.Identifier.String()
+	for idx, parent := range path {
+		if parent == name {
+			cycle := append(append([]string{}, path[idx:]...), name)
+			err := fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+			i.errs = multierror.Append(i.errs, err)
+			i.opts.observeInstall(InstallResult{Source: name, Reason: InstallReasonFailed, Err: err})
+			return
+		}
+	}
+	if i.done[name] {
+		return
+	}
+	i.done[name] = true
+
+	if i.state != nil {
+		if install := i.state.resume(req, i.opts); install != nil {
+			log.Printf("[TRACE] %s %s was installed by a previous run", name, install.Version)
+			install.Source = req.Identifier
+			i.installs = append(i.installs, install)
+			i.opts.observeInstall(InstallResult{Source: name, Reason: InstallReasonResumed, Installation: install})
+			i.installDependencies(install, append(path, name))
+			return
+		}
+	}
+
+	var existing map[string]bool
+	if i.opts.Transactional {
+		existing = existingFiles(req.installDir(i.opts.PluginDirectory, i.opts.BinaryInstallationOptions))
+	}
+
+	opts := i.opts
+	upToDate := &Installation{}
+	opts.upToDate = upToDate
+	start := time.Now()
+	install, err := req.InstallLatest(opts)
+	duration := time.Since(start)
+	if err != nil {
+		if len(path) > 0 {
+			err = fmt.Errorf("%s, required by %s: %w", name, path[len(path)-1], err)
+		}
+		i.errs = multierror.Append(i.errs, err)
+		i.opts.observeInstall(InstallResult{Source: name, Reason: InstallReasonFailed, Err: err, Duration: duration})
+		return
+	}
+	if install == nil {
+		i.opts.observeInstall(InstallResult{Source: name, Reason: InstallReasonUpToDate, Duration: duration})
+		// dependencies removed since it was installed are installed again.
+		if upToDate.Version != "" {
+			i.installDependencies(upToDate, append(path, name))
+		}
+		return
+	}
+	install.Source = req.Identifier
+	i.installs = append(i.installs, install)
+	if i.opts.Transactional {
+		checksummers := req.checksummers(i.opts.BinaryInstallationOptions)
+		binaryPath := filepath.FromSlash(install.BinaryPath)
+		files := append([]string{binaryPath}, sidecarFiles(binaryPath, checksummers)...)
+		for _, other := range install.OtherBinaries {
+			other := filepath.FromSlash(other)
+			files = append(files, other)
+			files = append(files, checksumFiles(other, checksummers)...)
+		}
+		for _, file := range files {
+			if !existing[filepath.Base(file)] {
+				i.created = append(i.created, file)
+			}
+		}
+	}
+
+	if i.state != nil {
+		if err := i.state.record(name, install, req.checksummers(i.opts.BinaryInstallationOptions)); err != nil {
+			log.Printf("[WARNING] not recording the installation of %s: %s", name, err)
+		} else if err := i.state.write(i.opts.StatePath); err != nil {
+			log.Printf("[WARNING] failed to write install state %q: %s", i.opts.StatePath, err)
+		}
+	}
+
+	i.opts.observeInstall(InstallResult{Source: name, Reason: InstallReasonInstalled, Installation: install, Duration: duration})
+	i.installDependencies(install, append(path, name))
+}
+
+// installDependencies installs the dependencies of install; path is the
+// chain of plugins that led to it, ending with it.
+func (i *dependencyInstaller) installDependencies(install *Installation, path []string) {
+	name := path[len(path)-1]
+	for _, dep := range install.Dependencies {
+		log.Printf("[TRACE] %s %s depends on %s %s", name, install.Version, dep.Identifier, dep.VersionConstraints)
+		i.install(dep, path)
+	}
+}
+
+// rollback removes the files created by the installer.
+func (i *dependencyInstaller) rollback() {
+	for _, file := range i.created {
+		log.Printf("[INFO] rolling back the installation of %q", file)
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			i.errs = multierror.Append(i.errs, fmt.Errorf("rollback: %w", err))
+		}
+	}
+}
+
+// existingFiles returns the names of the files in dir.
+func existingFiles(dir string) map[string]bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	res := map[string]bool{}
+	for _, entry := range entries {
+		res[entry.Name()] = true
+	}
+	return res
+}
@@ -0,0 +1,421 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// multiPluginGetter dispatches requests to a getter per plugin.
+type multiPluginGetter map[string]Getter
+
+func (g multiPluginGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	return g[options.PluginRequirement.Identifier.String()].Get(what, options)
+}
+
+// singleReleaseGetter returns a getter for a v1.0.0 release of plugin name
+// that depends on deps.
+func singleReleaseGetter(name string, deps ...*Requirement) *mockPluginGetter {
+	binary := "packer-plugin-" + name + "_v1.0.0_x5.0_linux_amd64"
+	zip, checksum := zipFileWithChecksum(map[string]string{
+		binary: elfHeader + name,
+	})
+	return &mockPluginGetter{
+		Releases: []Release{{Version: "v1.0.0", Dependencies: deps}},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"1.0.0": {{Filename: binary + ".zip", Checksum: checksum}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-" + name + "/" + binary + ".zip": zip,
+		},
+	}
+}
+
+func mustRequirement(t *testing.T, source, constraints string) *Requirement {
+	identifier, diags := addrs.ParsePluginSourceString(source)
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	req := &Requirement{Identifier: identifier}
+	if constraints != "" {
+		req.VersionConstraints = version.MustConstraints(version.NewConstraint(constraints))
+	}
+	return req
+}
+
+func dependenciesInstallOptions(getter Getter, pluginDir string) InstallOptions {
+	return InstallOptions{
+		Getters:         []Getter{getter},
+		PluginDirectory: pluginDir,
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "amd64",
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		},
+	}
+}
+
+func TestRelease_dependenciesJSON(t *testing.T) {
+	in := `[{"version":"v1.0.0"},{"version":"v1.1.0","dependencies":[{"source":"github.com/hashicorp/ansible","version":">= 1.1.0"}]}]`
+	releases, err := ParseReleases(io.NopCloser(strings.NewReader(in)))
+	if err != nil {
+		t.Fatalf("ParseReleases: %v", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 releases, got %d", len(releases))
+	}
+	if releases[0].Dependencies != nil {
+		t.Errorf("expected no dependencies for v1.0.0, got %v", releases[0].Dependencies)
+	}
+	deps := releases[1].Dependencies
+	if len(deps) != 1 || deps[0].Identifier.String() != "github.com/hashicorp/ansible" || deps[0].VersionConstraints.String() != ">= 1.1.0" {
+		t.Fatalf("unexpected dependencies for v1.1.0: %v", deps)
+	}
+
+	// releases are json encoded by getters, make sure dependencies survive.
+	out, err := json.Marshal(releases)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	decoded, err := ParseReleases(io.NopCloser(bytes.NewReader(out)))
+	if err != nil {
+		t.Fatalf("ParseReleases(%s): %v", out, err)
+	}
+	if diff := cmp.Diff(releases, decoded, cmp.Transformer("requirement", func(r *Requirement) string {
+		return r.Identifier.String() + " " + r.VersionConstraints.String()
+	})); diff != "" {
+		t.Errorf("releases changed after encoding: %s", diff)
+	}
+
+	_, err = ParseReleases(io.NopCloser(strings.NewReader(`[{"version":"v1.0.0","dependencies":[{"source":"ansible"}]}]`)))
+	if err == nil {
+		t.Errorf("expected an error for an invalid dependency source")
+	}
+
+	_, err = json.Marshal(Release{Version: "v1.0.0", Dependencies: Requirements{{}}})
+	if err == nil || !strings.Contains(err.Error(), "dependency without source") {
+		t.Errorf("expected an error for a dependency without source, got %v", err)
+	}
+}
+
+func TestRequirements_InstallAll(t *testing.T) {
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon",
+			mustRequirement(t, "github.com/hashicorp/ansible", ">= 1.0.0")),
+		"github.com/hashicorp/ansible": singleReleaseGetter("ansible",
+			mustRequirement(t, "github.com/hashicorp/docker", "")),
+		"github.com/hashicorp/docker": singleReleaseGetter("docker"),
+	}
+
+	pluginDir := t.TempDir()
+	reqs := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+		// already pulled in as a dependency, it must not be installed twice.
+		mustRequirement(t, "github.com/hashicorp/docker", ""),
+	}
+	installs, err := reqs.InstallAll(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+
+	var got []string
+	for _, install := range installs {
+		rel, err := filepath.Rel(pluginDir, filepath.FromSlash(install.BinaryPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{
+		"github.com/hashicorp/amazon/packer-plugin-amazon_v1.0.0_x5.0_linux_amd64",
+		"github.com/hashicorp/ansible/packer-plugin-ansible_v1.0.0_x5.0_linux_amd64",
+		"github.com/hashicorp/docker/packer-plugin-docker_v1.0.0_x5.0_linux_amd64",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected installs: %s", diff)
+	}
+}
+
+func TestRequirements_InstallAll_removedDependency(t *testing.T) {
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon",
+			mustRequirement(t, "github.com/hashicorp/ansible", "")),
+		"github.com/hashicorp/ansible": singleReleaseGetter("ansible"),
+	}
+	pluginDir := t.TempDir()
+	reqs := Requirements{mustRequirement(t, "github.com/hashicorp/amazon", "")}
+	if _, err := reqs.InstallAll(dependenciesInstallOptions(getter, pluginDir)); err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+
+	// the dependency is removed, amazon stays installed.
+	ansibleDir := filepath.Join(pluginDir, "github.com", "hashicorp", "ansible")
+	if err := os.RemoveAll(ansibleDir); err != nil {
+		t.Fatal(err)
+	}
+	getter["github.com/hashicorp/ansible"] = singleReleaseGetter("ansible")
+
+	installs, err := reqs.InstallAll(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+	if len(installs) != 1 || installs[0].Source.String() != "github.com/hashicorp/ansible" {
+		t.Fatalf("expected only the removed dependency to be installed again, got %v", installs)
+	}
+	if _, err := os.Stat(filepath.FromSlash(installs[0].BinaryPath)); err != nil {
+		t.Errorf("expected the dependency to be installed: %v", err)
+	}
+}
+
+func TestRequirements_InstallAll_cycle(t *testing.T) {
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon",
+			mustRequirement(t, "github.com/hashicorp/ansible", "")),
+		"github.com/hashicorp/ansible": singleReleaseGetter("ansible",
+			mustRequirement(t, "github.com/hashicorp/amazon", "")),
+	}
+
+	installs, err := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+	}.InstallAll(dependenciesInstallOptions(getter, t.TempDir()))
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: github.com/hashicorp/amazon -> github.com/hashicorp/ansible -> github.com/hashicorp/amazon") {
+		t.Fatalf("expected a dependency cycle error, got %v", err)
+	}
+	if len(installs) != 2 {
+		t.Errorf("expected both plugins to be installed, got %v", installs)
+	}
+}
+
+func TestRequirements_InstallAll_noDependencies(t *testing.T) {
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon"),
+	}
+
+	pluginDir := t.TempDir()
+	installs, err := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+	}.InstallAll(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+	want := []*Installation{{
+		BinaryPath: filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64")),
+		Version:    "v1.0.0",
+		Source:     mustRequirement(t, "github.com/hashicorp/amazon", "").Identifier,
+	}}
+	if diff := cmp.Diff(want, installs, cmpopts.EquateEmpty(), ignoreServedBy); diff != "" {
+		t.Errorf("unexpected installs: %s", diff)
+	}
+}
+
+func TestRequirements_InstallAll_perRequirementChecksummers(t *testing.T) {
+	// the amazon plugin only publishes sha512 checksums.
+	amazon := singleReleaseGetter("amazon",
+		mustRequirement(t, "github.com/hashicorp/ansible", ""))
+	amazonBinary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	amazonZip := &bytes.Buffer{}
+	if _, err := io.Copy(amazonZip, zipFile(map[string]string{amazonBinary: elfHeader + "amazon"})); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512(amazonZip.Bytes())
+	amazon.ChecksumFileEntries["1.0.0"][0].Checksum = hex.EncodeToString(sum[:])
+	amazon.Zips["github.com/hashicorp/packer-plugin-amazon/"+amazonBinary+".zip"] = io.NopCloser(amazonZip)
+
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon":  amazon,
+		"github.com/hashicorp/ansible": singleReleaseGetter("ansible"),
+	}
+
+	amazonReq := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	amazonReq.Checksummers = []Checksummer{{Type: "sha512", Hash: sha512.New()}}
+
+	pluginDir := t.TempDir()
+	installs, err := Requirements{amazonReq}.InstallAll(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+	if len(installs) != 2 {
+		t.Fatalf("expected 2 installations, got %v", installs)
+	}
+
+	for _, checksumFile := range []string{
+		installs[0].BinaryPath + "_SHA512SUM",
+		installs[1].BinaryPath + "_SHA256SUM",
+	} {
+		if _, err := os.Stat(checksumFile); err != nil {
+			t.Errorf("expected checksum file %q: %v", checksumFile, err)
+		}
+	}
+	if _, err := os.Stat(installs[0].BinaryPath + "_SHA256SUM"); !os.IsNotExist(err) {
+		t.Errorf("unexpected sha256 checksum file for the amazon plugin: %v", err)
+	}
+}
+
+func TestRequirements_InstallAll_transactional(t *testing.T) {
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon",
+			mustRequirement(t, "github.com/hashicorp/ansible", "")),
+		"github.com/hashicorp/ansible": singleReleaseGetter("ansible"),
+		// has no release, so it fails to install.
+		"github.com/hashicorp/docker": &mockPluginGetter{},
+	}
+
+	pluginDir := t.TempDir()
+	amazonDir := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+	preExisting := filepath.Join(amazonDir, "packer-plugin-amazon_v0.9.0_x5.0_linux_amd64")
+	if err := os.MkdirAll(amazonDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{preExisting, preExisting + "_SHA256SUM"} {
+		if err := os.WriteFile(file, []byte("v0.9.0"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := dependenciesInstallOptions(getter, pluginDir)
+	opts.Transactional = true
+	installs, err := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+		mustRequirement(t, "github.com/hashicorp/docker", ""),
+	}.InstallAll(opts)
+	if err == nil {
+		t.Fatal("InstallAll: expected the docker plugin to fail installing")
+	}
+	if len(installs) != 0 {
+		t.Errorf("expected no installation to be returned, got %v", installs)
+	}
+
+	for _, binary := range []string{
+		filepath.Join(amazonDir, "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"),
+		filepath.Join(pluginDir, "github.com", "hashicorp", "ansible", "packer-plugin-ansible_v1.0.0_x5.0_linux_amd64"),
+	} {
+		for _, file := range []string{binary, binary + "_SHA256SUM"} {
+			if _, err := os.Stat(file); !os.IsNotExist(err) {
+				t.Errorf("expected %q to be rolled back, stat returned: %v", file, err)
+			}
+		}
+	}
+	for _, file := range []string{preExisting, preExisting + "_SHA256SUM"} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected pre-existing %q to be kept: %v", file, err)
+		}
+	}
+}
+
+func TestRequirements_InstallAll_transactionalSidecars(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name       string
+		amazon     func() Getter
+		edit       func(*InstallOptions)
+		wantCreate []string
+	}{
+		{
+			name: "signed",
+			amazon: func() Getter {
+				mock := singleReleaseGetter("amazon")
+				checksumFile := mock.ChecksumFileEntries["1.0.0"][0].Checksum + "  " + binary + ".zip\n"
+				return &signedPluginGetter{
+					mockPluginGetter: mock,
+					ChecksumFile:     checksumFile,
+					Signature:        "signed(" + checksumFile + ")",
+				}
+			},
+			edit:       func(opts *InstallOptions) { opts.SignatureVerifier = signatureVerifier{} },
+			wantCreate: []string{binary + SignedChecksumFileExt, binary + SignedChecksumFileExt + SignatureFileExt},
+		},
+		{
+			name: "trusted-on-first-use",
+			amazon: func() Getter {
+				mock := singleReleaseGetter("amazon")
+				mock.ChecksumFileEntries = nil
+				return mock
+			},
+			edit:       func(opts *InstallOptions) { opts.TrustOnFirstUse = true },
+			wantCreate: []string{binary + ".zip_SHA256SUM.pin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := multiPluginGetter{
+				"github.com/hashicorp/amazon": tt.amazon(),
+				// has no release, so it fails to install.
+				"github.com/hashicorp/docker": &mockPluginGetter{},
+			}
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.Transactional = true
+			tt.edit(&opts)
+
+			// the sidecar files are created by the install.
+			installs, err := Requirements{mustRequirement(t, "github.com/hashicorp/amazon", "")}.InstallAll(opts)
+			if err != nil || len(installs) != 1 {
+				t.Fatalf("InstallAll: expected amazon to be installed, got %v: %v", installs, err)
+			}
+			amazonDir := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+			for _, file := range tt.wantCreate {
+				if _, err := os.Stat(filepath.Join(amazonDir, file)); err != nil {
+					t.Fatalf("expected %q to be created: %v", file, err)
+				}
+			}
+			if err := os.RemoveAll(amazonDir); err != nil {
+				t.Fatal(err)
+			}
+
+			getter["github.com/hashicorp/amazon"] = tt.amazon()
+			_, err = Requirements{
+				mustRequirement(t, "github.com/hashicorp/amazon", ""),
+				mustRequirement(t, "github.com/hashicorp/docker", ""),
+			}.InstallAll(opts)
+			if err == nil {
+				t.Fatal("InstallAll: expected the docker plugin to fail installing")
+			}
+			entries, _ := os.ReadDir(amazonDir)
+			for _, entry := range entries {
+				t.Errorf("expected %q to be rolled back", entry.Name())
+			}
+		})
+	}
+}
+
+func TestRequirements_InstallAll_notTransactional(t *testing.T) {
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon"),
+		"github.com/hashicorp/docker": &mockPluginGetter{},
+	}
+
+	installs, err := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+		mustRequirement(t, "github.com/hashicorp/docker", ""),
+	}.InstallAll(dependenciesInstallOptions(getter, t.TempDir()))
+	if err == nil {
+		t.Fatal("InstallAll: expected the docker plugin to fail installing")
+	}
+	if len(installs) != 1 {
+		t.Fatalf("expected the amazon plugin to stay installed, got %v", installs)
+	}
+	if _, err := os.Stat(filepath.FromSlash(installs[0].BinaryPath)); err != nil {
+		t.Errorf("expected %q to be kept: %v", installs[0].BinaryPath, err)
+	}
+}
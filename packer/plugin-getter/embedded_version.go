@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+)
+
+// EmbeddedVersionCheck tells InstallLatest what to do when an installed
+// binary reports, when described, another version than its filename tells,
+// ex: a mis-tagged release. Such binaries are ignored by ListInstallations.
+type EmbeddedVersionCheck int
+
+const (
+	// EmbeddedVersionUnchecked does not start installed binaries, the
+	// default.
+	EmbeddedVersionUnchecked EmbeddedVersionCheck = iota
+	// EmbeddedVersionWarn logs a warning on mismatch, or when the binary
+	// cannot describe itself. It never fails.
+	EmbeddedVersionWarn
+	// EmbeddedVersionFail removes the installed binary and fails with an
+	// *EmbeddedVersionError on mismatch, or with why it could not be
+	// described.
+	EmbeddedVersionFail
+)
+
+// ErrEmbeddedVersionMismatch is matched, with errors.Is, by
+// EmbeddedVersionErrors.
+var ErrEmbeddedVersionMismatch = errors.New("embedded version mismatch")
+
+// EmbeddedVersionError is returned when an installed binary reports another
+// version than its filename tells.
+type EmbeddedVersionError struct {
+	BinaryPath string
+	// Version is the one of the filename, ex: "v2.10.0", Embedded the one
+	// reported by the binary, ex: "2.9.0".
+	Version  string
+	Embedded string
+}
+
+func (e *EmbeddedVersionError) Error() string {
+	return fmt.Sprintf("%s: %q is named for version %s but reports version %s", ErrEmbeddedVersionMismatch, e.BinaryPath, e.Version, e.Embedded)
+}
+
+func (e *EmbeddedVersionError) Is(target error) bool { return target == ErrEmbeddedVersionMismatch }
+
+// checkEmbeddedVersion describes the binary of install and compares the
+// version it reports to the one of its filename, following
+// opts.EmbeddedVersionCheck. Binaries for another platform cannot be started
+// and are not checked, nor are the ones CheckInstallable did not extract.
+func (opts *InstallOptions) checkEmbeddedVersion(install *Installation) error {
+	if opts.EmbeddedVersionCheck == EmbeddedVersionUnchecked {
+		return nil
+	}
+	if opts.checkOnly {
+		log.Printf("[TRACE] not checking the embedded version of %s, it was not extracted", install.Version)
+		return nil
+	}
+	if opts.OS != runtime.GOOS || opts.ARCH != runtime.GOARCH {
+		log.Printf("[TRACE] not checking the embedded version of %q, built for %s_%s", install.BinaryPath, opts.OS, opts.ARCH)
+		return nil
+	}
+
+	binaryPath := filepath.FromSlash(install.BinaryPath)
+	err := checkDescribedVersion(binaryPath, install.Version)
+	if err == nil {
+		return nil
+	}
+	if opts.EmbeddedVersionCheck == EmbeddedVersionWarn {
+		log.Printf("[WARNING] %s", err)
+		return nil
+	}
+	for _, file := range append([]string{binaryPath}, sidecarFiles(binaryPath, opts.Checksummers)...) {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Printf("[WARNING] failed to remove %q: %s", file, err)
+		}
+	}
+	return err
+}
+
+// checkDescribedVersion describes the binary in binaryPath and returns an
+// *EmbeddedVersionError when it reports another version than version.
+func checkDescribedVersion(binaryPath, version string) error {
+	out, err := exec.Command(binaryPath, "describe").Output()
+	if err != nil {
+		return fmt.Errorf("failed to describe %q to check its version: %w", binaryPath, err)
+	}
+	var desc pluginsdk.SetDescription
+	if err := json.Unmarshal(out, &desc); err != nil {
+		return fmt.Errorf("failed to read the description of %q to check its version: %w", binaryPath, err)
+	}
+	if desc.Version == strings.TrimPrefix(version, "v") {
+		return nil
+	}
+	return &EmbeddedVersionError{
+		BinaryPath: binaryPath,
+		Version:    version,
+		Embedded:   desc.Version,
+	}
+}
+
+// checksumFiles returns the paths of the checksum files of binaryPath.
+func checksumFiles(binaryPath string, checksummers []Checksummer) []string {
+	var res []string
+	for _, checksummer := range checksummers {
+		res = append(res, binaryPath+checksummer.FileExt())
+	}
+	return res
+}
+
+// sidecarFiles returns the paths of the files installed next to binaryPath:
+// its checksum files, the pin of the zip it was installed from when there is
+// one, the checksum of that zip, and its stored signature, in that order.
+func sidecarFiles(binaryPath string, checksummers []Checksummer) []string {
+	files := checksumFiles(binaryPath, checksummers)
+	if pin := PinFile(binaryPath); pin != "" {
+		files = append(files, pin)
+	}
+	files = append(files, binaryPath+ZipChecksumFileExt)
+	return append(files, signatureFiles(binaryPath)...)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package plugingetter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installMistaggedPlugin installs a plugin named for v2.10.0 that reports
+// version 2.9.0 when described.
+func installMistaggedPlugin(t *testing.T) (*Installation, InstallOptions) {
+	pluginDir := t.TempDir()
+	binary := installFakePlugin(t, pluginDir, "github.com/hashicorp/amazon", "v2.9.0")
+	mistagged := filepath.Join(filepath.Dir(binary), strings.Replace(filepath.Base(binary), "v2.9.0", "v2.10.0", 1))
+	for _, ext := range []string{"", "_SHA256SUM"} {
+		if err := os.Rename(binary+ext, mistagged+ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := mirrorInstallOptions(pluginDir)
+	return &Installation{BinaryPath: mistagged, Version: "v2.10.0"}, opts
+}
+
+func TestInstallOptions_checkEmbeddedVersion(t *testing.T) {
+	t.Run("unchecked", func(t *testing.T) {
+		install, opts := installMistaggedPlugin(t)
+		if err := opts.checkEmbeddedVersion(install); err != nil {
+			t.Errorf("expected no check by default, got %v", err)
+		}
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		install, opts := installMistaggedPlugin(t)
+		opts.EmbeddedVersionCheck = EmbeddedVersionWarn
+		if err := opts.checkEmbeddedVersion(install); err != nil {
+			t.Errorf("expected a warning only, got %v", err)
+		}
+		if _, err := os.Stat(install.BinaryPath); err != nil {
+			t.Errorf("expected the binary to be kept: %v", err)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		install, opts := installMistaggedPlugin(t)
+		opts.EmbeddedVersionCheck = EmbeddedVersionFail
+		err := opts.checkEmbeddedVersion(install)
+		if !errors.Is(err, ErrEmbeddedVersionMismatch) {
+			t.Fatalf("expected ErrEmbeddedVersionMismatch, got %v", err)
+		}
+		var mismatch *EmbeddedVersionError
+		if !errors.As(err, &mismatch) || mismatch.Version != "v2.10.0" || mismatch.Embedded != "2.9.0" {
+			t.Errorf("unexpected error %#v", err)
+		}
+		for _, file := range []string{install.BinaryPath, install.BinaryPath + "_SHA256SUM"} {
+			if _, err := os.Stat(file); !os.IsNotExist(err) {
+				t.Errorf("expected %s to be removed, got %v", file, err)
+			}
+		}
+	})
+
+	// brokenPlugin replaces the binary of install with one that cannot
+	// describe itself.
+	brokenPlugin := func(t *testing.T, install *Installation) {
+		if err := os.WriteFile(install.BinaryPath, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("warn-describe-failure", func(t *testing.T) {
+		install, opts := installMistaggedPlugin(t)
+		brokenPlugin(t, install)
+		opts.EmbeddedVersionCheck = EmbeddedVersionWarn
+		if err := opts.checkEmbeddedVersion(install); err != nil {
+			t.Errorf("expected a warning only, got %v", err)
+		}
+		if _, err := os.Stat(install.BinaryPath); err != nil {
+			t.Errorf("expected the binary to be kept: %v", err)
+		}
+	})
+
+	t.Run("fail-describe-failure", func(t *testing.T) {
+		install, opts := installMistaggedPlugin(t)
+		brokenPlugin(t, install)
+		opts.EmbeddedVersionCheck = EmbeddedVersionFail
+		if err := opts.checkEmbeddedVersion(install); err == nil || !strings.Contains(err.Error(), "failed to describe") {
+			t.Fatalf("expected a describe error, got %v", err)
+		}
+		for _, file := range []string{install.BinaryPath, install.BinaryPath + "_SHA256SUM"} {
+			if _, err := os.Stat(file); !os.IsNotExist(err) {
+				t.Errorf("expected %s to be removed, got %v", file, err)
+			}
+		}
+	})
+
+	t.Run("check-only", func(t *testing.T) {
+		opts := mirrorInstallOptions(t.TempDir())
+		opts.EmbeddedVersionCheck = EmbeddedVersionFail
+		opts.checkOnly = true
+		if err := opts.checkEmbeddedVersion(&Installation{Version: "v2.9.0"}); err != nil {
+			t.Errorf("expected installations that were not extracted not to be checked, got %v", err)
+		}
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		pluginDir := t.TempDir()
+		binary := installFakePlugin(t, pluginDir, "github.com/hashicorp/amazon", "v2.9.0")
+		opts := mirrorInstallOptions(pluginDir)
+		opts.EmbeddedVersionCheck = EmbeddedVersionFail
+		if err := opts.checkEmbeddedVersion(&Installation{BinaryPath: binary, Version: "v2.9.0"}); err != nil {
+			t.Errorf("checkEmbeddedVersion: %v", err)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// zipErrorGetter fails to get zips with Err.
+type zipErrorGetter struct {
+	*mockPluginGetter
+	Err error
+}
+
+func (g *zipErrorGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	if what == "zip" {
+		return nil, g.Err
+	}
+	return g.mockPluginGetter.Get(what, options)
+}
+
+func TestRequirement_InstallLatest_errorsIs(t *testing.T) {
+	wrongChecksum := singleReleaseGetter("amazon")
+	wrongChecksum.ChecksumFileEntries["1.0.0"][0].Checksum = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name        string
+		getter      Getter
+		constraints string
+		want        error
+	}{
+		{
+			name:   "plugin not found",
+			getter: &mockPluginGetter{},
+			want:   ErrPluginNotFound,
+		},
+		{
+			name:        "no matching version",
+			getter:      singleReleaseGetter("amazon"),
+			constraints: ">= 2.0.0",
+			want:        ErrNoMatchingVersion,
+		},
+		{
+			name:   "checksum mismatch",
+			getter: wrongChecksum,
+			want:   ErrChecksumMismatch,
+		},
+		{
+			name: "rate limited",
+			getter: &failingPluginGetter{Err: &RateLimitError{
+				ResetTime: time.Now().Add(time.Hour),
+				Err:       errors.New("API rate limit exceeded"),
+			}},
+			want: ErrRateLimited,
+		},
+		{
+			name: "release file not found",
+			getter: &zipErrorGetter{
+				mockPluginGetter: singleReleaseGetter("amazon"),
+				Err:              fmt.Errorf("%w: 404", ErrReleaseFileNotFound),
+			},
+			want: ErrReleaseFileNotFound,
+		},
+		{
+			name:   "unsupported source",
+			getter: &failingPluginGetter{Err: fmt.Errorf("%w: gitlab.com", ErrUnsupportedSource)},
+			want:   ErrUnsupportedSource,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := dependenciesInstallOptions(tt.getter, t.TempDir())
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", tt.constraints).InstallLatest(opts)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected an error matching %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestErrorTypes_errorsIs(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{&ChecksumError{}, ErrChecksumMismatch},
+		{&IntegrityError{Err: &ChecksumError{}}, ErrChecksumMismatch},
+		{&RateLimitError{Err: errors.New("limited")}, ErrRateLimited},
+		{&NoCompatibleVersionError{}, ErrNoCompatibleVersion},
+		{&ChecksumConflictError{}, ErrChecksumConflict},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("expected %T to match %q", tt.err, tt.want)
+		}
+	}
+
+	cause := errors.New("limited")
+	if !errors.Is(&RateLimitError{Err: cause}, cause) {
+		t.Error("expected RateLimitError to unwrap its cause")
+	}
+}
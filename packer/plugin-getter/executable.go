@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// ErrNotAnExecutable is returned when a file extracted from a plugin zip is
+// not an executable for the OS we are installing for.
+var ErrNotAnExecutable = errors.New("not an executable")
+
+var (
+	elfMagic = []byte{0x7f, 'E', 'L', 'F'}
+	peMagic  = []byte{'M', 'Z'}
+
+	gzipMagic = []byte{0x1f, 0x8b}
+
+	machOMagics = [][]byte{
+		{0xfe, 0xed, 0xfa, 0xce}, // 32 bit, big endian
+		{0xfe, 0xed, 0xfa, 0xcf}, // 64 bit, big endian
+		{0xce, 0xfa, 0xed, 0xfe}, // 32 bit, little endian
+		{0xcf, 0xfa, 0xed, 0xfe}, // 64 bit, little endian
+		{0xca, 0xfe, 0xba, 0xbe}, // universal binary
+	}
+)
+
+// executableMagics returns the possible magic numbers of an executable
+// for goos. A nil result means we don't know how to recognise executables
+// for that OS and that no check should be done.
+func executableMagics(goos string) [][]byte {
+	switch goos {
+	case "darwin", "ios":
+		return machOMagics
+	case "windows":
+		return [][]byte{peMagic}
+	case "linux", "freebsd", "netbsd", "openbsd", "dragonfly", "solaris", "illumos", "android":
+		return [][]byte{elfMagic}
+	}
+	return nil
+}
+
+// checkExecutable peeks at the first bytes of r to make sure it is an
+// executable for goos. The returned reader must be used in place of r as
+// the peeked bytes are buffered in it.
+func checkExecutable(r io.Reader, goos string) (io.Reader, error) {
+	magics := executableMagics(goos)
+	if magics == nil {
+		return r, nil
+	}
+
+	br := bufio.NewReader(r)
+	for _, magic := range magics {
+		head, err := br.Peek(len(magic))
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read binary header: %w", err)
+		}
+		if bytes.Equal(head, magic) {
+			return br, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w for %s", ErrNotAnExecutable, goos)
+}
+
+// openBinary opens the binary f of a plugin zip. Some plugins ship their
+// binary gzip compressed inside the zip to save space: such binaries are
+// transparently decompressed, other ones are read as is.
+func openBinary(f *zip.File) (io.ReadCloser, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		r.Close()
+		return nil, fmt.Errorf("failed to read binary header: %w", err)
+	}
+	if !bytes.Equal(head, gzipMagic) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{br, r}, nil
+	}
+
+	log.Printf("[TRACE] %s is gzip compressed, decompressing it", f.Name)
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("invalid gzip compressed binary: %w", err)
+	}
+	// closing a gzip.Reader does not close the reader it decompresses.
+	return struct {
+		io.Reader
+		io.Closer
+	}{gr, r}, nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io"
+	"time"
+)
+
+// extractClock tells the time and waits for a throttledWriter.
+type extractClock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock is the extractClock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// extractWriter returns the writer binaries are extracted to w through,
+// throttled to opts.MaxExtractRate.
+func (opts *InstallOptions) extractWriter(w io.Writer) io.Writer {
+	if opts.MaxExtractRate <= 0 {
+		return w
+	}
+	clock := opts.extractClock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &throttledWriter{w: w, rate: opts.MaxExtractRate, clock: clock, start: clock.Now()}
+}
+
+// throttledWriter writes to w at no more than rate bytes per second, on
+// average since start.
+type throttledWriter struct {
+	w       io.Writer
+	rate    int64
+	clock   extractClock
+	start   time.Time
+	written int64
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	// Writes are split in chunks of a tenth of a second, so that the
+	// buffers of io.Copy do not burst above the rate.
+	chunkSize := t.rate / 10
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	n := 0
+	for len(p) > 0 {
+		chunk := p
+		if int64(len(chunk)) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		written, err := t.w.Write(chunk)
+		n += written
+		t.written += int64(written)
+		if err != nil {
+			return n, err
+		}
+		p = p[written:]
+
+		due := t.start.Add(time.Duration(float64(t.written) / float64(t.rate) * float64(time.Second)))
+		if wait := due.Sub(t.clock.Now()); wait > 0 {
+			t.clock.Sleep(wait)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock records the waits asked for, and moves its time by them.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) waited() time.Duration {
+	var total time.Duration
+	for _, wait := range c.waits {
+		total += wait
+	}
+	return total
+}
+
+func TestThrottledWriter(t *testing.T) {
+	const (
+		rate = 100 * 1024
+		size = 40 * 1024
+	)
+	buf := &bytes.Buffer{}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := (&InstallOptions{MaxExtractRate: rate, extractClock: clock}).extractWriter(buf)
+
+	// a single write bigger than a chunk must be throttled too.
+	n, err := w.Write(bytes.Repeat([]byte("x"), size))
+	if err != nil || n != size {
+		t.Fatalf("Write() = %d, %v, want %d", n, err, size)
+	}
+	if buf.Len() != size {
+		t.Errorf("expected %d bytes to be written, got %d", size, buf.Len())
+	}
+
+	// one wait per chunk of a tenth of a second.
+	want := []time.Duration{
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+	}
+	if !reflect.DeepEqual(clock.waits, want) {
+		t.Errorf("writing %d bytes at %d bytes/s waited %v, want %v", size, rate, clock.waits, want)
+	}
+}
+
+func TestThrottledWriter_slowWrites(t *testing.T) {
+	const rate = 100 * 1024
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := (&InstallOptions{MaxExtractRate: rate, extractClock: clock}).extractWriter(&bytes.Buffer{})
+
+	// the writes took longer than the rate asks for: nothing to wait for.
+	clock.now = clock.now.Add(time.Second)
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 20*1024)); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.waits) != 0 {
+		t.Errorf("expected no wait, got %v", clock.waits)
+	}
+}
+
+func TestRequirement_InstallLatest_maxExtractRate(t *testing.T) {
+	const mainBinary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	content := elfHeader + strings.Repeat("x", 40*1024)
+
+	pluginDir := t.TempDir()
+	getter := manifestPluginGetter(map[string]string{mainBinary: content})
+	opts := dependenciesInstallOptions(getter, pluginDir)
+	opts.MaxExtractRate = 100 * 1024
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	opts.extractClock = clock
+
+	if _, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts); err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	want := time.Duration(float64(len(content)) / float64(opts.MaxExtractRate) * float64(time.Second))
+	if got := clock.waited(); got != want {
+		t.Errorf("extracting %d bytes at %d bytes/s waited %s, want %s", len(content), opts.MaxExtractRate, got, want)
+	}
+
+	installed, err := os.ReadFile(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", mainBinary))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(installed) != content {
+		t.Errorf("the throttled extraction wrote %d bytes, want %d", len(installed), len(content))
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InstallFromMirror installs the highest version of pr found in mirrorDir, a
+// directory laid out like a plugin directory, ex: one seeded by copying the
+// plugin directory of another machine. Binaries are copied as they are,
+// without zip to extract, along with their checksum files; like
+// ListInstallations, binaries without a matching checksum file are ignored,
+// and copies are checked against it too.
+//
+// Like InstallLatest, nothing is done when the binary is already installed
+// unless opts.Force is set.
+func (pr *Requirement) InstallFromMirror(mirrorDir string, opts InstallOptions) (*Installation, error) {
+	opts.Checksummers = pr.checksummers(opts.BinaryInstallationOptions)
+	if err := opts.checkBinaryMode(); err != nil {
+		return nil, err
+	}
+	if err := CheckPluginDirWritable(opts.PluginDirectory); err != nil {
+		return nil, err
+	}
+
+	mirrored, err := pr.ListInstallations(ListInstallationsOptions{
+		PluginDirectory:           mirrorDir,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(mirrored) == 0 {
+		return nil, fmt.Errorf("%w in mirror %q for %s %s", ErrNoMatchingVersion, mirrorDir, pr.Identifier, pr.constraintsString())
+	}
+	source := mirrored[len(mirrored)-1]
+
+	rel, err := filepath.Rel(mirrorDir, source.BinaryPath)
+	if err != nil {
+		return nil, err
+	}
+	outputFileName := filepath.Join(opts.PluginDirectory, rel)
+
+	var checksum *FileChecksum
+	for _, checksummer := range opts.Checksummers {
+		cs, err := checksummer.GetCacheChecksumOfFile(source.BinaryPath)
+		if err == nil && len(cs) > 0 {
+			checksum = &FileChecksum{Filename: source.BinaryPath, Expected: cs, Checksummer: checksummer}
+			break
+		}
+	}
+	if checksum == nil {
+		return nil, fmt.Errorf("no checksum file found for %q", source.BinaryPath)
+	}
+
+	if !opts.Force {
+		if err := checksum.ChecksumFile(checksum.Expected, outputFileName); err == nil {
+			log.Printf("[INFO] %s %s plugin is already correctly installed in %q", pr.Identifier, source.Version, outputFileName)
+			return nil, nil
+		}
+	}
+
+	if err := opts.copyMirroredBinary(source.BinaryPath, outputFileName, checksum); err != nil {
+		return nil, err
+	}
+	copyZipChecksum(source.BinaryPath, outputFileName)
+
+	return &Installation{
+		BinaryPath: strings.ReplaceAll(outputFileName, "\\", "/"),
+		Version:    source.Version,
+	}, nil
+}
+
+// copyMirroredBinary copies the binary in src to dst, then writes its checksum
+// file. Like extractBinary, it is copied next to dst, and moved in place once
+// it matches checksum.
+func (opts *InstallOptions) copyMirroredBinary(src, dst string, checksum *FileChecksum) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("could not create plugin folder %q: %w", filepath.Dir(dst), err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	outputFile, err := os.CreateTemp(filepath.Dir(dst), opts.tempFilePattern(filepath.Base(dst)))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	defer os.Remove(outputFile.Name())
+	defer outputFile.Close()
+
+	if err := outputFile.Chmod(opts.binaryMode()); err != nil {
+		return fmt.Errorf("failed to make %s executable: %w", outputFile.Name(), err)
+	}
+	if _, err := io.Copy(outputFile, in); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if _, err := outputFile.Seek(0, 0); err != nil {
+		return fmt.Errorf("failed to seek %s: %w", outputFile.Name(), err)
+	}
+	if err := checksum.Checksummer.Checksum(checksum.Expected, outputFile); err != nil {
+		return &IntegrityError{Err: fmt.Errorf("%s: %w", src, err)}
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile.Name(), err)
+	}
+
+	if opts.ChownBinary != nil {
+		if err := opts.ChownBinary(outputFile.Name()); err != nil {
+			return fmt.Errorf("failed to change the owner of %s: %w", dst, err)
+		}
+	}
+	if err := os.Rename(outputFile.Name(), dst); err != nil {
+		return fmt.Errorf("failed to move binary to %s: %w", dst, err)
+	}
+
+	if err := os.WriteFile(dst+checksum.Checksummer.FileExt(), []byte(hex.EncodeToString(checksum.Expected)), 0644); err != nil {
+		log.Printf("[WARNING] failed to write local binary checksum file: %v, ignoring", err)
+	}
+	return nil
+}
+
+// copyZipChecksum copies the checksum of the zip the mirrored binary in src
+// was installed from, when it was recorded, see ZipChecksumFileExt.
+func copyZipChecksum(src, dst string) {
+	content, err := os.ReadFile(src + ZipChecksumFileExt)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(dst+ZipChecksumFileExt, content, 0644); err != nil {
+		log.Printf("[WARNING] failed to copy %s: %s", src+ZipChecksumFileExt, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package plugingetter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mirrorInstallOptions(pluginDir string) InstallOptions {
+	return InstallOptions{
+		PluginDirectory:           pluginDir,
+		BinaryInstallationOptions: localListInstallationsOptions(pluginDir).BinaryInstallationOptions,
+	}
+}
+
+func TestRequirement_InstallFromMirror(t *testing.T) {
+	mirrorDir := t.TempDir()
+	installFakePlugin(t, mirrorDir, "github.com/hashicorp/hashicups", "v1.0.0")
+	latest := installFakePlugin(t, mirrorDir, "github.com/hashicorp/hashicups", "v1.1.0")
+	if err := os.WriteFile(latest+ZipChecksumFileExt, []byte("abcd  packer-plugin-hashicups.zip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pluginDir := t.TempDir()
+	opts := mirrorInstallOptions(pluginDir)
+	req := mustRequirement(t, "github.com/hashicorp/hashicups", "")
+
+	install, err := req.InstallFromMirror(mirrorDir, opts)
+	if err != nil {
+		t.Fatalf("InstallFromMirror: %s", err)
+	}
+	want := filepath.Join(pluginDir, "github.com/hashicorp/hashicups", filepath.Base(latest))
+	if install == nil || install.Version != "v1.1.0" || install.BinaryPath != filepath.ToSlash(want) {
+		t.Fatalf("expected v1.1.0 to be installed in %s, got %#v", want, install)
+	}
+	for _, sidecar := range []string{"_SHA256SUM", ZipChecksumFileExt} {
+		mirrored, _ := os.ReadFile(latest + sidecar)
+		copied, err := os.ReadFile(want + sidecar)
+		if err != nil || string(copied) != string(mirrored) {
+			t.Errorf("expected %s to be copied as is, got %q (%v)", sidecar, copied, err)
+		}
+	}
+	fi, err := os.Stat(want)
+	if err != nil || fi.Mode().Perm() != 0755 {
+		t.Errorf("expected an executable binary, got %v (%v)", fi, err)
+	}
+
+	installs, err := req.ListInstallations(localListInstallationsOptions(pluginDir))
+	if err != nil || len(installs) != 1 || installs[0].Version != "v1.1.0" {
+		t.Errorf("expected the copied binary to be listed, got %v (%v)", installs, err)
+	}
+
+	install, err = req.InstallFromMirror(mirrorDir, opts)
+	if err != nil || install != nil {
+		t.Errorf("expected nothing to be done once installed, got %#v (%v)", install, err)
+	}
+}
+
+func TestRequirement_InstallFromMirror_constraints(t *testing.T) {
+	mirrorDir := t.TempDir()
+	installFakePlugin(t, mirrorDir, "github.com/hashicorp/hashicups", "v1.0.0")
+	installFakePlugin(t, mirrorDir, "github.com/hashicorp/hashicups", "v1.1.0")
+
+	install, err := mustRequirement(t, "github.com/hashicorp/hashicups", "< 1.1").InstallFromMirror(mirrorDir, mirrorInstallOptions(t.TempDir()))
+	if err != nil {
+		t.Fatalf("InstallFromMirror: %s", err)
+	}
+	if install == nil || install.Version != "v1.0.0" {
+		t.Errorf("expected v1.0.0 to be installed, got %#v", install)
+	}
+
+	_, err = mustRequirement(t, "github.com/hashicorp/hashicups", ">= 2").InstallFromMirror(mirrorDir, mirrorInstallOptions(t.TempDir()))
+	if !errors.Is(err, ErrNoMatchingVersion) {
+		t.Errorf("expected ErrNoMatchingVersion, got %v", err)
+	}
+}
+
+func TestRequirement_InstallFromMirror_checksumMismatch(t *testing.T) {
+	mirrorDir := t.TempDir()
+	binary := installFakePlugin(t, mirrorDir, "github.com/hashicorp/hashicups", "v1.0.0")
+	if err := os.WriteFile(binary+"_SHA256SUM", []byte("0000000000000000000000000000000000000000000000000000000000000000"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pluginDir := t.TempDir()
+
+	_, err := mustRequirement(t, "github.com/hashicorp/hashicups", "").InstallFromMirror(mirrorDir, mirrorInstallOptions(pluginDir))
+	if !errors.Is(err, ErrNoMatchingVersion) {
+		t.Errorf("expected the unverified binary to be ignored, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(pluginDir, "github.com/hashicorp/hashicups")); len(entries) != 0 {
+		t.Errorf("expected nothing to be installed, got %v", entries)
+	}
+}
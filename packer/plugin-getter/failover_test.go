@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingGetter records what it was asked for, and fails with Err when set.
+type recordingGetter struct {
+	Getter
+	Err   error
+	Asked []string
+}
+
+func (g *recordingGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	g.Asked = append(g.Asked, what)
+	if g.Err != nil {
+		return nil, g.Err
+	}
+	return g.Getter.Get(what, options)
+}
+
+func TestRequirement_InstallLatest_failover(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	// corruptZips makes the zips of getter differ from the released ones.
+	corruptZips := func(getter *mockPluginGetter) *mockPluginGetter {
+		for name := range getter.Zips {
+			getter.Zips[name] = zipFile(map[string]string{binary: elfHeader + "tampered"})
+		}
+		return getter
+	}
+
+	tests := []struct {
+		name              string
+		mirror            *recordingGetter
+		signatureVerifier SignatureVerifier
+		wantIntegrityErr  bool
+		wantGitHubAsked   []string
+	}{
+		{
+			name:            "mirror-outage",
+			mirror:          &recordingGetter{Err: errors.New("503 Service Unavailable")},
+			wantGitHubAsked: []string{"releases", "sha256", "zip"},
+		},
+		{
+			name:             "checksum-mismatch",
+			mirror:           &recordingGetter{Getter: corruptZips(singleReleaseGetter("amazon"))},
+			wantIntegrityErr: true,
+		},
+		{
+			name: "bad-signature",
+			mirror: &recordingGetter{Getter: &signedPluginGetter{
+				mockPluginGetter: singleReleaseGetter("amazon"),
+				ChecksumFile:     "checksums",
+				Signature:        "signed(something else)",
+			}},
+			signatureVerifier: signatureVerifier{},
+			wantIntegrityErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			github := &recordingGetter{Getter: singleReleaseGetter("amazon")}
+			if tt.signatureVerifier != nil {
+				mock := singleReleaseGetter("amazon")
+				checksumFile := mock.ChecksumFileEntries["1.0.0"][0].Checksum + "  " + binary + ".zip\n"
+				github.Getter = &signedPluginGetter{
+					mockPluginGetter: mock,
+					ChecksumFile:     checksumFile,
+					Signature:        "signed(" + checksumFile + ")",
+				}
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(tt.mirror, pluginDir)
+			opts.Getters = append(opts.Getters, github)
+			opts.SignatureVerifier = tt.signatureVerifier
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+
+			var integrityErr *IntegrityError
+			if errors.As(err, &integrityErr) != tt.wantIntegrityErr {
+				t.Fatalf("InstallLatest() error = %v, want an IntegrityError: %t", err, tt.wantIntegrityErr)
+			}
+			if !tt.wantIntegrityErr && err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+
+			_, statErr := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if installed := statErr == nil; installed == tt.wantIntegrityErr {
+				t.Errorf("expected the plugin to be installed: %t, stat returned %v", !tt.wantIntegrityErr, statErr)
+			}
+			if diff := cmp.Diff(tt.wantGitHubAsked, github.Asked); diff != "" {
+				t.Errorf("unexpected requests to the next getter: %s", diff)
+			}
+		})
+	}
+}
+
+// phaseOutageGetter fails to get Phase, and gets the rest from Getter.
+type phaseOutageGetter struct {
+	Getter
+	Phase string
+}
+
+func (g *phaseOutageGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	if what == g.Phase {
+		return nil, errors.New("503 Service Unavailable")
+	}
+	return g.Getter.Get(what, options)
+}
+
+func TestRequirement_InstallLatest_servedBy(t *testing.T) {
+	tests := []struct {
+		name   string
+		phase  string
+		served func(mirror, github Getter) PhaseGetters
+	}{
+		{
+			name:  "zip-outage",
+			phase: "zip",
+			served: func(mirror, github Getter) PhaseGetters {
+				return PhaseGetters{Releases: mirror, Checksum: mirror, Zip: github}
+			},
+		},
+		{
+			name:  "checksum-outage",
+			phase: "sha256",
+			served: func(mirror, github Getter) PhaseGetters {
+				return PhaseGetters{Releases: mirror, Checksum: github, Zip: mirror}
+			},
+		},
+		{
+			name:  "releases-outage",
+			phase: "releases",
+			served: func(mirror, github Getter) PhaseGetters {
+				return PhaseGetters{Releases: github, Checksum: mirror, Zip: mirror}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirror := &phaseOutageGetter{Getter: singleReleaseGetter("amazon"), Phase: tt.phase}
+			github := singleReleaseGetter("amazon")
+
+			opts := dependenciesInstallOptions(mirror, t.TempDir())
+			opts.Getters = append(opts.Getters, github)
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+
+			want := tt.served(mirror, github)
+			if install.ServedBy != want {
+				t.Errorf("expected the install to be served by %+v, got %+v", want, install.ServedBy)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// FilenameCase tells how the names of installed binaries are matched.
+type FilenameCase int
+
+const (
+	// FilenameCaseDefault matches names case-insensitively on macOS and
+	// Windows, whose filesystems usually are, and case-sensitively elsewhere.
+	FilenameCaseDefault FilenameCase = iota
+	// FilenameCaseSensitive matches names as they are.
+	FilenameCaseSensitive
+	// FilenameCaseInsensitive matches names that only differ by case.
+	FilenameCaseInsensitive
+)
+
+// insensitive reports whether names are matched case-insensitively on the
+// current platform.
+func (fc FilenameCase) insensitive() bool {
+	switch fc {
+	case FilenameCaseSensitive:
+		return false
+	case FilenameCaseInsensitive:
+		return true
+	}
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// globInstallations returns the binaries in dir that could be installations
+// of pr for the platform of opts. Only the part of the path below dir is
+// matched case-insensitively, dir itself is looked up as is.
+func (pr Requirement) globInstallations(dir string, opts ListInstallationsOptions) ([]string, error) {
+	longDir := LongPath(dir)
+	pattern := pr.installationsGlob(longDir, opts)
+	if opts.FilenameCase.insensitive() {
+		rel, err := filepath.Rel(longDir, pattern)
+		if err != nil {
+			return nil, err
+		}
+		pattern = filepath.Join(longDir, foldPattern(rel))
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil || longDir == dir {
+		return matches, err
+	}
+
+	// Binaries are listed below dir as given, not its long form.
+	for i, match := range matches {
+		rel, err := filepath.Rel(longDir, match)
+		if err != nil {
+			return nil, err
+		}
+		matches[i] = filepath.Join(dir, rel)
+	}
+	return matches, nil
+}
+
+// foldPattern makes every letter of the glob pattern match both of its
+// cases, ex: "amazon_*" becomes "[aA][mM][aA][zZ][oO][nN]_*".
+func foldPattern(pattern string) string {
+	b := &strings.Builder{}
+	for _, r := range pattern {
+		upper, lower := unicode.ToUpper(r), unicode.ToLower(r)
+		if upper == lower {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteRune('[')
+		b.WriteRune(lower)
+		b.WriteRune(upper)
+		b.WriteRune(']')
+	}
+	return b.String()
+}
+
+// trimFilename returns the part of the binary filename between prefix and
+// suffix. With insensitive, the case of prefix and suffix is ignored and the
+// result is lower cased, as versions are.
+func trimFilename(fname, prefix, suffix string, insensitive bool) string {
+	if !insensitive {
+		return strings.TrimSuffix(strings.TrimPrefix(fname, prefix), suffix)
+	}
+	if len(fname) >= len(prefix) && strings.EqualFold(fname[:len(prefix)], prefix) {
+		fname = fname[len(prefix):]
+	}
+	if len(fname) >= len(suffix) && strings.EqualFold(fname[len(fname)-len(suffix):], suffix) {
+		fname = fname[:len(fname)-len(suffix)]
+	}
+	return strings.ToLower(fname)
+}
+
+// sameDir reports whether a and b are in the same directory.
+func sameDir(a, b string, insensitive bool) bool {
+	if insensitive {
+		return strings.EqualFold(filepath.Dir(a), filepath.Dir(b))
+	}
+	return filepath.Dir(a) == filepath.Dir(b)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// installDir returns the directory pr is installed in below pluginDir: the
+// <hostname>/<namespace>/<type> directory of its source, or pluginDir itself
+// with a FlatLayout.
+func (pr Requirement) installDir(pluginDir string, opts BinaryInstallationOptions) string {
+	if opts.FlatLayout {
+		return pluginDir
+	}
+	return filepath.Join(pluginDir, filepath.Join(pr.Identifier.Parts()...))
+}
+
+// installedNamePrefix returns what the names of the installed binaries of pr
+// start with before packer-plugin-, ex: "github.com_hashicorp_" with a
+// FlatLayout. It is empty otherwise, as the directory tells the source.
+func (pr Requirement) installedNamePrefix(opts BinaryInstallationOptions) string {
+	if !opts.FlatLayout || pr.Identifier == nil {
+		return ""
+	}
+	return pr.Identifier.Hostname + "_" + pr.Identifier.Namespace + "_"
+}
+
+// splitFlatFilename splits the name of a binary of a FlatLayout plugin
+// directory, ex: github.com_hashicorp_packer-plugin-amazon_v1.2.3_x5.0_linux_amd64,
+// into the source of its plugin, github.com/hashicorp/amazon, and its name
+// in a nested layout, packer-plugin-amazon_v1.2.3_x5.0_linux_amd64. Hostnames,
+// namespaces and types cannot contain underscores. ok is false for names
+// that are not like this.
+func splitFlatFilename(fname string) (source, binary string, ok bool) {
+	parts := strings.SplitN(fname, "_", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	binary = parts[2]
+	if len(binary) < len("packer-plugin-") || !strings.EqualFold(binary[:len("packer-plugin-")], "packer-plugin-") {
+		return "", "", false
+	}
+	pluginType, _, found := strings.Cut(binary[len("packer-plugin-"):], "_")
+	if !found || pluginType == "" {
+		return "", "", false
+	}
+	return parts[0] + "/" + parts[1] + "/" + pluginType, binary, true
+}
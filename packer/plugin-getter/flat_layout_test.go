@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// scriptReleaseGetter releases v1.0.0 of the amazon plugin of namespace for
+// plan9, whose binaries are not checked to be executables, so that the
+// installed binary is a script answering to `describe`.
+func scriptReleaseGetter(namespace string) *mockPluginGetter {
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_plan9_amd64"
+	zip, checksum := zipFileWithChecksum(map[string]string{
+		binary: "#!/bin/sh\necho '{\"version\":\"1.0.0\",\"sdk_version\":\"0.5.2\",\"api_version\":\"x5.0\"}'\n",
+	})
+	return &mockPluginGetter{
+		Releases: []Release{{Version: "v1.0.0"}},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"1.0.0": {{Filename: binary + ".zip", Checksum: checksum}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/" + namespace + "/packer-plugin-amazon/" + binary + ".zip": zip,
+		},
+	}
+}
+
+func TestSplitFlatFilename(t *testing.T) {
+	tests := []struct {
+		fname      string
+		wantSource string
+		wantBinary string
+		wantOK     bool
+	}{
+		{"github.com_hashicorp_packer-plugin-amazon_v1.2.3_x5.0_linux_amd64", "github.com/hashicorp/amazon", "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64", true},
+		{"example.com_my-org_packer-plugin-my-cloud_v1.2.3_x5.0_linux_amd64.exe", "example.com/my-org/my-cloud", "packer-plugin-my-cloud_v1.2.3_x5.0_linux_amd64.exe", true},
+		{"packer-plugin-amazon_v1.2.3_x5.0_linux_amd64", "", "", false},
+		{"github.com_hashicorp_amazon_v1.2.3_x5.0_linux_amd64", "", "", false},
+		{"github.com__packer-plugin-amazon_v1.2.3", "", "", false},
+	}
+	for _, tt := range tests {
+		source, binary, ok := splitFlatFilename(tt.fname)
+		if source != tt.wantSource || binary != tt.wantBinary || ok != tt.wantOK {
+			t.Errorf("splitFlatFilename(%q) = %q, %q, %t, want %q, %q, %t", tt.fname, source, binary, ok, tt.wantSource, tt.wantBinary, tt.wantOK)
+		}
+	}
+}
+
+func TestRequirement_InstallLatest_flatLayout(t *testing.T) {
+	pluginDir := t.TempDir()
+	platform := BinaryInstallationOptions{
+		APIVersionMajor: "5", APIVersionMinor: "0",
+		OS: "plan9", ARCH: "amd64",
+		Checksummers: []Checksummer{{Type: "sha256", Hash: sha256.New()}},
+		FlatLayout:   true,
+	}
+
+	want := map[string]string{}
+	for _, namespace := range []string{"hashicorp", "fork"} {
+		source := "github.com/" + namespace + "/amazon"
+		install, err := mustRequirement(t, source, "").InstallLatest(InstallOptions{
+			Getters:                   []Getter{scriptReleaseGetter(namespace)},
+			PluginDirectory:           pluginDir,
+			BinaryInstallationOptions: platform,
+		})
+		if err != nil {
+			t.Fatalf("InstallLatest(%s): %v", source, err)
+		}
+		want[source] = filepath.ToSlash(filepath.Join(pluginDir, "github.com_"+namespace+"_packer-plugin-amazon_v1.0.0_x5.0_plan9_amd64"))
+		if install == nil || install.BinaryPath != want[source] {
+			t.Fatalf("expected %s to be installed as %s, got %#v", source, want[source], install)
+		}
+	}
+
+	entries, err := os.ReadDir(pluginDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			t.Errorf("expected no directory in a flat plugin directory, found %s", entry.Name())
+		}
+	}
+
+	listOpts := ListInstallationsOptions{PluginDirectory: pluginDir, BinaryInstallationOptions: platform}
+	for source, binaryPath := range want {
+		installs, err := mustRequirement(t, source, "").ListInstallations(listOpts)
+		if err != nil {
+			t.Fatalf("ListInstallations(%s): %v", source, err)
+		}
+		if len(installs) != 1 || filepath.ToSlash(installs[0].BinaryPath) != binaryPath || installs[0].Version != "v1.0.0" {
+			t.Errorf("expected only %s to be listed for %s, got %v", binaryPath, source, installs)
+		}
+	}
+
+	// both plugins have the same version, but are told apart.
+	all, err := Requirement{}.ListInstallations(listOpts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	var listed []string
+	for _, install := range all {
+		listed = append(listed, filepath.ToSlash(install.BinaryPath))
+	}
+	sort.Strings(listed)
+	if diff := cmp.Diff([]string{want["github.com/fork/amazon"], want["github.com/hashicorp/amazon"]}, listed); diff != "" {
+		t.Errorf("unexpected installations of every plugin: %s", diff)
+	}
+
+	nestedOpts := listOpts
+	nestedOpts.FlatLayout = false
+	nested, err := Requirement{}.ListInstallations(nestedOpts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	if len(nested) != 0 {
+		t.Errorf("expected the flat binaries not to be listed in a nested layout, got %v", nested)
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import "errors"
+
+// ErrNotEnoughInodes is returned when the filesystem of the plugin directory
+// has too few free inodes to install plugins, which would otherwise fail
+// with a "no space left on device" error while there is free space.
+var ErrNotEnoughInodes = errors.New("not enough free inodes")
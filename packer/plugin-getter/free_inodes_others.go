@@ -0,0 +1,12 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !linux && !darwin
+
+package plugingetter
+
+// CheckFreeInodes does nothing: free inodes are only checked on Linux and
+// macOS.
+func CheckFreeInodes(dir string, min uint64) error {
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build linux || darwin
+
+package plugingetter
+
+import (
+	"fmt"
+	"log"
+	"syscall"
+)
+
+// statfs is syscall.Statfs, replaced in tests.
+var statfs = syscall.Statfs
+
+// CheckFreeInodes makes sure at least min inodes are free on the filesystem
+// of dir, or of its closest existing parent. Filesystems that do not report
+// inodes, like btrfs, are not checked, nor are the ones of other systems
+// than Linux and macOS.
+func CheckFreeInodes(dir string, min uint64) error {
+	probeDir, err := closestExistingDir(dir)
+	if probeDir == "" || err != nil {
+		return err
+	}
+
+	var stat syscall.Statfs_t
+	if err := statfs(LongPath(probeDir), &stat); err != nil {
+		return fmt.Errorf("could not check the free inodes of %q: %w", probeDir, err)
+	}
+	if stat.Files == 0 {
+		log.Printf("[TRACE] the filesystem of %q does not report inodes, not checking them", probeDir)
+		return nil
+	}
+	if stat.Ffree < min {
+		return fmt.Errorf("%w on the filesystem of %q: %d free, %d required. Remove files from it, "+
+			"or set PACKER_PLUGIN_PATH to a directory of another filesystem", ErrNotEnoughInodes, probeDir, stat.Ffree, min)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build linux || darwin
+
+package plugingetter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// stubStatfs makes statfs report files and free inodes, and record the
+// paths it is called with, for the duration of the test.
+func stubStatfs(t *testing.T, files, free uint64) *[]string {
+	var paths []string
+	statfs = func(path string, stat *syscall.Statfs_t) error {
+		paths = append(paths, path)
+		stat.Files, stat.Ffree = files, free
+		return nil
+	}
+	t.Cleanup(func() { statfs = syscall.Statfs })
+	return &paths
+}
+
+func TestCheckFreeInodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   uint64
+		free    uint64
+		wantErr error
+	}{
+		{name: "enough", files: 1000, free: 100},
+		{name: "exhausted", files: 1000, free: 0, wantErr: ErrNotEnoughInodes},
+		{name: "too-few", files: 1000, free: 9, wantErr: ErrNotEnoughInodes},
+		{name: "no-inodes-reported", files: 0, free: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			paths := stubStatfs(t, tt.files, tt.free)
+
+			// the plugin directory does not exist yet, its parent is checked.
+			err := CheckFreeInodes(filepath.Join(dir, "plugins"), 10)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckFreeInodes() = %v, want %v", err, tt.wantErr)
+			}
+			if len(*paths) != 1 || (*paths)[0] != dir {
+				t.Errorf("expected the free inodes of %s to be checked, got %v", dir, *paths)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_minFreeInodes(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name          string
+		minFreeInodes uint64
+		wantErr       error
+	}{
+		{name: "enough", minFreeInodes: 10},
+		{name: "exhausted", minFreeInodes: 100, wantErr: ErrNotEnoughInodes},
+		{name: "not-checked", minFreeInodes: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubStatfs(t, 1000, 50)
+			getter := singleReleaseGetter("amazon")
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.MinFreeInodes = tt.minFreeInodes
+
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected an error matching %q, got %v", tt.wantErr, err)
+				}
+				// nothing was downloaded or written.
+				if entries, _ := os.ReadDir(pluginDir); len(entries) != 0 {
+					t.Errorf("expected nothing to be written, found %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if want := filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary)); install == nil || install.BinaryPath != want {
+				t.Errorf("expected %s to be installed, got %#v", want, install)
+			}
+		})
+	}
+}
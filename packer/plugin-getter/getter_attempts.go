@@ -0,0 +1,78 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// getterName names getter in errors: with its String method when it has
+// one, ex: "github", with its type otherwise.
+func getterName(getter Getter) string {
+	if s, ok := getter.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", getter), "*")
+}
+
+// getterAttempts records the outcome of the last request done to each getter
+// during an InstallLatest. Checksum files can be prefetched concurrently,
+// hence the lock.
+type getterAttempts struct {
+	mu       sync.Mutex
+	attempts []getterAttempt
+}
+
+type getterAttempt struct {
+	getter Getter
+	err    error
+}
+
+func (a *getterAttempts) record(getter Getter, err error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := range a.attempts {
+		if sameGetter(a.attempts[i].getter, getter) {
+			a.attempts[i].err = err
+			return
+		}
+	}
+	a.attempts = append(a.attempts, getterAttempt{getter: getter, err: err})
+}
+
+// err joins the errors of the getters whose last request failed, in the
+// order they were first tried, ex:
+//
+//	mirror: 503 Service Unavailable
+//	github: rate limited
+func (a *getterAttempts) err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for _, attempt := range a.attempts {
+		if attempt.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", getterName(attempt.getter), attempt.err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sameGetter tells whether a and b are the same getter. Getters that cannot
+// be compared, ex: maps, are told apart by type only.
+func sameGetter(a, b Getter) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	if !reflect.TypeOf(a).Comparable() {
+		return true
+	}
+	return a == b
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stringerFailingGetter is a failingPluginGetter named in errors.
+type stringerFailingGetter struct {
+	failingPluginGetter
+	name string
+}
+
+func (g *stringerFailingGetter) String() string { return g.name }
+
+func TestRequirement_InstallLatest_getterAttempts(t *testing.T) {
+	mirror := &stringerFailingGetter{
+		failingPluginGetter: failingPluginGetter{Err: errors.New("503 Service Unavailable")},
+		name:                "mirror",
+	}
+	github := &stringerFailingGetter{
+		failingPluginGetter: failingPluginGetter{Err: &RateLimitError{
+			ResetTime: time.Now().Add(time.Hour),
+			Err:       errors.New("API rate limit exceeded"),
+		}},
+		name: "github",
+	}
+	opts := dependenciesInstallOptions(mirror, t.TempDir())
+	opts.Getters = []Getter{mirror, github}
+
+	_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+	if err == nil {
+		t.Fatal("expected the install to fail")
+	}
+	for _, want := range []string{"mirror: 503 Service Unavailable", "github: Plugin host rate limited"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to contain %q, got:\n%s", want, err)
+		}
+	}
+	if strings.Index(err.Error(), "mirror: ") > strings.Index(err.Error(), "github: ") {
+		t.Errorf("expected getters in the order they were tried, got:\n%s", err)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected the error to match %q", ErrRateLimited)
+	}
+}
+
+func TestRequirement_InstallLatest_getterAttemptsSucceeded(t *testing.T) {
+	failing := &failingPluginGetter{Err: fmt.Errorf("mirror is down")}
+	opts := dependenciesInstallOptions(failing, t.TempDir())
+	opts.Getters = []Getter{failing, singleReleaseGetter("amazon")}
+
+	// the first getter failing is not an error when the next one succeeds.
+	if _, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts); err != nil {
+		t.Fatalf("InstallLatest: %s", err)
+	}
+}
+
+func TestGetterName(t *testing.T) {
+	if got := getterName(&stringerFailingGetter{name: "mirror"}); got != "mirror" {
+		t.Errorf("expected the name of a Stringer, got %q", got)
+	}
+	if got := getterName(&failingPluginGetter{}); got != "plugingetter.failingPluginGetter" {
+		t.Errorf("expected the type of the getter, got %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoGetterForSource is returned by GetterRegistry.Resolve when no getter
+// is registered for the scheme or hostname of a plugin source.
+var ErrNoGetterForSource = errors.New("no getter is registered for the plugin source")
+
+// A GetterConstructor returns the getter downloading the plugins of source.
+type GetterConstructor func(source string) (Getter, error)
+
+// GetterRegistry maps the schemes and hostnames of plugin sources to the
+// constructors of the getters downloading them, so that the getter of a
+// source is inferred instead of ordered manually, ex:
+//
+//	github.com/hashicorp/happycloud   the "github.com" host constructor
+//	s3://bucket/hashicorp/happycloud  the "s3" scheme constructor
+//	./plugins/happycloud              the "file" scheme constructor
+//
+// Local paths, relative or absolute, and file:// URLs resolve to the "file"
+// scheme. Schemes and hostnames are case insensitive.
+//
+// The zero value is an empty registry, ready to use.
+type GetterRegistry struct {
+	schemes map[string]GetterConstructor
+	hosts   map[string]GetterConstructor
+}
+
+// RegisterScheme sets the constructor of the getter of the sources with the
+// scheme, ex: "s3" for s3://bucket/hashicorp/happycloud.
+func (r *GetterRegistry) RegisterScheme(scheme string, constructor GetterConstructor) {
+	if r.schemes == nil {
+		r.schemes = map[string]GetterConstructor{}
+	}
+	r.schemes[strings.ToLower(scheme)] = constructor
+}
+
+// RegisterHost sets the constructor of the getter of the sources without
+// scheme whose hostname is hostname, ex: "github.com" for
+// github.com/hashicorp/happycloud.
+func (r *GetterRegistry) RegisterHost(hostname string, constructor GetterConstructor) {
+	if r.hosts == nil {
+		r.hosts = map[string]GetterConstructor{}
+	}
+	r.hosts[strings.ToLower(hostname)] = constructor
+}
+
+// Resolve returns the getter of source, built by the constructor registered
+// for its scheme or hostname. The error wraps ErrNoGetterForSource when none
+// is registered.
+func (r *GetterRegistry) Resolve(source string) (Getter, error) {
+	constructor, found := r.lookup(source)
+	if !found {
+		return nil, fmt.Errorf("%w %q", ErrNoGetterForSource, source)
+	}
+	getter, err := constructor(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the getter of %q: %w", source, err)
+	}
+	return getter, nil
+}
+
+func (r *GetterRegistry) lookup(source string) (GetterConstructor, bool) {
+	if isLocalPath(source) {
+		constructor, found := r.schemes["file"]
+		return constructor, found
+	}
+	if strings.Contains(source, "://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, false
+		}
+		constructor, found := r.schemes[strings.ToLower(u.Scheme)]
+		return constructor, found
+	}
+	hostname, _, _ := strings.Cut(source, "/")
+	constructor, found := r.hosts[strings.ToLower(hostname)]
+	return constructor, found
+}
+
+// isLocalPath reports whether source is a path of the local filesystem rather
+// than a hostname based source.
+func isLocalPath(source string) bool {
+	return source == "." || source == ".." ||
+		strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") ||
+		strings.HasPrefix(source, `.\`) || strings.HasPrefix(source, `..\`) ||
+		filepath.IsAbs(source) || strings.HasPrefix(source, "/")
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"testing"
+)
+
+// namedGetter is a getter telling which constructor built it.
+type namedGetter struct {
+	Getter
+	name   string
+	source string
+}
+
+func TestGetterRegistry_Resolve(t *testing.T) {
+	registry := &GetterRegistry{}
+	for _, name := range []string{"s3", "file"} {
+		name := name
+		registry.RegisterScheme(name, func(source string) (Getter, error) {
+			return namedGetter{name: name, source: source}, nil
+		})
+	}
+	registry.RegisterHost("github.com", func(source string) (Getter, error) {
+		return namedGetter{name: "github", source: source}, nil
+	})
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"github.com/hashicorp/happycloud", "github"},
+		{"GitHub.com/hashicorp/happycloud", "github"},
+		{"s3://bucket/hashicorp/happycloud", "s3"},
+		{"S3://bucket/hashicorp/happycloud", "s3"},
+		{"file:///opt/plugins/happycloud", "file"},
+		{"./plugins/happycloud", "file"},
+		{"../plugins/happycloud", "file"},
+		{"/opt/plugins/happycloud", "file"},
+		{"example.com/hashicorp/happycloud", ""},
+		{"gs://bucket/hashicorp/happycloud", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			getter, err := registry.Resolve(tt.source)
+			if tt.want == "" {
+				if !errors.Is(err, ErrNoGetterForSource) {
+					t.Fatalf("Resolve() = %v, %v; want ErrNoGetterForSource", getter, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(): %v", err)
+			}
+			got := getter.(namedGetter)
+			if got.name != tt.want || got.source != tt.source {
+				t.Errorf("Resolve() built the %q getter of %q, want the %q getter of %q", got.name, got.source, tt.want, tt.source)
+			}
+		})
+	}
+}
+
+func TestGetterRegistry_Resolve_constructorError(t *testing.T) {
+	registry := &GetterRegistry{}
+	failure := errors.New("no credentials")
+	registry.RegisterScheme("s3", func(string) (Getter, error) {
+		return nil, failure
+	})
+
+	_, err := registry.Resolve("s3://bucket/hashicorp/happycloud")
+	if !errors.Is(err, failure) || errors.Is(err, ErrNoGetterForSource) {
+		t.Errorf("Resolve() = %v, want the constructor error", err)
+	}
+}
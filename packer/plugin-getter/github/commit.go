@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+var _ plugingetter.CommitResolver = &Getter{}
+
+// ResolveCommit lists the tags of the repository of the plugin, and returns
+// the names of the ones pointing to the commit with sha, which can be
+// abbreviated.
+func (g *Getter) ResolveCommit(pr *plugingetter.Requirement, sha string) ([]string, error) {
+	if pr.Identifier.Hostname != defaultHostname {
+		return nil, unsupportedSourceError(pr)
+	}
+	if g.Client == nil {
+		if err := g.initClient(); err != nil {
+			return nil, err
+		}
+	}
+
+	owner, repo := pr.Identifier.Namespace, "packer-plugin-"+pr.Identifier.Type
+	ctx, cancel := g.phaseContext("commit", pr)
+	defer cancel()
+
+	sha = strings.ToLower(sha)
+	var tags []string
+	commit := ""
+	listOpts := &github.ListOptions{PerPage: 100}
+	for {
+		log.Printf("[DEBUG] github-getter: listing page %d of %s/%s tags", listOpts.Page, owner, repo)
+		page, resp, err := g.Client.Repositories.ListTags(ctx, owner, repo, listOpts)
+		if err != nil {
+			return nil, requestError(err, plugingetter.ErrPluginNotFound)
+		}
+		for _, tag := range page {
+			tagCommit := strings.ToLower(tag.GetCommit().GetSHA())
+			if !strings.HasPrefix(tagCommit, sha) {
+				continue
+			}
+			if commit != "" && commit != tagCommit {
+				return nil, fmt.Errorf("commit %s of %s/%s is ambiguous, it matches %s and %s", sha, owner, repo, commit, tagCommit)
+			}
+			commit = tagCommit
+			tags = append(tags, tag.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("%w: no tag of %s/%s points to commit %s", plugingetter.ErrCommitNotReleased, owner, repo, sha)
+	}
+	return tags, nil
+}
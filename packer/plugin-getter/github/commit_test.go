@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+func TestGetter_ResolveCommit(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	const (
+		releasedSHA = "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"
+		otherSHA    = "0a1b2c3fffffffffffffffffffffffffffffffff"
+	)
+
+	release := slowZipServer(t, 0)
+	defer release.Close()
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	// two pages of tags, the released commit being on the second one.
+	mux.HandleFunc("/repos/hashicorp/packer-plugin-amazon/tags", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/hashicorp/packer-plugin-amazon/tags?page=2>; rel="next"`, server.URL))
+			fmt.Fprintf(w, `[{"name": "v0.9.0", "commit": {"sha": %q}}]`, otherSHA)
+			return
+		}
+		fmt.Fprintf(w, `[{"name": "nightly", "commit": {"sha": %q}}, {"name": "v1.0.0", "commit": {"sha": %q}}]`, releasedSHA, releasedSHA)
+	})
+	mux.Handle("/", release.Config.Handler)
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	getter := &Getter{APIBaseURL: server.URL, DownloadBaseURL: server.URL}
+	pr := &plugingetter.Requirement{Identifier: identifier}
+
+	tests := []struct {
+		name     string
+		sha      string
+		wantTags []string
+		wantErr  error
+	}{
+		{name: "full sha", sha: releasedSHA, wantTags: []string{"nightly", "v1.0.0"}},
+		{name: "abbreviated sha", sha: releasedSHA[:10], wantTags: []string{"nightly", "v1.0.0"}},
+		{name: "other commit", sha: otherSHA[:12], wantTags: []string{"v0.9.0"}},
+		{name: "not released", sha: "deadbeef", wantErr: plugingetter.ErrCommitNotReleased},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := getter.ResolveCommit(pr, tt.sha)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveCommit: %v", err)
+			}
+			if fmt.Sprint(tags) != fmt.Sprint(tt.wantTags) {
+				t.Errorf("expected tags %v, got %v", tt.wantTags, tags)
+			}
+		})
+	}
+
+	t.Run("ambiguous sha", func(t *testing.T) {
+		if _, err := getter.ResolveCommit(pr, releasedSHA[:7]); err == nil {
+			t.Fatal("expected an abbreviation matching two commits to fail")
+		}
+	})
+
+	t.Run("install the released zip", func(t *testing.T) {
+		v, err := pr.ResolveCommit([]plugingetter.Getter{getter}, releasedSHA[:10])
+		if err != nil {
+			t.Fatalf("ResolveCommit: %v", err)
+		}
+		pinned, err := version.NewConstraint("= " + v.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pinnedPr := &plugingetter.Requirement{Identifier: identifier, VersionConstraints: pinned}
+		installed, err := pinnedPr.InstallLatest(plugingetter.InstallOptions{
+			Getters:         []plugingetter.Getter{getter},
+			PluginDirectory: t.TempDir(),
+			BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+				APIVersionMajor: "5", APIVersionMinor: "0",
+				OS: "linux", ARCH: "amd64",
+				Checksummers: []plugingetter.Checksummer{{Type: "sha256", Hash: sha256.New()}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("InstallLatest: %v", err)
+		}
+		if installed == nil || installed.Version != "v1.0.0" {
+			t.Errorf("expected v1.0.0 to be installed, got %#v", installed)
+		}
+	})
+}
@@ -6,16 +6,23 @@ package github
 import (
 	"bufio"
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v33/github"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
@@ -23,17 +30,143 @@ import (
 )
 
 const (
-	ghTokenAccessor  = "PACKER_GITHUB_API_TOKEN"
-	defaultUserAgent = "packer-github-plugin-getter"
-	defaultHostname  = "github.com"
+	ghTokenAccessor        = "PACKER_GITHUB_API_TOKEN"
+	defaultUserAgent       = "packer-github-plugin-getter"
+	defaultHostname        = "github.com"
+	defaultAPIHostname     = "api.github.com"
+	defaultDownloadBaseURL = "https://github.com/"
+
+	defaultMaxIdleConns = 100
+	// An install does most of its requests to the same few hosts.
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+
+	defaultTLSMinVersion = tls.VersionTLS12
 )
 
 type Getter struct {
 	Client    *github.Client
 	UserAgent string
+
+	// Token used to authenticate against the GitHub API. When empty, the
+	// PACKER_GITHUB_API_TOKEN env var is used.
+	Token string
+
+	// Credentials, when set, resolves the credentials of the requests for a
+	// plugin from the hostname of its source, ex: to share credentials
+	// between the getters of an install. They are sent to the API and
+	// download hosts, and take precedence over Token and
+	// PACKER_GITHUB_API_TOKEN unless PreferToken is set.
+	Credentials plugingetter.CredentialResolver
+	PreferToken bool
+
+	// APIBaseURL overrides the GitHub API URL, ex: an API proxy.
+	// Defaults to https://api.github.com/.
+	APIBaseURL string
+
+	// DownloadBaseURL overrides the URL release files are downloaded from,
+	// ex: a mirror of github.com releases. Defaults to https://github.com/.
+	DownloadBaseURL string
+
+	// ProxyURL is the proxy all requests go through. When empty, the proxy is
+	// read from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY env vars.
+	ProxyURL string
+
+	// CACertFile is the path to a PEM encoded CA bundle trusted on top of the
+	// system ones.
+	CACertFile string
+
+	// DisableHTTP2 disables HTTP/2, which is otherwise used when the server
+	// supports it, even with a custom CA or proxy, so that the many requests
+	// of an install share a connection.
+	DisableHTTP2 bool
+
+	// MaxIdleConns, MaxIdleConnsPerHost and IdleConnTimeout tune the HTTP
+	// keep-alive connections. Zero values use the defaults from
+	// defaultMaxIdleConns, defaultMaxIdleConnsPerHost and
+	// defaultIdleConnTimeout.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// MetadataTimeout bounds the small requests of an install, like
+	// "releases" and "sha256", and DownloadTimeout the "zip" ones, which can
+	// be hundreds of MB. Each covers a whole request, reading the response
+	// included. Zero values do not time out.
+	MetadataTimeout time.Duration
+	DownloadTimeout time.Duration
+
+	// TLSMinVersion is the oldest TLS version accepted, "1.2" or "1.3".
+	// Defaults to "1.2": older versions cannot be enabled, and servers
+	// negotiating them are refused.
+	TLSMinVersion string
+
+	// TLSCipherSuites, when set, are the only cipher suites offered, by
+	// their IANA name, ex: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". Suites
+	// Go considers insecure are refused. As in Go, TLS 1.3 suites are not
+	// configurable.
+	TLSCipherSuites []string
+
+	// MaxReleases, when set, limits the "releases" phase to this number of the
+	// most recent GitHub releases, which is faster for repositories with a lot
+	// of tags and enough to satisfy most constraints. Zero considers every tag
+	// of the repository.
+	MaxReleases int
+
+	// ZipAssetTemplate and ChecksumAssetTemplate are the templates of the
+	// names of the release zips and checksum files, for repositories that
+	// do not name them like plugingetter.DefaultZipAssetTemplate and
+	// plugingetter.DefaultChecksumAssetTemplate.
+	ZipAssetTemplate      string
+	ChecksumAssetTemplate string
+
+	// BinaryAssetTemplate is the template of the name of the plugin binary
+	// in the zips, when it is not named like the binaries Packer installs,
+	// ex: "{prefix}{version}". See plugingetter.AssetNames.
+	BinaryAssetTemplate string
+
+	// MaxRetries is how many times a request that failed transiently is
+	// retried, waiting RetryBackoff, 1s when zero, before the first retry
+	// and twice as long before each following one, or as long as the
+	// Retry-After header of a 429 or 503 answer asks. Zero does not retry.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// RetryPredicate, when set, decides in place of DefaultRetryPredicate
+	// whether a request failed transiently from its response or error, ex:
+	// for mirrors answering application-specific transient status codes.
+	RetryPredicate func(resp *http.Response, err error) bool
+
+	// WrapTransport, when set, wraps the HTTP transport of the Getter, ex:
+	// with a caching transport honoring Cache-Control. The metadata phases,
+	// like "releases" and "sha256", can be served from such a cache, while
+	// zip requests always ask to bypass it.
+	WrapTransport func(base http.RoundTripper) http.RoundTripper
 }
 
 var _ plugingetter.Getter = &Getter{}
+var _ plugingetter.AssetNamer = &Getter{}
+var _ plugingetter.ZipURLer = &Getter{}
+
+// String names the getter in errors.
+func (g *Getter) String() string { return "github" }
+
+// AssetNames returns the templates of the names of the release files.
+func (g *Getter) AssetNames() plugingetter.AssetNames {
+	return plugingetter.AssetNames{
+		Zip:      g.ZipAssetTemplate,
+		Checksum: g.ChecksumAssetTemplate,
+		Binary:   g.BinaryAssetTemplate,
+	}
+}
+
+func (g *Getter) checksumAssetName(opts plugingetter.GetOptions) string {
+	tmpl := g.ChecksumAssetTemplate
+	if tmpl == "" {
+		tmpl = plugingetter.DefaultChecksumAssetTemplate
+	}
+	return opts.RenderAssetName(tmpl)
+}
 
 func transformChecksumStream() func(in io.ReadCloser) (io.ReadCloser, error) {
 	return func(in io.ReadCloser) (io.ReadCloser, error) {
@@ -119,10 +252,11 @@ type HostSpecificTokenAuthTransport struct {
 }
 
 // RoundTrip authorizes and authenticates the request with an
-// access token from Transport's Source.
+// access token from Transport's Source. Requests that already have an
+// Authorization header are left as is.
 func (t *HostSpecificTokenAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	source, found := t.TokenSources[req.Host]
-	if found {
+	if found && req.Header.Get("Authorization") == "" {
 		reqBodyClosed := false
 		if req.Body != nil {
 			defer func() {
@@ -156,37 +290,363 @@ func (t *HostSpecificTokenAuthTransport) base() http.RoundTripper {
 	return http.DefaultTransport
 }
 
+type credentialsKey struct{}
+
+// credentialsTransport authenticates the requests aimed at Hosts with the
+// plugingetter.Credentials of their context, unless they already have an
+// Authorization header.
+type credentialsTransport struct {
+	Hosts map[string]bool
+	Base  http.RoundTripper
+}
+
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	creds, found := req.Context().Value(credentialsKey{}).(plugingetter.Credentials)
+	if !found || !t.Hosts[req.URL.Host] || req.Header.Get("Authorization") != "" {
+		return t.Base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the request.
+	req = req.Clone(req.Context())
+	creds.SetAuthHeader(req)
+	return t.Base.RoundTrip(req)
+}
+
+// decodingTransport asks for gzip or deflate compressed responses and decodes
+// them, for the requests that do not set an Accept-Encoding header themselves.
+// Go only transparently decodes gzip, while some mirrors answer with deflate.
+//
+// Zip downloads must stay raw, they set Accept-Encoding to identity.
+type decodingTransport struct {
+	Base http.RoundTripper
+}
+
+func (t *decodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") != "" {
+		return t.Base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the request.
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if err := decodeBody(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// decodeBody replaces the body of resp with its decoded content, following
+// the Content-Encoding header.
+func decodeBody(resp *http.Response) error {
+	var decoded io.ReadCloser
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("github-getter: failed to decode gzip response from %s: %s", resp.Request.URL, err)
+		}
+		decoded = zr
+	case "deflate":
+		// deflate is supposed to be zlib wrapped, but some servers send raw
+		// deflate data.
+		br := bufio.NewReader(resp.Body)
+		header, _ := br.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return fmt.Errorf("github-getter: failed to decode deflate response from %s: %s", resp.Request.URL, err)
+			}
+			decoded = zr
+		} else {
+			decoded = flate.NewReader(br)
+		}
+	default:
+		return fmt.Errorf("github-getter: unsupported content encoding %q from %s", encoding, resp.Request.URL)
+	}
+
+	resp.Body = &decodedBody{ReadCloser: decoded, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decodedBody closes the raw body along with its decoder.
+type decodedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if rawErr := b.raw.Close(); err == nil {
+		err = rawErr
+	}
+	return err
+}
+
+// transport returns the HTTP transport configured from the Getter's settings.
+func (g *Getter) transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if g.ProxyURL != "" {
+		proxyURL, err := url.Parse(g.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("github-getter: invalid proxy URL %q: %s", g.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	tlsConfig, err := g.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	transport.ForceAttemptHTTP2 = !g.DisableHTTP2
+	if g.DisableHTTP2 {
+		// A non-nil empty map is how HTTP/2 is disabled.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	transport.MaxIdleConns = defaultMaxIdleConns
+	if g.MaxIdleConns > 0 {
+		transport.MaxIdleConns = g.MaxIdleConns
+	}
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if g.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = g.MaxIdleConnsPerHost
+	}
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+	if g.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = g.IdleConnTimeout
+	}
+
+	return transport, nil
+}
+
+// tlsVersions are the accepted values of TLSMinVersion.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsConfig returns the TLS settings of the Getter. Connections to servers
+// that only offer a TLS version older than TLSMinVersion, or none of
+// TLSCipherSuites, fail.
+func (g *Getter) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: defaultTLSMinVersion}
+	if g.TLSMinVersion != "" {
+		version, found := tlsVersions[strings.TrimSpace(strings.TrimPrefix(g.TLSMinVersion, "TLS"))]
+		if !found {
+			return nil, fmt.Errorf("github-getter: unsupported TLS minimum version %q, expected \"1.2\" or \"1.3\"", g.TLSMinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if len(g.TLSCipherSuites) > 0 {
+		suites := map[string]uint16{}
+		for _, suite := range tls.CipherSuites() {
+			suites[suite.Name] = suite.ID
+		}
+		for _, name := range g.TLSCipherSuites {
+			id, found := suites[name]
+			if !found {
+				return nil, fmt.Errorf("github-getter: unknown or insecure TLS cipher suite %q", name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+
+	if g.CACertFile != "" {
+		pem, err := os.ReadFile(g.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("github-getter: failed to read CA file: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			log.Printf("[WARNING] github-getter: could not load system CA pool, only trusting %q: %s", g.CACertFile, err)
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("github-getter: no certificate found in %q", g.CACertFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// initClient sets up the GitHub client from the Getter's settings.
+func (g *Getter) initClient() error {
+	transport, err := g.transport()
+	if err != nil {
+		return err
+	}
+
+	var apiBaseURL *url.URL
+	apiHostname := defaultAPIHostname
+	if g.APIBaseURL != "" {
+		u, err := url.Parse(g.APIBaseURL)
+		if err != nil {
+			return fmt.Errorf("github-getter: invalid API base URL %q: %s", g.APIBaseURL, err)
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		apiBaseURL = u
+		apiHostname = u.Host
+	}
+
+	var base http.RoundTripper = transport
+	if g.WrapTransport != nil {
+		base = g.WrapTransport(base)
+	}
+	base = g.retryTransport(base)
+	var rt http.RoundTripper = &decodingTransport{Base: base}
+	token := g.Token
+	if token == "" {
+		token = os.Getenv(ghTokenAccessor)
+		if token != "" {
+			log.Printf("[DEBUG] github-getter: using %s", ghTokenAccessor)
+		}
+	}
+	// The transport setting the Authorization header first wins.
+	if g.Credentials != nil && g.PreferToken {
+		rt = g.credentialsTransport(rt, apiHostname)
+	}
+	if token != "" {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+		rt = &HostSpecificTokenAuthTransport{
+			TokenSources: map[string]oauth2.TokenSource{
+				apiHostname: ts,
+			},
+			Base: rt,
+		}
+	} else if g.Credentials == nil {
+		log.Printf("[WARNING] github-getter: no GitHub token set, if you intend to install plugins often, please set the %s env var", ghTokenAccessor)
+	}
+	if g.Credentials != nil && !g.PreferToken {
+		rt = g.credentialsTransport(rt, apiHostname)
+	}
+
+	g.Client = github.NewClient(&http.Client{
+		Transport:     rt,
+		CheckRedirect: checkRedirect,
+	})
+	if apiBaseURL != nil {
+		g.Client.BaseURL = apiBaseURL
+	}
+	g.Client.UserAgent = defaultUserAgent
+	if g.UserAgent != "" {
+		g.Client.UserAgent = g.UserAgent
+	}
+	return nil
+}
+
+// credentialsTransport returns a transport sending the resolved credentials
+// to the API and download hosts.
+func (g *Getter) credentialsTransport(base http.RoundTripper, apiHostname string) http.RoundTripper {
+	hosts := map[string]bool{apiHostname: true}
+	if u, err := url.Parse(g.downloadBaseURL()); err == nil {
+		hosts[u.Host] = true
+	}
+	return &credentialsTransport{Hosts: hosts, Base: base}
+}
+
+// checkRedirect follows redirects but drops the Authorization header as soon
+// as the redirect leaves the host the request was made to. Release downloads
+// are redirected to signed storage URLs, that must not receive our token.
+//
+// Go already does this for other domains, but keeps the header for
+// subdomains.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Hostname() != via[0].URL.Hostname() {
+		if req.Header.Get("Authorization") != "" {
+			log.Printf("[TRACE] github-getter: not forwarding credentials to %s", req.URL.Hostname())
+		}
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+func (g *Getter) downloadBaseURL() string {
+	if g.DownloadBaseURL == "" {
+		return defaultDownloadBaseURL
+	}
+	return strings.TrimSuffix(g.DownloadBaseURL, "/") + "/"
+}
+
 func (g *Getter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
 	if opts.PluginRequirement.Identifier.Hostname != defaultHostname {
-		s := opts.PluginRequirement.Identifier.String() + " doesn't appear to be a valid " + defaultHostname + " source address; check source and try again."
-		return nil, errors.New(s)
+		return nil, unsupportedSourceError(opts.PluginRequirement)
 	}
 
-	ctx := context.TODO()
 	if g.Client == nil {
-		var tc *http.Client
-		if tk := os.Getenv(ghTokenAccessor); tk != "" {
-			log.Printf("[DEBUG] github-getter: using %s", ghTokenAccessor)
-			ts := oauth2.StaticTokenSource(
-				&oauth2.Token{AccessToken: tk},
-			)
-			tc = &http.Client{
-				Transport: &HostSpecificTokenAuthTransport{
-					TokenSources: map[string]oauth2.TokenSource{
-						"api.github.com": ts,
-					},
-				},
-			}
-		} else {
-			log.Printf("[WARNING] github-getter: no GitHub token set, if you intend to install plugins often, please set the %s env var", ghTokenAccessor)
+		if err := g.initClient(); err != nil {
+			return nil, err
 		}
-		g.Client = github.NewClient(tc)
-		g.Client.UserAgent = defaultUserAgent
-		if g.UserAgent != "" {
-			g.Client.UserAgent = g.UserAgent
+	}
+
+	ctx, cancel := g.phaseContext(what, opts.PluginRequirement)
+	body, err := g.get(ctx, what, opts)
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("github-getter: getting %q timed out after %s: %w", what, g.phaseTimeout(what), err)
+		}
+		return nil, err
+	}
+	// The response is read after returning: the request is only done once
+	// the body is closed.
+	return &cancelOnClose{ReadCloser: body, cancel: cancel}, nil
+}
+
+// phaseTimeout returns the timeout of the requests of the what phase, zero
+// when they do not time out.
+func (g *Getter) phaseTimeout(what string) time.Duration {
+	if what == "zip" {
+		return g.DownloadTimeout
+	}
+	return g.MetadataTimeout
+}
+
+// phaseContext returns the context of a request of the what phase for pr,
+// carrying the credentials resolved for its host.
+func (g *Getter) phaseContext(what string, pr *plugingetter.Requirement) (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if g.Credentials != nil {
+		if creds, found := g.Credentials.Credentials(pr.Identifier.Hostname); found {
+			ctx = context.WithValue(ctx, credentialsKey{}, creds)
 		}
 	}
+	if timeout := g.phaseTimeout(what); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
 
+// cancelOnClose cancels the context of a request once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+func (g *Getter) get(ctx context.Context, what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
 	var req *http.Request
 	var err error
 	transform := func(in io.ReadCloser) (io.ReadCloser, error) {
@@ -195,25 +655,60 @@ func (g *Getter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser,
 
 	switch what {
 	case "releases":
+		if g.MaxReleases > 0 {
+			return g.getLatestReleases(ctx, opts)
+		}
 		u := filepath.ToSlash("/repos/" + opts.PluginRequirement.Identifier.RealRelativePath() + "/git/matching-refs/tags")
 		req, err = g.Client.NewRequest("GET", u, nil)
 		transform = transformVersionStream
 	case "sha256":
 		// something like https://github.com/sylviamoss/packer-plugin-comment/releases/download/v0.2.11/packer-plugin-comment_v0.2.11_x5_SHA256SUMS
-		u := filepath.ToSlash("https://github.com/" + opts.PluginRequirement.Identifier.RealRelativePath() + "/releases/download/" + opts.Version() + "/" + opts.PluginRequirement.FilenamePrefix() + opts.Version() + "_SHA256SUMS")
+		u := filepath.ToSlash(g.downloadBaseURL() + opts.PluginRequirement.Identifier.RealRelativePath() + "/releases/download/" + opts.Version() + "/" + g.checksumAssetName(opts))
 		req, err = g.Client.NewRequest(
 			"GET",
 			u,
 			nil,
 		)
 		transform = transformChecksumStream()
+	case "sha256sums", "sha256sums.sig":
+		// the raw checksum file, and its detached signature.
+		u := filepath.ToSlash(g.downloadBaseURL() + opts.PluginRequirement.Identifier.RealRelativePath() + "/releases/download/" + opts.Version() + "/" + g.checksumAssetName(opts))
+		if what == "sha256sums.sig" {
+			u += ".sig"
+		}
+		req, err = g.Client.NewRequest(
+			"GET",
+			u,
+			nil,
+		)
+	case "provenance":
+		// the SLSA provenance attestation of the release, ex:
+		// packer-plugin-amazon_v1.0.0.intoto.jsonl
+		u := filepath.ToSlash(g.downloadBaseURL() + opts.PluginRequirement.Identifier.RealRelativePath() + "/releases/download/" + opts.Version() + "/" + opts.PluginRequirement.FilenamePrefix() + opts.Version() + ".intoto.jsonl")
+		req, err = g.Client.NewRequest(
+			"GET",
+			u,
+			nil,
+		)
+	case "sigstore":
+		// the sigstore bundle of the signature of the zip, ex:
+		// packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip.sigstore.json
+		req, err = g.Client.NewRequest(
+			"GET",
+			g.zipURL(opts)+".sigstore.json",
+			nil,
+		)
 	case "zip":
-		u := filepath.ToSlash("https://github.com/" + opts.PluginRequirement.Identifier.RealRelativePath() + "/releases/download/" + opts.Version() + "/" + opts.ExpectedZipFilename())
+		u := g.zipURL(opts)
 		req, err = g.Client.NewRequest(
 			"GET",
 			u,
 			nil,
 		)
+		if err == nil {
+			// the zip is checksummed as is, it must not be decoded.
+			req.Header.Set("Accept-Encoding", "identity")
+		}
 
 	default:
 		return nil, fmt.Errorf("%q not implemented", what)
@@ -221,6 +716,7 @@ func (g *Getter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser,
 	if err != nil {
 		return nil, err
 	}
+	setCachePolicy(req, what)
 	log.Printf("[DEBUG] github-getter: getting %q", req.URL)
 	resp, err := g.Client.BareDo(ctx, req)
 	if err != nil {
@@ -230,19 +726,164 @@ func (g *Getter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser,
 		if resp != nil {
 			resp.Body.Close()
 		}
-		switch err := err.(type) {
-		case *github.RateLimitError:
-			return nil, &plugingetter.RateLimitError{
-				SetableEnvVar: ghTokenAccessor,
-				Err:           err,
-				ResetTime:     err.Rate.Reset.Time,
-			}
-		default:
-			log.Printf("[TRACE] failed requesting: %T. %v", err, err)
-			return nil, err
+		if what == "releases" {
+			return nil, requestError(err, plugingetter.ErrPluginNotFound)
 		}
-
+		return nil, requestError(err, plugingetter.ErrReleaseFileNotFound)
+	}
+	if contentType := resp.Header.Get("Content-Type"); what == "zip" && strings.HasPrefix(contentType, "text/html") {
+		resp.Body.Close()
+		return nil, plugingetter.UnexpectedContentTypeError(opts.ExpectedZipFilename(), contentType)
 	}
 
 	return transform(resp.Body)
 }
+
+// setCachePolicy makes the zip requests bypass the caches a transport may
+// have, see WrapTransport: zips are big, and must be checksummed as
+// downloaded. Other requests are left cacheable.
+func setCachePolicy(req *http.Request, what string) {
+	if what == "zip" {
+		req.Header.Set("Cache-Control", "no-cache, no-store")
+	}
+}
+
+// unsupportedSourceError is returned for plugins not hosted on GitHub.
+func unsupportedSourceError(pr *plugingetter.Requirement) error {
+	return fmt.Errorf("%w: %s doesn't appear to be a valid %s source address; check source and try again.", plugingetter.ErrUnsupportedSource, pr.Identifier, defaultHostname)
+}
+
+// ZipURL returns the URL the zip of opts is downloaded from.
+func (g *Getter) ZipURL(opts plugingetter.GetOptions) (string, error) {
+	if opts.PluginRequirement.Identifier.Hostname != defaultHostname {
+		return "", unsupportedSourceError(opts.PluginRequirement)
+	}
+	return g.zipURL(opts), nil
+}
+
+func (g *Getter) zipURL(opts plugingetter.GetOptions) string {
+	return filepath.ToSlash(g.downloadBaseURL() + opts.PluginRequirement.Identifier.RealRelativePath() + "/releases/download/" + opts.Version() + "/" + opts.ExpectedZipFilename())
+}
+
+// ZipExists tells whether the zip of opts was released, with a HEAD request.
+func (g *Getter) ZipExists(opts plugingetter.GetOptions) (bool, error) {
+	if opts.PluginRequirement.Identifier.Hostname != defaultHostname {
+		return false, unsupportedSourceError(opts.PluginRequirement)
+	}
+	if g.Client == nil {
+		if err := g.initClient(); err != nil {
+			return false, err
+		}
+	}
+
+	req, err := g.Client.NewRequest("HEAD", g.zipURL(opts), nil)
+	if err != nil {
+		return false, err
+	}
+	log.Printf("[DEBUG] github-getter: checking %q", req.URL)
+	ctx, cancel := g.phaseContext("zip-exists", opts.PluginRequirement)
+	defer cancel()
+	resp, err := g.Client.BareDo(ctx, req)
+	if resp != nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, requestError(err, nil)
+	}
+	return true, nil
+}
+
+// ReleaseNotes returns the body of the GitHub release of the version.
+func (g *Getter) ReleaseNotes(pr *plugingetter.Requirement, version string) (string, error) {
+	if pr.Identifier.Hostname != defaultHostname {
+		return "", nil
+	}
+	if g.Client == nil {
+		if err := g.initClient(); err != nil {
+			return "", err
+		}
+	}
+
+	owner, repo := pr.Identifier.Namespace, "packer-plugin-"+pr.Identifier.Type
+	log.Printf("[DEBUG] github-getter: getting the %s release notes of %s/%s", version, owner, repo)
+	ctx, cancel := g.phaseContext("release-notes", pr)
+	defer cancel()
+	release, _, err := g.Client.Repositories.GetReleaseByTag(ctx, owner, repo, version)
+	if err != nil {
+		return "", requestError(err, nil)
+	}
+	return release.GetBody(), nil
+}
+
+// requestError converts errors from the GitHub client to the ones Packer
+// knows how to handle; 404 responses wrap notFound, when set.
+func requestError(err error, notFound error) error {
+	switch err := err.(type) {
+	case *github.RateLimitError:
+		return &plugingetter.RateLimitError{
+			SetableEnvVar: ghTokenAccessor,
+			Err:           err,
+			ResetTime:     err.Rate.Reset.Time,
+		}
+	case *github.AbuseRateLimitError:
+		return &plugingetter.RateLimitError{
+			SetableEnvVar: ghTokenAccessor,
+			Err:           err,
+			ResetTime:     time.Now().Add(err.GetRetryAfter()),
+		}
+	case *github.ErrorResponse:
+		if notFound != nil && err.Response != nil && err.Response.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %w", notFound, err)
+		}
+		log.Printf("[TRACE] failed requesting: %T. %v", err, err)
+		return err
+	default:
+		log.Printf("[TRACE] failed requesting: %T. %v", err, err)
+		return err
+	}
+}
+
+// getLatestReleases lists the g.MaxReleases most recent releases of the
+// plugin, newest first, as a json list of Release.
+func (g *Getter) getLatestReleases(ctx context.Context, opts plugingetter.GetOptions) (io.ReadCloser, error) {
+	owner, repo := opts.PluginRequirement.Identifier.Namespace, "packer-plugin-"+opts.PluginRequirement.Identifier.Type
+
+	out := []plugingetter.Release{}
+	listOpts := &github.ListOptions{PerPage: g.MaxReleases}
+	if listOpts.PerPage > 100 {
+		// GitHub does not return more per page
+		listOpts.PerPage = 100
+	}
+	for len(out) < g.MaxReleases {
+		log.Printf("[DEBUG] github-getter: listing page %d of %s/%s releases", listOpts.Page, owner, repo)
+		releases, resp, err := g.Client.Repositories.ListReleases(ctx, owner, repo, listOpts)
+		if err != nil {
+			return nil, requestError(err, plugingetter.ErrPluginNotFound)
+		}
+		for _, release := range releases {
+			if len(out) == g.MaxReleases {
+				break
+			}
+			if release.GetDraft() {
+				continue
+			}
+			out = append(out, plugingetter.Release{
+				Version:     release.GetTagName(),
+				PublishedAt: release.GetPublishedAt().Time,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(out); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
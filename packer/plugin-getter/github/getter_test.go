@@ -0,0 +1,839 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v33/github"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// releasesServer serves releases v1.<n>.0 of the amazon plugin, newest first,
+// and records the pages that were requested. The second release is a draft.
+func releasesServer(t *testing.T, count int, requestedPages *[]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/hashicorp/packer-plugin-amazon/releases", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		*requestedPages = append(*requestedPages, fmt.Sprintf("%d/%d", page, perPage))
+
+		releases := []map[string]interface{}{}
+		for i := (page - 1) * perPage; i < page*perPage && i < count; i++ {
+			releases = append(releases, map[string]interface{}{
+				"tag_name": fmt.Sprintf("v1.%d.0", count-i),
+				"draft":    i == 1,
+			})
+		}
+		if page*perPage < count {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d&per_page=%d>; rel="next"`, "http://"+r.Host, r.URL.Path, page+1, perPage))
+		}
+		_ = json.NewEncoder(w).Encode(releases)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetter_Get_maxReleases(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	tests := []struct {
+		name         string
+		maxReleases  int
+		wantPages    []string
+		wantVersions []string
+	}{
+		{
+			// the draft release does not count, so one more page is needed.
+			name:         "drafts-are-skipped",
+			maxReleases:  3,
+			wantPages:    []string{"1/3", "2/3"},
+			wantVersions: []string{"v1.250.0", "v1.248.0", "v1.247.0"},
+		},
+		{
+			name:        "older-pages-are-not-fetched",
+			maxReleases: 150,
+			wantPages:   []string{"1/100", "2/100"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages []string
+			server := releasesServer(t, 250, &pages)
+
+			g := &Getter{
+				APIBaseURL:  server.URL,
+				MaxReleases: tt.maxReleases,
+			}
+			rc, err := g.Get("releases", plugingetter.GetOptions{
+				PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+			})
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			releases, err := plugingetter.ParseReleases(rc)
+			if err != nil {
+				t.Fatalf("ParseReleases: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantPages, pages); diff != "" {
+				t.Errorf("unexpected pages fetched: %s", diff)
+			}
+			if len(releases) != tt.maxReleases {
+				t.Errorf("expected %d releases, got %d", tt.maxReleases, len(releases))
+			}
+			if tt.wantVersions != nil {
+				var versions []string
+				for _, release := range releases {
+					versions = append(versions, release.Version)
+				}
+				if diff := cmp.Diff(tt.wantVersions, versions); diff != "" {
+					t.Errorf("unexpected versions: %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestGetter_Get_crossHostRedirectDropsToken(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	storageAuth := "unset"
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storageAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`[{"ref": "refs/tags/v1.0.0"}]`))
+	}))
+	defer storage.Close()
+	// same server, seen as another host.
+	storageURL := strings.Replace(storage.URL, "127.0.0.1", "localhost", 1)
+
+	apiAuth := ""
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiAuth = r.Header.Get("Authorization")
+		http.Redirect(w, r, storageURL+"/signed-url", http.StatusFound)
+	}))
+	defer api.Close()
+
+	g := &Getter{
+		APIBaseURL: api.URL,
+		Token:      "secret",
+	}
+	rc, err := g.Get("releases", plugingetter.GetOptions{
+		PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+	})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	releases, err := plugingetter.ParseReleases(rc)
+	if err != nil {
+		t.Fatalf("ParseReleases: %v", err)
+	}
+	if len(releases) != 1 || releases[0].Version != "v1.0.0" {
+		t.Errorf("unexpected releases %v", releases)
+	}
+
+	if apiAuth != "Bearer secret" {
+		t.Errorf("expected the API to get the token, got %q", apiAuth)
+	}
+	if storageAuth != "" {
+		t.Errorf("expected the redirect target not to get the token, got %q", storageAuth)
+	}
+}
+
+func TestGetter_Get_credentials(t *testing.T) {
+	t.Setenv(ghTokenAccessor, "")
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	creds := plugingetter.HostCredentials{
+		"github.com":         {Username: "packer", Password: "github-secret"},
+		"gitlab.example.com": {Token: "gitlab-secret"},
+	}
+	githubBasicAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("packer:github-secret"))
+
+	tests := []struct {
+		name        string
+		token       string
+		credentials plugingetter.CredentialResolver
+		preferToken bool
+		want        string
+	}{
+		{"credentials", "", creds, false, githubBasicAuth},
+		{"credentials-over-token", "secret", creds, false, githubBasicAuth},
+		{"prefer-token", "secret", creds, true, "Bearer secret"},
+		{"prefer-token-without-token", "", creds, true, githubBasicAuth},
+		{"no-credentials-for-host", "secret", plugingetter.HostCredentials{"gitlab.example.com": {Token: "gitlab-secret"}}, false, "Bearer secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageAuth := "unset"
+			storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				storageAuth = r.Header.Get("Authorization")
+				_, _ = w.Write([]byte(`[{"ref": "refs/tags/v1.0.0"}]`))
+			}))
+			defer storage.Close()
+			// same server, seen as another host.
+			storageURL := strings.Replace(storage.URL, "127.0.0.1", "localhost", 1)
+
+			apiAuth := ""
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiAuth = r.Header.Get("Authorization")
+				http.Redirect(w, r, storageURL+"/signed-url", http.StatusFound)
+			}))
+			defer api.Close()
+
+			g := &Getter{
+				APIBaseURL:  api.URL,
+				Token:       tt.token,
+				Credentials: tt.credentials,
+				PreferToken: tt.preferToken,
+			}
+			rc, err := g.Get("releases", plugingetter.GetOptions{
+				PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+			})
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if _, err := plugingetter.ParseReleases(rc); err != nil {
+				t.Fatalf("ParseReleases: %v", err)
+			}
+
+			if apiAuth != tt.want {
+				t.Errorf("API got Authorization %q, expected %q", apiAuth, tt.want)
+			}
+			if storageAuth != "" {
+				t.Errorf("expected the redirect target not to get credentials, got %q", storageAuth)
+			}
+		})
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantAuth bool
+	}{
+		{"same-host", "https://api.github.com/repos", "https://api.github.com/repositories/1", true},
+		{"other-domain", "https://github.com/releases/download", "https://objects.githubusercontent.com/1", false},
+		// Go keeps the header for subdomains by default.
+		{"subdomain", "https://github.com/releases/download", "https://objects.github.com/1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := httptest.NewRequest("GET", tt.from, nil)
+			to := httptest.NewRequest("GET", tt.to, nil)
+			to.Header.Set("Authorization", "Bearer secret")
+
+			if err := checkRedirect(to, []*http.Request{from}); err != nil {
+				t.Fatalf("checkRedirect: %v", err)
+			}
+			if gotAuth := to.Header.Get("Authorization") != ""; gotAuth != tt.wantAuth {
+				t.Errorf("Authorization header kept: %t, expected %t", gotAuth, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestGetter_transport(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		transport, err := (&Getter{}).transport()
+		if err != nil {
+			t.Fatalf("transport: %v", err)
+		}
+		if !transport.ForceAttemptHTTP2 {
+			t.Errorf("expected HTTP/2 to be attempted")
+		}
+		if transport.MaxIdleConns != defaultMaxIdleConns ||
+			transport.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost ||
+			transport.IdleConnTimeout != defaultIdleConnTimeout {
+			t.Errorf("unexpected keep-alive settings %d, %d, %s", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+		}
+		if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 || transport.TLSClientConfig.CipherSuites != nil {
+			t.Errorf("unexpected TLS settings %x, %v", transport.TLSClientConfig.MinVersion, transport.TLSClientConfig.CipherSuites)
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		transport, err := (&Getter{
+			DisableHTTP2:        true,
+			MaxIdleConns:        5,
+			MaxIdleConnsPerHost: 3,
+			IdleConnTimeout:     10 * time.Second,
+		}).transport()
+		if err != nil {
+			t.Fatalf("transport: %v", err)
+		}
+		if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+			t.Errorf("expected HTTP/2 to be disabled")
+		}
+		if transport.MaxIdleConns != 5 || transport.MaxIdleConnsPerHost != 3 || transport.IdleConnTimeout != 10*time.Second {
+			t.Errorf("unexpected keep-alive settings %d, %d, %s", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+		}
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		transport, err := (&Getter{
+			TLSMinVersion: "1.3",
+			TLSCipherSuites: []string{
+				"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+				"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			},
+		}).transport()
+		if err != nil {
+			t.Fatalf("transport: %v", err)
+		}
+		want := []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}
+		if diff := cmp.Diff(want, transport.TLSClientConfig.CipherSuites); diff != "" {
+			t.Errorf("unexpected cipher suites: %s", diff)
+		}
+		if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+			t.Errorf("expected TLS 1.3 minimum, got %x", transport.TLSClientConfig.MinVersion)
+		}
+	})
+
+	for _, g := range []*Getter{
+		{TLSMinVersion: "1.1"},
+		{TLSMinVersion: "1.0"},
+		{TLSCipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+		{TLSCipherSuites: []string{"TLS_UNKNOWN"}},
+	} {
+		if _, err := g.transport(); err == nil {
+			t.Errorf("expected an error for TLS version %q and cipher suites %v", g.TLSMinVersion, g.TLSCipherSuites)
+		}
+	}
+}
+
+func TestGetter_Get_tlsMinVersion(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		minVersion string
+		wantErr    bool
+	}{
+		{"", false},
+		{"1.3", true},
+	} {
+		g := &Getter{
+			APIBaseURL:    server.URL,
+			CACertFile:    caFile,
+			TLSMinVersion: tt.minVersion,
+		}
+		rc, err := g.Get("releases", plugingetter.GetOptions{
+			PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+		})
+		if err == nil {
+			rc.Close()
+		}
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("TLS minimum %q against a TLS 1.2 server: got error %v, expected one: %t", tt.minVersion, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGetter_Get_http2(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	proto := ""
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		disableHTTP2 bool
+		wantProto    string
+	}{
+		{false, "HTTP/2.0"},
+		{true, "HTTP/1.1"},
+	} {
+		t.Run(tt.wantProto, func(t *testing.T) {
+			g := &Getter{
+				APIBaseURL:   server.URL,
+				CACertFile:   caFile,
+				DisableHTTP2: tt.disableHTTP2,
+			}
+			rc, err := g.Get("releases", plugingetter.GetOptions{
+				PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+			})
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			rc.Close()
+			if proto != tt.wantProto {
+				t.Errorf("expected %s, got %s", tt.wantProto, proto)
+			}
+		})
+	}
+}
+
+func TestGetter_ReleaseNotes(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/hashicorp/packer-plugin-amazon/releases/tags/v1.2.0", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"tag_name": "v1.2.0",
+			"body":     "* Add the amazon-ami datasource.",
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	g := &Getter{APIBaseURL: server.URL}
+	pr := &plugingetter.Requirement{Identifier: identifier}
+
+	notes, err := g.ReleaseNotes(pr, "v1.2.0")
+	if err != nil {
+		t.Fatalf("ReleaseNotes: %v", err)
+	}
+	if want := "* Add the amazon-ami datasource."; notes != want {
+		t.Errorf("ReleaseNotes() = %q, want %q", notes, want)
+	}
+
+	if _, err := g.ReleaseNotes(pr, "v0.0.1"); err == nil {
+		t.Error("ReleaseNotes: expected an error for a missing release")
+	}
+}
+
+func TestGetter_Get_encodedReleases(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	refs := `[{"ref":"refs/tags/v1.0.0"},{"ref":"refs/tags/v1.1.0"}]`
+
+	tests := []struct {
+		encoding string
+		encode   func(w io.Writer) io.WriteCloser
+	}{
+		{"gzip", func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }},
+		{"deflate", func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }},
+		{"deflate", func(w io.Writer) io.WriteCloser {
+			// raw deflate, without the zlib wrapping
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/hashicorp/packer-plugin-amazon/git/matching-refs/tags" {
+					t.Errorf("unexpected request to %s", r.URL)
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if got := r.Header.Get("Accept-Encoding"); got != "gzip, deflate" {
+					t.Errorf("unexpected Accept-Encoding %q", got)
+				}
+				if tt.encode == nil {
+					_, _ = io.WriteString(w, refs)
+					return
+				}
+				w.Header().Set("Content-Encoding", tt.encoding)
+				enc := tt.encode(w)
+				_, _ = io.WriteString(enc, refs)
+				_ = enc.Close()
+			}))
+			defer server.Close()
+
+			g := &Getter{APIBaseURL: server.URL}
+			rc, err := g.Get("releases", plugingetter.GetOptions{
+				PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+			})
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			releases, err := plugingetter.ParseReleases(rc)
+			if err != nil {
+				t.Fatalf("ParseReleases: %v", err)
+			}
+			want := []plugingetter.Release{{Version: "v1.0.0"}, {Version: "v1.1.0"}}
+			if diff := cmp.Diff(want, releases); diff != "" {
+				t.Errorf("unexpected releases: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodingTransport_identityIsNotDecoded(t *testing.T) {
+	// a gzip stream stands for a zip file served by a misconfigured mirror:
+	// zip downloads ask for the identity encoding and are returned as is.
+	raw := &bytes.Buffer{}
+	zw := gzip.NewWriter(raw)
+	_, _ = io.WriteString(zw, "zip content")
+	_ = zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Encoding"); got != "identity" {
+			t.Errorf("unexpected Accept-Encoding %q", got)
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(raw.Bytes())
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &decodingTransport{Base: http.DefaultTransport}}
+	req, err := http.NewRequest("GET", server.URL+"/packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", "identity")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, raw.Bytes()) {
+		t.Errorf("zip body was modified: got %q, want %q", got, raw.Bytes())
+	}
+}
+
+// cachingTransport serves the GET responses with a max-age Cache-Control
+// from memory, unless the request asks to bypass caches.
+type cachingTransport struct {
+	Base  http.RoundTripper
+	cache map[string][]byte
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cacheable := req.Method == "GET" && !strings.Contains(req.Header.Get("Cache-Control"), "no-")
+	if dump, found := t.cache[req.URL.String()]; found && cacheable {
+		return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	}
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil || !cacheable || !strings.Contains(resp.Header.Get("Cache-Control"), "max-age") {
+		return resp, err
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	t.cache[req.URL.String()] = dump
+	return resp, nil
+}
+
+func TestGetter_Get_cachedReleases(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	requests := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		_, _ = w.Write([]byte(`[{"ref": "refs/tags/v1.0.0"}]`))
+	}))
+	defer api.Close()
+
+	g := &Getter{
+		APIBaseURL: api.URL,
+		WrapTransport: func(base http.RoundTripper) http.RoundTripper {
+			return &cachingTransport{Base: base, cache: map[string][]byte{}}
+		},
+	}
+	for i := 0; i < 2; i++ {
+		rc, err := g.Get("releases", plugingetter.GetOptions{
+			PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+		})
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		releases, err := plugingetter.ParseReleases(rc)
+		if err != nil {
+			t.Fatalf("ParseReleases: %v", err)
+		}
+		if len(releases) != 1 || releases[0].Version != "v1.0.0" {
+			t.Errorf("unexpected releases %v", releases)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the second releases request to be served from cache, the API got %d requests", requests)
+	}
+}
+
+func TestSetCachePolicy(t *testing.T) {
+	for _, what := range []string{"releases", "sha256", "sha256sums", "sha256sums.sig", "provenance", "sigstore", "zip"} {
+		req := httptest.NewRequest("GET", "https://github.com/file", nil)
+		setCachePolicy(req, what)
+		got := req.Header.Get("Cache-Control")
+		if what == "zip" {
+			if !strings.Contains(got, "no-cache") {
+				t.Errorf("expected zip requests to bypass caches, got Cache-Control %q", got)
+			}
+			continue
+		}
+		if got != "" {
+			t.Errorf("expected %s requests to be cacheable, got Cache-Control %q", what, got)
+		}
+	}
+}
+
+func TestGetter_Get_errorsIs(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+	}{
+		{
+			name: "plugin not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			},
+			want: plugingetter.ErrPluginNotFound,
+		},
+		{
+			name: "rate limited",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"message": "API rate limit exceeded for 127.0.0.1."}`))
+			},
+			want: plugingetter.ErrRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			g := &Getter{APIBaseURL: server.URL}
+			_, err := g.Get("releases", plugingetter.GetOptions{
+				PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected an error matching %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Run("unsupported source", func(t *testing.T) {
+		identifier, diags := addrs.ParsePluginSourceString("gitlab.com/hashicorp/amazon")
+		if diags.HasErrors() {
+			t.Fatalf("ParsePluginSourceString: %v", diags)
+		}
+		_, err := (&Getter{}).Get("releases", plugingetter.GetOptions{
+			PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+		})
+		if !errors.Is(err, plugingetter.ErrUnsupportedSource) {
+			t.Errorf("expected an error matching %q, got %v", plugingetter.ErrUnsupportedSource, err)
+		}
+	})
+}
+
+// slowZipServer serves the v1.0.0 linux_amd64 release of the amazon plugin,
+// with a zip taking zipDelay to complete.
+func slowZipServer(t *testing.T, zipDelay time.Duration) *httptest.Server {
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	zipBuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zipBuf)
+	w, err := zw.Create(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("\x7fELF amazon")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(zipBuf.Bytes())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/hashicorp/packer-plugin-amazon/git/matching-refs/tags", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"ref": "refs/tags/v1.0.0"}]`))
+	})
+	mux.HandleFunc("/hashicorp/packer-plugin-amazon/releases/download/v1.0.0/packer-plugin-amazon_v1.0.0_SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  %s.zip\n", hex.EncodeToString(sum[:]), binary)
+	})
+	mux.HandleFunc("/hashicorp/packer-plugin-amazon/releases/download/v1.0.0/"+binary+".zip", func(w http.ResponseWriter, r *http.Request) {
+		content := zipBuf.Bytes()
+		_, _ = w.Write(content[:len(content)/2])
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(zipDelay):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write(content[len(content)/2:])
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGetter_phaseTimeouts(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	server := slowZipServer(t, 300*time.Millisecond)
+	defer server.Close()
+
+	install := func(g *Getter) (*plugingetter.Installation, error) {
+		g.APIBaseURL, g.DownloadBaseURL = server.URL, server.URL
+		pr := &plugingetter.Requirement{Identifier: identifier}
+		return pr.InstallLatest(plugingetter.InstallOptions{
+			Getters:         []plugingetter.Getter{g},
+			PluginDirectory: t.TempDir(),
+			BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+				APIVersionMajor: "5", APIVersionMinor: "0",
+				OS: "linux", ARCH: "amd64",
+				Checksummers: []plugingetter.Checksummer{{Type: "sha256", Hash: sha256.New()}},
+			},
+		})
+	}
+
+	t.Run("zip uses the download timeout", func(t *testing.T) {
+		installed, err := install(&Getter{
+			MetadataTimeout: 100 * time.Millisecond,
+			DownloadTimeout: 5 * time.Second,
+		})
+		if err != nil {
+			t.Fatalf("expected the zip to be downloaded within the download timeout, got %v", err)
+		}
+		if installed == nil || installed.Version != "v1.0.0" {
+			t.Errorf("expected v1.0.0 to be installed, got %#v", installed)
+		}
+	})
+
+	t.Run("download timeout covers reading the zip", func(t *testing.T) {
+		_, err := install(&Getter{
+			MetadataTimeout: 5 * time.Second,
+			DownloadTimeout: 100 * time.Millisecond,
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the zip download to time out, got %v", err)
+		}
+	})
+
+	t.Run("metadata timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(300 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		}))
+		defer slow.Close()
+
+		g := &Getter{
+			APIBaseURL:      slow.URL,
+			MetadataTimeout: 100 * time.Millisecond,
+			DownloadTimeout: 5 * time.Second,
+		}
+		_, err := g.Get("releases", plugingetter.GetOptions{
+			PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the releases to time out, got %v", err)
+		}
+	})
+}
+
+func TestRequestError_notFound(t *testing.T) {
+	ghErr := &github.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusNotFound, Request: httptest.NewRequest("GET", "https://github.com/", nil)},
+		Message:  "Not Found",
+	}
+	err := requestError(ghErr, plugingetter.ErrReleaseFileNotFound)
+	if !errors.Is(err, plugingetter.ErrReleaseFileNotFound) {
+		t.Errorf("expected an error matching %q, got %v", plugingetter.ErrReleaseFileNotFound, err)
+	}
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) {
+		t.Errorf("expected the GitHub error to be kept, got %v", err)
+	}
+	if err := requestError(ghErr, nil); err != error(ghErr) {
+		t.Errorf("expected the error to be returned as is without a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"net/http"
+	"time"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// An Option configures a Getter created with New.
+type Option func(*Getter)
+
+// New returns a Getter configured by opts, for embedders that would rather
+// not fill a Getter literal. Options are applied in order, so a later one
+// overrides an earlier one setting the same field.
+func New(opts ...Option) *Getter {
+	g := &Getter{}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// WithToken sets the Token used to authenticate against the GitHub API.
+func WithToken(token string) Option {
+	return func(g *Getter) { g.Token = token }
+}
+
+// WithCredentials sets the CredentialResolver of the requests, see
+// Getter.Credentials.
+func WithCredentials(credentials plugingetter.CredentialResolver) Option {
+	return func(g *Getter) { g.Credentials = credentials }
+}
+
+// WithUserAgent sets the User-Agent of the requests.
+func WithUserAgent(userAgent string) Option {
+	return func(g *Getter) { g.UserAgent = userAgent }
+}
+
+// WithProxyURL sets the proxy all requests go through.
+func WithProxyURL(proxyURL string) Option {
+	return func(g *Getter) { g.ProxyURL = proxyURL }
+}
+
+// WithCACertFile sets the path to a PEM encoded CA bundle trusted on top of
+// the system ones.
+func WithCACertFile(path string) Option {
+	return func(g *Getter) { g.CACertFile = path }
+}
+
+// WithTimeouts sets the MetadataTimeout and DownloadTimeout of the requests.
+// Zero values do not time out.
+func WithTimeouts(metadata, download time.Duration) Option {
+	return func(g *Getter) {
+		g.MetadataTimeout = metadata
+		g.DownloadTimeout = download
+	}
+}
+
+// WithRetries sets the MaxRetries of the requests that failed transiently,
+// as told by predicate, or by DefaultRetryPredicate when nil.
+func WithRetries(maxRetries int, predicate func(resp *http.Response, err error) bool) Option {
+	return func(g *Getter) {
+		g.MaxRetries = maxRetries
+		g.RetryPredicate = predicate
+	}
+}
+
+// WithBaseURLs sets the APIBaseURL and DownloadBaseURL, ex: for a GitHub
+// Enterprise Server or a mirror. Empty values keep the github.com defaults.
+func WithBaseURLs(api, download string) Option {
+	return func(g *Getter) {
+		g.APIBaseURL = api
+		g.DownloadBaseURL = download
+	}
+}
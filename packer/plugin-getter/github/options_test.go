@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+func TestNew(t *testing.T) {
+	g := New(
+		WithToken("first"),
+		WithUserAgent("embedder/1.0"),
+		WithProxyURL("http://proxy.example.com:3128"),
+		WithCACertFile("/etc/ssl/company.pem"),
+		WithTimeouts(10*time.Second, 5*time.Minute),
+		WithRetries(2, nil),
+		WithToken("secret"),
+	)
+	if g.Token != "secret" {
+		t.Errorf("expected the last token to win, got %q", g.Token)
+	}
+	if g.UserAgent != "embedder/1.0" || g.ProxyURL != "http://proxy.example.com:3128" || g.CACertFile != "/etc/ssl/company.pem" {
+		t.Errorf("unexpected getter %#v", g)
+	}
+	if g.MaxRetries != 2 || g.RetryPredicate != nil {
+		t.Errorf("expected 2 retries with the default predicate, got %d", g.MaxRetries)
+	}
+	if g.phaseTimeout("releases") != 10*time.Second || g.phaseTimeout("zip") != 5*time.Minute {
+		t.Errorf("unexpected timeouts %s and %s", g.MetadataTimeout, g.DownloadTimeout)
+	}
+
+	if g := New(); g.Token != "" || g.APIBaseURL != "" || g.MetadataTimeout != 0 {
+		t.Errorf("expected New without options to return a zero Getter, got %#v", g)
+	}
+}
+
+func TestNew_Get(t *testing.T) {
+	t.Setenv(ghTokenAccessor, "")
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+
+	var auth, userAgent string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		userAgent = r.Header.Get("User-Agent")
+		_, _ = w.Write([]byte(`[{"ref": "refs/tags/v1.0.0"}]`))
+	}))
+	defer api.Close()
+
+	g := New(
+		WithBaseURLs(api.URL, ""),
+		WithToken("secret"),
+		WithUserAgent("embedder/1.0"),
+		WithTimeouts(time.Minute, 0),
+	)
+	rc, err := g.Get("releases", plugingetter.GetOptions{
+		PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+	})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	releases, err := plugingetter.ParseReleases(rc)
+	if err != nil {
+		t.Fatalf("ParseReleases: %v", err)
+	}
+	if len(releases) != 1 || releases[0].Version != "v1.0.0" {
+		t.Errorf("unexpected releases %v", releases)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("expected the token to be sent, got %q", auth)
+	}
+	if userAgent != "embedder/1.0" {
+		t.Errorf("expected the user agent to be sent, got %q", userAgent)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"net/http"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// DefaultRetryPredicate tells whether a request failed transiently and can be
+// retried, see plugingetter.DefaultRetryPredicate.
+func DefaultRetryPredicate(resp *http.Response, err error) bool {
+	return plugingetter.DefaultRetryPredicate(resp, err)
+}
+
+// retryTransport returns base, retrying as configured by the Getter.
+func (g *Getter) retryTransport(base http.RoundTripper) http.RoundTripper {
+	if g.MaxRetries <= 0 {
+		return base
+	}
+	return &plugingetter.RetryTransport{
+		MaxRetries: g.MaxRetries,
+		Backoff:    g.RetryBackoff,
+		Predicate:  g.RetryPredicate,
+		Base:       base,
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// flakyServer answers status to the first failures requests for the tags of
+// the amazon plugin, then lists v1.0.0. It counts the requests it got.
+func flakyServer(t *testing.T, status, failures int, requests *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if *requests <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"ref": "refs/tags/v1.0.0"}]`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetter_Get_retries(t *testing.T) {
+	t.Setenv(ghTokenAccessor, "")
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	// 418 is not transient for DefaultRetryPredicate.
+	retryTeapots := func(resp *http.Response, err error) bool {
+		return err == nil && resp.StatusCode == http.StatusTeapot
+	}
+
+	tests := []struct {
+		name         string
+		status       int
+		maxRetries   int
+		predicate    func(resp *http.Response, err error) bool
+		wantRequests int
+		wantErr      bool
+	}{
+		{"no-retries", http.StatusServiceUnavailable, 0, nil, 1, true},
+		{"default-predicate", http.StatusServiceUnavailable, 3, nil, 3, false},
+		{"default-predicate-not-transient", http.StatusTeapot, 3, nil, 1, true},
+		{"custom-predicate", http.StatusTeapot, 3, retryTeapots, 3, false},
+		{"custom-predicate-replaces-default", http.StatusServiceUnavailable, 3, retryTeapots, 1, true},
+		{"retries-exhausted", http.StatusTeapot, 1, retryTeapots, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := flakyServer(t, tt.status, 2, &requests)
+			g := &Getter{
+				APIBaseURL:     server.URL,
+				MaxRetries:     tt.maxRetries,
+				RetryBackoff:   time.Millisecond,
+				RetryPredicate: tt.predicate,
+			}
+			rc, err := g.Get("releases", plugingetter.GetOptions{
+				PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil {
+				releases, err := plugingetter.ParseReleases(rc)
+				if err != nil || len(releases) != 1 {
+					t.Errorf("unexpected releases %v: %v", releases, err)
+				}
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("expected %d requests, got %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestDefaultRetryPredicate(t *testing.T) {
+	for status, want := range map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusNotFound:            false,
+		http.StatusForbidden:           false,
+		http.StatusTooManyRequests:     true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusInternalServerError: true,
+	} {
+		if got := DefaultRetryPredicate(&http.Response{StatusCode: status}, nil); got != want {
+			t.Errorf("DefaultRetryPredicate(%d) = %t, want %t", status, got, want)
+		}
+	}
+	if !DefaultRetryPredicate(nil, errors.New("connection reset by peer")) {
+		t.Errorf("expected connection errors to be retried")
+	}
+}
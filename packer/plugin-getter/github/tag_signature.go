@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"log"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+var _ plugingetter.TagSignatureGetter = &Getter{}
+
+// GetTagSignature returns the signature of the git tag of the release of
+// opts, as exposed by the GitHub API. Lightweight tags, which point to a
+// commit instead of a tag object, are returned without a signature.
+func (g *Getter) GetTagSignature(opts plugingetter.GetOptions) (*plugingetter.TagSignature, error) {
+	pr := opts.PluginRequirement
+	if pr.Identifier.Hostname != defaultHostname {
+		return nil, unsupportedSourceError(pr)
+	}
+	if g.Client == nil {
+		if err := g.initClient(); err != nil {
+			return nil, err
+		}
+	}
+
+	owner, repo := pr.Identifier.Namespace, "packer-plugin-"+pr.Identifier.Type
+	tag := opts.Version()
+	ctx, cancel := g.phaseContext("tag-signature", pr)
+	defer cancel()
+
+	log.Printf("[DEBUG] github-getter: getting the tag %s of %s/%s", tag, owner, repo)
+	ref, _, err := g.Client.Git.GetRef(ctx, owner, repo, "tags/"+tag)
+	if err != nil {
+		return nil, requestError(err, plugingetter.ErrReleaseFileNotFound)
+	}
+	signature := &plugingetter.TagSignature{Tag: tag}
+	if ref.GetObject().GetType() != "tag" {
+		return signature, nil
+	}
+
+	tagObject, _, err := g.Client.Git.GetTag(ctx, owner, repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return nil, requestError(err, plugingetter.ErrReleaseFileNotFound)
+	}
+	verification := tagObject.GetVerification()
+	signature.Payload = []byte(verification.GetPayload())
+	signature.Signature = []byte(verification.GetSignature())
+	return signature, nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// signatureVerifier trusts the tags signed with "good signature".
+type signatureVerifier struct {
+	verified []*plugingetter.TagSignature
+}
+
+func (v *signatureVerifier) VerifyTagSignature(pr *plugingetter.Requirement, signature *plugingetter.TagSignature) error {
+	v.verified = append(v.verified, signature)
+	switch string(signature.Signature) {
+	case "":
+		return fmt.Errorf("%w %s", plugingetter.ErrUnsignedTag, signature.Tag)
+	case "good signature":
+		return nil
+	default:
+		return fmt.Errorf("bad signature of %s", signature.Tag)
+	}
+}
+
+func TestGetter_GetTagSignature(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	const tagSHA = "5b1c9e1e2e64bc4e5c4a8fc0f7e2a5d7b8f3f9a1"
+
+	tests := []struct {
+		name          string
+		refObjectType string
+		verification  map[string]interface{}
+		wantSignature string
+		wantInstalled bool
+	}{
+		{
+			name:          "signed tag",
+			refObjectType: "tag",
+			verification: map[string]interface{}{
+				"verified":  true,
+				"reason":    "valid",
+				"signature": "good signature",
+				"payload":   "object 0a1b\ntype commit\ntag v1.0.0\n",
+			},
+			wantSignature: "good signature",
+			wantInstalled: true,
+		},
+		{
+			name:          "unsigned annotated tag",
+			refObjectType: "tag",
+			verification:  map[string]interface{}{"verified": false, "reason": "unsigned"},
+		},
+		{
+			name:          "lightweight tag",
+			refObjectType: "commit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := slowZipServer(t, 0)
+			defer release.Close()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/hashicorp/packer-plugin-amazon/git/ref/tags/v1.0.0", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"ref":    "refs/tags/v1.0.0",
+					"object": map[string]string{"type": tt.refObjectType, "sha": tagSHA},
+				})
+			})
+			mux.HandleFunc("/repos/hashicorp/packer-plugin-amazon/git/tags/"+tagSHA, func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"tag":          "v1.0.0",
+					"sha":          tagSHA,
+					"verification": tt.verification,
+				})
+			})
+			mux.Handle("/", release.Config.Handler)
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			verifier := &signatureVerifier{}
+			pr := &plugingetter.Requirement{Identifier: identifier}
+			installed, err := pr.InstallLatest(plugingetter.InstallOptions{
+				Getters:              []plugingetter.Getter{&Getter{APIBaseURL: server.URL, DownloadBaseURL: server.URL}},
+				PluginDirectory:      t.TempDir(),
+				TagSignatureVerifier: verifier,
+				BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+					APIVersionMajor: "5", APIVersionMinor: "0",
+					OS: "linux", ARCH: "amd64",
+					Checksummers: []plugingetter.Checksummer{{Type: "sha256", Hash: sha256.New()}},
+				},
+			})
+
+			if len(verifier.verified) != 1 {
+				t.Fatalf("expected the tag to be verified once, got %d verifications", len(verifier.verified))
+			}
+			if got := verifier.verified[0]; got.Tag != "v1.0.0" || string(got.Signature) != tt.wantSignature {
+				t.Errorf("unexpected tag signature %q of %s", got.Signature, got.Tag)
+			}
+			if !tt.wantInstalled {
+				var integrityErr *plugingetter.IntegrityError
+				if !errors.As(err, &integrityErr) || !errors.Is(err, plugingetter.ErrUnsignedTag) {
+					t.Errorf("expected an unsigned tag IntegrityError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if installed == nil || installed.Version != "v1.0.0" {
+				t.Errorf("expected v1.0.0 to be installed, got %#v", installed)
+			}
+		})
+	}
+}
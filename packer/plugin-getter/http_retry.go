@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = time.Second
+	// maxRetryAfter is the longest Retry-After a RetryTransport waits for,
+	// longer ones fail the request right away.
+	maxRetryAfter = time.Minute
+)
+
+// DefaultRetryPredicate tells whether a request failed transiently and can be
+// retried: when the connection failed, or the server answered 408, 429, 500,
+// 502, 503 or 504.
+func DefaultRetryPredicate(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryTransport retries the requests Predicate tells failed transiently, up
+// to MaxRetries times, waiting Backoff, 1s when zero, before the first retry
+// and twice as long before each following one. A 429 or 503 answer with a
+// Retry-After header is retried after the time it asks for instead, unless
+// it is more than a minute. Predicate defaults to DefaultRetryPredicate and
+// Base to http.DefaultTransport.
+type RetryTransport struct {
+	MaxRetries int
+	Backoff    time.Duration
+	Predicate  func(resp *http.Response, err error) bool
+	Base       http.RoundTripper
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base, predicate, backoff := t.Base, t.Predicate, t.Backoff
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if predicate == nil {
+		predicate = DefaultRetryPredicate
+	}
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		// requests with a body that cannot be sent again are not retried.
+		replayable := req.Body == nil || req.GetBody != nil
+		if attempt >= t.MaxRetries || !replayable || !predicate(resp, err) {
+			return resp, err
+		}
+		wait := backoff
+		if resp != nil {
+			if retryAfter, ok := retryAfter(resp); ok {
+				if retryAfter > maxRetryAfter {
+					log.Printf("[DEBUG] not retrying %s after %s, it asks to retry in %s", req.URL, resp.Status, retryAfter)
+					return resp, err
+				}
+				wait = retryAfter
+			}
+			log.Printf("[DEBUG] retrying %s after %s, in %s", req.URL, resp.Status, wait)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			log.Printf("[DEBUG] retrying %s after %s, in %s", req.URL, err, wait)
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter returns how long the Retry-After header of a 429 or 503 answer,
+// in seconds or as an HTTP date, asks to wait before retrying.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport_retryAfter(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		retryAfter   string
+		wantRequests int
+		wantStatus   int
+	}{
+		// the backoff of an hour would time the request out.
+		{"too-many-requests", http.StatusTooManyRequests, "1", 2, http.StatusOK},
+		{"unavailable-http-date", http.StatusServiceUnavailable, time.Now().UTC().Format(http.TimeFormat), 2, http.StatusOK},
+		{"longer-than-a-minute", http.StatusTooManyRequests, "3600", 1, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(tt.status)
+				}
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: &RetryTransport{MaxRetries: 2, Backoff: time.Hour}}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do: %s", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || requests != tt.wantRequests {
+				t.Errorf("expected %d after %d requests, got %d after %d", tt.wantStatus, tt.wantRequests, resp.StatusCode, requests)
+			}
+		})
+	}
+}
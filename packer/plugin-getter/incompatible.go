@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// ErrNoCompatibleVersion is matched, with errors.Is, by the error InstallLatest
+// returns when releases match the version constraints but none of them has a
+// binary Packer can use. Use errors.As with a *NoCompatibleVersionError to
+// know what was released.
+var ErrNoCompatibleVersion = errors.New("no compatible version")
+
+// IncompatibleRelease is a release matching the version constraints that has
+// no binary Packer can use.
+type IncompatibleRelease struct {
+	Version string
+
+	// ProtocolVersions of the binaries released for the platform, ex: "x6.0".
+	// Empty when no binary was released for the platform.
+	ProtocolVersions []string
+}
+
+func (r IncompatibleRelease) String() string {
+	if len(r.ProtocolVersions) == 0 {
+		return r.Version + ": no binary for this platform"
+	}
+	return r.Version + ": " + strings.Join(r.ProtocolVersions, ", ")
+}
+
+// NoCompatibleVersionError tells why no version of a plugin could be
+// installed.
+type NoCompatibleVersionError struct {
+	Plugin *addrs.Plugin
+
+	// Platform and protocol version of the binaries Packer can use, ex:
+	// linux_amd64 and x5.0.
+	OS, ARCH   string
+	APIVersion string
+
+	// Releases that were looked at, in the order they were tried.
+	Releases []IncompatibleRelease
+}
+
+func (e *NoCompatibleVersionError) Error() string {
+	var releases []string
+	for _, release := range e.Releases {
+		releases = append(releases, release.String())
+	}
+	return fmt.Sprintf("%s: none of the releases of %s has a %s_%s binary with a protocol version compatible with %s (%s)",
+		ErrNoCompatibleVersion, e.Plugin, e.OS, e.ARCH, e.APIVersion, strings.Join(releases, "; "))
+}
+
+func (e *NoCompatibleVersionError) Is(target error) bool {
+	return target == ErrNoCompatibleVersion
+}
+
+// missingPlatform reports whether err tells that the plugin was not released
+// for the platform: no release lists a binary for it, or its zip was not
+// found.
+func missingPlatform(err error) bool {
+	var noCompatibleVersion *NoCompatibleVersionError
+	if errors.As(err, &noCompatibleVersion) {
+		for _, release := range noCompatibleVersion.Releases {
+			if len(release.ProtocolVersions) > 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return errors.Is(err, ErrReleaseFileNotFound)
+}
+
+// addProtocolVersion records that release has a binary with protocolVersion
+// for the platform.
+func (r *IncompatibleRelease) addProtocolVersion(protocolVersion string) {
+	for _, v := range r.ProtocolVersions {
+		if v == protocolVersion {
+			return
+		}
+	}
+	r.ProtocolVersions = append(r.ProtocolVersions, protocolVersion)
+}
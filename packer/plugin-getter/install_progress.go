@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// InstallReason tells what InstallAll did with a plugin.
+type InstallReason string
+
+const (
+	// InstallReasonInstalled is for a plugin that was downloaded and
+	// installed.
+	InstallReasonInstalled InstallReason = "installed"
+	// InstallReasonResumed is for a plugin installed by a previous,
+	// interrupted, InstallAll and found in InstallOptions.StatePath.
+	InstallReasonResumed InstallReason = "resumed"
+	// InstallReasonUpToDate is for a plugin a matching version of which was
+	// already installed.
+	InstallReasonUpToDate InstallReason = "up_to_date"
+	// InstallReasonFailed is for a plugin that could not be installed.
+	InstallReasonFailed InstallReason = "failed"
+)
+
+// InstallResult is what InstallAll did with a plugin.
+type InstallResult struct {
+	// Source is the plugin source, ex: github.com/hashicorp/amazon.
+	Source string
+	Reason InstallReason
+	// Installation is the installed binary, nil unless Reason is
+	// InstallReasonInstalled or InstallReasonResumed.
+	Installation *Installation
+	// Err is why the plugin could not be installed.
+	Err error
+	// Duration is how long installing the plugin took, without its
+	// dependencies.
+	Duration time.Duration
+}
+
+// InstallProgress is notified by InstallAll of every plugin it is done with,
+// and by InstallLatestForPlatforms of every platform, as soon as it is, like
+// GetterMetrics is of requests.
+type InstallProgress interface {
+	ObserveInstall(result InstallResult)
+}
+
+// JSONLinesProgress is an InstallProgress writing every result to W as a JSON
+// line, ex:
+//
+//	{"source":"github.com/hashicorp/amazon","reason":"installed","version":"v1.2.3","binary_path":"/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"}
+//	{"source":"github.com/hashicorp/docker","reason":"failed","error":"no matching version found for constraints: \">= 2.0.0\""}
+type JSONLinesProgress struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+var _ InstallProgress = &JSONLinesProgress{}
+
+// installResultJSON is how a result is written by JSONLinesProgress.
+type installResultJSON struct {
+	Source        string        `json:"source"`
+	Reason        InstallReason `json:"reason"`
+	Version       string        `json:"version,omitempty"`
+	BinaryPath    string        `json:"binary_path,omitempty"`
+	OtherBinaries []string      `json:"other_binaries,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+func (p *JSONLinesProgress) ObserveInstall(result InstallResult) {
+	out := installResultJSON{
+		Source: result.Source,
+		Reason: result.Reason,
+	}
+	if result.Installation != nil {
+		out.Version = result.Installation.Version
+		out.BinaryPath = result.Installation.BinaryPath
+		out.OtherBinaries = result.Installation.OtherBinaries
+	}
+	if result.Err != nil {
+		out.Error = result.Err.Error()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// json.Encoder ends every value with a new line.
+	_ = json.NewEncoder(p.W).Encode(out)
+}
+
+// observeInstall reports result to opts.Progress and opts.AuditLogPath, if
+// set.
+func (opts *InstallOptions) observeInstall(result InstallResult) {
+	opts.auditInstall(result)
+	if opts.Progress != nil {
+		opts.Progress.ObserveInstall(result)
+	}
+}
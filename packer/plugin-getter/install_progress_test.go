@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRequirements_InstallAll_jsonLinesProgress(t *testing.T) {
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon",
+			mustRequirement(t, "github.com/hashicorp/ansible", "")),
+		"github.com/hashicorp/ansible": singleReleaseGetter("ansible"),
+		"github.com/hashicorp/docker":  singleReleaseGetter("docker"),
+	}
+	pluginDir := t.TempDir()
+	binaryPath := func(name string) string {
+		return filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", name, "packer-plugin-"+name+"_v1.0.0_x5.0_linux_amd64"))
+	}
+	reqs := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+		mustRequirement(t, "github.com/hashicorp/docker", ">= 2.0.0"),
+	}
+
+	tests := []struct {
+		name string
+		want []installResultJSON
+	}{
+		{"first-run", []installResultJSON{
+			{Source: "github.com/hashicorp/amazon", Reason: InstallReasonInstalled, Version: "v1.0.0", BinaryPath: binaryPath("amazon")},
+			{Source: "github.com/hashicorp/ansible", Reason: InstallReasonInstalled, Version: "v1.0.0", BinaryPath: binaryPath("ansible")},
+			{Source: "github.com/hashicorp/docker", Reason: InstallReasonFailed},
+		}},
+		{"second-run", []installResultJSON{
+			{Source: "github.com/hashicorp/amazon", Reason: InstallReasonUpToDate},
+			{Source: "github.com/hashicorp/ansible", Reason: InstallReasonUpToDate},
+			{Source: "github.com/hashicorp/docker", Reason: InstallReasonFailed},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.Progress = &JSONLinesProgress{W: out}
+			_, _ = reqs.InstallAll(opts)
+
+			var got []installResultJSON
+			for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+				var result installResultJSON
+				if err := json.Unmarshal([]byte(line), &result); err != nil {
+					t.Fatalf("invalid JSON line %q: %v", line, err)
+				}
+				if result.Reason == InstallReasonFailed {
+					if result.Error == "" {
+						t.Errorf("expected an error for %s", line)
+					}
+					result.Error = ""
+				}
+				got = append(got, result)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected progress: %s", diff)
+			}
+		})
+	}
+}
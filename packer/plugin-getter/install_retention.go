@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// pruneInstalledVersions removes the installed versions of pr, whatever its
+// version constraints, beyond the opts.KeepVersions newest ones. The version
+// that was just installed is kept even when it is not one of the newest.
+func (pr *Requirement) pruneInstalledVersions(install *Installation, opts InstallOptions) error {
+	all := Requirement{Identifier: pr.Identifier, Checksummers: pr.Checksummers}
+	installs, err := all.ListInstallations(ListInstallationsOptions{
+		PluginDirectory:           opts.PluginDirectory,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+	})
+	if err != nil {
+		return err
+	}
+	if len(installs) <= opts.KeepVersions {
+		return nil
+	}
+
+	// oldest first
+	sort.Sort(installs)
+	var errs *multierror.Error
+	for _, old := range installs[:len(installs)-opts.KeepVersions] {
+		if old.BinaryPath == install.BinaryPath {
+			continue
+		}
+		log.Printf("[INFO] removing %s %s, more than %d versions are installed", pr.Identifier, old.Version, opts.KeepVersions)
+		files := append([]string{old.BinaryPath}, sidecarFiles(old.BinaryPath, opts.Checksummers)...)
+		if err := removeFilesAtomically(files); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not remove %s %s: %w", pr.Identifier, old.Version, err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// removeFilesAtomically removes files, which are all in the same directory,
+// ignoring the missing ones. They are first moved to a temporary directory,
+// and moved back when one of them cannot be, so that either all of them or
+// none of them are removed.
+func removeFilesAtomically(files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	tmpDir, err := os.MkdirTemp(LongPath(filepath.Dir(files[0])), ".packer-remove-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var moved []string
+	for _, file := range files {
+		err := os.Rename(LongPath(file), filepath.Join(tmpDir, filepath.Base(file)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			for _, m := range moved {
+				if restoreErr := os.Rename(filepath.Join(tmpDir, filepath.Base(m)), LongPath(m)); restoreErr != nil {
+					log.Printf("[WARNING] failed to restore %q: %s", m, restoreErr)
+				}
+			}
+			return err
+		}
+		moved = append(moved, file)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// installRetained installs the fake versions of the amazon plugin in order,
+// pruning the installed versions after each, and returns the files left.
+func installRetained(t *testing.T, keepVersions int, versions ...string) []string {
+	pluginDir := t.TempDir()
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	opts := InstallOptions{
+		PluginDirectory: pluginDir,
+		KeepVersions:    keepVersions,
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			OS:   runtime.GOOS,
+			ARCH: runtime.GOARCH,
+			Checksummers: []Checksummer{
+				{Type: "sha256", Hash: sha256.New()},
+			},
+		},
+	}
+	for _, v := range versions {
+		binaryPath := installFakePlugin(t, pluginDir, "github.com/hashicorp/amazon", v)
+		zipFilename := filepath.Base(binaryPath) + ".zip"
+		if err := writeZipChecksum(binaryPath, zipFilename, "0123"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pinFilename(filepath.Dir(binaryPath), zipFilename), []byte("0123"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := pr.pruneInstalledVersions(&Installation{BinaryPath: binaryPath, Version: v}, opts); err != nil {
+			t.Fatalf("pruneInstalledVersions after installing %s: %v", v, err)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []string
+	for _, entry := range entries {
+		res = append(res, strings.Replace(entry.Name(), "_"+runtime.GOOS+"_"+runtime.GOARCH, "", 1))
+	}
+	return res
+}
+
+func TestRequirement_pruneInstalledVersions(t *testing.T) {
+	got := installRetained(t, 2, "v1.0.0", "v1.1.0", "v1.10.0", "v1.2.0")
+	want := []string{
+		"packer-plugin-amazon_v1.10.0_x5.0",
+		"packer-plugin-amazon_v1.10.0_x5.0.zip_SHA256SUM.pin",
+		"packer-plugin-amazon_v1.10.0_x5.0_SHA256SUM",
+		"packer-plugin-amazon_v1.10.0_x5.0_ZIP_SHA256SUM",
+		"packer-plugin-amazon_v1.2.0_x5.0",
+		"packer-plugin-amazon_v1.2.0_x5.0.zip_SHA256SUM.pin",
+		"packer-plugin-amazon_v1.2.0_x5.0_SHA256SUM",
+		"packer-plugin-amazon_v1.2.0_x5.0_ZIP_SHA256SUM",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected installed files: %s", diff)
+	}
+}
+
+func TestRequirement_pruneInstalledVersions_keepsInstalledVersion(t *testing.T) {
+	// the older version is installed last, and the newest one is kept too.
+	got := installRetained(t, 1, "v1.1.0", "v1.0.0")
+	want := []string{
+		"packer-plugin-amazon_v1.0.0_x5.0",
+		"packer-plugin-amazon_v1.0.0_x5.0.zip_SHA256SUM.pin",
+		"packer-plugin-amazon_v1.0.0_x5.0_SHA256SUM",
+		"packer-plugin-amazon_v1.0.0_x5.0_ZIP_SHA256SUM",
+		"packer-plugin-amazon_v1.1.0_x5.0",
+		"packer-plugin-amazon_v1.1.0_x5.0.zip_SHA256SUM.pin",
+		"packer-plugin-amazon_v1.1.0_x5.0_SHA256SUM",
+		"packer-plugin-amazon_v1.1.0_x5.0_ZIP_SHA256SUM",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected installed files: %s", diff)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// installState is the content of InstallOptions.StatePath, ex:
+//
+//	{"completed": [{
+//		"source": "github.com/hashicorp/amazon",
+//		"binary_path": "/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.2.3_x5.0_linux_amd64",
+//		"checksum": "sha256:9f86d081884c7d65...",
+//		"release": {"version": "v1.2.3"}
+//	}]}
+type installState struct {
+	Completed []completedInstall `json:"completed"`
+}
+
+// completedInstall is a plugin InstallAll installed.
+type completedInstall struct {
+	Source        string   `json:"source"`
+	BinaryPath    string   `json:"binary_path"`
+	Checksum      string   `json:"checksum"`
+	OtherBinaries []string `json:"other_binaries,omitempty"`
+	// Release holds the version and the dependencies of the installed
+	// release, so that they are installed on resume too.
+	Release Release `json:"release"`
+}
+
+// readInstallState reads the state file in path, a missing file is an empty
+// state.
+func readInstallState(path string) (*installState, error) {
+	state := &installState{}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install state: %w", err)
+	}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("invalid install state %q: %w", path, err)
+	}
+	return state, nil
+}
+
+// write replaces the state file in path atomically, so that an interrupted
+// write does not lose what was already installed.
+func (s *installState) write(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// record adds install of the plugin source to the state, with the checksum
+// stored by the first of checksummers that has one.
+func (s *installState) record(source string, install *Installation, checksummers []Checksummer) error {
+	checksum, err := storedChecksum(install.BinaryPath, checksummers)
+	if err != nil {
+		return err
+	}
+	s.Completed = append(s.Completed, completedInstall{
+		Source:        source,
+		BinaryPath:    install.BinaryPath,
+		Checksum:      checksum,
+		OtherBinaries: install.OtherBinaries,
+		Release: Release{
+			Version:      install.Version,
+			Dependencies: install.Dependencies,
+		},
+	})
+	return nil
+}
+
+// storedChecksum returns the checksum stored next to binaryPath by the first
+// of checksummers that has one, prefixed with its algorithm, ex:
+// "sha512:cf83e1357eefb8bd...".
+func storedChecksum(binaryPath string, checksummers []Checksummer) (string, error) {
+	for _, checksummer := range checksummers {
+		sum, err := checksummer.GetCacheChecksumOfFile(LongPath(filepath.FromSlash(binaryPath)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return checksummer.Type + ":" + hex.EncodeToString(sum), nil
+	}
+	return "", fmt.Errorf("%w for %s", ErrNoStoredChecksum, binaryPath)
+}
+
+// resume returns the installation of req recorded in the state, once its
+// binaries were verified to be the ones that were installed. nil is
+// returned when req has to be installed.
+func (s *installState) resume(req *Requirement, opts InstallOptions) *Installation {
+	source := req.Identifier.String()
+	for _, completed := range s.Completed {
+		if completed.Source != source {
+			continue
+		}
+		if err := completed.verify(req, opts); err != nil {
+			log.Printf("[INFO] installing %s again: %s", source, err)
+			return nil
+		}
+		return &Installation{
+			BinaryPath:    completed.BinaryPath,
+			Version:       completed.Release.Version,
+			Dependencies:  completed.Release.Dependencies,
+			OtherBinaries: completed.OtherBinaries,
+		}
+	}
+	return nil
+}
+
+// verify returns nil when the recorded installation still satisfies req and
+// its binaries were not modified since.
+func (c completedInstall) verify(req *Requirement, opts InstallOptions) error {
+	v, err := version.NewVersion(c.Release.Version)
+	if err != nil {
+		return fmt.Errorf("invalid recorded version %q: %s", c.Release.Version, err)
+	}
+	if !req.AcceptsVersion(v) {
+		return fmt.Errorf("recorded version %s does not match %s", c.Release.Version, req.constraintsString())
+	}
+
+	checksummers := req.checksummers(opts.BinaryInstallationOptions)
+	algorithm, sum, _ := strings.Cut(c.Checksum, ":")
+	var checksummer *Checksummer
+	for i := range checksummers {
+		if checksummers[i].Type == algorithm {
+			checksummer = &checksummers[i]
+			break
+		}
+	}
+	if checksummer == nil {
+		return fmt.Errorf("unsupported recorded checksum %q", c.Checksum)
+	}
+	if err := checksummer.VerifyFile(filepath.FromSlash(c.BinaryPath), sum); err != nil {
+		return err
+	}
+	for _, other := range c.OtherBinaries {
+		if err := verifyInstallation(filepath.FromSlash(other), checksummers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// zipCountingGetter records the plugins whose zip was downloaded.
+type zipCountingGetter struct {
+	Getter
+	Zips []string
+}
+
+func (g *zipCountingGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	if what == "zip" {
+		g.Zips = append(g.Zips, opts.PluginRequirement.Identifier.Type)
+	}
+	return g.Getter.Get(what, opts)
+}
+
+func TestRequirements_InstallAll_resume(t *testing.T) {
+	tests := []struct {
+		name string
+		// interrupt runs between the interrupted install and the restart.
+		interrupt func(t *testing.T, pluginDir string)
+		// wantZips are the zips downloaded after the restart.
+		wantZips []string
+	}{
+		{
+			name:     "restart",
+			wantZips: []string{"docker"},
+		},
+		{
+			name: "modified-since",
+			interrupt: func(t *testing.T, pluginDir string) {
+				binary := filepath.Join(pluginDir, "github.com", "hashicorp", "ansible", "packer-plugin-ansible_v1.0.0_x5.0_linux_amd64")
+				if err := os.WriteFile(binary, []byte(elfHeader+"tampered"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantZips: []string{"ansible", "docker"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			statePath := filepath.Join(t.TempDir(), "install-state.json")
+			reqs := Requirements{
+				mustRequirement(t, "github.com/hashicorp/amazon", ""),
+				mustRequirement(t, "github.com/hashicorp/docker", ""),
+			}
+			getters := func(dockerReleased bool) *zipCountingGetter {
+				docker := &mockPluginGetter{Releases: []Release{{Version: "v1.0.0"}}}
+				if dockerReleased {
+					docker = singleReleaseGetter("docker")
+				}
+				return &zipCountingGetter{Getter: multiPluginGetter{
+					"github.com/hashicorp/amazon": singleReleaseGetter("amazon",
+						mustRequirement(t, "github.com/hashicorp/ansible", "")),
+					"github.com/hashicorp/ansible": singleReleaseGetter("ansible"),
+					"github.com/hashicorp/docker":  docker,
+				}}
+			}
+
+			// The batch stops at docker, which has no checksum file yet.
+			getter := getters(false)
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.StatePath = statePath
+			if _, err := reqs.InstallAll(opts); err == nil {
+				t.Fatalf("expected the docker install to fail")
+			}
+			if _, err := os.Stat(statePath); err != nil {
+				t.Fatalf("expected the state to be kept: %v", err)
+			}
+			if tt.interrupt != nil {
+				tt.interrupt(t, pluginDir)
+			}
+
+			getter = getters(true)
+			opts = dependenciesInstallOptions(getter, pluginDir)
+			opts.StatePath = statePath
+			installs, err := reqs.InstallAll(opts)
+			if err != nil {
+				t.Fatalf("InstallAll: %v", err)
+			}
+
+			sort.Strings(getter.Zips)
+			if diff := cmp.Diff(tt.wantZips, getter.Zips); diff != "" {
+				t.Errorf("unexpected zips downloaded after the restart: %s", diff)
+			}
+			var got []string
+			for _, install := range installs {
+				got = append(got, filepath.Base(install.BinaryPath)+" "+install.Version)
+			}
+			want := []string{
+				"packer-plugin-amazon_v1.0.0_x5.0_linux_amd64 v1.0.0",
+				"packer-plugin-ansible_v1.0.0_x5.0_linux_amd64 v1.0.0",
+				"packer-plugin-docker_v1.0.0_x5.0_linux_amd64 v1.0.0",
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected installs: %s", diff)
+			}
+			if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+				t.Errorf("expected the state to be removed once the batch completed, stat returned %v", err)
+			}
+		})
+	}
+}
+
+func TestRequirements_InstallAll_resumeSHA512(t *testing.T) {
+	// sha512Getter returns a getter of a v1.0.0 amazon release that only
+	// publishes sha512 checksums.
+	sha512Getter := func() *mockPluginGetter {
+		amazon := singleReleaseGetter("amazon")
+		binary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+		zip := &bytes.Buffer{}
+		if _, err := io.Copy(zip, zipFile(map[string]string{binary: elfHeader + "amazon"})); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha512.Sum512(zip.Bytes())
+		amazon.ChecksumFileEntries["1.0.0"][0].Checksum = hex.EncodeToString(sum[:])
+		amazon.Zips["github.com/hashicorp/packer-plugin-amazon/"+binary+".zip"] = io.NopCloser(zip)
+		return amazon
+	}
+	getters := func(dockerReleased bool) *zipCountingGetter {
+		docker := &mockPluginGetter{Releases: []Release{{Version: "v1.0.0"}}}
+		if dockerReleased {
+			docker = singleReleaseGetter("docker")
+		}
+		return &zipCountingGetter{Getter: multiPluginGetter{
+			"github.com/hashicorp/amazon": sha512Getter(),
+			"github.com/hashicorp/docker": docker,
+		}}
+	}
+
+	pluginDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "install-state.json")
+	amazonReq := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	amazonReq.Checksummers = []Checksummer{{Type: "sha512", Hash: sha512.New()}}
+	reqs := Requirements{amazonReq, mustRequirement(t, "github.com/hashicorp/docker", "")}
+
+	opts := dependenciesInstallOptions(getters(false), pluginDir)
+	opts.StatePath = statePath
+	if _, err := reqs.InstallAll(opts); err == nil {
+		t.Fatalf("expected the docker install to fail")
+	}
+
+	getter := getters(true)
+	progress := &bytes.Buffer{}
+	opts = dependenciesInstallOptions(getter, pluginDir)
+	opts.StatePath = statePath
+	opts.Progress = &JSONLinesProgress{W: progress}
+	if _, err := reqs.InstallAll(opts); err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+	if diff := cmp.Diff([]string{"docker"}, getter.Zips); diff != "" {
+		t.Errorf("unexpected zips downloaded after the restart: %s", diff)
+	}
+	if !strings.Contains(progress.String(), `"source":"github.com/hashicorp/amazon","reason":"resumed"`) {
+		t.Errorf("expected amazon to be resumed with its sha512 checksum, got %s", progress)
+	}
+}
+
+func TestRequirements_InstallAll_invalidState(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "install-state.json")
+	if err := os.WriteFile(statePath, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := dependenciesInstallOptions(singleReleaseGetter("amazon"), t.TempDir())
+	opts.StatePath = statePath
+	if _, err := (Requirements{mustRequirement(t, "github.com/hashicorp/amazon", "")}).InstallAll(opts); err == nil {
+		t.Fatalf("expected an error for an invalid state file")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// InstalledVersion is a binary of a plugin, with the checksum stored next to
+// it when it was installed.
+type InstalledVersion struct {
+	Version    string
+	BinaryPath string
+	// Checksum is the stored checksum, ex: "sha256:9f86d081884c7d65...",
+	// empty when it could not be read.
+	Checksum string
+	// Err is why Checksum could not be read. It wraps ErrNoStoredChecksum
+	// when the binary has no checksum file.
+	Err error
+}
+
+// ListInstalledVersions lists every binary of pr installed for the platform
+// of opts, with its stored checksum, for audit. Unlike ListInstallations,
+// binaries are not run nor checksummed: a version installed in several
+// directories, or for several protocol versions, is listed once per binary,
+// and binaries without a valid checksum file are listed too.
+//
+// Binaries are sorted by version, then by path.
+func (pr Requirement) ListInstalledVersions(opts ListInstallationsOptions) ([]InstalledVersion, error) {
+	var res []InstalledVersion
+	for _, dir := range append([]string{opts.PluginDirectory}, opts.FromFolders...) {
+		paths, err := pr.globInstallations(dir, opts)
+		if err != nil {
+			return nil, fmt.Errorf("ListInstalledVersions: %q failed to list binaries in folder: %v", pr.Identifier.String(), err)
+		}
+		for _, path := range paths {
+			v, err := pr.installedVersion(path, opts)
+			if err != nil {
+				log.Printf("[TRACE] %s, ignoring", err)
+				continue
+			}
+			if v.Prerelease() != "" && opts.ReleasesOnly {
+				continue
+			}
+			if !pr.AcceptsVersion(v.Core()) {
+				continue
+			}
+			installed := InstalledVersion{
+				Version:    "v" + v.String(),
+				BinaryPath: path,
+			}
+			installed.Checksum, installed.Err = (&Installation{BinaryPath: path}).StoredChecksum()
+			res = append(res, installed)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		vi, vj := version.Must(version.NewVersion(res[i].Version)), version.Must(version.NewVersion(res[j].Version))
+		if !vi.Equal(vj) {
+			return vi.LessThan(vj)
+		}
+		return res[i].BinaryPath < res[j].BinaryPath
+	})
+	return res, nil
+}
+
+// installedVersion returns the version of the binary in path, as told by its
+// name, ex: packer-plugin-amazon_v1.2.3_x5.0_darwin_amd64.
+func (pr Requirement) installedVersion(path string, opts ListInstallationsOptions) (*version.Version, error) {
+	versionsStr := trimFilename(filepath.Base(path), pr.FilenamePrefix(), opts.FilenameSuffix(), opts.FilenameCase.insensitive())
+	if pr.Identifier == nil {
+		if idx := strings.Index(versionsStr, "_"); idx > 0 {
+			versionsStr = versionsStr[idx+1:]
+		}
+	}
+	versionStr, _, _ := strings.Cut(versionsStr, "_")
+	if pluginVersionRegex.FindStringSubmatch(versionStr) == nil {
+		return nil, fmt.Errorf("%q has no valid version in its name", path)
+	}
+	return version.NewVersion(versionStr)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRequirement_ListInstalledVersions(t *testing.T) {
+	pluginDir, otherDir := t.TempDir(), t.TempDir()
+	writeBinary := func(dir, version string, withChecksum bool) (string, string) {
+		path := filepath.Join(dir, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_"+version+"_x5.0_linux_amd64")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(elfHeader+version), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if !withChecksum {
+			return path, ""
+		}
+		sum := fmt.Sprintf("%x", sha256.Sum256([]byte(elfHeader+version)))
+		if err := os.WriteFile(path+"_SHA256SUM", []byte(sum), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path, "sha256:" + sum
+	}
+	v100, v100Sum := writeBinary(pluginDir, "v1.0.0", true)
+	v110, _ := writeBinary(pluginDir, "v1.1.0", false)
+	otherV110, otherV110Sum := writeBinary(otherDir, "v1.1.0", true)
+	v200, v200Sum := writeBinary(pluginDir, "v2.0.0", true)
+
+	opts := ListInstallationsOptions{
+		PluginDirectory: pluginDir,
+		FromFolders:     []string{otherDir},
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "amd64",
+		},
+	}
+	errorsAreNoStoredChecksum := cmp.Comparer(func(x, y error) bool {
+		return errors.Is(x, ErrNoStoredChecksum) == errors.Is(y, ErrNoStoredChecksum)
+	})
+
+	tests := []struct {
+		name        string
+		constraints string
+		want        []InstalledVersion
+	}{
+		{"all", "", []InstalledVersion{
+			{Version: "v1.0.0", BinaryPath: v100, Checksum: v100Sum},
+			{Version: "v1.1.0", BinaryPath: v110, Err: ErrNoStoredChecksum},
+			{Version: "v1.1.0", BinaryPath: otherV110, Checksum: otherV110Sum},
+			{Version: "v2.0.0", BinaryPath: v200, Checksum: v200Sum},
+		}},
+		{"constrained", "< 2.0.0", []InstalledVersion{
+			{Version: "v1.0.0", BinaryPath: v100, Checksum: v100Sum},
+			{Version: "v1.1.0", BinaryPath: v110, Err: ErrNoStoredChecksum},
+			{Version: "v1.1.0", BinaryPath: otherV110, Checksum: otherV110Sum},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mustRequirement(t, "github.com/hashicorp/amazon", tt.constraints).ListInstalledVersions(opts)
+			if err != nil {
+				t.Fatalf("ListInstalledVersions: %v", err)
+			}
+			// otherDir and pluginDir are not sorted the same way on every
+			// run.
+			sortByVersionAndDir := cmpopts.SortSlices(func(a, b InstalledVersion) bool {
+				if a.Version != b.Version {
+					return a.Version < b.Version
+				}
+				return a.Checksum < b.Checksum
+			})
+			if diff := cmp.Diff(tt.want, got, errorsAreNoStoredChecksum, sortByVersionAndDir); diff != "" {
+				t.Errorf("unexpected versions: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package ipfs gets plugin zips from IPFS, by the CID derived from their
+// sha256 checksum.
+package ipfs
+
+import (
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const (
+	cidVersion = 0x01
+	rawCodec   = 0x55
+	sha256Code = 0x12
+	sha256Size = 0x20
+)
+
+var cidEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// defaultClient is the Client of the Gateways that do not set one. Gateways
+// can look for content no node provides for a long time, so requests that are
+// not answered within 30s give up, and downloads time out after 10 minutes.
+var defaultClient = &http.Client{
+	Timeout:   10 * time.Minute,
+	Transport: defaultTransport(),
+}
+
+func defaultTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return transport
+}
+
+// CID returns the CIDv1 of the raw block with the sha256 digest, base32
+// encoded like IPFS does by default, ex: "bafkrei...".
+func CID(sha256 plugingetter.Checksum) (string, error) {
+	if len(sha256) != sha256Size {
+		return "", fmt.Errorf("invalid sha256 digest of %d bytes", len(sha256))
+	}
+	raw := append([]byte{cidVersion, rawCodec, sha256Code, sha256Size}, sha256...)
+	// "b" is the multibase prefix of lowercase base32.
+	return "b" + strings.ToLower(cidEncoding.EncodeToString(raw)), nil
+}
+
+// Gateway is a ContentStore getting files from an IPFS HTTP gateway.
+//
+// Files are addressed by the CID of a single raw block, so zips must be added
+// as one, ex: with `ipfs block put --cid-codec raw --allow-big-block`.
+// Files added with `ipfs add` are chunked and have another CID.
+type Gateway struct {
+	// BaseURL is the URL of the gateway, ex: "http://127.0.0.1:8080/" for a
+	// local node. Files are got from <BaseURL>/ipfs/<CID>.
+	BaseURL string
+
+	// Client defaults to a client giving up on requests that are not
+	// answered within 30s, and on downloads taking more than 10 minutes.
+	Client *http.Client
+
+	// MaxRetries is how many times a request that failed transiently, as
+	// told by RetryPredicate or plugingetter.DefaultRetryPredicate when nil,
+	// is retried, waiting RetryBackoff, 1s when zero, before the first retry
+	// and twice as long before each following one, or as long as the
+	// Retry-After header of a 429 or 503 answer asks. Zero does not retry.
+	MaxRetries     int
+	RetryBackoff   time.Duration
+	RetryPredicate func(resp *http.Response, err error) bool
+}
+
+var _ plugingetter.ContentStore = &Gateway{}
+
+func (g *Gateway) GetContent(checksumType string, checksum plugingetter.Checksum) (io.ReadCloser, error) {
+	if checksumType != "sha256" {
+		return nil, fmt.Errorf("%w: IPFS files are addressed by their sha256 checksum, not %s", plugingetter.ErrContentNotFound, checksumType)
+	}
+	cid, err := CID(checksum)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.JoinPath(g.BaseURL, "ipfs", cid)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IPFS gateway URL %q: %w", g.BaseURL, err)
+	}
+
+	resp, err := g.client().Get(u)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", plugingetter.ErrContentNotFound, u)
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("unexpected status %s getting %s", resp.Status, u)
+}
+
+// client returns the Client of g, retrying as configured by g.
+func (g *Gateway) client() *http.Client {
+	client := g.Client
+	if client == nil {
+		client = defaultClient
+	}
+	if g.MaxRetries <= 0 {
+		return client
+	}
+	retrying := *client
+	retrying.Transport = &plugingetter.RetryTransport{
+		MaxRetries: g.MaxRetries,
+		Backoff:    g.RetryBackoff,
+		Predicate:  g.RetryPredicate,
+		Base:       client.Transport,
+	}
+	return &retrying
+}
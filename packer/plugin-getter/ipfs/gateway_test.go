@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package ipfs
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+func TestCID(t *testing.T) {
+	sum := sha256.Sum256(nil)
+	cid, err := CID(sum[:])
+	if err != nil {
+		t.Fatalf("CID: %s", err)
+	}
+	// ipfs block put --cid-codec raw < /dev/null
+	if expected := "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"; cid != expected {
+		t.Errorf("expected %s, got %s", expected, cid)
+	}
+
+	if _, err := CID([]byte("short")); err == nil {
+		t.Error("expected an error for a digest that is not a sha256 one")
+	}
+}
+
+func TestGateway_GetContent(t *testing.T) {
+	content := []byte("zip content")
+	sum := sha256.Sum256(content)
+	cid, _ := CID(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ipfs/"+cid {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	gateway := &Gateway{BaseURL: server.URL + "/"}
+
+	body, err := gateway.GetContent("sha256", sum[:])
+	if err != nil {
+		t.Fatalf("GetContent: %s", err)
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || string(got) != string(content) {
+		t.Errorf("expected %q, got %q (%v)", content, got, err)
+	}
+
+	missing := sha256.Sum256([]byte("other"))
+	if _, err := gateway.GetContent("sha256", missing[:]); !errors.Is(err, plugingetter.ErrContentNotFound) {
+		t.Errorf("expected ErrContentNotFound for a missing file, got %v", err)
+	}
+	if _, err := gateway.GetContent("sha512", sum[:]); !errors.Is(err, plugingetter.ErrContentNotFound) {
+		t.Errorf("expected ErrContentNotFound for sha512 checksums, got %v", err)
+	}
+}
+
+func TestGateway_GetContent_retries(t *testing.T) {
+	content := []byte("zip content")
+	sum := sha256.Sum256(content)
+
+	for _, maxRetries := range []int{0, 2} {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests <= 2 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write(content)
+		}))
+		gateway := &Gateway{BaseURL: server.URL, MaxRetries: maxRetries, RetryBackoff: time.Millisecond}
+
+		body, err := gateway.GetContent("sha256", sum[:])
+		server.Close()
+		if maxRetries == 0 {
+			if err == nil || requests != 1 {
+				t.Errorf("without retries, expected a single failed request, got %d requests: %v", requests, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("GetContent with %d retries: %s", maxRetries, err)
+		}
+		body.Close()
+		if requests != 3 {
+			t.Errorf("expected 3 requests, got %d", requests)
+		}
+	}
+}
+
+func TestGateway_client(t *testing.T) {
+	for _, gateway := range []*Gateway{{}, {MaxRetries: 2}} {
+		client := gateway.client()
+		if client.Timeout == 0 {
+			t.Errorf("expected the default client to time out, with %d retries", gateway.MaxRetries)
+		}
+		transport := client.Transport
+		if retrying, ok := transport.(*plugingetter.RetryTransport); ok {
+			transport = retrying.Base
+		}
+		if httpTransport, ok := transport.(*http.Transport); !ok || httpTransport.ResponseHeaderTimeout == 0 {
+			t.Errorf("expected the default transport to time out waiting for answers, got %#v", transport)
+		}
+	}
+
+	custom := &http.Client{}
+	if got := (&Gateway{Client: custom}).client(); got != custom {
+		t.Errorf("expected the client of the gateway to be used, got %#v", got)
+	}
+}
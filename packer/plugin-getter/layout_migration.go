@@ -0,0 +1,267 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Layout tells where the binaries of a plugin directory are installed.
+type Layout int
+
+const (
+	// LayoutNested installs binaries in the <hostname>/<namespace>/<type>
+	// directory of their source, which is the default.
+	LayoutNested Layout = iota
+	// LayoutFlat installs binaries directly in the plugin directory, their
+	// names prefixed by <hostname>_<namespace>_, see
+	// BinaryInstallationOptions.FlatLayout.
+	LayoutFlat
+)
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutNested:
+		return "nested"
+	case LayoutFlat:
+		return "flat"
+	}
+	return fmt.Sprintf("Layout(%d)", int(l))
+}
+
+// layoutMove is a binary to move from one layout to another.
+type layoutMove struct {
+	src, dst string
+}
+
+// MigrateLayout moves the binaries installed in dir with the from layout to
+// where the to layout installs them, along with their checksum files, the
+// checksum of the zip they were installed from and their stored signatures.
+// It returns the new paths of the binaries that were moved.
+//
+// Every binary is verified against its checksum file, ex: _SHA256SUM, before
+// being moved; the ones that do not match it, or have none, are left in place
+// and reported, as IntegrityErrors for mismatches. So are the binaries whose
+// destination already exists with another content. Other binaries are still
+// moved.
+//
+// Checksums pinned on first use, see InstallOptions.TrustOnFirstUse, are
+// moved with the binaries installed from their zip, so that the trust they
+// hold is kept. A binary whose pin conflicts with one already pinning
+// another checksum at the destination, ex: for a zip of the same name from
+// another source in a flat plugin directory, is left in place and reported.
+//
+// MigrateLayout can be run again, ex: after an interrupted migration: the
+// sidecar files of a binary are moved before it, and found at the destination
+// when they are not next to it anymore. A destination that already holds the
+// same binary is overwritten.
+func MigrateLayout(dir string, from, to Layout) ([]string, error) {
+	if from == to {
+		return nil, nil
+	}
+	moves, err := layoutMoves(dir, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs *multierror.Error
+	var moved []string
+	for _, m := range moves {
+		if err := migrateBinary(m.src, m.dst); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not move %s to the %s layout: %w", m.src, to, err))
+			continue
+		}
+		log.Printf("[INFO] moved %s to %s", m.src, m.dst)
+		moved = append(moved, m.dst)
+		if from == LayoutNested {
+			removeEmptySourceDirs(dir, filepath.Dir(m.src))
+		}
+	}
+	return moved, errs.ErrorOrNil()
+}
+
+// layoutMoves returns the binaries installed in dir with the from layout,
+// and where they go in the other layout.
+func layoutMoves(dir string, from Layout) ([]layoutMove, error) {
+	var moves []layoutMove
+	switch from {
+	case LayoutFlat:
+		entries, err := os.ReadDir(LongPath(dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() || isSidecarFile(entry.Name()) {
+				continue
+			}
+			source, binary, ok := splitFlatFilename(entry.Name())
+			if !ok {
+				continue
+			}
+			moves = append(moves, layoutMove{
+				src: filepath.Join(dir, entry.Name()),
+				dst: filepath.Join(dir, filepath.FromSlash(source), binary),
+			})
+		}
+	case LayoutNested:
+		matches, err := filepath.Glob(filepath.Join(dir, "*", "*", "*", "*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(dir, match)
+			if err != nil {
+				return nil, err
+			}
+			parts := strings.Split(rel, string(filepath.Separator))
+			hostname, namespace, pluginType, binary := parts[0], parts[1], parts[2], parts[3]
+			prefix := "packer-plugin-" + pluginType + "_"
+			if len(binary) < len(prefix) || !strings.EqualFold(binary[:len(prefix)], prefix) || isSidecarFile(binary) {
+				continue
+			}
+			if info, err := os.Lstat(LongPath(match)); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			moves = append(moves, layoutMove{
+				src: match,
+				dst: filepath.Join(dir, hostname+"_"+namespace+"_"+binary),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown plugin directory layout %s", from)
+	}
+	return moves, nil
+}
+
+// isSidecarFile tells whether fname is a file stored next to a binary, ex: a
+// checksum file, rather than a binary.
+func isSidecarFile(fname string) bool {
+	upper := strings.ToUpper(fname)
+	for _, suffix := range []string{"SUM", "SUMS", strings.ToUpper(SignatureFileExt), ".PIN"} {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateBinary verifies the binary in src and moves it, with its sidecar
+// files, to dst. The checksum files of every known checksummer are moved,
+// and the binary is verified with the first one found. The checksum file of
+// src is read from dst when it was moved there by an interrupted migration.
+func migrateBinary(src, dst string) error {
+	checksummers := knownChecksummers()
+	checksummer, expected, err := migrationChecksum(src, dst, checksummers)
+	if err != nil {
+		return err
+	}
+	if err := checksummer.ChecksumFile(expected, LongPath(src)); err != nil {
+		if errors.Is(err, ErrChecksumMismatch) {
+			return &IntegrityError{Err: fmt.Errorf("%s does not match its checksum file: %w", src, err)}
+		}
+		return err
+	}
+	if _, err := os.Stat(LongPath(dst)); err == nil {
+		if err := checksummer.ChecksumFile(expected, LongPath(dst)); err != nil {
+			return fmt.Errorf("%s already exists and differs: %w", dst, err)
+		}
+	}
+
+	srcPin, dstPin := migrationPin(src, dst)
+	if srcPin != "" {
+		if err := checkPinConflict(srcPin, dstPin); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(LongPath(filepath.Dir(dst)), 0755); err != nil {
+		return err
+	}
+	// the checksum files go first, so that they are found if src is not
+	// moved, and the pin before the zip checksum it is found with.
+	for _, sidecar := range sidecarFiles(src, checksummers) {
+		target := dst + strings.TrimPrefix(sidecar, src)
+		if sidecar == srcPin {
+			target = dstPin
+		}
+		err := os.Rename(LongPath(sidecar), LongPath(target))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(LongPath(src), LongPath(dst))
+}
+
+// migrationPin returns the file pinning the checksum of the zip src was
+// installed from, and where it goes for dst, or empty paths when src was not
+// installed from a known zip. The zip is read from dst when its checksum was
+// moved there by an interrupted migration.
+func migrationPin(src, dst string) (srcPin, dstPin string) {
+	zipFilename, _, err := readZipChecksum(src)
+	if err != nil {
+		if zipFilename, _, err = readZipChecksum(dst); err != nil {
+			return "", ""
+		}
+	}
+	return pinFilename(filepath.Dir(src), zipFilename), pinFilename(filepath.Dir(dst), zipFilename)
+}
+
+// checkPinConflict makes sure that dstPin, when it exists, pins the same
+// checksum as srcPin, so that moving srcPin does not change what is trusted.
+func checkPinConflict(srcPin, dstPin string) error {
+	pinned, err := os.ReadFile(LongPath(srcPin))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(LongPath(dstPin))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(strings.TrimSpace(string(pinned)), strings.TrimSpace(string(existing))) {
+		return &IntegrityError{Err: fmt.Errorf("%s pins another checksum than %s, remove the one that is not trusted to migrate", dstPin, srcPin)}
+	}
+	return nil
+}
+
+// migrationChecksum returns the first of checksummers with a checksum file
+// next to src, or else next to dst, and the checksum it holds.
+func migrationChecksum(src, dst string, checksummers []Checksummer) (*Checksummer, Checksum, error) {
+	for _, path := range []string{src, dst} {
+		for i := range checksummers {
+			expected, err := checksummers[i].GetCacheChecksumOfFile(LongPath(path))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			return &checksummers[i], expected, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w for %s, it cannot be verified", ErrNoStoredChecksum, src)
+}
+
+// removeEmptySourceDirs removes srcDir, a <hostname>/<namespace>/<type>
+// directory of dir, and its parents below dir, when they are empty.
+func removeEmptySourceDirs(dir, srcDir string) {
+	for i := 0; i < 3 && srcDir != dir; i++ {
+		if err := os.Remove(LongPath(srcDir)); err != nil {
+			return
+		}
+		srcDir = filepath.Dir(srcDir)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeLayoutFixture writes files, by slash separated path below dir, with
+// their content.
+func writeLayoutFixture(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readLayoutFixture returns the files below dir, by slash separated path,
+// with their content.
+func readLayoutFixture(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// installedFixture returns a binary with content, named binary below
+// prefix, with its checksum file and the other sidecar files of an install.
+func installedFixture(prefix, binary, content string) map[string]string {
+	sum := sha256.Sum256([]byte(content))
+	path := prefix + binary
+	return map[string]string{
+		path:                         content,
+		path + "_SHA256SUM":          hex.EncodeToString(sum[:]),
+		path + ZipChecksumFileExt:    "0123  " + binary + ".zip\n",
+		path + SignedChecksumFileExt: "0123  " + binary + ".zip\n",
+		path + SignedChecksumFileExt + SignatureFileExt: "signed",
+	}
+}
+
+func mergeFixtures(fixtures ...map[string]string) map[string]string {
+	res := map[string]string{}
+	for _, fixture := range fixtures {
+		for name, content := range fixture {
+			res[name] = content
+		}
+	}
+	return res
+}
+
+func TestMigrateLayout(t *testing.T) {
+	const (
+		amazon = "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"
+		docker = "packer-plugin-docker_v1.0.0_x5.0_windows_amd64.exe"
+	)
+	flat := mergeFixtures(
+		installedFixture("github.com_hashicorp_", amazon, elfHeader+"amazon"),
+		installedFixture("example.com_acme_", docker, "MZdocker"),
+		map[string]string{"README": "not a plugin"},
+	)
+	nested := mergeFixtures(
+		installedFixture("github.com/hashicorp/amazon/", amazon, elfHeader+"amazon"),
+		installedFixture("example.com/acme/docker/", docker, "MZdocker"),
+		map[string]string{"README": "not a plugin"},
+	)
+
+	dir := t.TempDir()
+	writeLayoutFixture(t, dir, flat)
+
+	moved, err := MigrateLayout(dir, LayoutFlat, LayoutNested)
+	if err != nil {
+		t.Fatalf("MigrateLayout: %v", err)
+	}
+	sort.Strings(moved)
+	wantMoved := []string{
+		filepath.Join(dir, "example.com", "acme", "docker", docker),
+		filepath.Join(dir, "github.com", "hashicorp", "amazon", amazon),
+	}
+	if !reflect.DeepEqual(moved, wantMoved) {
+		t.Errorf("expected %v to be moved, got %v", wantMoved, moved)
+	}
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, nested) {
+		t.Errorf("unexpected nested layout %v", got)
+	}
+
+	// migrating again has nothing to do.
+	moved, err = MigrateLayout(dir, LayoutFlat, LayoutNested)
+	if err != nil || len(moved) != 0 {
+		t.Errorf("expected nothing to be moved again, moved %v: %v", moved, err)
+	}
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, nested) {
+		t.Errorf("unexpected nested layout after migrating again %v", got)
+	}
+
+	moved, err = MigrateLayout(dir, LayoutNested, LayoutFlat)
+	if err != nil || len(moved) != 2 {
+		t.Fatalf("expected 2 binaries to be moved back, moved %v: %v", moved, err)
+	}
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, flat) {
+		t.Errorf("unexpected flat layout %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "github.com")); !os.IsNotExist(err) {
+		t.Errorf("expected the emptied source directories to be removed, stat returned %v", err)
+	}
+}
+
+func TestMigrateLayout_tampered(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"
+	dir := t.TempDir()
+	fixture := installedFixture("github.com_hashicorp_", binary, elfHeader+"amazon")
+	fixture["github.com_hashicorp_"+binary] = elfHeader + "tampered"
+	fixture["github.com_hashicorp_packer-plugin-docker_v1.0.0_x5.0_linux_amd64"] = elfHeader + "docker"
+	writeLayoutFixture(t, dir, fixture)
+
+	moved, err := MigrateLayout(dir, LayoutFlat, LayoutNested)
+	if len(moved) != 0 {
+		t.Errorf("expected nothing to be moved, moved %v", moved)
+	}
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) || !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected a checksum IntegrityError, got %v", err)
+	}
+	if !errors.Is(err, ErrNoStoredChecksum) {
+		t.Errorf("expected the binary without checksum file to be reported, got %v", err)
+	}
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, fixture) {
+		t.Errorf("expected the binaries to be left in place, got %v", got)
+	}
+}
+
+func TestMigrateLayout_interrupted(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"
+	dir := t.TempDir()
+	flat := installedFixture("github.com_hashicorp_", binary, elfHeader+"amazon")
+	writeLayoutFixture(t, dir, flat)
+
+	// the checksum file was moved, not the binary.
+	nestedDir := filepath.Join(dir, "github.com", "hashicorp", "amazon")
+	if err := os.MkdirAll(nestedDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "github.com_hashicorp_"+binary+"_SHA256SUM"), filepath.Join(nestedDir, binary+"_SHA256SUM")); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := MigrateLayout(dir, LayoutFlat, LayoutNested)
+	if err != nil || len(moved) != 1 {
+		t.Fatalf("expected the binary to be moved, moved %v: %v", moved, err)
+	}
+	want := installedFixture("github.com/hashicorp/amazon/", binary, elfHeader+"amazon")
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected nested layout %v", got)
+	}
+}
+
+func TestMigrateLayout_conflict(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"
+	dir := t.TempDir()
+	fixture := mergeFixtures(
+		installedFixture("github.com_hashicorp_", binary, elfHeader+"amazon"),
+		map[string]string{"github.com/hashicorp/amazon/" + binary: elfHeader + "other"},
+	)
+	writeLayoutFixture(t, dir, fixture)
+
+	moved, err := MigrateLayout(dir, LayoutFlat, LayoutNested)
+	if err == nil || len(moved) != 0 {
+		t.Errorf("expected the existing destination to be reported, moved %v: %v", moved, err)
+	}
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, fixture) {
+		t.Errorf("expected the binaries to be left in place, got %v", got)
+	}
+}
+
+func TestMigrateLayout_sha512(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"
+	content := elfHeader + "amazon"
+	sum := sha512.Sum512([]byte(content))
+	flat := installedFixture("github.com_hashicorp_", binary, content)
+	flat["github.com_hashicorp_"+binary+"_SHA512SUM"] = hex.EncodeToString(sum[:])
+	delete(flat, "github.com_hashicorp_"+binary+"_SHA256SUM")
+	dir := t.TempDir()
+	writeLayoutFixture(t, dir, flat)
+
+	moved, err := MigrateLayout(dir, LayoutFlat, LayoutNested)
+	if err != nil || len(moved) != 1 {
+		t.Fatalf("expected the binary to be moved, moved %v: %v", moved, err)
+	}
+	want := installedFixture("github.com/hashicorp/amazon/", binary, content)
+	want["github.com/hashicorp/amazon/"+binary+"_SHA512SUM"] = hex.EncodeToString(sum[:])
+	delete(want, "github.com/hashicorp/amazon/"+binary+"_SHA256SUM")
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected nested layout %v", got)
+	}
+}
+
+func TestMigrateLayout_pins(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"
+	pin := binary + ".zip_SHA256SUM.pin"
+	flat := mergeFixtures(
+		installedFixture("github.com_hashicorp_", binary, elfHeader+"amazon"),
+		map[string]string{pin: "0123"},
+	)
+	nested := mergeFixtures(
+		installedFixture("github.com/hashicorp/amazon/", binary, elfHeader+"amazon"),
+		map[string]string{"github.com/hashicorp/amazon/" + pin: "0123"},
+	)
+	dir := t.TempDir()
+	writeLayoutFixture(t, dir, flat)
+
+	if moved, err := MigrateLayout(dir, LayoutFlat, LayoutNested); err != nil || len(moved) != 1 {
+		t.Fatalf("expected the binary to be moved, moved %v: %v", moved, err)
+	}
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, nested) {
+		t.Errorf("expected the pin to be moved with the binary, got %v", got)
+	}
+
+	if moved, err := MigrateLayout(dir, LayoutNested, LayoutFlat); err != nil || len(moved) != 1 {
+		t.Fatalf("expected the binary to be moved back, moved %v: %v", moved, err)
+	}
+	if got := readLayoutFixture(t, dir); !reflect.DeepEqual(got, flat) {
+		t.Errorf("expected the pin to be moved back with the binary, got %v", got)
+	}
+}
+
+func TestMigrateLayout_pinConflict(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"
+	pin := binary + ".zip_SHA256SUM.pin"
+	// the same zip, pinned with other checksums for two sources.
+	nested := mergeFixtures(
+		installedFixture("github.com/fork/amazon/", binary, elfHeader+"fork"),
+		installedFixture("github.com/hashicorp/amazon/", binary, elfHeader+"amazon"),
+		map[string]string{
+			"github.com/fork/amazon/" + pin:      "4567",
+			"github.com/hashicorp/amazon/" + pin: "0123",
+		},
+	)
+	dir := t.TempDir()
+	writeLayoutFixture(t, dir, nested)
+
+	moved, err := MigrateLayout(dir, LayoutNested, LayoutFlat)
+	if want := []string{filepath.Join(dir, "github.com_fork_"+binary)}; !reflect.DeepEqual(moved, want) {
+		t.Errorf("expected only %v to be moved, moved %v", want, moved)
+	}
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) || !strings.Contains(err.Error(), "pins another checksum") {
+		t.Fatalf("expected the conflicting pin to be reported, got %v", err)
+	}
+	got := readLayoutFixture(t, dir)
+	if got[pin] != "4567" || got["github.com/hashicorp/amazon/"+pin] != "0123" || got["github.com/hashicorp/amazon/"+binary] == "" {
+		t.Errorf("expected the conflicting binary and pins to be left in place, got %v", got)
+	}
+}
@@ -0,0 +1,480 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package plugingetter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// installFakePlugin writes a shell script answering to `describe` like the
+// given version of the plugin would, along with its checksum file, and returns
+// its path.
+func installFakePlugin(t testing.TB, pluginDir, source, version string) string {
+	parts := strings.Split(source, "/")
+	name := parts[len(parts)-1]
+	binaryPath := filepath.Join(pluginDir, source,
+		fmt.Sprintf("packer-plugin-%s_%s_x5.0_%s_%s", name, version, runtime.GOOS, runtime.GOARCH))
+
+	script := fmt.Sprintf("#!/bin/sh\necho '{\"version\":%q,\"sdk_version\":\"0.5.2\",\"api_version\":\"x5.0\"}'\n",
+		strings.TrimPrefix(version, "v"))
+
+	if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binaryPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(script))
+	if err := os.WriteFile(binaryPath+"_SHA256SUM", []byte(hex.EncodeToString(sum[:])), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return binaryPath
+}
+
+func localListInstallationsOptions(pluginDir string) ListInstallationsOptions {
+	return ListInstallationsOptions{
+		PluginDirectory: pluginDir,
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			OS:   runtime.GOOS,
+			ARCH: runtime.GOARCH,
+			Checksummers: []Checksummer{
+				{Type: "sha256", Hash: sha256.New()},
+			},
+		},
+	}
+}
+
+func TestRequirement_ListInstallations_withFileInfo(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	modTime := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	if err := os.Chtimes(binary, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := localListInstallationsOptions(pluginDir)
+
+	installs, err := Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	if len(installs) != 1 {
+		t.Fatalf("expected one installation, got %v", installs)
+	}
+	if installs[0].Size != 0 || !installs[0].ModTime.IsZero() {
+		t.Errorf("file info should only be set when asked for, got size %d and mtime %s", installs[0].Size, installs[0].ModTime)
+	}
+
+	opts.WithFileInfo = true
+	installs, err = Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	if len(installs) != 1 {
+		t.Fatalf("expected one installation, got %v", installs)
+	}
+	if installs[0].Size != fi.Size() {
+		t.Errorf("expected size %d, got %d", fi.Size(), installs[0].Size)
+	}
+	if !installs[0].ModTime.Equal(modTime) {
+		t.Errorf("expected mtime %s, got %s", modTime, installs[0].ModTime)
+	}
+}
+
+func TestRequirement_ListInstallations_duplicatesAcrossDirectories(t *testing.T) {
+	firstDir, secondDir := t.TempDir(), t.TempDir()
+	first := installFakePlugin(t, firstDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	installFakePlugin(t, secondDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	other := installFakePlugin(t, secondDir, "github.com/hashicorp/hashicups", "v1.0.2")
+
+	opts := localListInstallationsOptions(firstDir)
+	opts.FromFolders = []string{secondDir}
+
+	installs, err := Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	want := InstallList{
+		{BinaryPath: first, Version: "v1.0.1"},
+		{BinaryPath: other, Version: "v1.0.2"},
+	}
+	if diff := cmp.Diff(want, installs); diff != "" {
+		t.Errorf("unexpected installations: %s", diff)
+	}
+
+	opts.ErrorOnDuplicates = true
+	_, err = Requirement{}.ListInstallations(opts)
+	if !errors.Is(err, ErrDuplicateInstallation) {
+		t.Fatalf("expected ErrDuplicateInstallation, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "github.com/hashicorp/hashicups v1.0.1") {
+		t.Errorf("expected the error to name the duplicate plugin, got %q", err)
+	}
+}
+
+func TestRequirement_ListInstallations_checksum(t *testing.T) {
+	pluginDir := t.TempDir()
+	v101 := installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.2")
+	// the same binary, installed for another plugin.
+	fork := installFakePlugin(t, pluginDir, "github.com/fork/hashicups", "v1.0.1")
+	zipped := installFakePlugin(t, pluginDir, "github.com/hashicorp/amazon", "v1.0.0")
+	zipChecksum := strings.Repeat("ab", sha256.Size)
+	if err := writeZipChecksum(zipped, "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip", zipChecksum); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := (&Installation{BinaryPath: v101}).StoredChecksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		req      Requirement
+		checksum string
+		want     InstallList
+		wantErr  string
+	}{
+		{
+			name:     "binary-checksum",
+			checksum: stored,
+			want: InstallList{
+				{BinaryPath: fork, Version: "v1.0.1"},
+				{BinaryPath: v101, Version: "v1.0.1"},
+			},
+		},
+		{
+			name:     "binary-checksum-without-algorithm",
+			req:      Requirement{Identifier: mustRequirement(t, "github.com/hashicorp/hashicups", "").Identifier},
+			checksum: strings.ToUpper(strings.TrimPrefix(stored, "sha256:")),
+			want:     InstallList{{BinaryPath: v101, Version: "v1.0.1"}},
+		},
+		{
+			name:     "zip-checksum",
+			checksum: "sha256:" + zipChecksum,
+			want:     InstallList{{BinaryPath: zipped, Version: "v1.0.0"}},
+		},
+		{
+			name:     "no-match",
+			checksum: strings.Repeat("0", 2*sha256.Size),
+			want:     InstallList{},
+		},
+		{
+			name:     "other-algorithm",
+			checksum: "sha512:" + strings.Repeat("0", 2*sha256.Size),
+			wantErr:  "only sha256 checksums are stored",
+		},
+		{
+			name:     "invalid",
+			checksum: "not-a-checksum",
+			wantErr:  "expected a hex encoded sha256 checksum",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := localListInstallationsOptions(pluginDir)
+			opts.Checksum = tt.checksum
+			installs, err := tt.req.ListInstallations(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListInstallations: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, installs); diff != "" {
+				t.Errorf("unexpected installations: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRequirement_ListInstallations_checksumAcrossDirectories(t *testing.T) {
+	firstDir, secondDir := t.TempDir(), t.TempDir()
+	installFakePlugin(t, firstDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	second := installFakePlugin(t, secondDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	zipChecksum := strings.Repeat("ab", sha256.Size)
+	if err := writeZipChecksum(second, "packer-plugin-hashicups_v1.0.1_x5.0_linux_amd64.zip", zipChecksum); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := localListInstallationsOptions(firstDir)
+	opts.FromFolders = []string{secondDir}
+	opts.Checksum = "sha256:" + zipChecksum
+	opts.ErrorOnDuplicates = true
+
+	// the binary of the first directory does not match, and does not hide
+	// the one of the second directory.
+	installs, err := Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	want := InstallList{{BinaryPath: second, Version: "v1.0.1"}}
+	if diff := cmp.Diff(want, installs); diff != "" {
+		t.Errorf("unexpected installations: %s", diff)
+	}
+}
+
+func TestRequirement_InstallLatest_failIfInstalled(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	pr := mustRequirement(t, "github.com/hashicorp/hashicups", ">= 1.0.0")
+	opts := InstallOptions{
+		Getters:                   []Getter{&unexpectedCallGetter{t}},
+		PluginDirectory:           pluginDir,
+		FailIfInstalled:           true,
+		BinaryInstallationOptions: localListInstallationsOptions(pluginDir).BinaryInstallationOptions,
+	}
+	opts.APIVersionMajor, opts.APIVersionMinor = "5", "0"
+
+	install, err := pr.InstallLatest(opts)
+	if !errors.Is(err, ErrAlreadyInstalled) {
+		t.Fatalf("InstallLatest: expected ErrAlreadyInstalled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), binary) {
+		t.Errorf("expected error to mention the installed binary %q, got %q", binary, err)
+	}
+	if install != nil {
+		t.Errorf("expected no installation, got %v", install)
+	}
+}
+
+func TestRequirement_InstallLatest_failIfInstalledOtherVersion(t *testing.T) {
+	pluginDir := t.TempDir()
+	installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	// v1.0.1 does not match, so the getters are asked for releases.
+	pr := mustRequirement(t, "github.com/hashicorp/hashicups", ">= 2.0.0")
+	opts := InstallOptions{
+		Getters:                   []Getter{&mockPluginGetter{}},
+		PluginDirectory:           pluginDir,
+		FailIfInstalled:           true,
+		BinaryInstallationOptions: localListInstallationsOptions(pluginDir).BinaryInstallationOptions,
+	}
+	opts.APIVersionMajor, opts.APIVersionMinor = "5", "0"
+
+	_, err := pr.InstallLatest(opts)
+	if err == nil {
+		t.Fatal("InstallLatest: expected an error, no release is available")
+	}
+	if errors.Is(err, ErrAlreadyInstalled) {
+		t.Fatalf("InstallLatest: unexpected ErrAlreadyInstalled: %v", err)
+	}
+}
+
+// renameWithChecksum moves binary and its checksum file to newName, in the
+// same directory, and returns the new binary path.
+func renameWithChecksum(t *testing.T, binary, newName string) string {
+	renamed := filepath.Join(filepath.Dir(binary), newName)
+	if err := os.Rename(binary, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(binary+"_SHA256SUM", renamed+"_SHA256SUM"); err != nil {
+		t.Fatal(err)
+	}
+	return renamed
+}
+
+func TestRequirement_ListInstallations_filenameCase(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	upper := renameWithChecksum(t, binary, strings.ToUpper(filepath.Base(binary)))
+
+	for _, req := range []*Requirement{{}, mustRequirement(t, "github.com/hashicorp/hashicups", "")} {
+		opts := localListInstallationsOptions(pluginDir)
+
+		opts.FilenameCase = FilenameCaseInsensitive
+		installs, err := req.ListInstallations(opts)
+		if err != nil {
+			t.Fatalf("ListInstallations: %v", err)
+		}
+		want := InstallList{{BinaryPath: upper, Version: "v1.0.1"}}
+		if diff := cmp.Diff(want, installs); diff != "" {
+			t.Errorf("unexpected case-insensitive installations of %v: %s", req.Identifier, diff)
+		}
+
+		opts.FilenameCase = FilenameCaseSensitive
+		installs, err = req.ListInstallations(opts)
+		if err != nil {
+			t.Fatalf("ListInstallations: %v", err)
+		}
+		if len(installs) != 0 {
+			t.Errorf("expected no case-sensitive installation of %v, got %v", req.Identifier, installs)
+		}
+	}
+}
+
+func TestRequirement_ListInstallations_filenameCaseVariants(t *testing.T) {
+	pluginDir := t.TempDir()
+	binary := installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	variant := filepath.Join(filepath.Dir(binary), "Packer-Plugin-HashiCups"+strings.TrimPrefix(filepath.Base(binary), "packer-plugin-hashicups"))
+	if err := os.Link(binary, variant); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(binary+"_SHA256SUM", variant+"_SHA256SUM"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := localListInstallationsOptions(pluginDir)
+	opts.FilenameCase = FilenameCaseInsensitive
+	installs, err := Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	if len(installs) != 1 {
+		t.Errorf("expected case variants to be listed once, got %v", installs)
+	}
+
+	opts.FilenameCase = FilenameCaseSensitive
+	installs, err = Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	want := InstallList{{BinaryPath: binary, Version: "v1.0.1"}}
+	if diff := cmp.Diff(want, installs); diff != "" {
+		t.Errorf("unexpected case-sensitive installations: %s", diff)
+	}
+}
+
+func TestRequirement_ListInstallations_filenameCaseDuplicates(t *testing.T) {
+	firstDir, secondDir := t.TempDir(), t.TempDir()
+	installFakePlugin(t, firstDir, "github.com/hashicorp/hashicups", "v1.0.1")
+	installFakePlugin(t, secondDir, "github.com/HashiCorp/hashicups", "v1.0.1")
+
+	opts := localListInstallationsOptions(firstDir)
+	opts.FromFolders = []string{secondDir}
+	opts.ErrorOnDuplicates = true
+
+	opts.FilenameCase = FilenameCaseInsensitive
+	_, err := Requirement{}.ListInstallations(opts)
+	if !errors.Is(err, ErrDuplicateInstallation) {
+		t.Fatalf("expected ErrDuplicateInstallation, got %v", err)
+	}
+
+	opts.FilenameCase = FilenameCaseSensitive
+	installs, err := Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	if len(installs) != 2 {
+		t.Errorf("expected both installations when case-sensitive, got %v", installs)
+	}
+}
+
+// padFakePlugin grows binary to size bytes with a trailing shell comment,
+// updating its checksum file.
+func padFakePlugin(t testing.TB, binary string, size int) {
+	script, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script = append(script, '#')
+	script = append(script, strings.Repeat("x", size-len(script))...)
+	if err := os.WriteFile(binary, script, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(script)
+	if err := os.WriteFile(binary+"_SHA256SUM", []byte(hex.EncodeToString(sum[:])), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequirement_ListInstallations_workers(t *testing.T) {
+	firstDir, secondDir := t.TempDir(), t.TempDir()
+	for _, source := range []string{"github.com/hashicorp/hashicups", "github.com/hashicorp/amazon", "github.com/sylviamoss/comment"} {
+		for _, version := range []string{"v1.0.0", "v1.0.1", "v1.2.3", "v2.0.0"} {
+			installFakePlugin(t, firstDir, source, version)
+		}
+		installFakePlugin(t, secondDir, source, "v1.0.1")
+		installFakePlugin(t, secondDir, source, "v3.0.0")
+	}
+	corrupt := installFakePlugin(t, firstDir, "github.com/hashicorp/amazon", "v4.0.0")
+	if err := os.WriteFile(corrupt+"_SHA256SUM", []byte(strings.Repeat("0", 64)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := localListInstallationsOptions(firstDir)
+	opts.FromFolders = []string{secondDir}
+
+	opts.Workers = 1
+	serial, err := Requirement{}.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %v", err)
+	}
+	if len(serial) != 15 {
+		t.Errorf("expected 15 installations, got %d: %v", len(serial), serial)
+	}
+
+	for _, workers := range []int{0, 2, 8, 64} {
+		opts.Workers = workers
+		parallel, err := Requirement{}.ListInstallations(opts)
+		if err != nil {
+			t.Fatalf("ListInstallations: %v", err)
+		}
+		if diff := cmp.Diff(serial, parallel); diff != "" {
+			t.Errorf("%d workers listed other installations than one: %s", workers, diff)
+		}
+	}
+}
+
+func TestRequirement_ListInstallationsContext_canceled(t *testing.T) {
+	pluginDir := t.TempDir()
+	installFakePlugin(t, pluginDir, "github.com/hashicorp/hashicups", "v1.0.1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	installs, err := Requirement{}.ListInstallationsContext(ctx, localListInstallationsOptions(pluginDir))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if installs != nil {
+		t.Errorf("expected no installations, got %v", installs)
+	}
+}
+
+func BenchmarkRequirement_ListInstallations(b *testing.B) {
+	pluginDir := b.TempDir()
+	for i := 0; i < 16; i++ {
+		binary := installFakePlugin(b, pluginDir, "github.com/hashicorp/hashicups", fmt.Sprintf("v1.0.%d", i))
+		padFakePlugin(b, binary, 8<<20)
+	}
+
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			opts := localListInstallationsOptions(pluginDir)
+			opts.Workers = workers
+			for i := 0; i < b.N; i++ {
+				installs, err := Requirement{}.ListInstallations(opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(installs) != 16 {
+					b.Fatalf("expected 16 installations, got %d", len(installs))
+				}
+			}
+		})
+	}
+}
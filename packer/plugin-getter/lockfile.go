@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Lockfile pins the plugins of a plugin directory, ex:
+//
+//	{"plugins": [{
+//		"source": "github.com/hashicorp/amazon",
+//		"version": "v1.2.3",
+//		"checksum": "sha256:9f86d081884c7d65..."
+//	}]}
+type Lockfile struct {
+	Plugins []LockedPlugin `json:"plugins"`
+}
+
+// LockedPlugin is a plugin version pinned by a Lockfile, with the checksum
+// of its binary for the current OS and architecture.
+type LockedPlugin struct {
+	Source   string `json:"source"`
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"`
+}
+
+// ReadLockfile reads the lockfile in path.
+func ReadLockfile(path string) (Lockfile, error) {
+	var lock Lockfile
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return lock, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return lock, fmt.Errorf("invalid lockfile %q: %w", path, err)
+	}
+	return lock, nil
+}
+
+// LockfileDriftError is returned by VerifyLockfile when plugin directories
+// do not match a lockfile. Plugins are listed as "<source> <version>".
+type LockfileDriftError struct {
+	// Missing are the locked plugins that are not installed.
+	Missing []string
+	// Extra are the installed plugins that are not locked.
+	Extra []string
+	// Changed are the locked plugins whose installed binary does not have
+	// the locked checksum.
+	Changed []string
+	// Incompatible are the locked plugins that are only installed for a
+	// protocol version this version of Packer cannot communicate with.
+	Incompatible []string
+}
+
+func (e *LockfileDriftError) Error() string {
+	var drifts []string
+	for _, d := range []struct {
+		what    string
+		plugins []string
+	}{{"missing", e.Missing}, {"not locked", e.Extra}, {"changed", e.Changed}, {"incompatible", e.Incompatible}} {
+		if len(d.plugins) > 0 {
+			drifts = append(drifts, fmt.Sprintf("%s: %s", d.what, strings.Join(d.plugins, ", ")))
+		}
+	}
+	return "plugin directory does not match the lockfile; " + strings.Join(drifts, "; ")
+}
+
+// VerifyLockfile makes sure the plugins installed in opts.PluginDirectory and
+// opts.FromFolders for the platform and protocol version of opts are exactly
+// the ones of lock, at the locked version and with the locked checksum. Like
+// for ListInstallations, the binary of the first directory takes precedence.
+// Locked versions are compared once normalized, so "1.2.3" locks v1.2.3. A
+// *LockfileDriftError lists the differences.
+func VerifyLockfile(lock Lockfile, opts ListInstallationsOptions) error {
+	installed := map[string]string{}
+	incompatible := map[string]bool{}
+	for _, dir := range append([]string{opts.PluginDirectory}, opts.FromFolders...) {
+		dirOpts := opts
+		dirOpts.PluginDirectory = dir
+		if err := lockedInstallations(dirOpts, installed, incompatible); err != nil {
+			return err
+		}
+	}
+
+	drift := &LockfileDriftError{}
+	locked := map[string]bool{}
+	for _, plugin := range lock.Plugins {
+		key := plugin.Source + " " + plugin.Version
+		if v, err := version.NewVersion(plugin.Version); err == nil {
+			key = plugin.Source + " v" + v.String()
+		}
+		locked[key] = true
+		binaryPath, found := installed[key]
+		switch {
+		case !found && incompatible[key]:
+			drift.Incompatible = append(drift.Incompatible, key)
+			continue
+		case !found:
+			drift.Missing = append(drift.Missing, key)
+			continue
+		}
+		if err := verifyLockedChecksum(binaryPath, plugin.Checksum); err != nil {
+			log.Printf("[TRACE] %s: %s", binaryPath, err)
+			drift.Changed = append(drift.Changed, key)
+		}
+	}
+	for key := range installed {
+		if !locked[key] {
+			drift.Extra = append(drift.Extra, key)
+		}
+	}
+	sort.Strings(drift.Extra)
+
+	if len(drift.Missing) == 0 && len(drift.Extra) == 0 && len(drift.Changed) == 0 && len(drift.Incompatible) == 0 {
+		return nil
+	}
+	return drift
+}
+
+// lockedInstallations adds the binaries installed in opts.PluginDirectory for
+// the platform of opts to installed, by "<source> <version>", unless one was
+// already found. Binaries of another protocol version are added to
+// incompatible instead.
+func lockedInstallations(opts ListInstallationsOptions, installed map[string]string, incompatible map[string]bool) error {
+	err := filepath.WalkDir(opts.PluginDirectory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == opts.PluginDirectory {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() || !strings.HasPrefix(name, "packer-plugin-") || !strings.HasSuffix(name, opts.FilenameSuffix()) {
+			return nil
+		}
+		identifier, v, protocolVersion, err := parseInstallationPath(opts, path)
+		if err != nil {
+			log.Printf("[TRACE] ignoring %q: %s", path, err)
+			return nil
+		}
+		key := identifier.String() + " v" + v.String()
+		if _, found := installed[key]; found {
+			return nil
+		}
+		if err := opts.CheckProtocolVersion(protocolVersion); err != nil {
+			log.Printf("[TRACE] ignoring %q: %s", path, err)
+			incompatible[key] = true
+			return nil
+		}
+		installed[key] = path
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list the plugins of %q: %w", opts.PluginDirectory, err)
+	}
+	return nil
+}
+
+// verifyLockedChecksum checks that the binary in path has checksum, ex:
+// "sha256:9f86d081884c7d65...".
+func verifyLockedChecksum(path, checksum string) error {
+	checksummer := Checksummer{Type: "sha256", Hash: sha256.New()}
+	sum, found := strings.CutPrefix(checksum, checksummer.Type+":")
+	if !found {
+		return fmt.Errorf("unsupported checksum %q, expected something like %s:9f86d081884c7d65...", checksum, checksummer.Type)
+	}
+	return checksummer.VerifyFile(path, sum)
+}
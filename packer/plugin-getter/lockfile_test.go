@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeLockedPlugin writes a binary of the amazon plugin for the current
+// platform in dir and returns its lockfile entry.
+func writeLockedPlugin(t *testing.T, dir, version, content string) LockedPlugin {
+	return writeLockedPluginProtocol(t, dir, version, "x5.0", content)
+}
+
+// writeLockedPluginProtocol is writeLockedPlugin for the protocol version.
+func writeLockedPluginProtocol(t *testing.T, dir, version, protocolVersion, content string) LockedPlugin {
+	name := fmt.Sprintf("packer-plugin-amazon_%s_%s_%s_%s", version, protocolVersion, runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(dir, "github.com", "hashicorp", "amazon", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return LockedPlugin{
+		Source:   "github.com/hashicorp/amazon",
+		Version:  version,
+		Checksum: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content))),
+	}
+}
+
+func TestVerifyLockfile(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, dir, fromFolder string) Lockfile
+		wantDrift *LockfileDriftError
+	}{
+		{
+			name: "match",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				return Lockfile{Plugins: []LockedPlugin{writeLockedPlugin(t, dir, "v1.2.3", "amazon")}}
+			},
+		},
+		{
+			name: "missing",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				locked := writeLockedPlugin(t, dir, "v1.2.3", "amazon")
+				return Lockfile{Plugins: []LockedPlugin{locked, {Source: "github.com/hashicorp/docker", Version: "v1.0.0", Checksum: locked.Checksum}}}
+			},
+			wantDrift: &LockfileDriftError{Missing: []string{"github.com/hashicorp/docker v1.0.0"}},
+		},
+		{
+			name: "checksum-drift",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				locked := writeLockedPlugin(t, dir, "v1.2.3", "amazon")
+				writeLockedPlugin(t, dir, "v1.2.3", "tampered amazon")
+				return Lockfile{Plugins: []LockedPlugin{locked}}
+			},
+			wantDrift: &LockfileDriftError{Changed: []string{"github.com/hashicorp/amazon v1.2.3"}},
+		},
+		{
+			name: "extra",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				locked := writeLockedPlugin(t, dir, "v1.2.3", "amazon")
+				writeLockedPlugin(t, dir, "v1.3.0", "newer amazon")
+				return Lockfile{Plugins: []LockedPlugin{locked}}
+			},
+			wantDrift: &LockfileDriftError{Extra: []string{"github.com/hashicorp/amazon v1.3.0"}},
+		},
+		{
+			name: "unprefixed-version",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				locked := writeLockedPlugin(t, dir, "v1.2.3", "amazon")
+				locked.Version = "1.2.3"
+				return Lockfile{Plugins: []LockedPlugin{locked}}
+			},
+		},
+		{
+			name: "from-folders",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				locked := writeLockedPlugin(t, fromFolder, "v1.2.3", "amazon")
+				writeLockedPlugin(t, fromFolder, "v1.3.0", "newer amazon")
+				return Lockfile{Plugins: []LockedPlugin{locked}}
+			},
+			wantDrift: &LockfileDriftError{Extra: []string{"github.com/hashicorp/amazon v1.3.0"}},
+		},
+		{
+			name: "plugin-directory-takes-precedence",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				locked := writeLockedPlugin(t, fromFolder, "v1.2.3", "amazon")
+				writeLockedPlugin(t, dir, "v1.2.3", "tampered amazon")
+				return Lockfile{Plugins: []LockedPlugin{locked}}
+			},
+			wantDrift: &LockfileDriftError{Changed: []string{"github.com/hashicorp/amazon v1.2.3"}},
+		},
+		{
+			name: "incompatible-protocol-version",
+			setup: func(t *testing.T, dir, fromFolder string) Lockfile {
+				locked := writeLockedPluginProtocol(t, dir, "v1.2.3", "x6.0", "amazon")
+				return Lockfile{Plugins: []LockedPlugin{locked}}
+			},
+			wantDrift: &LockfileDriftError{Incompatible: []string{"github.com/hashicorp/amazon v1.2.3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, fromFolder := t.TempDir(), t.TempDir()
+			opts := ListInstallationsOptions{
+				PluginDirectory: dir,
+				FromFolders:     []string{fromFolder},
+				BinaryInstallationOptions: BinaryInstallationOptions{
+					OS:              runtime.GOOS,
+					ARCH:            runtime.GOARCH,
+					APIVersionMajor: "5",
+					APIVersionMinor: "0",
+				},
+			}
+			if runtime.GOOS == "windows" {
+				opts.Ext = ".exe"
+			}
+			err := VerifyLockfile(tt.setup(t, dir, fromFolder), opts)
+			if tt.wantDrift == nil {
+				if err != nil {
+					t.Fatalf("VerifyLockfile: %v", err)
+				}
+				return
+			}
+			var drift *LockfileDriftError
+			if !errors.As(err, &drift) {
+				t.Fatalf("expected a *LockfileDriftError, got %v", err)
+			}
+			if diff := cmp.Diff(tt.wantDrift, drift); diff != "" {
+				t.Errorf("unexpected drift: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReadLockfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugins.lock.json")
+	content := `{"plugins": [{"source": "github.com/hashicorp/amazon", "version": "v1.2.3", "checksum": "sha256:abcd"}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("ReadLockfile: %v", err)
+	}
+	want := Lockfile{Plugins: []LockedPlugin{{Source: "github.com/hashicorp/amazon", Version: "v1.2.3", Checksum: "sha256:abcd"}}}
+	if diff := cmp.Diff(want, lock); diff != "" {
+		t.Errorf("unexpected lockfile: %s", diff)
+	}
+}
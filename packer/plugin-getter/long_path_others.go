@@ -0,0 +1,12 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package plugingetter
+
+// LongPath returns path as is: only Windows limits the length of paths to
+// MAX_PATH characters.
+func LongPath(path string) string {
+	return path
+}
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build windows
+
+package plugingetter
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	longPathPrefix    = `\\?\`
+	longUNCPathPrefix = `\\?\UNC\`
+)
+
+// LongPath returns the extended-length form of path, prefixed with \\?\, so
+// that file operations on deep plugin directories are not limited to
+// MAX_PATH characters. A UNC path, like \\server\share\plugins, becomes
+// \\?\UNC\server\share\plugins. As Windows does not resolve the prefixed
+// paths, relative paths are made absolute and slashes become backslashes.
+//
+// Paths are returned as is on other systems.
+func LongPath(path string) string {
+	if path == "" || strings.HasPrefix(path, longPathPrefix) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return longUNCPathPrefix + strings.TrimPrefix(abs, `\\`)
+	}
+	return longPathPrefix + abs
+}
@@ -0,0 +1,78 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build windows
+
+package plugingetter
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`C:\plugins`, `\\?\C:\plugins`},
+		{`C:/plugins/github.com/hashicorp/amazon`, `\\?\C:\plugins\github.com\hashicorp\amazon`},
+		{`C:\plugins\..\other`, `\\?\C:\other`},
+		{`\\server\share\plugins`, `\\?\UNC\server\share\plugins`},
+		{`\\?\C:\plugins`, `\\?\C:\plugins`},
+		{``, ``},
+	}
+	for _, tt := range tests {
+		if got := LongPath(tt.path); got != tt.want {
+			t.Errorf("LongPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRequirement_InstallLatest_longPath(t *testing.T) {
+	// well over MAX_PATH once the plugin path and binary name are added.
+	pluginDir := t.TempDir()
+	for len(pluginDir) < 300 {
+		pluginDir = filepath.Join(pluginDir, strings.Repeat("d", 50))
+	}
+
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_windows_amd64"
+	zip, checksum := zipFileWithChecksum(map[string]string{binary + ".exe": "MZ"})
+	getter := &mockPluginGetter{
+		Releases: []Release{{Version: "v1.0.0"}},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"1.0.0": {{Filename: binary + ".zip", Checksum: checksum}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip": zip,
+		},
+	}
+	opts := dependenciesInstallOptions(getter, pluginDir)
+	opts.OS, opts.ARCH, opts.Ext = "windows", "amd64", ".exe"
+
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	install, err := pr.InstallLatest(opts)
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	if install == nil || !strings.HasSuffix(install.BinaryPath, binary+".exe") {
+		t.Fatalf("expected the .exe to be installed, got %#v", install)
+	}
+
+	installed, err := pr.ListInstalledVersions(ListInstallationsOptions{
+		PluginDirectory:           pluginDir,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+	})
+	if err != nil {
+		t.Fatalf("ListInstalledVersions: %v", err)
+	}
+	if len(installed) != 1 || installed[0].Err != nil {
+		t.Fatalf("expected the installed binary to be listed with its checksum, got %#v", installed)
+	}
+	want := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary+".exe")
+	if installed[0].BinaryPath != want {
+		t.Errorf("BinaryPath = %q, want %q", installed[0].BinaryPath, want)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"archive/zip"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManifestFilename is the name of the file listing the binaries of a plugin
+// zip that ships more than one, ex:
+//
+//	{"binaries": [
+//		{"name": "packer-plugin-amazon_v1.2.3_x5.0_linux_amd64"},
+//		{"name": "packer-plugin-amazon-ssm_v1.2.3_x5.0_linux_amd64"}
+//	]}
+//
+// Zips without a manifest only have their plugin binary installed.
+const ManifestFilename = "packer-plugin-manifest.json"
+
+type zipManifest struct {
+	Binaries []struct {
+		Name string `json:"name"`
+	} `json:"binaries"`
+}
+
+// zipBinaries returns the files of zr to install: the ones listed in its
+// manifest when it has one, otherwise only mainBinary. When listed, binaries
+// must be named like plugin binaries for the platform of opts, and
+// mainBinary must be one of them.
+//
+// Without a manifest, the plugin binary is the binaryEntry file when set,
+// otherwise mainBinary or, when the zip has no such file, its only
+// executable. It is returned renamed to mainBinary.
+func zipBinaries(zr *zip.Reader, mainBinary, binaryEntry string, opts BinaryInstallationOptions) ([]*zip.File, error) {
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifestFile, found := files[ManifestFilename]
+	if !found {
+		if binaryEntry != "" {
+			if f, found := files[binaryEntry]; found {
+				return []*zip.File{renamedZipFile(f, mainBinary)}, nil
+			}
+			return nil, nil
+		}
+		if f, found := files[mainBinary]; found {
+			return []*zip.File{f}, nil
+		}
+		f, err := singleExecutable(zr, opts.OS)
+		if f == nil || err != nil {
+			return nil, err
+		}
+		return []*zip.File{renamedZipFile(f, mainBinary)}, nil
+	}
+
+	r, err := manifestFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", ManifestFilename, err)
+	}
+	defer r.Close()
+	var manifest zipManifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFilename, err)
+	}
+
+	var res []*zip.File
+	mainListed := false
+	for _, binary := range manifest.Binaries {
+		name := binary.Name
+		if strings.ContainsAny(name, `/\`) || !strings.HasPrefix(name, "packer-plugin-") || !strings.HasSuffix(name, opts.FilenameSuffix()) {
+			return nil, fmt.Errorf("%s: %q is not named like a plugin binary for %s_%s", ManifestFilename, name, opts.OS, opts.ARCH)
+		}
+		f, found := files[name]
+		if !found {
+			return nil, fmt.Errorf("%s: %q is not in the zip", ManifestFilename, name)
+		}
+		mainListed = mainListed || name == mainBinary
+		res = append(res, f)
+	}
+	if !mainListed {
+		return nil, fmt.Errorf("%s does not list %q", ManifestFilename, mainBinary)
+	}
+	return res, nil
+}
+
+// singleExecutable returns the only file of zr that is an executable for
+// goos, or nil when there is none or several.
+func singleExecutable(zr *zip.Reader, goos string) (*zip.File, error) {
+	var found *zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		r, err := openBinary(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		_, err = checkExecutable(r, goos)
+		r.Close()
+		if err != nil {
+			continue
+		}
+		if found != nil {
+			log.Printf("[TRACE] %s and %s are both executables, not guessing which one is the plugin", found.Name, f.Name)
+			return nil, nil
+		}
+		found = f
+	}
+	return found, nil
+}
+
+// renamedZipFile returns f, to be extracted as name.
+func renamedZipFile(f *zip.File, name string) *zip.File {
+	if f.Name == name {
+		return f
+	}
+	log.Printf("[TRACE] installing %s from the zip as %s", f.Name, name)
+	renamed := *f
+	renamed.Name = name
+	return &renamed
+}
+
+// binaryMode returns the mode of installed binaries.
+func (opts *InstallOptions) binaryMode() os.FileMode {
+	if opts.BinaryMode == 0 {
+		return 0755
+	}
+	return opts.BinaryMode
+}
+
+// checkBinaryMode makes sure opts.BinaryMode is a valid mode for binaries.
+func (opts *InstallOptions) checkBinaryMode() error {
+	mode := opts.binaryMode()
+	if mode&^os.ModePerm != 0 {
+		return fmt.Errorf("invalid binary mode %#o: only permission bits can be set", mode)
+	}
+	if mode&0100 == 0 {
+		return fmt.Errorf("invalid binary mode %#o: binaries must be executable by their owner", mode)
+	}
+	return nil
+}
+
+// extractBinary writes the binary f to outputFolder along with its checksum
+// file. It is extracted next to its final path and moved in place once
+// complete, so that an existing binary is replaced atomically. Gzip
+// compressed binaries are written decompressed.
+func (opts *InstallOptions) extractBinary(f *zip.File, outputFolder string, checksummer Checksummer) error {
+	outputFileName := filepath.Join(LongPath(outputFolder), f.Name)
+
+	copyFrom, err := openBinary(f)
+	if err != nil {
+		return fmt.Errorf("failed to open temp file: %w", err)
+	}
+	defer copyFrom.Close()
+
+	// A matching checksum only tells us the zip is the one that was
+	// released, make sure its content can actually be run here before
+	// writing anything.
+	binaryContent, err := checkExecutable(copyFrom, opts.OS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+
+	outputFile, err := os.CreateTemp(LongPath(outputFolder), opts.tempFilePattern(f.Name))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outputFileName, err)
+	}
+	defer os.Remove(outputFile.Name())
+	defer outputFile.Close()
+
+	if err := outputFile.Chmod(opts.binaryMode()); err != nil {
+		return fmt.Errorf("failed to make %s executable: %w", outputFile.Name(), err)
+	}
+
+	if _, err := io.Copy(opts.extractWriter(outputFile), binaryContent); err != nil {
+		return fmt.Errorf("extract file: %w", err)
+	}
+
+	if _, err := outputFile.Seek(0, 0); err != nil {
+		log.Printf("[WARNING] Error seeking begining of binary file for checksumming: %v, ignoring", err)
+	}
+
+	cs, err := checksummer.Sum(outputFile)
+	if err != nil {
+		log.Printf("[WARNING] failed to checksum binary file: %v, ignoring", err)
+	}
+
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile.Name(), err)
+	}
+
+	if opts.ChownBinary != nil {
+		if err := opts.ChownBinary(outputFile.Name()); err != nil {
+			return fmt.Errorf("failed to change the owner of %s: %w", outputFileName, err)
+		}
+	}
+
+	if err := os.Rename(outputFile.Name(), outputFileName); err != nil {
+		return fmt.Errorf("failed to move binary to %s: %w", outputFileName, err)
+	}
+
+	if err := os.WriteFile(outputFileName+checksummer.FileExt(), []byte(hex.EncodeToString(cs)), 0644); err != nil {
+		log.Printf("[WARNING] failed to write local binary checksum file: %v, ignoring", err)
+	}
+	return nil
+}
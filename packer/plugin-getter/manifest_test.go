@@ -0,0 +1,357 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// manifestPluginGetter releases v1.0.0 of the amazon plugin as a zip with the
+// given content.
+func manifestPluginGetter(content map[string]string) *mockPluginGetter {
+	const zipName = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip"
+	zip, checksum := zipFileWithChecksum(content)
+	return &mockPluginGetter{
+		Releases: []Release{{Version: "v1.0.0"}},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"1.0.0": {{Filename: zipName, Checksum: checksum}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/" + zipName: zip,
+		},
+	}
+}
+
+func TestRequirement_InstallLatest_manifest(t *testing.T) {
+	const (
+		mainBinary  = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+		otherBinary = "packer-plugin-amazon-ssm_v1.0.0_x5.0_linux_amd64"
+	)
+
+	tests := []struct {
+		name          string
+		content       map[string]string
+		wantInstalled []string
+		wantErr       string
+	}{
+		{
+			name: "no-manifest",
+			content: map[string]string{
+				mainBinary:  elfHeader + "main",
+				otherBinary: elfHeader + "other",
+			},
+			wantInstalled: []string{mainBinary},
+		},
+		{
+			name: "manifest",
+			content: map[string]string{
+				ManifestFilename: `{"binaries": [{"name": "` + mainBinary + `"}, {"name": "` + otherBinary + `"}]}`,
+				mainBinary:       elfHeader + "main",
+				otherBinary:      elfHeader + "other",
+				"README.md":      "not a binary",
+			},
+			wantInstalled: []string{mainBinary, otherBinary},
+		},
+		{
+			name: "manifest-without-main-binary",
+			content: map[string]string{
+				ManifestFilename: `{"binaries": [{"name": "` + otherBinary + `"}]}`,
+				mainBinary:       elfHeader + "main",
+				otherBinary:      elfHeader + "other",
+			},
+			wantErr: ManifestFilename + ` does not list "` + mainBinary + `"`,
+		},
+		{
+			name: "manifest-missing-binary",
+			content: map[string]string{
+				ManifestFilename: `{"binaries": [{"name": "` + mainBinary + `"}, {"name": "` + otherBinary + `"}]}`,
+				mainBinary:       elfHeader + "main",
+			},
+			wantErr: `"` + otherBinary + `" is not in the zip`,
+		},
+		{
+			name: "manifest-path-traversal",
+			content: map[string]string{
+				ManifestFilename:    `{"binaries": [{"name": "` + mainBinary + `"}, {"name": "../` + otherBinary + `"}]}`,
+				mainBinary:          elfHeader + "main",
+				"../" + otherBinary: elfHeader + "other",
+			},
+			wantErr: "is not named like a plugin binary for linux_amd64",
+		},
+		{
+			name: "manifest-other-platform",
+			content: map[string]string{
+				ManifestFilename: `{"binaries": [{"name": "` + mainBinary + `"}, {"name": "packer-plugin-amazon-ssm_v1.0.0_x5.0_darwin_arm64"}]}`,
+				mainBinary:       elfHeader + "main",
+				"packer-plugin-amazon-ssm_v1.0.0_x5.0_darwin_arm64": machoHeader + "other",
+			},
+			wantErr: "is not named like a plugin binary for linux_amd64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(manifestPluginGetter(tt.content), pluginDir)
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+
+			outputFolder := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				if entries, _ := os.ReadDir(outputFolder); len(entries) != 0 {
+					t.Errorf("expected nothing to be installed, found %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+
+			var wantOthers []string
+			for _, binary := range tt.wantInstalled[1:] {
+				wantOthers = append(wantOthers, filepath.ToSlash(filepath.Join(outputFolder, binary)))
+			}
+			if diff := cmp.Diff(wantOthers, install.OtherBinaries); diff != "" {
+				t.Errorf("unexpected other binaries: %s", diff)
+			}
+
+			var gotInstalled []string
+			entries, err := os.ReadDir(outputFolder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				gotInstalled = append(gotInstalled, entry.Name())
+			}
+			var wantInstalled []string
+			for _, binary := range tt.wantInstalled {
+				wantInstalled = append(wantInstalled, binary, binary+"_SHA256SUM")
+			}
+			// only the checksum of the zip of the main binary is recorded.
+			wantInstalled = append(wantInstalled, tt.wantInstalled[0]+"_ZIP_SHA256SUM")
+			sort.Strings(wantInstalled)
+			if diff := cmp.Diff(wantInstalled, gotInstalled); diff != "" {
+				t.Errorf("unexpected installed files: %s", diff)
+			}
+
+			for _, binary := range tt.wantInstalled {
+				path := filepath.Join(outputFolder, binary)
+				content, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := tt.content[binary]; string(content) != want {
+					t.Errorf("%s contains %q, want %q", binary, content, want)
+				}
+				sum := sha256.Sum256(content)
+				checksum, err := os.ReadFile(path + "_SHA256SUM")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(checksum) != hex.EncodeToString(sum[:]) {
+					t.Errorf("%s has checksum %q, want %x", binary, checksum, sum)
+				}
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_binaryEntry(t *testing.T) {
+	const mainBinary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name           string
+		content        map[string]string
+		binaryTemplate string
+		wantContent    string
+		wantErr        string
+	}{
+		{
+			name: "single-executable",
+			content: map[string]string{
+				"packer-plugin-amazon": elfHeader + "main",
+				"README.md":            "not a binary",
+			},
+			wantContent: elfHeader + "main",
+		},
+		{
+			name: "single-executable-in-directory",
+			content: map[string]string{
+				"bin/packer-plugin-amazon": elfHeader + "main",
+			},
+			wantContent: elfHeader + "main",
+		},
+		{
+			name: "several-executables",
+			content: map[string]string{
+				"packer-plugin-amazon":     elfHeader + "main",
+				"packer-plugin-amazon-ssm": elfHeader + "other",
+			},
+			wantErr: "could not find a packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip file in zipfile",
+		},
+		{
+			name: "binary-template",
+			content: map[string]string{
+				"packer-plugin-amazon_v1.0.0": elfHeader + "main",
+				"packer-plugin-amazon-ssm":    elfHeader + "other",
+			},
+			binaryTemplate: "{prefix}{version}",
+			wantContent:    elfHeader + "main",
+		},
+		{
+			name: "binary-template-not-in-zip",
+			content: map[string]string{
+				mainBinary: elfHeader + "main",
+			},
+			binaryTemplate: "{prefix}{version}",
+			wantErr:        "could not find a packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip file in zipfile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			getter := &namedAssetsGetter{
+				mockPluginGetter: manifestPluginGetter(tt.content),
+				Names:            AssetNames{Binary: tt.binaryTemplate},
+			}
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, pluginDir))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+
+			wantPath := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", mainBinary)
+			if install == nil || install.BinaryPath != filepath.ToSlash(wantPath) || len(install.OtherBinaries) != 0 {
+				t.Fatalf("expected only %s to be installed, got %#v", wantPath, install)
+			}
+			content, err := os.ReadFile(wantPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("%s contains %q, want %q", mainBinary, content, tt.wantContent)
+			}
+		})
+	}
+}
+
+// gzipped returns content gzip compressed.
+func gzipped(t *testing.T, content string) string {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRequirement_InstallLatest_gzipBinary(t *testing.T) {
+	const mainBinary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	corrupted := gzipped(t, elfHeader+"main")
+	// flip a byte of the crc32 trailer
+	corrupted = corrupted[:len(corrupted)-8] + string(corrupted[len(corrupted)-8]^0xff) + corrupted[len(corrupted)-7:]
+
+	tests := []struct {
+		name        string
+		content     map[string]string
+		wantContent string
+		wantErr     string
+	}{
+		{
+			name: "raw",
+			content: map[string]string{
+				mainBinary: elfHeader + "main",
+			},
+			wantContent: elfHeader + "main",
+		},
+		{
+			name: "gzip",
+			content: map[string]string{
+				mainBinary: gzipped(t, elfHeader+"main"),
+			},
+			wantContent: elfHeader + "main",
+		},
+		{
+			name: "gzip-single-executable",
+			content: map[string]string{
+				"packer-plugin-amazon": gzipped(t, elfHeader+"main"),
+				"README.md":            "not a binary",
+			},
+			wantContent: elfHeader + "main",
+		},
+		{
+			name: "gzip-not-an-executable",
+			content: map[string]string{
+				mainBinary: gzipped(t, "not a binary"),
+			},
+			wantErr: "not an executable",
+		},
+		{
+			name: "gzip-corrupted",
+			content: map[string]string{
+				mainBinary: corrupted,
+			},
+			wantErr: "gzip: invalid checksum",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			getter := manifestPluginGetter(tt.content)
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, pluginDir))
+			wantPath := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", mainBinary)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				if _, err := os.Stat(wantPath); !os.IsNotExist(err) {
+					t.Errorf("expected %s not to be installed, stat returned: %v", wantPath, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+
+			if install == nil || install.BinaryPath != filepath.ToSlash(wantPath) {
+				t.Fatalf("expected %s to be installed, got %#v", wantPath, install)
+			}
+			content, err := os.ReadFile(wantPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("%s contains %q, want %q", mainBinary, content, tt.wantContent)
+			}
+			sum := sha256.Sum256(content)
+			stored, err := os.ReadFile(wantPath + "_SHA256SUM")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(stored) != hex.EncodeToString(sum[:]) {
+				t.Errorf("expected the checksum of the decompressed binary to be stored, got %s", stored)
+			}
+		})
+	}
+}
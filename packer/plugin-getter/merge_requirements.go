@@ -0,0 +1,175 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+)
+
+// MergeRequirements merges the requirements of the same plugin, ex: required
+// in several required_plugins blocks under different accessors, into one
+// whose version constraints are the intersection of theirs. Requirements are
+// returned in the order their plugin is first required, with the accessor of
+// the first one.
+//
+// An error is returned when no version can satisfy the intersection, ex:
+// ">= 2.5" and "< 2.0", or when the requirements have different version
+// patterns.
+func MergeRequirements(reqs []*Requirement) ([]*Requirement, error) {
+	var res []*Requirement
+	merged := map[string]*Requirement{}
+	for _, req := range reqs {
+		name := req.Identifier.String()
+		m, found := merged[name]
+		if !found {
+			m = &Requirement{
+				Accessor:           req.Accessor,
+				Identifier:         req.Identifier,
+				VersionConstraints: append(version.Constraints{}, req.VersionConstraints...),
+				VersionPattern:     req.VersionPattern,
+				Checksummers:       req.Checksummers,
+			}
+			merged[name] = m
+			res = append(res, m)
+			continue
+		}
+
+		for _, c := range req.VersionConstraints {
+			if !hasConstraint(m.VersionConstraints, c) {
+				m.VersionConstraints = append(m.VersionConstraints, c)
+			}
+		}
+		if m.VersionPattern == "" {
+			m.VersionPattern = req.VersionPattern
+		} else if req.VersionPattern != "" && req.VersionPattern != m.VersionPattern {
+			return nil, fmt.Errorf("%s is required with different version patterns: %q and %q", name, m.VersionPattern, req.VersionPattern)
+		}
+		if len(m.Checksummers) == 0 {
+			m.Checksummers = req.Checksummers
+		}
+	}
+
+	var errs *multierror.Error
+	for _, req := range res {
+		if !satisfiable(req.VersionConstraints) {
+			errs = multierror.Append(errs, fmt.Errorf("no version of %s can satisfy all its version constraints: %q", req.Identifier, req.VersionConstraints.String()))
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return res, nil
+}
+
+func hasConstraint(cs version.Constraints, c *version.Constraint) bool {
+	for _, existing := range cs {
+		if existing.Equals(c) {
+			return true
+		}
+	}
+	return false
+}
+
+var constraintRegexp = regexp.MustCompile(`^\s*(=|!=|>=|<=|>|<|~>)?\s*v?([0-9][0-9A-Za-z.+-]*)\s*$`)
+
+// versionBound is the lowest or highest version constraints accept.
+type versionBound struct {
+	v         *version.Version
+	inclusive bool
+}
+
+// satisfiable reports whether a version can satisfy every constraint of cs.
+// Constraints that cannot be understood are assumed satisfiable.
+func satisfiable(cs version.Constraints) bool {
+	var lower, upper *versionBound
+	var points []*version.Version
+	for _, c := range cs {
+		match := constraintRegexp.FindStringSubmatch(c.String())
+		if match == nil {
+			return true
+		}
+		v, err := version.NewVersion(match[2])
+		if err != nil {
+			return true
+		}
+		switch match[1] {
+		case "", "=":
+			points = append(points, v)
+		case ">":
+			lower = higherBound(lower, &versionBound{v: v})
+		case ">=":
+			lower = higherBound(lower, &versionBound{v: v, inclusive: true})
+		case "<":
+			upper = lowerBound(upper, &versionBound{v: v})
+		case "<=":
+			upper = lowerBound(upper, &versionBound{v: v, inclusive: true})
+		case "~>":
+			lower = higherBound(lower, &versionBound{v: v, inclusive: true})
+			upper = lowerBound(upper, &versionBound{v: pessimisticLimit(v, strings.Count(match[2], ".")+1)})
+		}
+	}
+
+	if lower != nil && lower.inclusive && upper != nil && upper.inclusive && lower.v.Equal(upper.v) {
+		points = append(points, lower.v)
+	}
+	if len(points) > 0 {
+		// at most one version is accepted, check it against every constraint.
+		return cs.Check(points[0])
+	}
+	if lower == nil || upper == nil {
+		return true
+	}
+	switch cmp := lower.v.Compare(upper.v); {
+	case cmp > 0:
+		return false
+	case cmp == 0:
+		return lower.inclusive && upper.inclusive
+	}
+	return true
+}
+
+func higherBound(a, b *versionBound) *versionBound {
+	if a == nil {
+		return b
+	}
+	if cmp := b.v.Compare(a.v); cmp > 0 || (cmp == 0 && !b.inclusive) {
+		return b
+	}
+	return a
+}
+
+func lowerBound(a, b *versionBound) *versionBound {
+	if a == nil {
+		return b
+	}
+	if cmp := b.v.Compare(a.v); cmp < 0 || (cmp == 0 && !b.inclusive) {
+		return b
+	}
+	return a
+}
+
+// pessimisticLimit returns the version "~> v" accepts versions below, v
+// being written with the given number of segments: 2.0 for "~> 1.2", 1.3.0
+// for "~> 1.2.3".
+func pessimisticLimit(v *version.Version, segments int) *version.Version {
+	s := v.Segments()
+	if segments > len(s) {
+		segments = len(s)
+	}
+	bumped := 0
+	if segments > 1 {
+		bumped = segments - 2
+	}
+	limit := make([]string, bumped+1)
+	for i := 0; i < bumped; i++ {
+		limit[i] = fmt.Sprint(s[i])
+	}
+	limit[bumped] = fmt.Sprint(s[bumped] + 1)
+	return version.Must(version.NewVersion(strings.Join(limit, ".")))
+}
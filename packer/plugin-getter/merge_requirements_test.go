@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeRequirements(t *testing.T) {
+	amazon := mustRequirement(t, "github.com/hashicorp/amazon", ">= v2")
+	amazonBelow25 := mustRequirement(t, "github.com/hashicorp/amazon", "< v2.5")
+	amazonBelow25.Accessor = "aws"
+	docker := mustRequirement(t, "github.com/hashicorp/docker", "~> 1.0")
+
+	reqs, err := MergeRequirements([]*Requirement{amazon, docker, amazonBelow25})
+	if err != nil {
+		t.Fatalf("MergeRequirements: %s", err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requirements, got %d", len(reqs))
+	}
+	if got := reqs[0].Identifier.String(); got != "github.com/hashicorp/amazon" {
+		t.Errorf("expected amazon first, got %s", got)
+	}
+	if got, want := reqs[0].VersionConstraints.String(), ">= v2,< v2.5"; got != want {
+		t.Errorf("expected constraints %q, got %q", want, got)
+	}
+	if reqs[0].Accessor != amazon.Accessor {
+		t.Errorf("expected the accessor of the first requirement, got %q", reqs[0].Accessor)
+	}
+	if got, want := reqs[1].VersionConstraints.String(), docker.VersionConstraints.String(); got != want {
+		t.Errorf("expected docker constraints %q, got %q", want, got)
+	}
+	if len(amazon.VersionConstraints) != 1 {
+		t.Errorf("the merged requirement was modified: %s", amazon.VersionConstraints)
+	}
+}
+
+func TestMergeRequirements_emptyIntersection(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints []string
+		wantErr     bool
+	}{
+		{"overlapping ranges", []string{">= v2", "< v2.5"}, false},
+		{"disjoint ranges", []string{">= v3", "< v2.5"}, true},
+		{"touching inclusive bounds", []string{">= 2.5", "<= 2.5"}, false},
+		{"touching exclusive bound", []string{">= 2.5", "< 2.5"}, true},
+		{"pinned version in range", []string{"= 2.1.0", "< 2.5"}, false},
+		{"pinned version out of range", []string{"2.6.0", "< 2.5"}, true},
+		{"two pinned versions", []string{"= 2.1.0", "= 2.2.0"}, true},
+		{"excluded pinned version", []string{"= 2.1.0", "!= 2.1.0"}, true},
+		{"pessimistic in range", []string{"~> 1.2", ">= 1.9"}, false},
+		{"pessimistic out of range", []string{"~> 1.2", ">= 2.0"}, true},
+		{"pessimistic patch out of range", []string{"~> 1.2.3", ">= 1.3"}, true},
+		{"duplicated constraint", []string{">= 2", ">= 2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reqs []*Requirement
+			for _, c := range tt.constraints {
+				reqs = append(reqs, mustRequirement(t, "github.com/hashicorp/amazon", c))
+			}
+			merged, err := MergeRequirements(reqs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", merged[0].VersionConstraints)
+				}
+				if !strings.Contains(err.Error(), "github.com/hashicorp/amazon") {
+					t.Errorf("expected the error to name the plugin, got %q", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeRequirements: %s", err)
+			}
+			if len(merged) != 1 {
+				t.Fatalf("expected 1 requirement, got %d", len(merged))
+			}
+		})
+	}
+}
+
+func TestMergeRequirements_versionPatterns(t *testing.T) {
+	a := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	a.VersionPattern = "v1.*"
+	b := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	b.VersionPattern = "v2.*"
+	if _, err := MergeRequirements([]*Requirement{a, b}); err == nil {
+		t.Fatal("expected conflicting version patterns to be an error")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// GetterMetrics is notified of every request done to a Getter during an
+// install.
+type GetterMetrics interface {
+	// ObserveGet is called once per Getter.Get call, after the returned stream
+	// was closed or as soon as Get failed. bytes is the number of bytes read
+	// from the stream and latency the time Get took to return.
+	ObserveGet(getter Getter, what string, bytes int64, latency time.Duration, err error)
+}
+
+// GetterStat holds the accumulated measurements of a single getter.
+type GetterStat struct {
+	Requests int
+	Failures int
+	Bytes    int64
+	Latency  time.Duration
+}
+
+// GetterStats is a GetterMetrics accumulating measurements per getter. Getters
+// are told apart by identity, so they should be pointers.
+type GetterStats struct {
+	mu    sync.Mutex
+	stats map[Getter]*GetterStat
+}
+
+var _ GetterMetrics = &GetterStats{}
+
+func (s *GetterStats) ObserveGet(getter Getter, what string, bytes int64, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats == nil {
+		s.stats = map[Getter]*GetterStat{}
+	}
+	stat, found := s.stats[getter]
+	if !found {
+		stat = &GetterStat{}
+		s.stats[getter] = stat
+	}
+	stat.Requests++
+	if err != nil {
+		stat.Failures++
+	}
+	stat.Bytes += bytes
+	stat.Latency += latency
+}
+
+// Get returns the accumulated measurements of getter.
+func (s *GetterStats) Get(getter Getter) GetterStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stat, found := s.stats[getter]; found {
+		return *stat
+	}
+	return GetterStat{}
+}
+
+// countingReadCloser counts the bytes read from a getter stream and reports
+// them on Close.
+type countingReadCloser struct {
+	io.ReadCloser
+	bytes   int64
+	onClose func(bytes int64)
+	once    sync.Once
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.bytes += int64(n)
+	return n, err
+}
+
+func (c *countingReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.once.Do(func() { c.onClose(c.bytes) })
+	return err
+}
+
+// get returns the what file of getter, read from its release bundle when it
+// is part of one, see BundleGetter, or else requested with getter.Get and
+// reported to opts.Metrics, if set, and to the attempts of InstallLatest.
+func (opts *InstallOptions) get(getter Getter, what string, getOpts GetOptions) (io.ReadCloser, error) {
+	if bundleGetter, ok := getter.(BundleGetter); ok && opts.bundles != nil && what != "releases" {
+		if rc, found := opts.bundle(bundleGetter, getOpts).open(what, assetNames(getter), getOpts); found {
+			return rc, nil
+		}
+	}
+	rc, err := opts.observe(getter, what, getOpts, func() (io.ReadCloser, error) {
+		return getter.Get(what, getOpts)
+	})
+	opts.attempts.record(getter, err)
+	return rc, err
+}
+
+// observe calls get, once opts.RateLimiter allows it, and reports it as the
+// what request of getter to opts.Metrics and opts.Tracer, if set.
+func (opts *InstallOptions) observe(getter Getter, what string, getOpts GetOptions, get func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	if err := opts.waitRateLimit(what); err != nil {
+		return nil, err
+	}
+	if opts.Metrics == nil && opts.Tracer == nil {
+		return get()
+	}
+
+	var span InstallSpan
+	if opts.Tracer != nil {
+		span = opts.Tracer.StartSpan(what, spanAttributes(getter, getOpts))
+	}
+	start := time.Now()
+	rc, err := get()
+	latency := time.Since(start)
+	if err != nil {
+		opts.observeGet(getter, what, span, 0, latency, err)
+		return nil, err
+	}
+	return &countingReadCloser{
+		ReadCloser: rc,
+		onClose: func(bytes int64) {
+			opts.observeGet(getter, what, span, bytes, latency, nil)
+		},
+	}, nil
+}
+
+func (opts *InstallOptions) observeGet(getter Getter, what string, span InstallSpan, bytes int64, latency time.Duration, err error) {
+	if opts.Metrics != nil {
+		opts.Metrics.ObserveGet(getter, what, bytes, latency, err)
+	}
+	if span != nil {
+		span.End(bytes, err)
+	}
+}
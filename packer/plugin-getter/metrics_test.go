@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+type failingPluginGetter struct {
+	Err error
+}
+
+func (g *failingPluginGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	return nil, g.Err
+}
+
+var _ Getter = &failingPluginGetter{}
+
+func TestRequirement_InstallLatest_getterStats(t *testing.T) {
+	zip, checksum := zipFileWithChecksum(map[string]string{
+		"packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64": machoHeader + "v2.10.0_x6.0_darwin_amd64",
+	})
+	zipContent, err := io.ReadAll(zip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	releases := []Release{{Version: "v2.10.0"}}
+	checksums := []ChecksumFileEntry{{
+		Filename: "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip",
+		Checksum: checksum,
+	}}
+
+	failing := &failingPluginGetter{Err: fmt.Errorf("mirror is down")}
+	working := &mockPluginGetter{
+		Releases: releases,
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"2.10.0": checksums,
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip": io.NopCloser(bytes.NewReader(zipContent)),
+		},
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &Requirement{
+		Identifier: identifier,
+	}
+
+	stats := &GetterStats{}
+	_, err = pr.InstallLatest(InstallOptions{
+		Getters:         []Getter{failing, working},
+		PluginDirectory: t.TempDir(),
+		Metrics:         stats,
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "6", APIVersionMinor: "1",
+			OS: "darwin", ARCH: "amd64",
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	// the mock getter json encodes its responses, with a trailing new line.
+	encodedLen := func(v interface{}) int64 {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int64(len(b) + 1)
+	}
+	wantWorkingBytes := encodedLen(releases) + encodedLen(checksums) + int64(len(zipContent))
+
+	ignoreLatency := cmp.FilterPath(func(p cmp.Path) bool { return p.Last().String() == ".Latency" }, cmp.Ignore())
+	if diff := cmp.Diff(GetterStat{Requests: 3, Failures: 3}, stats.Get(failing), ignoreLatency); diff != "" {
+		t.Errorf("unexpected stats for the failing getter: %s", diff)
+	}
+	if diff := cmp.Diff(GetterStat{Requests: 3, Bytes: wantWorkingBytes}, stats.Get(working), ignoreLatency); diff != "" {
+		t.Errorf("unexpected stats for the working getter: %s", diff)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+)
+
+// MirrorSyncAction is what SyncMirror did with a version of a plugin for a
+// platform.
+type MirrorSyncAction string
+
+const (
+	// MirrorSyncAdded is a version downloaded in the mirror.
+	MirrorSyncAdded MirrorSyncAction = "added"
+	// MirrorSyncSkipped is a version that was already in the mirror.
+	MirrorSyncSkipped MirrorSyncAction = "skipped"
+	// MirrorSyncPruned is a version removed from the mirror, as it is no
+	// longer retained.
+	MirrorSyncPruned MirrorSyncAction = "pruned"
+	// MirrorSyncFailed is a version that could not be added or pruned.
+	MirrorSyncFailed MirrorSyncAction = "failed"
+)
+
+// MirrorSyncResult reports what SyncMirror did with a version of a plugin for
+// a platform.
+type MirrorSyncResult struct {
+	Source  string
+	Version string
+	OS      string
+	ARCH    string
+	Action  MirrorSyncAction
+	// BinaryPath is the binary in the mirror, empty when adding failed.
+	BinaryPath string
+	// Err is why the action failed.
+	Err error
+}
+
+// SyncMirrorOptions configures SyncMirror.
+type SyncMirrorOptions struct {
+	// InstallOptions tells how to download the plugins; its PluginDirectory
+	// is replaced by the mirror directory.
+	InstallOptions
+
+	// Platforms to mirror, the platform of InstallOptions when empty.
+	Platforms []BinaryInstallationOptions
+
+	// KeepVersions is how many of the highest versions matching a
+	// requirement are kept in the mirror; all of them when 0.
+	KeepVersions int
+}
+
+// SyncMirror brings the plugin mirror in mirrorDir up to date with the
+// releases of reqs: the retained versions, see SyncMirrorOptions.KeepVersions,
+// missing for a platform are downloaded, the ones already there are skipped,
+// and the binaries of the other versions are removed.
+//
+// Binaries are looked up by name, and not run, so that a mirror can hold
+// binaries of other systems. An error with a version or a platform does not
+// prevent syncing the others; nothing is pruned for a plugin whose releases
+// could not be listed.
+func SyncMirror(reqs Requirements, mirrorDir string, opts SyncMirrorOptions) ([]MirrorSyncResult, error) {
+	platforms := opts.Platforms
+	if len(platforms) == 0 {
+		platforms = []BinaryInstallationOptions{opts.BinaryInstallationOptions}
+	}
+	installOpts := opts.InstallOptions
+	installOpts.PluginDirectory = mirrorDir
+
+	var results []MirrorSyncResult
+	var errs *multierror.Error
+	for _, pr := range reqs {
+		retained, err := pr.remoteVersions(installOpts)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", pr.Identifier, err))
+			continue
+		}
+		if opts.KeepVersions > 0 && len(retained) > opts.KeepVersions {
+			retained = retained[len(retained)-opts.KeepVersions:]
+		}
+		log.Printf("[TRACE] syncing %s %s in mirror %q", pr.Identifier, retained, mirrorDir)
+
+		for _, platform := range platforms {
+			platformOpts := installOpts
+			platformOpts.BinaryInstallationOptions = platform
+			platformResults, err := pr.syncMirrorPlatform(retained, platformOpts)
+			results = append(results, platformResults...)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("%s %s_%s: %w", pr.Identifier, platform.OS, platform.ARCH, err))
+			}
+		}
+	}
+	return results, errs.ErrorOrNil()
+}
+
+// remoteVersions returns the released versions of pr accepted by its
+// constraints, lowest first, as listed by the first getter that lists any.
+func (pr *Requirement) remoteVersions(opts InstallOptions) (version.Collection, error) {
+	var errs *multierror.Error
+	for _, getter := range opts.Getters {
+		releasesFile, err := opts.get(getter, "releases", GetOptions{
+			PluginRequirement:         pr,
+			BinaryInstallationOptions: opts.BinaryInstallationOptions,
+		})
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		releases, err := ParseReleases(releasesFile)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not parse release: %w", err))
+			continue
+		}
+		versions := version.Collection{}
+		for _, release := range releases {
+			v, err := version.NewVersion(release.Version)
+			if err != nil {
+				log.Printf("[TRACE] could not parse release version %s, ignoring it: %s", release.Version, err)
+				continue
+			}
+			if pr.AcceptsVersion(v) {
+				versions = append(versions, v)
+			}
+		}
+		if len(versions) == 0 {
+			errs = multierror.Append(errs, fmt.Errorf("%w found in releases. In %v", ErrNoMatchingVersion, releases))
+			continue
+		}
+		sort.Sort(versions)
+		return versions, nil
+	}
+	if errs.Len() == 0 {
+		return nil, fmt.Errorf("%w found for constraints: %q", ErrNoMatchingVersion, pr.constraintsString())
+	}
+	return nil, errs
+}
+
+// syncMirrorPlatform downloads the retained versions of pr missing in the
+// mirror for the platform of opts, then prunes the other ones.
+func (pr *Requirement) syncMirrorPlatform(retained version.Collection, opts InstallOptions) ([]MirrorSyncResult, error) {
+	// Every binary of the plugin is listed, including the ones that no
+	// longer match the constraints, so that they get pruned.
+	all := Requirement{Identifier: pr.Identifier}
+	listOpts := ListInstallationsOptions{
+		PluginDirectory:           opts.PluginDirectory,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+	}
+	mirrored, err := all.ListInstalledVersions(listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MirrorSyncResult
+	var errs *multierror.Error
+	newResult := func(v string, action MirrorSyncAction, binaryPath string, err error) MirrorSyncResult {
+		return MirrorSyncResult{
+			Source:     pr.Identifier.String(),
+			Version:    v,
+			OS:         opts.OS,
+			ARCH:       opts.ARCH,
+			Action:     action,
+			BinaryPath: binaryPath,
+			Err:        err,
+		}
+	}
+
+	for _, v := range retained {
+		versionStr := "v" + v.String()
+		if binaryPath := mirroredBinary(mirrored, v); binaryPath != "" {
+			results = append(results, newResult(versionStr, MirrorSyncSkipped, binaryPath, nil))
+			continue
+		}
+		exact := &Requirement{
+			Identifier:         pr.Identifier,
+			VersionConstraints: version.MustConstraints(version.NewConstraint("= " + v.String())),
+			Checksummers:       pr.Checksummers,
+		}
+		install, err := exact.InstallLatest(opts)
+		switch {
+		case err != nil:
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", versionStr, err))
+			results = append(results, newResult(versionStr, MirrorSyncFailed, "", err))
+		case install == nil:
+			results = append(results, newResult(versionStr, MirrorSyncSkipped, "", nil))
+		default:
+			results = append(results, newResult(install.Version, MirrorSyncAdded, install.BinaryPath, nil))
+		}
+	}
+
+	for _, installed := range mirrored {
+		v, err := version.NewVersion(installed.Version)
+		if err != nil || isRetained(retained, v) {
+			continue
+		}
+		if err := pruneMirroredBinary(installed.BinaryPath, pr.checksummers(opts.BinaryInstallationOptions)); err != nil {
+			errs = multierror.Append(errs, err)
+			results = append(results, newResult(installed.Version, MirrorSyncFailed, installed.BinaryPath, err))
+			continue
+		}
+		results = append(results, newResult(installed.Version, MirrorSyncPruned, installed.BinaryPath, nil))
+	}
+	return results, errs.ErrorOrNil()
+}
+
+// mirroredBinary returns the path of a binary of version v with a stored
+// checksum, or an empty string when there is none.
+func mirroredBinary(mirrored []InstalledVersion, v *version.Version) string {
+	for _, installed := range mirrored {
+		if installed.Err != nil {
+			continue
+		}
+		if iv, err := version.NewVersion(installed.Version); err == nil && iv.Equal(v) {
+			return installed.BinaryPath
+		}
+	}
+	return ""
+}
+
+func isRetained(retained version.Collection, v *version.Version) bool {
+	for _, r := range retained {
+		if r.Equal(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneMirroredBinary removes binaryPath along with its sidecar files.
+func pruneMirroredBinary(binaryPath string, checksummers []Checksummer) error {
+	if err := os.Remove(binaryPath); err != nil {
+		return err
+	}
+	for _, file := range sidecarFiles(binaryPath, checksummers) {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
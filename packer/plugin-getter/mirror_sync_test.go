@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// releasesGetter returns a getter for linux_amd64 releases of the amazon
+// plugin at each of versions.
+func releasesGetter(versions ...string) *mockPluginGetter {
+	getter := &mockPluginGetter{
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{},
+		Zips:                map[string]io.ReadCloser{},
+	}
+	for _, v := range versions {
+		binary := "packer-plugin-amazon_" + v + "_x5.0_linux_amd64"
+		zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader + v})
+		getter.Releases = append(getter.Releases, Release{Version: v})
+		getter.ChecksumFileEntries[v[1:]] = []ChecksumFileEntry{{Filename: binary + ".zip", Checksum: checksum}}
+		getter.Zips["github.com/hashicorp/packer-plugin-amazon/"+binary+".zip"] = zip
+	}
+	return getter
+}
+
+// syncActions returns the action taken for each version in results.
+func syncActions(results []MirrorSyncResult) map[string]MirrorSyncAction {
+	res := map[string]MirrorSyncAction{}
+	for _, result := range results {
+		res[result.Version] = result.Action
+	}
+	return res
+}
+
+func TestSyncMirror_incrementalAdd(t *testing.T) {
+	mirrorDir := t.TempDir()
+	reqs := Requirements{mustRequirement(t, "github.com/hashicorp/amazon", ">= 1.1.0")}
+
+	opts := SyncMirrorOptions{InstallOptions: dependenciesInstallOptions(releasesGetter("v1.0.0", "v1.1.0"), "")}
+	results, err := SyncMirror(reqs, mirrorDir, opts)
+	if err != nil {
+		t.Fatalf("SyncMirror: %v", err)
+	}
+	want := map[string]MirrorSyncAction{"v1.1.0": MirrorSyncAdded}
+	if diff := cmp.Diff(want, syncActions(results)); diff != "" {
+		t.Fatalf("unexpected first sync: %s", diff)
+	}
+	binary := filepath.Join(mirrorDir, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_v1.1.0_x5.0_linux_amd64")
+	if results[0].BinaryPath != binary {
+		t.Errorf("expected %q to be added, got %q", binary, results[0].BinaryPath)
+	}
+
+	// The zip of v1.1.0 is not served anymore: it must not be downloaded
+	// again.
+	getter := releasesGetter("v1.0.0", "v1.1.0", "v1.2.0")
+	delete(getter.Zips, "github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v1.1.0_x5.0_linux_amd64.zip")
+	opts.InstallOptions = dependenciesInstallOptions(getter, "")
+	results, err = SyncMirror(reqs, mirrorDir, opts)
+	if err != nil {
+		t.Fatalf("SyncMirror: %v", err)
+	}
+	want = map[string]MirrorSyncAction{"v1.1.0": MirrorSyncSkipped, "v1.2.0": MirrorSyncAdded}
+	if diff := cmp.Diff(want, syncActions(results)); diff != "" {
+		t.Errorf("unexpected second sync: %s", diff)
+	}
+}
+
+func TestSyncMirror_prune(t *testing.T) {
+	mirrorDir := t.TempDir()
+	reqs := Requirements{mustRequirement(t, "github.com/hashicorp/amazon", "")}
+
+	opts := SyncMirrorOptions{
+		InstallOptions: dependenciesInstallOptions(releasesGetter("v1.0.0", "v1.1.0"), ""),
+		KeepVersions:   2,
+	}
+	if _, err := SyncMirror(reqs, mirrorDir, opts); err != nil {
+		t.Fatalf("SyncMirror: %v", err)
+	}
+
+	opts.InstallOptions = dependenciesInstallOptions(releasesGetter("v1.0.0", "v1.1.0", "v1.2.0"), "")
+	results, err := SyncMirror(reqs, mirrorDir, opts)
+	if err != nil {
+		t.Fatalf("SyncMirror: %v", err)
+	}
+	want := map[string]MirrorSyncAction{
+		"v1.0.0": MirrorSyncPruned,
+		"v1.1.0": MirrorSyncSkipped,
+		"v1.2.0": MirrorSyncAdded,
+	}
+	if diff := cmp.Diff(want, syncActions(results)); diff != "" {
+		t.Fatalf("unexpected sync: %s", diff)
+	}
+
+	pluginDir := filepath.Join(mirrorDir, "github.com", "hashicorp", "amazon")
+	entries, err := os.ReadDir(pluginDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, entry := range entries {
+		files = append(files, entry.Name())
+	}
+	wantFiles := []string{
+		"packer-plugin-amazon_v1.1.0_x5.0_linux_amd64",
+		"packer-plugin-amazon_v1.1.0_x5.0_linux_amd64_SHA256SUM",
+		"packer-plugin-amazon_v1.1.0_x5.0_linux_amd64" + ZipChecksumFileExt,
+		"packer-plugin-amazon_v1.2.0_x5.0_linux_amd64",
+		"packer-plugin-amazon_v1.2.0_x5.0_linux_amd64_SHA256SUM",
+		"packer-plugin-amazon_v1.2.0_x5.0_linux_amd64" + ZipChecksumFileExt,
+	}
+	if diff := cmp.Diff(wantFiles, files); diff != "" {
+		t.Errorf("unexpected mirror content: %s", diff)
+	}
+}
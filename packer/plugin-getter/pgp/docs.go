@@ -0,0 +1,6 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package pgp defines a PGP signature verifier for plugin checksum files.
+
+package pgp
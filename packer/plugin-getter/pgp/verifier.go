@@ -0,0 +1,331 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pgp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// Verifier checks that plugin checksum files, or the git tags of plugin
+// releases, are signed by a PGP key.
+type Verifier struct {
+	// PublicKey is the armored public key signatures are checked against.
+	PublicKey string
+
+	// PublicKeyFile is the file the armored public key is read from, when
+	// PublicKey is empty.
+	PublicKeyFile string
+
+	// Fingerprint of the signing key. It is required to fetch the key from
+	// KeyServer and, when set without TrustedFingerprints, PublicKey must
+	// match it too.
+	Fingerprint string
+
+	// TrustedFingerprints are the fingerprints of other keys allowed to sign,
+	// ex: the old and new keys during a key rotation. When set, PublicKey can
+	// hold several keys, signatures made by keys that are neither listed nor
+	// the Fingerprint key are rejected, and every trusted key is fetched from
+	// KeyServer when PublicKey is empty.
+	TrustedFingerprints []string
+
+	// KeyServer is the URL of the HKP keyserver the key is fetched from when
+	// PublicKey is empty, ex: https://keys.openpgp.org.
+	KeyServer string
+
+	// KeyID is used to look the key up on the KeyServer. Defaults to the
+	// long key ID of Fingerprint.
+	KeyID string
+
+	// CacheDir is where keys fetched from KeyServer are kept, so that they
+	// are only fetched once. Keys are not cached when empty.
+	CacheDir string
+
+	// Client is used to query KeyServer, defaults to http.DefaultClient.
+	Client *http.Client
+
+	keyringOnce sync.Once
+	keyring     openpgp.EntityList
+	keyringErr  error
+}
+
+var (
+	_ plugingetter.SignatureVerifier    = &Verifier{}
+	_ plugingetter.TagSignatureVerifier = &Verifier{}
+)
+
+// ErrFingerprintMismatch is returned when a key does not have the configured
+// fingerprint.
+var ErrFingerprintMismatch = errors.New("key fingerprint does not match")
+
+// ErrUntrustedSigner is returned when a signature was made by a key that is
+// not one of the trusted fingerprints.
+var ErrUntrustedSigner = errors.New("signer is not trusted")
+
+func (v *Verifier) VerifySignature(pr *plugingetter.Requirement, checksumFile, signature []byte) error {
+	signer, err := v.checkSignature(checksumFile, signature)
+	if err != nil {
+		return fmt.Errorf("pgp: invalid signature for %s: %w", pr.Identifier, err)
+	}
+	log.Printf("[TRACE] pgp: checksum file of %s signed by %s", pr.Identifier, signer.PrimaryKey.KeyIdString())
+	return nil
+}
+
+// VerifyTagSignature checks that the git tag of a release is signed by the
+// key. Unsigned tags are not trusted.
+func (v *Verifier) VerifyTagSignature(pr *plugingetter.Requirement, signature *plugingetter.TagSignature) error {
+	if len(signature.Signature) == 0 {
+		return fmt.Errorf("pgp: %w %s of %s", plugingetter.ErrUnsignedTag, signature.Tag, pr.Identifier)
+	}
+	signer, err := v.checkSignature(signature.Payload, signature.Signature)
+	if err != nil {
+		return fmt.Errorf("pgp: invalid signature of tag %s of %s: %w", signature.Tag, pr.Identifier, err)
+	}
+	log.Printf("[TRACE] pgp: tag %s of %s signed by %s", signature.Tag, pr.Identifier, signer.PrimaryKey.KeyIdString())
+	return nil
+}
+
+// checkSignature checks that signature, armored or not, was made by the key
+// over signed, and returns the signer.
+func (v *Verifier) checkSignature(signed, signature []byte) (*openpgp.Entity, error) {
+	v.keyringOnce.Do(func() {
+		v.keyring, v.keyringErr = v.loadKeyring()
+	})
+	if v.keyringErr != nil {
+		return nil, v.keyringErr
+	}
+
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	signer, err := check(v.keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+	if err != nil {
+		return nil, err
+	}
+	if trusted := v.trustedFingerprints(); len(trusted) > 0 {
+		got := strings.ToUpper(hex.EncodeToString(signer.PrimaryKey.Fingerprint))
+		if !trusted[got] {
+			return nil, fmt.Errorf("%w: %s", ErrUntrustedSigner, got)
+		}
+	}
+	return signer, nil
+}
+
+// trustedFingerprints returns the normalized Fingerprint and
+// TrustedFingerprints, or nil when none is set.
+func (v *Verifier) trustedFingerprints() map[string]bool {
+	var trusted map[string]bool
+	for _, fingerprint := range append([]string{v.Fingerprint}, v.TrustedFingerprints...) {
+		if fingerprint = normalizeFingerprint(fingerprint); fingerprint == "" {
+			continue
+		}
+		if trusted == nil {
+			trusted = map[string]bool{}
+		}
+		trusted[fingerprint] = true
+	}
+	return trusted
+}
+
+func (v *Verifier) loadKeyring() (openpgp.EntityList, error) {
+	fingerprint := normalizeFingerprint(v.Fingerprint)
+
+	publicKey := v.PublicKey
+	if publicKey == "" && v.PublicKeyFile != "" {
+		content, err := os.ReadFile(v.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("pgp: could not read public key file: %w", err)
+		}
+		publicKey = string(content)
+	}
+	if publicKey != "" {
+		keyring, err := readArmoredKeys(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("pgp: could not read public key: %w", err)
+		}
+		// with TrustedFingerprints, the keyring can hold keys that are not
+		// trusted: their signatures are rejected by checkSignature.
+		if fingerprint != "" && len(v.TrustedFingerprints) == 0 {
+			if err := checkFingerprint(keyring, fingerprint); err != nil {
+				return nil, err
+			}
+		}
+		return keyring, nil
+	}
+
+	if v.KeyServer == "" {
+		return nil, fmt.Errorf("pgp: either a public key or a keyserver must be set")
+	}
+	// Without a fingerprint anyone able to publish a key on the keyserver
+	// could sign releases.
+	trusted := v.trustedFingerprints()
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("pgp: a key fingerprint is required to fetch the key from %s", v.KeyServer)
+	}
+	fingerprints := make([]string, 0, len(trusted))
+	for fp := range trusted {
+		if len(fp) < 16 {
+			return nil, fmt.Errorf("pgp: invalid key fingerprint %q", fp)
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+	sort.Strings(fingerprints)
+
+	var keyring openpgp.EntityList
+	for _, fp := range fingerprints {
+		keyID := ""
+		if fp == fingerprint {
+			keyID = v.KeyID
+		}
+		key, err := v.serverKey(fp, keyID)
+		if err != nil {
+			return nil, err
+		}
+		keyring = append(keyring, key...)
+	}
+	return keyring, nil
+}
+
+// serverKey returns the key with fingerprint from the cache, or fetches it
+// from the KeyServer and caches it.
+func (v *Verifier) serverKey(fingerprint, keyID string) (openpgp.EntityList, error) {
+	cacheFile := ""
+	if v.CacheDir != "" {
+		cacheFile = filepath.Join(v.CacheDir, fingerprint+".asc")
+		if keyring, err := readKeyFile(cacheFile, fingerprint); err == nil {
+			return keyring, nil
+		} else if !os.IsNotExist(err) {
+			log.Printf("[WARNING] pgp: ignoring cached key %q: %s", cacheFile, err)
+		}
+	}
+
+	armored, err := v.fetchKey(fingerprint, keyID)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("pgp: could not read key from %s: %w", v.KeyServer, err)
+	}
+	if err := checkFingerprint(keyring, fingerprint); err != nil {
+		return nil, err
+	}
+
+	if cacheFile != "" {
+		if err := os.MkdirAll(v.CacheDir, 0755); err != nil {
+			log.Printf("[WARNING] pgp: could not cache key: %s", err)
+		} else if err := os.WriteFile(cacheFile, armored, 0644); err != nil {
+			log.Printf("[WARNING] pgp: could not cache key: %s", err)
+		}
+	}
+	return keyring, nil
+}
+
+// fetchKey gets the armored key from the HKP keyserver, looking it up by
+// keyID, which defaults to the long key ID of fingerprint.
+func (v *Verifier) fetchKey(fingerprint, keyID string) ([]byte, error) {
+	if keyID == "" {
+		keyID = fingerprint[len(fingerprint)-16:]
+	}
+	keyID = strings.TrimPrefix(strings.ToUpper(keyID), "0X")
+
+	u := strings.TrimSuffix(v.KeyServer, "/") + "/pks/lookup?" + url.Values{
+		"op":      {"get"},
+		"options": {"mr"},
+		"search":  {"0x" + keyID},
+	}.Encode()
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	log.Printf("[DEBUG] pgp: fetching key %s from %q", keyID, u)
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("pgp: could not fetch key %s: %w", keyID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pgp: could not fetch key %s: %s", keyID, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// readArmoredKeys reads the keys of every armored block of armored, as
+// found in files concatenating several exported keys.
+func readArmoredKeys(armored string) (openpgp.EntityList, error) {
+	const blockStart = "-----BEGIN PGP"
+	var blocks []string
+	for start := strings.Index(armored, blockStart); start >= 0; {
+		armored = armored[start:]
+		start = strings.Index(armored[len(blockStart):], blockStart)
+		if start < 0 {
+			blocks = append(blocks, armored)
+			break
+		}
+		start += len(blockStart)
+		blocks = append(blocks, armored[:start])
+	}
+	if len(blocks) == 0 {
+		// let openpgp report what is wrong.
+		blocks = []string{armored}
+	}
+
+	var keyring openpgp.EntityList
+	for _, block := range blocks {
+		keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(block))
+		if err != nil {
+			return nil, err
+		}
+		keyring = append(keyring, keys...)
+	}
+	return keyring, nil
+}
+
+func readKeyFile(path, fingerprint string) (openpgp.EntityList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, err
+	}
+	return keyring, checkFingerprint(keyring, fingerprint)
+}
+
+// checkFingerprint makes sure keyring only holds the key with fingerprint.
+func checkFingerprint(keyring openpgp.EntityList, fingerprint string) error {
+	if len(keyring) == 0 {
+		return fmt.Errorf("pgp: no key found")
+	}
+	for _, entity := range keyring {
+		got := strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint))
+		if got != fingerprint {
+			return fmt.Errorf("pgp: %w: expected %s, got %s", ErrFingerprintMismatch, fingerprint, got)
+		}
+	}
+	return nil
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	fingerprint = strings.ToUpper(strings.ReplaceAll(fingerprint, " ", ""))
+	return strings.TrimPrefix(fingerprint, "0X")
+}
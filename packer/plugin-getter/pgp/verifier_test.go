@@ -0,0 +1,267 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pgp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const checksumFile = "4b2c8b2b0e1e2ac1b5b6b8f0c9e3bd4ff3aa5fd9bd8b4c2a7e4e6ef16c2b1f20  packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip\n"
+
+type testKey struct {
+	entity      *openpgp.Entity
+	armored     string
+	fingerprint string
+}
+
+func newTestKey(t *testing.T) testKey {
+	entity, err := openpgp.NewEntity("Packer test", "", "packer@example.com", &packet.Config{
+		Algorithm: packet.PubKeyAlgoEdDSA,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return testKey{
+		entity:      entity,
+		armored:     buf.String(),
+		fingerprint: hex.EncodeToString(entity.PrimaryKey.Fingerprint),
+	}
+}
+
+func (k testKey) sign(t *testing.T, data string) []byte {
+	buf := &bytes.Buffer{}
+	if err := openpgp.DetachSign(buf, k.entity, bytes.NewBufferString(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// keyServer serves key for any lookup and counts the lookups.
+func keyServer(t *testing.T, key testKey, lookups *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pks/lookup" || r.URL.Query().Get("op") != "get" {
+			t.Errorf("unexpected keyserver request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		*lookups++
+		_, _ = w.Write([]byte(key.armored))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testRequirement(t *testing.T) *plugingetter.Requirement {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if diags.HasErrors() {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	return &plugingetter.Requirement{Identifier: identifier}
+}
+
+func TestVerifier_VerifySignature_inlineKey(t *testing.T) {
+	key := newTestKey(t)
+	other := newTestKey(t)
+	pr := testRequirement(t)
+
+	v := &Verifier{PublicKey: key.armored}
+	if err := v.VerifySignature(pr, []byte(checksumFile), key.sign(t, checksumFile)); err != nil {
+		t.Errorf("VerifySignature: %v", err)
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile+"tampered"), key.sign(t, checksumFile)); err == nil {
+		t.Errorf("expected an error for a tampered checksum file")
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile), other.sign(t, checksumFile)); err == nil {
+		t.Errorf("expected an error for a signature from another key")
+	}
+
+	v = &Verifier{PublicKey: key.armored, Fingerprint: other.fingerprint}
+	if err := v.VerifySignature(pr, []byte(checksumFile), key.sign(t, checksumFile)); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("expected ErrFingerprintMismatch, got %v", err)
+	}
+}
+
+func TestVerifier_VerifySignature_keyServer(t *testing.T) {
+	key := newTestKey(t)
+	pr := testRequirement(t)
+	signature := key.sign(t, checksumFile)
+
+	lookups := 0
+	server := keyServer(t, key, &lookups)
+	cacheDir := filepath.Join(t.TempDir(), "keys")
+
+	v := &Verifier{
+		Fingerprint: key.fingerprint,
+		KeyServer:   server.URL,
+		CacheDir:    cacheDir,
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile), signature); err != nil {
+		t.Fatalf("VerifySignature: %v", err)
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile), signature); err != nil {
+		t.Fatalf("VerifySignature: %v", err)
+	}
+	if lookups != 1 {
+		t.Errorf("expected a single keyserver lookup, got %d", lookups)
+	}
+
+	// a new verifier uses the cached key.
+	server.Close()
+	v = &Verifier{
+		Fingerprint: key.fingerprint,
+		KeyServer:   server.URL,
+		CacheDir:    cacheDir,
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile), signature); err != nil {
+		t.Fatalf("VerifySignature with cached key: %v", err)
+	}
+}
+
+func TestVerifier_VerifySignature_keyServerFingerprintMismatch(t *testing.T) {
+	key := newTestKey(t)
+	impostor := newTestKey(t)
+	pr := testRequirement(t)
+
+	lookups := 0
+	server := keyServer(t, impostor, &lookups)
+	cacheDir := t.TempDir()
+
+	v := &Verifier{
+		Fingerprint: key.fingerprint,
+		KeyServer:   server.URL,
+		CacheDir:    cacheDir,
+	}
+	err := v.VerifySignature(pr, []byte(checksumFile), impostor.sign(t, checksumFile))
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("expected ErrFingerprintMismatch, got %v", err)
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the mismatching key not to be cached, found %v", entries)
+	}
+}
+
+func TestVerifier_VerifySignature_keyServerRequiresFingerprint(t *testing.T) {
+	key := newTestKey(t)
+	lookups := 0
+	server := keyServer(t, key, &lookups)
+
+	v := &Verifier{KeyServer: server.URL}
+	if err := v.VerifySignature(testRequirement(t), []byte(checksumFile), key.sign(t, checksumFile)); err == nil {
+		t.Fatal("expected an error without a fingerprint")
+	}
+	if lookups != 0 {
+		t.Errorf("expected no keyserver lookup, got %d", lookups)
+	}
+}
+
+func TestVerifier_VerifyTagSignature(t *testing.T) {
+	key := newTestKey(t)
+	other := newTestKey(t)
+	pr := testRequirement(t)
+	payload := "object 4b2c8b2b0e1e2ac1b5b6b8f0c9e3bd4ff3aa5fd9\ntype commit\ntag v1.0.0\ntagger Packer test <packer@example.com> 1700000000 +0000\n\nv1.0.0\n"
+
+	keyFile := filepath.Join(t.TempDir(), "key.asc")
+	if err := os.WriteFile(keyFile, []byte(key.armored), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v := &Verifier{PublicKeyFile: keyFile}
+
+	signed := &plugingetter.TagSignature{Tag: "v1.0.0", Payload: []byte(payload), Signature: key.sign(t, payload)}
+	if err := v.VerifyTagSignature(pr, signed); err != nil {
+		t.Errorf("VerifyTagSignature: %v", err)
+	}
+	unsigned := &plugingetter.TagSignature{Tag: "v1.0.0", Payload: []byte(payload)}
+	if err := v.VerifyTagSignature(pr, unsigned); !errors.Is(err, plugingetter.ErrUnsignedTag) {
+		t.Errorf("expected ErrUnsignedTag, got %v", err)
+	}
+	otherKey := &plugingetter.TagSignature{Tag: "v1.0.0", Payload: []byte(payload), Signature: other.sign(t, payload)}
+	if err := v.VerifyTagSignature(pr, otherKey); err == nil {
+		t.Errorf("expected an error for a tag signed by another key")
+	}
+
+	v = &Verifier{PublicKeyFile: filepath.Join(t.TempDir(), "missing.asc")}
+	if err := v.VerifyTagSignature(pr, signed); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing public key file error, got %v", err)
+	}
+}
+
+func TestVerifier_VerifySignature_trustedFingerprints(t *testing.T) {
+	oldKey := newTestKey(t)
+	newKey := newTestKey(t)
+	revoked := newTestKey(t)
+	pr := testRequirement(t)
+
+	v := &Verifier{
+		PublicKey:           oldKey.armored + newKey.armored + revoked.armored,
+		Fingerprint:         oldKey.fingerprint,
+		TrustedFingerprints: []string{newKey.fingerprint},
+	}
+	for name, key := range map[string]testKey{"old": oldKey, "new": newKey} {
+		if err := v.VerifySignature(pr, []byte(checksumFile), key.sign(t, checksumFile)); err != nil {
+			t.Errorf("VerifySignature with the %s key: %v", name, err)
+		}
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile), revoked.sign(t, checksumFile)); !errors.Is(err, ErrUntrustedSigner) {
+		t.Errorf("expected ErrUntrustedSigner for a key that is not trusted, got %v", err)
+	}
+
+	// keys are fetched by fingerprint, only the trusted ones.
+	var lookups []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		search := r.URL.Query().Get("search")
+		lookups = append(lookups, search)
+		for _, key := range []testKey{oldKey, newKey, revoked} {
+			if strings.EqualFold(search, "0x"+key.fingerprint[len(key.fingerprint)-16:]) {
+				_, _ = w.Write([]byte(key.armored))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	v = &Verifier{
+		KeyServer:           server.URL,
+		TrustedFingerprints: []string{oldKey.fingerprint, strings.ToUpper(newKey.fingerprint)},
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile), newKey.sign(t, checksumFile)); err != nil {
+		t.Errorf("VerifySignature with a fetched trusted key: %v", err)
+	}
+	if err := v.VerifySignature(pr, []byte(checksumFile), revoked.sign(t, checksumFile)); err == nil {
+		t.Errorf("expected an error for a signature from a key that is not trusted")
+	}
+	if len(lookups) != 2 {
+		t.Errorf("expected the two trusted keys to be fetched, got lookups %v", lookups)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrPluginDirReadOnly is returned when plugins cannot be written to or
+// removed from the plugin directory.
+var ErrPluginDirReadOnly = errors.New("plugin directory is read-only")
+
+// CheckPluginDirWritable makes sure files can be created and removed in dir.
+// When dir does not exist yet, its closest existing parent is checked
+// instead, as that is where dir will be created.
+func CheckPluginDirWritable(dir string) error {
+	probeDir, err := closestExistingDir(dir)
+	if probeDir == "" || err != nil {
+		// when nothing exists, creating the directory will tell us more.
+		return err
+	}
+
+	probe, err := os.CreateTemp(LongPath(probeDir), ".packer-write-probe-*")
+	if err != nil {
+		if os.IsPermission(err) || errors.Is(err, syscall.EROFS) {
+			return fmt.Errorf("%w: %q. Make sure the current user can write to it, "+
+				"or set PACKER_PLUGIN_PATH to a writable directory", ErrPluginDirReadOnly, probeDir)
+		}
+		return fmt.Errorf("could not check if %q is writable: %w", probeDir, err)
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// closestExistingDir returns dir, or its closest parent when it does not
+// exist yet, as that is where dir will be created. It returns an empty path
+// when none of them exists.
+func closestExistingDir(dir string) (string, error) {
+	for {
+		fi, err := os.Stat(LongPath(dir))
+		if err == nil {
+			if !fi.IsDir() {
+				return "", fmt.Errorf("%q is not a directory", dir)
+			}
+			return dir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
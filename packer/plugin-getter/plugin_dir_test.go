@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// readOnlyDir returns a directory the current user cannot write to.
+func readOnlyDir(t *testing.T) string {
+	dir := t.TempDir()
+	makeReadOnly(t, dir)
+	return dir
+}
+
+// makeReadOnly prevents the current user from writing to dir until the end
+// of the test.
+func makeReadOnly(t *testing.T, dir string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions don't prevent writes on windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(dir, 0755) })
+}
+
+func TestCheckPluginDirWritable(t *testing.T) {
+	t.Run("writable", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := CheckPluginDirWritable(dir); err != nil {
+			t.Fatalf("CheckPluginDirWritable: %v", err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expected probe file to be removed, found %v", entries)
+		}
+	})
+
+	t.Run("writable-parent-of-missing-dir", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "plugins", "github.com")
+		if err := CheckPluginDirWritable(dir); err != nil {
+			t.Fatalf("CheckPluginDirWritable: %v", err)
+		}
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		dir := readOnlyDir(t)
+		if err := CheckPluginDirWritable(dir); !errors.Is(err, ErrPluginDirReadOnly) {
+			t.Fatalf("CheckPluginDirWritable error = %v, expected ErrPluginDirReadOnly", err)
+		}
+	})
+
+	t.Run("read-only-parent-of-missing-dir", func(t *testing.T) {
+		dir := filepath.Join(readOnlyDir(t), "plugins")
+		if err := CheckPluginDirWritable(dir); !errors.Is(err, ErrPluginDirReadOnly) {
+			t.Fatalf("CheckPluginDirWritable error = %v, expected ErrPluginDirReadOnly", err)
+		}
+	})
+}
+
+type unexpectedCallGetter struct {
+	t *testing.T
+}
+
+func (g *unexpectedCallGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	g.t.Fatalf("unexpected call to get %q", what)
+	return nil, nil
+}
+
+func TestRequirement_InstallLatest_readOnlyPluginDir(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &Requirement{
+		Identifier: identifier,
+	}
+	getter := &zipCountingGetter{Getter: singleReleaseGetter("amazon")}
+	_, err := pr.InstallLatest(dependenciesInstallOptions(getter, readOnlyDir(t)))
+	if !errors.Is(err, ErrPluginDirReadOnly) {
+		t.Fatalf("InstallLatest error = %v, expected ErrPluginDirReadOnly", err)
+	}
+	if len(getter.Zips) != 0 {
+		t.Errorf("expected nothing to be downloaded, got the zips of %v", getter.Zips)
+	}
+}
+
+func TestRequirement_InstallLatest_readOnlyOutputFolder(t *testing.T) {
+	pluginDir := t.TempDir()
+	outputFolder := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	makeReadOnly(t, outputFolder)
+
+	getter := &zipCountingGetter{Getter: singleReleaseGetter("amazon")}
+	_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, pluginDir))
+	if !errors.Is(err, ErrPluginDirReadOnly) {
+		t.Fatalf("InstallLatest error = %v, expected ErrPluginDirReadOnly", err)
+	}
+	if len(getter.Zips) != 0 {
+		t.Errorf("expected nothing to be downloaded, got the zips of %v", getter.Zips)
+	}
+}
+
+func TestRequirement_InstallLatest_readOnlyAlreadyInstalled(t *testing.T) {
+	pluginDir := t.TempDir()
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	if _, err := pr.InstallLatest(dependenciesInstallOptions(singleReleaseGetter("amazon"), pluginDir)); err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	// the plugin directory is provisioned, then made read-only.
+	outputFolder := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+	makeReadOnly(t, outputFolder)
+	makeReadOnly(t, pluginDir)
+
+	install, err := pr.InstallLatest(dependenciesInstallOptions(singleReleaseGetter("amazon"), pluginDir))
+	if err != nil || install != nil {
+		t.Fatalf("expected nothing to be done, got %#v: %v", install, err)
+	}
+}
@@ -4,9 +4,9 @@
 package plugingetter
 
 import (
-	"archive/zip"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,9 +15,11 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -44,9 +46,94 @@ type Requirement struct {
 	// previous example.
 	Identifier *addrs.Plugin
 
-	// VersionConstraints as defined by user. Empty ( to be avoided ) means
-	// highest found version.
+	// VersionConstraints as defined by user. Empty means any version, in
+	// which case InstallLatest installs the highest compatible release.
 	VersionConstraints version.Constraints
+
+	// VersionPattern, when set, is a glob the version must match, ex:
+	// "v2.10.*", see path.Match for the syntax. A version must be accepted
+	// by both VersionConstraints and VersionPattern.
+	VersionPattern string
+
+	// Checksummers, when set, replaces the Checksummers of the options to
+	// verify this plugin with, for plugins that publish other checksums than
+	// the rest.
+	Checksummers []Checksummer
+
+	// SkipMissingPlatforms makes InstallLatestForPlatforms skip, with a
+	// warning, the platforms the plugin was not released for, instead of
+	// failing.
+	SkipMissingPlatforms bool
+}
+
+// checksummers returns the Checksummers to verify pr with.
+func (pr Requirement) checksummers(opts BinaryInstallationOptions) []Checksummer {
+	if len(pr.Checksummers) > 0 {
+		return pr.Checksummers
+	}
+	return opts.Checksummers
+}
+
+// AnyVersion reports whether pr accepts any version of the plugin, which is
+// the case when it has no version constraints nor pattern.
+func (pr Requirement) AnyVersion() bool {
+	return len(pr.VersionConstraints) == 0 && pr.VersionPattern == ""
+}
+
+// AcceptsVersion reports whether v satisfies the version constraints and
+// pattern of pr.
+func (pr Requirement) AcceptsVersion(v *version.Version) bool {
+	if pr.AnyVersion() {
+		return true
+	}
+	if pr.VersionPattern != "" {
+		matched, err := path.Match(versionPattern(pr.VersionPattern), "v"+v.String())
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return len(pr.VersionConstraints) == 0 || pr.VersionConstraints.Check(v)
+}
+
+// ParseVersionFilter sets the version constraints of pr from s. When s is not
+// valid constraint syntax but is a glob, ex: "v2.10.*", it is set as the
+// version pattern instead, so constraint syntax always takes precedence.
+func (pr *Requirement) ParseVersionFilter(s string) error {
+	constraints, err := version.NewConstraint(s)
+	if err == nil {
+		pr.VersionConstraints = constraints
+		return nil
+	}
+	if !strings.ContainsAny(s, "*?[") {
+		return err
+	}
+	if _, patternErr := path.Match(versionPattern(s), ""); patternErr != nil {
+		return fmt.Errorf("%q is neither a version constraint nor a version pattern: %w", s, patternErr)
+	}
+	pr.VersionPattern = s
+	return nil
+}
+
+// versionPattern prefixes pattern with a "v" when it has none, as versions
+// are matched with theirs.
+func versionPattern(pattern string) string {
+	if strings.HasPrefix(pattern, "v") {
+		return pattern
+	}
+	return "v" + pattern
+}
+
+// constraintsString describes the version constraints of pr for messages.
+func (pr Requirement) constraintsString() string {
+	switch {
+	case pr.AnyVersion():
+		return "any version"
+	case pr.VersionPattern == "":
+		return pr.VersionConstraints.String()
+	case len(pr.VersionConstraints) == 0:
+		return pr.VersionPattern
+	}
+	return pr.VersionConstraints.String() + ", " + pr.VersionPattern
 }
 
 type BinaryInstallationOptions struct {
@@ -64,15 +151,102 @@ type BinaryInstallationOptions struct {
 	// ReleasesOnly may be set by commands like validate or build, and
 	// forces Packer to not consider plugin pre-releases.
 	ReleasesOnly bool
+
+	// MinAPIVersion, when set, is the lowest plugin protocol version
+	// accepted, ex: "x5.1" or "5.1", on top of the ones APIVersionMajor and
+	// APIVersionMinor can communicate with.
+	MinAPIVersion string
+
+	// FlatLayout installs and lists binaries directly in the plugin
+	// directory, instead of in <hostname>/<namespace>/<type> directories.
+	// Their names then start with the hostname and namespace of their
+	// source, ex: github.com_hashicorp_packer-plugin-amazon_v1.2.3_x5.0_linux_amd64,
+	// so that the binaries of different plugins do not collide.
+	FlatLayout bool
 }
 
 type ListInstallationsOptions struct {
 	// The directory in which to look for when installing plugins
 	PluginDirectory string
 
+	// FromFolders are other directories plugins are listed from, after
+	// PluginDirectory.
+	FromFolders []string
+
+	// ErrorOnDuplicates makes ListInstallations fail when the same version of
+	// a plugin is installed in several directories, instead of logging a
+	// warning.
+	ErrorOnDuplicates bool
+
+	// WithFileInfo sets the Size and ModTime of the listed installations,
+	// at the cost of a stat call per binary.
+	WithFileInfo bool
+
+	// FilenameCase tells whether binaries whose names only differ by case
+	// are the same. By default they are on macOS and Windows, and are not
+	// on other platforms.
+	FilenameCase FilenameCase
+
+	// Workers is the maximum number of binaries described and checksummed
+	// at the same time. Zero means one per CPU.
+	Workers int
+
+	// Checksum, when set, only lists the binaries whose stored checksum, or
+	// the recorded checksum of the zip they were installed from, is this
+	// sha256 checksum, ex: "sha256:9f86d081884c7d65..." or
+	// "9f86d081884c7d65...".
+	Checksum string
+
 	BinaryInstallationOptions
 }
 
+// workers returns the size of the pool listing binaries.
+func (opts ListInstallationsOptions) workers() int {
+	if opts.Workers > 0 {
+		return opts.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// ErrDuplicateInstallation is returned by ListInstallations when the same
+// version of a plugin is installed in several directories and
+// ErrorOnDuplicates is set.
+var ErrDuplicateInstallation = errors.New("plugin installed in several directories")
+
+// ErrAlreadyInstalled is returned by InstallLatest when
+// InstallOptions.FailIfInstalled is set and a matching version is installed.
+var ErrAlreadyInstalled = errors.New("plugin already installed")
+
+// ErrPluginNotFound is matched, with errors.Is, when a getter knows of no
+// release of a plugin, ex: its repository does not exist.
+var ErrPluginNotFound = errors.New("plugin not found")
+
+// ErrNoMatchingVersion is matched by the error InstallLatest returns when no
+// release of the plugin satisfies its version constraints.
+var ErrNoMatchingVersion = errors.New("no matching version")
+
+// ErrReleaseFileNotFound is matched when a file of a release, ex: a checksum
+// file or a zip, does not exist.
+var ErrReleaseFileNotFound = errors.New("release file not found")
+
+// ErrUnsupportedSource is matched by the errors of getters asked for a plugin
+// they cannot get, ex: a GitHub getter for a plugin hosted elsewhere.
+var ErrUnsupportedSource = errors.New("unsupported plugin source")
+
+// IntegrityError is returned by InstallLatest when a downloaded file does not
+// match its checksum, signature or provenance attestation. Unlike failing to
+// get a file, this is not worked around by trying the next getter.
+type IntegrityError struct {
+	Err error
+}
+
+func (e *IntegrityError) Error() string { return e.Err.Error() }
+
+func (e *IntegrityError) Unwrap() error { return e.Err }
+
+// ErrRateLimited is matched, with errors.Is, by RateLimitErrors.
+var ErrRateLimited = errors.New("rate limited")
+
 // RateLimitError is returned when a getter is being rate limited.
 type RateLimitError struct {
 	SetableEnvVar string
@@ -89,6 +263,10 @@ func (rlerr *RateLimitError) Error() string {
 	return s
 }
 
+func (rlerr *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+func (rlerr *RateLimitError) Unwrap() error { return rlerr.Err }
+
 func (pr Requirement) FilenamePrefix() string {
 	if pr.Identifier == nil {
 		return "packer-plugin-"
@@ -106,134 +284,286 @@ var pluginVersionRegex = regexp.MustCompile(`^v([0-9]+\.[0-9]+\.[0-9]+)(-dev)?$`
 // with opts as a filter.
 //
 // Installations are sorted by version and one binary per version is returned.
-// Last binary detected takes precedence: in the order 'FromFolders' option.
+// When the same version is installed in several directories, the first
+// directory takes precedence: PluginDirectory, then the 'FromFolders' option
+// in order.
 //
 // At least one opts.Checksumers must be given for a binary to be even
 // considered.
+//
+// Filenames are matched case-insensitively when opts.FilenameCase says so,
+// in which case binaries that only differ by case are listed once.
 func (pr Requirement) ListInstallations(opts ListInstallationsOptions) (InstallList, error) {
+	return pr.ListInstallationsContext(context.Background(), opts)
+}
+
+// ListInstallationsContext works like ListInstallations. Binaries are
+// described and checksummed by opts.Workers goroutines; ctx stops the listing
+// and its error is returned when it is done.
+func (pr Requirement) ListInstallationsContext(ctx context.Context, opts ListInstallationsOptions) (InstallList, error) {
 	res := InstallList{}
-	FilenamePrefix := pr.FilenamePrefix()
-	filenameSuffix := opts.FilenameSuffix()
+	opts.Checksummers = pr.checksummers(opts.BinaryInstallationOptions)
+	insensitive := opts.FilenameCase.insensitive()
+	var checksum string
+	if opts.Checksum != "" {
+		var err error
+		if checksum, err = normalizeChecksum(opts.Checksum); err != nil {
+			return nil, err
+		}
+	}
 	log.Printf("[TRACE] listing potential installations for %q that match %q. %#v", pr.Identifier, pr.VersionConstraints, opts)
 
-	glob := ""
-	if pr.Identifier == nil {
-		glob = filepath.Join(opts.PluginDirectory, "*", "*", "*", FilenamePrefix+"*"+filenameSuffix)
-	} else {
-		glob = filepath.Join(opts.PluginDirectory, pr.Identifier.Hostname, pr.Identifier.Namespace, pr.Identifier.Type, FilenamePrefix+"*"+filenameSuffix)
+	type match struct{ dir, path string }
+	var matches []match
+	var paths []string
+	for _, dir := range append([]string{opts.PluginDirectory}, opts.FromFolders...) {
+		dirMatches, err := pr.globInstallations(dir, opts)
+		if err != nil {
+			return nil, fmt.Errorf("ListInstallations: %q failed to list binaries in folder: %v", pr.Identifier.String(), err)
+		}
+		for _, path := range dirMatches {
+			matches = append(matches, match{dir, path})
+			paths = append(paths, path)
+		}
 	}
 
-	matches, err := filepath.Glob(glob)
+	installations, err := pr.inspectInstallations(ctx, paths, opts)
 	if err != nil {
-		return nil, fmt.Errorf("ListInstallations: %q failed to list binaries in folder: %v", pr.Identifier.String(), err)
+		return nil, err
 	}
-	for _, path := range matches {
-		fname := filepath.Base(path)
-		if fname == "." {
-			continue
-		}
-
-		// base name could look like packer-plugin-amazon_v1.2.3_x5.1_darwin_amd64.exe
-		versionsStr := strings.TrimPrefix(fname, FilenamePrefix)
-		versionsStr = strings.TrimSuffix(versionsStr, filenameSuffix)
 
-		if pr.Identifier == nil {
-			if idx := strings.Index(versionsStr, "_"); idx > 0 {
-				versionsStr = versionsStr[idx+1:]
-			}
+	// plugin path and version, to the installation found first.
+	seen := map[string]*Installation{}
+	for i, m := range matches {
+		path := m.path
+		installation := installations[i]
+		if installation == nil {
+			continue
 		}
-
-		descOut, err := exec.Command(path, "describe").Output()
-		if err != nil {
-			log.Printf("couldn't call describe on %q, ignoring", path)
+		pluginVersionStr := installation.Version
+		// binaries filtered out do not shadow the ones of other directories.
+		if checksum != "" && !installation.hasChecksum(checksum) {
+			log.Printf("[TRACE] %q does not have checksum %s, ignoring", path, checksum)
 			continue
 		}
 
-		var describeInfo pluginsdk.SetDescription
-		err = json.Unmarshal(descOut, &describeInfo)
-		if err != nil {
-			log.Printf("%q: describe output deserialization error %q, ignoring", path, err)
+		pluginPath, _ := filepath.Rel(m.dir, filepath.Dir(path))
+		if opts.FlatLayout {
+			pluginPath, _, _ = splitFlatFilename(filepath.Base(path))
 		}
-
-		// versionsStr now looks like v1.2.3_x5.1 or amazon_v1.2.3_x5.1
-		parts := strings.SplitN(versionsStr, "_", 2)
-		pluginVersionStr, protocolVerionStr := parts[0], parts[1]
-		ver, err := version.NewVersion(pluginVersionStr)
-		if err != nil {
-			// could not be parsed, ignoring the file
-			log.Printf("found %q with an incorrect %q version, ignoring it. %v", path, pluginVersionStr, err)
+		key := filepath.ToSlash(pluginPath) + " " + pluginVersionStr
+		if insensitive {
+			key = strings.ToLower(key)
+		}
+		first, found := seen[key]
+		if found && insensitive && strings.EqualFold(first.BinaryPath, path) {
+			log.Printf("[TRACE] %q only differs by case from %q, ignoring", path, first.BinaryPath)
 			continue
 		}
-
-		if ver.Prerelease() != "" && opts.ReleasesOnly {
-			log.Printf("ignoring pre-release plugin %q", path)
+		if found && !sameDir(first.BinaryPath, path, insensitive) {
+			if opts.ErrorOnDuplicates {
+				return nil, fmt.Errorf("%w: %s %s is installed as %q and %q", ErrDuplicateInstallation, pluginPath, pluginVersionStr, first.BinaryPath, path)
+			}
+			log.Printf("[WARNING] %s %s is installed as %q and %q, using %q", pluginPath, pluginVersionStr, first.BinaryPath, path, first.BinaryPath)
 			continue
 		}
+		seen[key] = installation
 
-		matches := pluginVersionRegex.FindStringSubmatch(pluginVersionStr)
-		if matches == nil {
-			log.Printf("invalid version found: %q, ignoring", pluginVersionStr)
-			continue
+		if opts.WithFileInfo {
+			fi, err := os.Stat(path)
+			if err != nil {
+				log.Printf("[TRACE] could not stat %q, ignoring: %v", path, err)
+				continue
+			}
+			installation.Size = fi.Size()
+			installation.ModTime = fi.ModTime()
 		}
+		res = append(res, installation)
+	}
 
-		absVersion := matches[1]
-		if len(matches) == 3 {
-			absVersion = fmt.Sprintf("%s%s", absVersion, matches[2])
+	sort.Sort(res)
+
+	return res, nil
+}
+
+// inspectInstallations returns, for every binary in paths, its installation
+// or nil when it is not one of pr for opts. Binaries are looked at by a pool
+// of opts.Workers goroutines, each with its own checksummers, results are in
+// the order of paths.
+func (pr Requirement) inspectInstallations(ctx context.Context, paths []string, opts ListInstallationsOptions) ([]*Installation, error) {
+	res := make([]*Installation, len(paths))
+
+	workerChecksummers := [][]Checksummer{opts.Checksummers}
+	for len(workerChecksummers) < opts.workers() && len(workerChecksummers) < len(paths) {
+		checksummers, ok := cloneChecksummers(opts.Checksummers)
+		if !ok {
+			log.Printf("[TRACE] checksummers cannot be used concurrently, hashing binaries serially")
+			break
 		}
+		workerChecksummers = append(workerChecksummers, checksummers)
+	}
 
-		if absVersion != describeInfo.Version {
-			log.Printf("plugin %q reported version %s while its name implies version %s, ignoring", path, describeInfo.Version, absVersion)
-			continue
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for _, checksummers := range workerChecksummers {
+		wg.Add(1)
+		go func(checksummers []Checksummer) {
+			defer wg.Done()
+			for i := range jobs {
+				res[i] = pr.inspectInstallation(ctx, paths[i], opts, checksummers)
+			}
+		}(checksummers)
+	}
+
+feed:
+	for i := range paths {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		rawVersion, _ := version.NewVersion(matches[1])
-		// no constraint means always pass, this will happen for implicit
-		// plugin requirements and when we list all plugins.
-		//
-		// Note: we use the raw version name here, without the pre-release
-		// suffix, as otherwise constraints reject them, which is not
-		// what we want by default.
-		if !pr.VersionConstraints.Check(rawVersion) {
-			log.Printf("[TRACE] version %q of file %q does not match constraint %q", pluginVersionStr, path, pr.VersionConstraints.String())
-			continue
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// inspectInstallation returns the installation of pr in path, or nil when the
+// binary is not one for opts, or cannot be trusted.
+func (pr Requirement) inspectInstallation(ctx context.Context, path string, opts ListInstallationsOptions, checksummers []Checksummer) *Installation {
+	FilenamePrefix := pr.FilenamePrefix()
+	filenameSuffix := opts.FilenameSuffix()
+	insensitive := opts.FilenameCase.insensitive()
+
+	fname := filepath.Base(path)
+	if fname == "." {
+		return nil
+	}
+	if opts.FlatLayout {
+		_, binary, ok := splitFlatFilename(fname)
+		if !ok {
+			return nil
 		}
+		fname = binary
+	}
 
-		if err := opts.CheckProtocolVersion(protocolVerionStr); err != nil {
-			log.Printf("[NOTICE] binary %s requires protocol version %s that is incompatible "+
-				"with this version of Packer. %s", path, protocolVerionStr, err)
-			continue
+	// base name could look like packer-plugin-amazon_v1.2.3_x5.1_darwin_amd64.exe
+	versionsStr := trimFilename(fname, FilenamePrefix, filenameSuffix, insensitive)
+
+	if pr.Identifier == nil {
+		if idx := strings.Index(versionsStr, "_"); idx > 0 {
+			versionsStr = versionsStr[idx+1:]
 		}
+	}
 
-		checksumOk := false
-		for _, checksummer := range opts.Checksummers {
+	descOut, err := exec.CommandContext(ctx, LongPath(path), "describe").Output()
+	if err != nil {
+		log.Printf("couldn't call describe on %q, ignoring", path)
+		return nil
+	}
 
-			cs, err := checksummer.GetCacheChecksumOfFile(path)
-			if err != nil {
-				log.Printf("[TRACE] GetChecksumOfFile(%q) failed: %v", path, err)
-				continue
-			}
+	var describeInfo pluginsdk.SetDescription
+	err = json.Unmarshal(descOut, &describeInfo)
+	if err != nil {
+		log.Printf("%q: describe output deserialization error %q, ignoring", path, err)
+	}
 
-			if err := checksummer.ChecksumFile(cs, path); err != nil {
-				log.Printf("[TRACE] ChecksumFile(%q) failed: %v", path, err)
-				continue
-			}
-			checksumOk = true
-			break
-		}
-		if !checksumOk {
-			log.Printf("[TRACE] No checksum found for %q ignoring possibly unsafe binary", path)
+	// versionsStr now looks like v1.2.3_x5.1 or amazon_v1.2.3_x5.1
+	parts := strings.SplitN(versionsStr, "_", 2)
+	pluginVersionStr, protocolVerionStr := parts[0], parts[1]
+	ver, err := version.NewVersion(pluginVersionStr)
+	if err != nil {
+		// could not be parsed, ignoring the file
+		log.Printf("found %q with an incorrect %q version, ignoring it. %v", path, pluginVersionStr, err)
+		return nil
+	}
+
+	if ver.Prerelease() != "" && opts.ReleasesOnly {
+		log.Printf("ignoring pre-release plugin %q", path)
+		return nil
+	}
+
+	matches := pluginVersionRegex.FindStringSubmatch(pluginVersionStr)
+	if matches == nil {
+		log.Printf("invalid version found: %q, ignoring", pluginVersionStr)
+		return nil
+	}
+
+	absVersion := matches[1]
+	if len(matches) == 3 {
+		absVersion = fmt.Sprintf("%s%s", absVersion, matches[2])
+	}
+
+	if absVersion != describeInfo.Version {
+		log.Printf("plugin %q reported version %s while its name implies version %s, ignoring", path, describeInfo.Version, absVersion)
+		return nil
+	}
+
+	rawVersion, _ := version.NewVersion(matches[1])
+	// no constraint means always pass, this will happen for implicit
+	// plugin requirements and when we list all plugins.
+	//
+	// Note: we use the raw version name here, without the pre-release
+	// suffix, as otherwise constraints reject them, which is not
+	// what we want by default.
+	if !pr.AcceptsVersion(rawVersion) {
+		log.Printf("[TRACE] version %q of file %q does not match constraint %q", pluginVersionStr, path, pr.constraintsString())
+		return nil
+	}
+
+	if err := opts.CheckProtocolVersion(protocolVerionStr); err != nil {
+		log.Printf("[NOTICE] binary %s requires protocol version %s that is incompatible "+
+			"with this version of Packer. %s", path, protocolVerionStr, err)
+		return nil
+	}
+
+	checksumOk := false
+	for _, checksummer := range checksummers {
+
+		cs, err := checksummer.GetCacheChecksumOfFile(LongPath(path))
+		if err != nil {
+			log.Printf("[TRACE] GetChecksumOfFile(%q) failed: %v", path, err)
 			continue
 		}
 
-		res = append(res, &Installation{
-			BinaryPath: path,
-			Version:    pluginVersionStr,
-		})
+		if err := checksummer.ChecksumFile(cs, LongPath(path)); err != nil {
+			log.Printf("[TRACE] ChecksumFile(%q) failed: %v", path, err)
+			continue
+		}
+		checksumOk = true
+		break
+	}
+	if !checksumOk {
+		log.Printf("[TRACE] No checksum found for %q ignoring possibly unsafe binary", path)
+		return nil
 	}
 
-	sort.Sort(res)
+	return &Installation{
+		BinaryPath: path,
+		Version:    pluginVersionStr,
+	}
+}
 
-	return res, nil
+// installationsGlob returns the glob matching every binary in dir that could
+// be an installation of pr for the platform of opts.
+func (pr Requirement) installationsGlob(dir string, opts ListInstallationsOptions) string {
+	filenamePrefix := pr.FilenamePrefix()
+	filenameSuffix := opts.FilenameSuffix()
+	if opts.FlatLayout {
+		namePrefix := pr.installedNamePrefix(opts.BinaryInstallationOptions)
+		if pr.Identifier == nil {
+			namePrefix = "*_*_"
+		}
+		return filepath.Join(dir, namePrefix+filenamePrefix+"*"+filenameSuffix)
+	}
+	if pr.Identifier == nil {
+		return filepath.Join(dir, "*", "*", "*", filenamePrefix+"*"+filenameSuffix)
+	}
+	return filepath.Join(dir, pr.Identifier.Hostname, pr.Identifier.Namespace, pr.Identifier.Type, filenamePrefix+"*"+filenameSuffix)
 }
 
 // InstallList is a list of installed plugins (binaries) with their versions,
@@ -304,6 +634,59 @@ func (l InstallList) Swap(i, j int) {
 	l[j] = tmp
 }
 
+// Diff compares l, a list of installations taken before a change, with
+// other, taken after. Installations are matched by plugin identifier, which
+// is deduced from their binary path. When a plugin has several installed
+// versions, its highest one is compared.
+//
+// added and changed are installations of other: the plugins that were not in
+// l, and the ones whose version is not the one in l. removed are the
+// installations of l whose plugin is not in other anymore.
+func (l InstallList) Diff(other InstallList) (added, removed, changed []*Installation) {
+	before, after := l.highestVersions(), other.highestVersions()
+	for _, install := range other {
+		previous, found := before[install.pluginKey()]
+		switch {
+		case after[install.pluginKey()] != install:
+			// a lower version of the plugin
+		case !found:
+			added = append(added, install)
+		case semver.Compare(previous.Version, install.Version) != 0:
+			changed = append(changed, install)
+		}
+	}
+	for _, install := range l {
+		if before[install.pluginKey()] != install {
+			continue
+		}
+		if _, found := after[install.pluginKey()]; !found {
+			removed = append(removed, install)
+		}
+	}
+	return added, removed, changed
+}
+
+// highestVersions returns the highest installed version of each plugin of
+// l, by pluginKey.
+func (l InstallList) highestVersions() map[string]*Installation {
+	res := map[string]*Installation{}
+	for _, install := range l {
+		key := install.pluginKey()
+		if highest, found := res[key]; !found || semver.Compare(highest.Version, install.Version) < 0 {
+			res[key] = install
+		}
+	}
+	return res
+}
+
+// pluginKey identifies the plugin of the installation: the directory of its
+// binary, ex: .../github.com/hashicorp/amazon, and its raw name, as a
+// directory can hold the binaries of several plugins.
+func (i *Installation) pluginKey() string {
+	binaryPath := filepath.ToSlash(i.BinaryPath)
+	return path.Dir(binaryPath) + "/" + rawPluginName.FindString(path.Base(binaryPath))
+}
+
 // Installation describes a plugin installation
 type Installation struct {
 	// Path to where binary is installed.
@@ -313,6 +696,44 @@ type Installation struct {
 	// Version of this plugin. Ex:
 	//  * v1.2.3 for packer-plugin-amazon_v1.2.3_darwin_x5
 	Version string
+
+	// Dependencies declared by the release that was installed, only set by
+	// InstallLatest.
+	Dependencies Requirements
+
+	// Source is the plugin installed, only set by InstallAll, ex: so that
+	// the dependencies it installed can be told apart.
+	Source *addrs.Plugin
+
+	// OtherBinaries are the paths of the binaries installed along with
+	// BinaryPath, when the zip has a manifest listing several of them. Only
+	// set by InstallLatest.
+	OtherBinaries []string
+
+	// SignaturePath is the path of the stored signature of the checksum file
+	// the binary was verified against, see VerifyStoredSignature. Only set
+	// by InstallLatest, with a SignatureVerifier.
+	SignaturePath string
+
+	// ServedBy tells which getters served the installed version, which can
+	// differ from one phase to the other when getters failed over. Only set
+	// by InstallLatest.
+	ServedBy PhaseGetters
+
+	// Size in bytes and modification time of the binary, only set by
+	// ListInstallations when ListInstallationsOptions.WithFileInfo is set.
+	Size    int64
+	ModTime time.Time
+}
+
+// PhaseGetters are the getters that served each phase of an install.
+type PhaseGetters struct {
+	// Releases served the "releases" the installed version was picked from.
+	Releases Getter
+	// Checksum served the checksum file, ex: "sha256", listing the zip.
+	Checksum Getter
+	// Zip served the "zip" the binary was extracted from.
+	Zip Getter
 }
 
 // InstallOptions describes the possible options for installing the plugin that
@@ -327,7 +748,195 @@ type InstallOptions struct {
 	// Forces installation of the plugin, even if already installed.
 	Force bool
 
+	// FailIfInstalled makes InstallLatest return ErrAlreadyInstalled when a
+	// version matching the requirement is already installed, instead of
+	// installing or doing nothing.
+	FailIfInstalled bool
+
+	// Transactional makes InstallAll remove the binaries it installed when
+	// any of the requirements or dependencies fails to install, so that the
+	// plugin directory is left as it was. Binaries that were already present
+	// are never removed, even when they were reinstalled.
+	Transactional bool
+
+	// TrustOnFirstUse allows installing releases without a checksum file,
+	// the ones whose getter tells it is not found, see
+	// ErrReleaseFileNotFound; other errors getting it still fail the
+	// install. The checksum of such a zip is computed and pinned next to the plugin
+	// on its first install, and later installs of the same version fail
+	// when the zip does not match the pinned checksum anymore. Zips are
+	// expected to be named like the getter's zip template, built for the
+	// plugin API version of Packer. It has no effect with a
+	// SignatureVerifier, which requires checksum files.
+	TrustOnFirstUse bool
+
+	// MinReleaseAge, when set, excludes the releases published less than
+	// MinReleaseAge ago, ex: to let new versions soak before using them.
+	// Release dates come from getters; for a getter that does not tell when
+	// one of its releases was published, the filter is disabled with a
+	// warning.
+	MinReleaseAge time.Duration
+
+	// CrossCheckChecksums makes InstallLatest compare the checksum of the
+	// zip to install with the one listed by every other getter, and fail
+	// with ErrChecksumConflict when they differ. By default, the checksum
+	// of the first getter listing the zip is trusted.
+	CrossCheckChecksums bool
+
+	// CaseSensitiveChecksumFilenames disables the case-insensitive matching
+	// of the filenames listed in checksum files. By default, an entry like
+	// PACKER-PLUGIN-AMAZON_v1.2.3_x5.0_Linux_amd64.zip is downloaded as
+	// listed and installed as packer-plugin-amazon_v1.2.3_x5.0_linux_amd64,
+	// which is how releases are named. The checksum itself is always exact,
+	// so a wrongly matched entry can only make the verification fail;
+	// entries only differing by case and with different checksums are
+	// ignored.
+	CaseSensitiveChecksumFilenames bool
+
+	// StatePath, when set, is the file InstallAll records the plugins it
+	// installed in, so that a batch that was interrupted resumes where it
+	// stopped when run again. Recorded plugins are only skipped once their
+	// binary is verified against the recorded checksum. The file is removed
+	// when every plugin installed.
+	StatePath string
+
+	// Metrics, when set, is notified of every request done to the Getters.
+	Metrics GetterMetrics
+
+	// Tracer, when set, gets a span for every request done to the Getters.
+	Tracer InstallTracer
+
+	// RateLimiter, when set, paces every request done to the Getters.
+	RateLimiter RateLimiter
+
+	// Progress, when set, is notified by InstallAll of every plugin it is
+	// done with, ex: to stream results as JSON lines with a
+	// JSONLinesProgress.
+	Progress InstallProgress
+
+	// AuditLogPath, when set, is the file InstallAll appends an AuditRecord
+	// to for every plugin it is done with.
+	AuditLogPath string
+
+	// SignatureVerifier, when set, makes sure checksum files were signed by
+	// the plugin authors before trusting them.
+	SignatureVerifier SignatureVerifier
+
+	// ProvenanceVerifier, when set, makes sure downloaded zips match the
+	// provenance attestation of their release before trusting them.
+	ProvenanceVerifier ProvenanceVerifier
+
+	// SigstoreVerifier, when set, makes sure downloaded zips were signed, as
+	// recorded in a transparency log, by a trusted identity before trusting
+	// them.
+	SigstoreVerifier SigstoreVerifier
+
+	// TagSignatureVerifier, when set, makes sure the git tag of a release
+	// was signed by a trusted key before installing it, for the getters that
+	// are TagSignatureGetters.
+	TagSignatureVerifier TagSignatureVerifier
+
+	// ZipTransform, when set, is called with the zip body returned by a
+	// getter, and returns the zip to checksum and extract instead, ex: to
+	// decrypt zips a mirror stores encrypted. Closing the returned
+	// ReadCloser must close body. When an error is returned, body is closed
+	// and the next getter is tried.
+	ZipTransform func(body io.ReadCloser) (io.ReadCloser, error)
+
+	// VersionSelector, when set, replaces the default highest version
+	// selection. It is called with the released versions matching the
+	// version constraints and returns the one to try first. It is then called
+	// again with the remaining candidates to rank the versions to fall back
+	// to when one has no compatible binary. When it returns nil or a version
+	// that is not a candidate, the remaining candidates are tried highest
+	// first.
+	VersionSelector func(candidates []*version.Version) *version.Version
+
+	// ChecksumFetchWorkers, when greater than one, is the number of checksum
+	// files InstallLatest gets at the same time from the first getter: the
+	// checksum files of the next candidate versions are fetched ahead of
+	// time, so that skipping incompatible versions is faster. Metrics and
+	// Tracer are then called concurrently.
+	ChecksumFetchWorkers int
+
+	// ConcurrentAssetFetches makes InstallLatest get the checksum files of a
+	// version, their signatures, and the zip expected for the platform at
+	// the same time, instead of one after the other. Files are still
+	// verified before being used, so a checksum mismatch still aborts the
+	// install before the binary is written, but a zip may be downloaded for
+	// nothing, ex: when its checksum file lists another plugin API version.
+	// Metrics and Tracer are then called concurrently.
+	ConcurrentAssetFetches bool
+
+	// BinaryMode is the mode of installed binaries, 0755 when zero. It must
+	// keep binaries executable by their owner.
+	BinaryMode os.FileMode
+
+	// ChownBinary, when set, is called with every extracted binary before it
+	// is moved in place, ex: to give it to a specific group.
+	ChownBinary func(path string) error
+
+	// MaxExtractRate, when greater than zero, is the number of bytes per
+	// second binaries are written at when extracted, ex: so that installs
+	// do not saturate the disk of a shared CI host. Unlimited by default.
+	MaxExtractRate int64
+
+	// TempFilePrefix is the prefix of the temporary files binaries are
+	// extracted to before being moved in place, ex: so that the tools
+	// sharing a plugin directory can tell whose files are in flight.
+	// DefaultTempFilePrefix when empty.
+	TempFilePrefix string
+
+	// EmbeddedVersionCheck, when set, starts installed binaries to make sure
+	// they report the version their filename tells.
+	EmbeddedVersionCheck EmbeddedVersionCheck
+
+	// KeepVersions, when greater than zero, is how many installed versions
+	// of a plugin InstallLatest keeps after installing one: the binaries of
+	// the older versions, along with their checksum files, are removed. The
+	// version just installed is always kept.
+	KeepVersions int
+
+	// MinFreeInodes, when greater than zero, is how many inodes must be free
+	// on the filesystem of PluginDirectory for InstallLatest to download a
+	// plugin, see CheckFreeInodes.
+	MinFreeInodes uint64
+
 	BinaryInstallationOptions
+
+	// bundles holds the release bundles got by InstallLatest.
+	bundles bundleCache
+
+	// checksumFiles holds the checksum files prefetched by InstallLatest.
+	checksumFiles checksumFileCache
+
+	// assets holds the files fetched concurrently by InstallLatest.
+	assets assetCache
+
+	// verifiedZips holds the zips verified during an InstallAll.
+	verifiedZips verifiedZipCache
+
+	// checkOnly makes installLatest stop once the zip to install is
+	// verified, without writing to the plugin directory. See
+	// CheckInstallable.
+	checkOnly bool
+
+	// attempts holds the outcome of the requests done to each getter by
+	// InstallLatest.
+	attempts *getterAttempts
+
+	// ctx interrupts the rate limit waits of an install, see
+	// InstallLatestContext.
+	ctx context.Context
+
+	// upToDate, when set, is filled by InstallLatest with the installation
+	// it found already installed, ex: so that InstallAll can install its
+	// dependencies.
+	upToDate *Installation
+
+	// extractClock throttles the extraction of binaries to MaxExtractRate,
+	// the system clock when nil.
+	extractClock extractClock
 }
 
 type GetOptions struct {
@@ -338,6 +947,8 @@ type GetOptions struct {
 	version *version.Version
 
 	expectedZipFilename string
+
+	expectedZipChecksum *FileChecksum
 }
 
 // ExpectedZipFilename is the filename of the zip we expect to find, the
@@ -346,6 +957,16 @@ func (gp *GetOptions) ExpectedZipFilename() string {
 	return gp.expectedZipFilename
 }
 
+// ExpectedZipChecksum is the checksum the zip must match and its type, ex:
+// "sha256". Like ExpectedZipFilename, it is known only after parsing the
+// checksum file; it is nil for zips trusted on first use.
+func (gp *GetOptions) ExpectedZipChecksum() (string, Checksum) {
+	if gp.expectedZipChecksum == nil {
+		return "", nil
+	}
+	return gp.expectedZipChecksum.Checksummer.Type, gp.expectedZipChecksum.Expected
+}
+
 func (binOpts *BinaryInstallationOptions) CheckProtocolVersion(remoteProt string) error {
 	remoteProt = strings.TrimPrefix(remoteProt, "x")
 	parts := strings.Split(remoteProt, ".")
@@ -364,7 +985,7 @@ func (binOpts *BinaryInstallationOptions) CheckProtocolVersion(remoteProt string
 			" This version of Packer can only communicate with plugins using that version.", vMajor, binOpts.APIVersionMajor)
 	}
 
-	if vMinor == binOpts.APIVersionMinor {
+	if vMinor == binOpts.APIVersionMinor && binOpts.MinAPIVersion == "" {
 		return nil
 	}
 
@@ -383,6 +1004,23 @@ func (binOpts *BinaryInstallationOptions) CheckProtocolVersion(remoteProt string
 			"Please upgrade Packer or use an older version of the plugin if possible.", vMinor, binOpts.APIVersionMinor)
 	}
 
+	return binOpts.checkMinAPIVersion(vMajor, vMinori)
+}
+
+// checkMinAPIVersion returns an error when the remote protocol version is
+// lower than binOpts.MinAPIVersion.
+func (binOpts *BinaryInstallationOptions) checkMinAPIVersion(vMajor string, vMinor int) error {
+	if binOpts.MinAPIVersion == "" {
+		return nil
+	}
+	minMajor, minMinorStr, found := strings.Cut(strings.TrimPrefix(binOpts.MinAPIVersion, "x"), ".")
+	minMinor, err := strconv.Atoi(minMinorStr)
+	if !found || err != nil {
+		return fmt.Errorf("Invalid minimum protocol: %q, expected something like 'x%s.%s'", binOpts.MinAPIVersion, binOpts.APIVersionMajor, binOpts.APIVersionMinor)
+	}
+	if vMajor != minMajor || vMinor < minMinor {
+		return fmt.Errorf("Unsupported remote protocol version \"x%s.%d\". The minimum protocol version accepted is %q.", vMajor, vMinor, "x"+minMajor+"."+minMinorStr)
+	}
 	return nil
 }
 
@@ -443,6 +1081,19 @@ type Getter interface {
 
 type Release struct {
 	Version string `json:"version"`
+
+	// Dependencies are the companion plugins this release needs, when the
+	// release metadata declares some. See InstallAll.
+	Dependencies Requirements `json:"-"`
+
+	// PublishedAt is when the release was published, when the getter knows
+	// it. See InstallOptions.MinReleaseAge.
+	PublishedAt time.Time `json:"-"`
+
+	// Checksums are the checksum entries of the release assets, by
+	// checksummer type, when the release metadata embeds them. They are used
+	// instead of getting the checksum file of the release.
+	Checksums map[string][]ChecksumFileEntry `json:"-"`
 }
 
 func ParseReleases(f io.ReadCloser) ([]Release, error) {
@@ -456,10 +1107,23 @@ type ChecksumFileEntry struct {
 	Checksum                  string `json:"checksum"`
 	ext, binVersion, os, arch string
 	protVersion               string
+
+	// versionCore is binVersion without the commit or build suffix some
+	// release pipelines add to the filenames, ex: v0.3.0 for v0.3.0-abcdef1.
+	versionCore string
+
+	// zipName is the name of the zip to download, and binaryName the name of
+	// the binary in it, without extension.
+	zipName, binaryName string
+
+	// foldCase makes versions match whatever their case, for entries
+	// initialized case-insensitively.
+	foldCase bool
 }
 
 func (e ChecksumFileEntry) Ext() string         { return e.ext }
 func (e ChecksumFileEntry) BinVersion() string  { return e.binVersion }
+func (e ChecksumFileEntry) VersionCore() string { return e.versionCore }
 func (e ChecksumFileEntry) ProtVersion() string { return e.protVersion }
 func (e ChecksumFileEntry) Os() string          { return e.os }
 func (e ChecksumFileEntry) Arch() string        { return e.arch }
@@ -467,9 +1131,18 @@ func (e ChecksumFileEntry) Arch() string        { return e.arch }
 // a file inside will look like so:
 //
 //	packer-plugin-comment_v0.2.12_x5.0_freebsd_amd64.zip
-func (e *ChecksumFileEntry) init(req *Requirement) (err error) {
+//
+// With caseInsensitive, the case of the prefix, protocol version, OS and
+// architecture is ignored, and versions are matched case-insensitively. The
+// zip is still downloaded as listed, and its version kept as is, ex: for
+// pre-releases like v1.0.0-RC1.
+func (e *ChecksumFileEntry) init(req *Requirement, caseInsensitive bool) (err error) {
+	prefix := req.FilenamePrefix()
 	filename := e.Filename
-	res := strings.TrimPrefix(filename, req.FilenamePrefix())
+	if caseInsensitive && len(filename) >= len(prefix) && strings.EqualFold(filename[:len(prefix)], prefix) {
+		filename = prefix + filename[len(prefix):]
+	}
+	res := strings.TrimPrefix(filename, prefix)
 	// res now looks like v0.2.12_x5.0_freebsd_amd64.zip
 
 	e.ext = filepath.Ext(res)
@@ -480,18 +1153,101 @@ func (e *ChecksumFileEntry) init(req *Requirement) (err error) {
 	parts := strings.Split(res, "_")
 	// ["v0.2.12", "x5.0", "freebsd", "amd64"]
 	if len(parts) < 4 {
-		return fmt.Errorf("malformed filename expected %s{version}_x{protocol-version}_{os}_{arch}", req.FilenamePrefix())
+		return fmt.Errorf("malformed filename expected %s{version}_x{protocol-version}_{os}_{arch}", prefix)
 	}
 
 	e.binVersion, e.protVersion, e.os, e.arch = parts[0], parts[1], parts[2], parts[3]
+	e.versionCore = versionCore(e.binVersion)
+	e.zipName = e.Filename
+	e.binaryName = strings.TrimSuffix(filename, e.ext)
+	if caseInsensitive {
+		e.foldCase = true
+		e.protVersion, e.os, e.arch = strings.ToLower(e.protVersion), strings.ToLower(e.os), strings.ToLower(e.arch)
+		// the binary is named like releases are.
+		e.binaryName = prefix + strings.Join([]string{e.binVersion, e.protVersion, e.os, e.arch}, "_")
+	}
 
 	return err
 }
 
+// initChecksumFileEntry parses the filename of entry, listed in the checksum
+// file of getter.
+func (opts *InstallOptions) initChecksumFileEntry(pr *Requirement, getter Getter, entry *ChecksumFileEntry) error {
+	if tmpl := assetNames(getter).Zip; tmpl != DefaultZipAssetTemplate {
+		return entry.initFromTemplate(pr, tmpl, opts.BinaryInstallationOptions)
+	}
+	return entry.init(pr, !opts.CaseSensitiveChecksumFilenames)
+}
+
+// conflictingEntries returns the lower cased filenames that entries list
+// several times, with different cases and checksums. As they are matched
+// case-insensitively, which one is right cannot be told.
+func conflictingEntries(entries []ChecksumFileEntry) map[string]bool {
+	checksums := map[string]string{}
+	res := map[string]bool{}
+	for _, entry := range entries {
+		filename := strings.ToLower(entry.Filename)
+		if checksum, found := checksums[filename]; found && !strings.EqualFold(checksum, entry.Checksum) {
+			res[filename] = true
+		}
+		checksums[filename] = entry.Checksum
+	}
+	return res
+}
+
+// filenameVersionRegex matches the versions of the filenames listed in
+// checksum files that have a commit or build suffix, with the semver core in
+// the first group, ex: v0.3.0 for v0.3.0-abcdef1 or v0.3.0+build.12.
+// Pre-releases, ex: v0.3.0-rc.1, are other versions and are not matched.
+var filenameVersionRegex = regexp.MustCompile(`^([vV]?[0-9]+\.[0-9]+\.[0-9]+)(?:-[0-9a-f]{7,40}(?:\+.+)?|\+.+)$`)
+
+// versionCore returns the semver core of the version of a filename, or the
+// version itself when it has no commit or build suffix or is not a semver.
+func versionCore(v string) string {
+	if matches := filenameVersionRegex.FindStringSubmatch(v); matches != nil {
+		return matches[1]
+	}
+	return v
+}
+
+// matchesVersion tells whether e is a binary of version v, either as is or
+// once stripped of its commit or build suffix.
+func (e *ChecksumFileEntry) matchesVersion(v string) bool {
+	equal := func(a, b string) bool { return a == b }
+	if e.foldCase {
+		equal = strings.EqualFold
+	}
+	return equal(e.binVersion, v) || (e.versionCore != "" && equal(e.versionCore, v))
+}
+
+// exactVersionsFirst returns entries with the ones listing version v as is
+// first, so that they are installed rather than the ones only matching v
+// once stripped of their commit or build suffix.
+func (opts *InstallOptions) exactVersionsFirst(pr *Requirement, getter Getter, entries []ChecksumFileEntry, v string) []ChecksumFileEntry {
+	var exact, others []ChecksumFileEntry
+	for _, entry := range entries {
+		parsed := entry
+		if err := opts.initChecksumFileEntry(pr, getter, &parsed); err == nil &&
+			(parsed.binVersion == v || parsed.foldCase && strings.EqualFold(parsed.binVersion, v)) {
+			exact = append(exact, entry)
+			continue
+		}
+		others = append(others, entry)
+	}
+	return append(exact, others...)
+}
+
+// validate checks that e is a binary of expectedVersion for the platform of
+// installOpts. When e only matches expectedVersion without its suffix, the
+// zip keeps its name but the binary in it is expected to be named, and is
+// installed, like the binaries of expectedVersion.
 func (e *ChecksumFileEntry) validate(expectedVersion string, installOpts BinaryInstallationOptions) error {
-	if e.binVersion != expectedVersion {
+	if !e.matchesVersion(expectedVersion) {
 		return fmt.Errorf("wrong version: '%s' does not match expected %s ", e.binVersion, expectedVersion)
 	}
+	if e.binVersion != expectedVersion {
+		e.binaryName = strings.Replace(e.binaryName, "_"+e.binVersion+"_", "_"+expectedVersion+"_", 1)
+	}
 	if e.os != installOpts.OS || e.arch != installOpts.ARCH {
 		return fmt.Errorf("wrong system, expected %s_%s ", installOpts.OS, installOpts.ARCH)
 	}
@@ -504,16 +1260,78 @@ func ParseChecksumFileEntries(f io.Reader) ([]ChecksumFileEntry, error) {
 	return entries, json.NewDecoder(f).Decode(&entries)
 }
 
+// InstallLatestContext is InstallLatest, with its waits for
+// opts.RateLimiter interrupted when ctx is done.
+func (pr *Requirement) InstallLatestContext(ctx context.Context, opts InstallOptions) (*Installation, error) {
+	opts.ctx = ctx
+	return pr.InstallLatest(opts)
+}
+
+// InstallLatest installs the highest released version of pr that is
+// compatible with opts, or does nothing when it is already installed.
+//
+// Versions that have no binary for the platform of opts, either because their
+// checksum file lists none or because the listed zip cannot be downloaded,
+// are skipped in favour of the next highest version.
+//
+// On failure, the error also tells the last error of each getter, so that
+// the failures of the getters tried first are not lost.
 func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error) {
+	opts.attempts = &getterAttempts{}
+	install, err := pr.installLatest(opts)
+	if err != nil {
+		if attemptsErr := opts.attempts.err(); attemptsErr != nil {
+			err = errors.Join(err, attemptsErr)
+		}
+		return nil, err
+	}
+	if install != nil {
+		opts.Checksummers = pr.checksummers(opts.BinaryInstallationOptions)
+		if err := opts.checkEmbeddedVersion(install); err != nil {
+			return nil, err
+		}
+		if opts.KeepVersions > 0 {
+			if err := pr.pruneInstalledVersions(install, opts); err != nil {
+				log.Printf("[WARNING] %s", err)
+			}
+		}
+	}
+	return install, nil
+}
 
+func (pr *Requirement) installLatest(opts InstallOptions) (*Installation, error) {
 	getters := opts.Getters
+	opts.Checksummers = pr.checksummers(opts.BinaryInstallationOptions)
+	opts.bundles = bundleCache{}
+	opts.checksumFiles = checksumFileCache{}
+	opts.assets = assetCache{}
+	defer opts.assets.cleanup()
+
+	if err := opts.checkBinaryMode(); err != nil {
+		return nil, err
+	}
+
+	if opts.FailIfInstalled {
+		installs, err := pr.ListInstallations(ListInstallationsOptions{
+			PluginDirectory:           opts.PluginDirectory,
+			BinaryInstallationOptions: opts.BinaryInstallationOptions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(installs) > 0 {
+			return nil, fmt.Errorf("%w: %s %s in %q", ErrAlreadyInstalled, pr.Identifier, installs[0].Version, installs[0].BinaryPath)
+		}
+	}
 
-	log.Printf("[TRACE] getting available versions for the %s plugin", pr.Identifier)
+	log.Printf("[TRACE] getting available versions for the %s plugin, accepting %s", pr.Identifier, pr.constraintsString())
 	versions := version.Collection{}
+	dependencies := map[string]Requirements{}
+	var served PhaseGetters
 	var errs *multierror.Error
-	for _, getter := range getters {
+	for getterIdx, getter := range getters {
 
-		releasesFile, err := getter.Get("releases", GetOptions{
+		releasesFile, err := opts.get(getter, "releases", GetOptions{
 			PluginRequirement:         pr,
 			BinaryInstallationOptions: opts.BinaryInstallationOptions,
 		})
@@ -531,11 +1349,12 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 			continue
 		}
 		if len(releases) == 0 {
-			err := fmt.Errorf("no release found")
+			err := fmt.Errorf("%w: no release found", ErrPluginNotFound)
 			errs = multierror.Append(errs, err)
 			log.Printf("[TRACE] %s", err.Error())
 			continue
 		}
+		minPublishedAt := opts.minPublishedAt(releases)
 		for _, release := range releases {
 			v, err := version.NewVersion(release.Version)
 			if err != nil {
@@ -544,23 +1363,32 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 				log.Printf("[TRACE] %s, ignoring it", err.Error())
 				continue
 			}
-			if pr.VersionConstraints.Check(v) {
+			if !minPublishedAt.IsZero() && release.PublishedAt.After(minPublishedAt) {
+				log.Printf("[TRACE] ignoring %s %s, published on %s, less than %s ago", pr.Identifier, release.Version, release.PublishedAt.Format(time.RFC3339), opts.MinReleaseAge)
+				continue
+			}
+			if pr.AcceptsVersion(v) {
 				versions = append(versions, v)
+				dependencies[v.String()] = release.Dependencies
+				if getterIdx == 0 {
+					opts.checksumFiles.addReleaseChecksums(v, release.Checksums)
+				}
 			}
 		}
 		if len(versions) == 0 {
-			err := fmt.Errorf("no matching version found in releases. In %v", releases)
+			err := fmt.Errorf("%w found in releases. In %v", ErrNoMatchingVersion, releases)
 			errs = multierror.Append(errs, err)
 			log.Printf("[TRACE] %s", err.Error())
 			continue
 		}
 
+		served.Releases = getter
 		break
 	}
 
 	if len(versions) == 0 {
 		if errs.Len() == 0 {
-			err := fmt.Errorf("no release version found for constraints: %q", pr.VersionConstraints.String())
+			err := fmt.Errorf("%w found for constraints: %q", ErrNoMatchingVersion, pr.constraintsString())
 			errs = multierror.Append(errs, err)
 		}
 		return nil, errs
@@ -569,40 +1397,88 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 	// Here we want to try every release in order, starting from the highest one
 	// that matches the requirements. The system and protocol version need to
 	// match too.
-	sort.Sort(sort.Reverse(versions))
+	versions = opts.orderVersions(versions)
 	log.Printf("[DEBUG] will try to install: %s", versions)
 
-	for _, version := range versions {
+	// Tells what was released when no version turns out to be compatible.
+	noCompatibleVersion := &NoCompatibleVersionError{
+		Plugin:     pr.Identifier,
+		OS:         opts.OS,
+		ARCH:       opts.ARCH,
+		APIVersion: fmt.Sprintf("x%s.%s", opts.APIVersionMajor, opts.APIVersionMinor),
+	}
+	foundCompatible := false
+
+	for versionIdx, version := range versions {
 		//TODO(azr): split in its own InstallVersion(version, opts) function
 
-		outputFolder := filepath.Join(
-			// Pick last folder as it's the one with the highest priority
-			opts.PluginDirectory,
-			// add expected full path
-			filepath.Join(pr.Identifier.Parts()...),
-		)
+		if opts.ChecksumFetchWorkers > 1 && len(getters) > 0 {
+			opts.prefetchChecksumFiles(getters[0], pr, versions[versionIdx:])
+		}
+
+		outputFolder := pr.installDir(opts.PluginDirectory, opts.BinaryInstallationOptions)
+		namePrefix := pr.installedNamePrefix(opts.BinaryInstallationOptions)
 
 		log.Printf("[TRACE] fetching checksums file for the %q version of the %s plugin in %q...", version, pr.Identifier, outputFolder)
 
 		var checksum *FileChecksum
-		for _, getter := range getters {
+		release := IncompatibleRelease{Version: "v" + version.String()}
+		checksumRead := false
+		tagVerified := false
+		for getterIdx, getter := range getters {
 			if checksum != nil {
 				break
 			}
+			if opts.TagSignatureVerifier != nil && !tagVerified {
+				tagGetOpts := GetOptions{
+					PluginRequirement:         pr,
+					BinaryInstallationOptions: opts.BinaryInstallationOptions,
+					version:                   version,
+				}
+				verified, err := opts.verifyTagSignature(getter, tagGetOpts)
+				if err != nil {
+					var integrityErr *IntegrityError
+					aborting := errors.As(err, &integrityErr)
+					err := fmt.Errorf("could not verify the git tag of %s version %s: %w", pr.Identifier, version, err)
+					errs = multierror.Append(errs, err)
+					log.Printf("[TRACE] %s", err)
+					if aborting {
+						return nil, errs
+					}
+					continue
+				}
+				tagVerified = verified
+			}
+			if opts.ConcurrentAssetFetches {
+				opts.prefetchAssets(getterIdx, getter, pr, version, outputFolder, namePrefix)
+			}
 			for _, checksummer := range opts.Checksummers {
 				if checksum != nil {
 					break
 				}
-				checksumFile, err := getter.Get(checksummer.Type, GetOptions{
+				checksumGetOpts := GetOptions{
 					PluginRequirement:         pr,
 					BinaryInstallationOptions: opts.BinaryInstallationOptions,
 					version:                   version,
-				})
+				}
+				checksumFile, err := opts.getChecksumFile(getterIdx, getter, checksummer.Type, checksumGetOpts)
+				trustedOnFirstUse := false
 				if err != nil {
 					err := fmt.Errorf("could not get %s checksum file for %s version %s. Is the file present on the release and correctly named ? %w", checksummer.Type, pr.Identifier, version, err)
 					errs = multierror.Append(errs, err)
 					log.Printf("[TRACE] %s", err)
-					continue
+					// only releases that have no checksum file are trusted,
+					// not the ones whose checksum file could not be got.
+					if !opts.trustsOnFirstUse(checksummer) || !errors.Is(err, ErrReleaseFileNotFound) {
+						continue
+					}
+					checksumFile, err = trustOnFirstUseChecksumFile(getter, checksumGetOpts, outputFolder)
+					if err != nil {
+						errs = multierror.Append(errs, err)
+						log.Printf("[TRACE] %s", err)
+						continue
+					}
+					trustedOnFirstUse = true
 				}
 				entries, err := ParseChecksumFileEntries(checksumFile)
 				_ = checksumFile.Close()
@@ -612,14 +1488,44 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 					log.Printf("[TRACE] %s", err)
 					continue
 				}
+				var signed *signedChecksumFile
+				if opts.SignatureVerifier != nil {
+					entries, signed, err = opts.verifiedChecksumFileEntries(getterIdx, getter, checksummer, checksumGetOpts, entries)
+					if err != nil {
+						var integrityErr *IntegrityError
+						aborting := errors.As(err, &integrityErr)
+						err := fmt.Errorf("could not verify the signature of the %s checksum file for %s version %s: %w", checksummer.Type, pr.Identifier, version, err)
+						errs = multierror.Append(errs, err)
+						log.Printf("[TRACE] %s", err)
+						if aborting {
+							return nil, errs
+						}
+						continue
+					}
+				}
 
+				checksumRead = true
+				var conflicting map[string]bool
+				if !opts.CaseSensitiveChecksumFilenames {
+					conflicting = conflictingEntries(entries)
+				}
+				entries = opts.exactVersionsFirst(pr, getter, entries, "v"+version.String())
 				for _, entry := range entries {
-					if err := entry.init(pr); err != nil {
+					if conflicting[strings.ToLower(entry.Filename)] {
+						err := fmt.Errorf("ignoring %s: it is listed several times with different checksums", entry.Filename)
+						errs = multierror.Append(errs, err)
+						log.Printf("[TRACE] %s", err)
+						continue
+					}
+					if err := opts.initChecksumFileEntry(pr, getter, &entry); err != nil {
 						err := fmt.Errorf("could not parse checksum filename %s. Is it correctly formatted ? %s", entry.Filename, err)
 						errs = multierror.Append(errs, err)
 						log.Printf("[TRACE] %s", err)
 						continue
 					}
+					if entry.matchesVersion(release.Version) && entry.os == opts.OS && entry.arch == opts.ARCH {
+						release.addProtocolVersion(entry.protVersion)
+					}
 					if err := entry.validate("v"+version.String(), opts.BinaryInstallationOptions); err != nil {
 						err := fmt.Errorf("ignoring invalid remote binary %s: %s", entry.Filename, err)
 						errs = multierror.Append(errs, err)
@@ -629,12 +1535,24 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 
 					log.Printf("[TRACE] About to get: %s", entry.Filename)
 
-					cs, err := checksummer.ParseChecksum(strings.NewReader(entry.Checksum))
-					if err != nil {
-						err := fmt.Errorf("could not parse %s checksum: %s. Make sure the checksum file contains the checksum and only the checksum", checksummer.Type, err)
-						errs = multierror.Append(errs, err)
-						log.Printf("[TRACE] %s", err)
-						continue
+					// Without checksum, the zip is trusted on first use.
+					var cs Checksum
+					if !trustedOnFirstUse || entry.Checksum != "" {
+						cs, err = checksummer.ParseChecksum(strings.NewReader(entry.Checksum))
+						if err != nil {
+							err := fmt.Errorf("could not parse %s checksum: %s. Make sure the checksum file contains the checksum and only the checksum", checksummer.Type, err)
+							errs = multierror.Append(errs, err)
+							log.Printf("[TRACE] %s", err)
+							continue
+						}
+					}
+
+					if opts.CrossCheckChecksums {
+						if err := opts.crossCheckChecksum(getterIdx, checksumGetOpts, checksummer, entry); err != nil {
+							errs = multierror.Append(errs, err)
+							log.Printf("[TRACE] %s, aborting", err)
+							return nil, errs
+						}
 					}
 
 					checksum = &FileChecksum{
@@ -642,19 +1560,20 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 						Expected:    cs,
 						Checksummer: checksummer,
 					}
-					expectedZipFilename := checksum.Filename
-					expectedBinaryFilename := strings.TrimSuffix(expectedZipFilename, filepath.Ext(expectedZipFilename)) + opts.BinaryInstallationOptions.Ext
+					served.Checksum = getter
+					expectedZipFilename := entry.zipName
+					expectedBinaryFilename := entry.binaryName + opts.BinaryInstallationOptions.Ext
 
 					outputFileName := filepath.Join(
 						outputFolder,
-						expectedBinaryFilename,
+						namePrefix+expectedBinaryFilename,
 					)
 					for _, potentialChecksumer := range opts.Checksummers {
 						// First check if a local checksum file is already here in the expected
 						// download folder. Here we want to download a binary so we only check
 						// for an existing checksum file from the folder we want to download
 						// into.
-						cs, err := potentialChecksumer.GetCacheChecksumOfFile(outputFileName)
+						cs, err := potentialChecksumer.GetCacheChecksumOfFile(LongPath(outputFileName))
 						if err == nil && len(cs) > 0 {
 							localChecksum := &FileChecksum{
 								Expected:    cs,
@@ -663,25 +1582,81 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 
 							log.Printf("[TRACE] found a pre-exising %q checksum file", potentialChecksumer.Type)
 							// if outputFile is there and matches the checksum: do nothing more.
-							if err := localChecksum.ChecksumFile(localChecksum.Expected, outputFileName); err == nil && !opts.Force {
+							if err := localChecksum.ChecksumFile(localChecksum.Expected, LongPath(outputFileName)); err == nil && !opts.Force {
 								log.Printf("[INFO] %s v%s plugin is already correctly installed in %q", pr.Identifier, version, outputFileName)
+								if opts.upToDate != nil {
+									*opts.upToDate = Installation{
+										BinaryPath:   strings.ReplaceAll(outputFileName, "\\", "/"),
+										Version:      "v" + version.String(),
+										Dependencies: dependencies[version.String()],
+									}
+								}
 								return nil, nil // success
 							}
 						}
 					}
 
 					// The last folder from the installation list is where we will install.
-					outputFileName = filepath.Join(outputFolder, expectedBinaryFilename)
+					outputFileName = filepath.Join(outputFolder, namePrefix+expectedBinaryFilename)
 
 					// create directories if need be
-					if err := os.MkdirAll(outputFolder, 0755); err != nil {
-						err := fmt.Errorf("could not create plugin folder %q: %w", outputFolder, err)
-						errs = multierror.Append(errs, err)
-						log.Printf("[TRACE] %s", err.Error())
-						return nil, errs
+					if !opts.checkOnly {
+						// Fail before downloading rather than after when
+						// we cannot write the plugin in the end. Already
+						// installed plugins do not get here, so that
+						// read-only plugin directories can be provisioned.
+						if err := CheckPluginDirWritable(outputFolder); err != nil {
+							errs = multierror.Append(errs, err)
+							return nil, errs
+						}
+						if opts.MinFreeInodes > 0 {
+							if err := CheckFreeInodes(outputFolder, opts.MinFreeInodes); err != nil {
+								errs = multierror.Append(errs, err)
+								return nil, errs
+							}
+						}
+						if err := os.MkdirAll(LongPath(outputFolder), 0755); err != nil {
+							err := fmt.Errorf("could not create plugin folder %q: %w", outputFolder, err)
+							errs = multierror.Append(errs, err)
+							log.Printf("[TRACE] %s", err.Error())
+							return nil, errs
+						}
 					}
 
-					for _, getter := range getters {
+					for zipGetterIdx, getter := range getters {
+						zipGetOpts := GetOptions{
+							PluginRequirement:         pr,
+							BinaryInstallationOptions: opts.BinaryInstallationOptions,
+							version:                   version,
+							expectedZipFilename:       expectedZipFilename,
+							expectedZipChecksum:       checksum,
+						}
+						binaryEntry := ""
+						if tmpl := assetNames(getter).Binary; tmpl != "" {
+							binaryEntry = zipGetOpts.RenderAssetName(tmpl) + opts.BinaryInstallationOptions.Ext
+						}
+
+						// Another requirement of the InstallAll resolved to
+						// this zip, which was downloaded and verified then.
+						if zipKey, ok := opts.verifiedZipKey(getter, zipGetOpts, checksum); ok && opts.verifiedZips[zipKey] != "" {
+							otherBinaries, err := opts.installVerifiedZip(opts.verifiedZips[zipKey], checksum, outputFolder, namePrefix, expectedBinaryFilename, binaryEntry)
+							if err != nil {
+								errs = multierror.Append(errs, err)
+								return nil, errs
+							}
+							log.Printf("[TRACE] installed %s from the %s zip verified for another requirement", pr.Identifier, expectedZipFilename)
+							recordZipChecksum(outputFileName, expectedZipFilename, checksum, "")
+							served.Zip = getter
+							return &Installation{
+								BinaryPath:    strings.ReplaceAll(outputFileName, "\\", "/"),
+								Version:       "v" + version.String(),
+								Dependencies:  dependencies[version.String()],
+								OtherBinaries: otherBinaries,
+								SignaturePath: storeSignature(outputFileName, checksum, signed),
+								ServedBy:      served,
+							}, nil
+						}
+
 						// create temporary file that will receive a temporary binary.zip
 						tmpFile, err := tmp.File("packer-plugin-*.zip")
 						if err != nil {
@@ -691,20 +1666,30 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 						}
 						defer tmpFile.Close()
 
-						// start fetching binary
-						remoteZipFile, err := getter.Get("zip", GetOptions{
-							PluginRequirement:         pr,
-							BinaryInstallationOptions: opts.BinaryInstallationOptions,
-							version:                   version,
-							expectedZipFilename:       expectedZipFilename,
-						})
+						// start fetching binary, unless it already was
+						remoteZipFile, prefetched := opts.takeZip(zipGetterIdx, zipGetOpts)
+						if !prefetched {
+							remoteZipFile, err = opts.get(getter, "zip", zipGetOpts)
+						}
 						if err != nil {
-							err := fmt.Errorf("could not get binary for %s version %s. Is the file present on the release and correctly named ? %s", pr.Identifier, version, err)
+							err := fmt.Errorf("could not get binary for %s version %s. Is the file present on the release and correctly named ? %w", pr.Identifier, version, err)
 							errs = multierror.Append(errs, err)
 							log.Printf("[TRACE] %v", err)
 							continue
 						}
 
+						if opts.ZipTransform != nil {
+							transformed, err := opts.ZipTransform(remoteZipFile)
+							if err != nil {
+								_ = remoteZipFile.Close()
+								err := fmt.Errorf("could not transform %s: %w", expectedZipFilename, err)
+								errs = multierror.Append(errs, err)
+								log.Printf("[TRACE] %v", err)
+								continue
+							}
+							remoteZipFile = transformed
+						}
+
 						// write binary to tmp file
 						_, err = io.Copy(tmpFile, remoteZipFile)
 						_ = remoteZipFile.Close()
@@ -722,87 +1707,122 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 							continue
 						}
 
-						// verify that the checksum for the zip is what we expect.
-						if err := checksum.Checksummer.Checksum(checksum.Expected, tmpFile); err != nil {
-							err := fmt.Errorf("%w. Is the checksum file correct ? Is the binary file correct ?", err)
+						// A page served in place of the zip would otherwise
+						// be reported as a checksum mismatch.
+						if err := checkZipContent(tmpFile, expectedZipFilename); err != nil {
 							errs = multierror.Append(errs, err)
-							log.Printf("%s, truncating the zipfile", err)
+							log.Printf("[TRACE] %s, truncating the zipfile", err)
 							if err := tmpFile.Truncate(0); err != nil {
 								log.Printf("[TRACE] %v", err)
 							}
 							continue
 						}
 
-						tmpFileStat, err := tmpFile.Stat()
-						if err != nil {
-							err := fmt.Errorf("failed to stat: %w", err)
-							errs = multierror.Append(errs, err)
-							return nil, errs
-						}
-
-						zr, err := zip.NewReader(tmpFile, tmpFileStat.Size())
-						if err != nil {
-							err := fmt.Errorf("zip : %v", err)
-							errs = multierror.Append(errs, err)
-							return nil, errs
-						}
-
-						var copyFrom io.ReadCloser
-						for _, f := range zr.File {
-							if f.Name != expectedBinaryFilename {
-								continue
+						// verify that the checksum for the zip is what we expect.
+						var pin string
+						if trustedOnFirstUse && checksum.Expected == nil {
+							// trusted on first use, pinned once installed.
+							if pin, err = checksum.Checksummer.SumHex(tmpFile); err == nil {
+								_, err = tmpFile.Seek(0, 0)
 							}
-							copyFrom, err = f.Open()
 							if err != nil {
-								err := fmt.Errorf("failed to open temp file: %w", err)
+								err := fmt.Errorf("failed to checksum %s: %w", expectedZipFilename, err)
 								errs = multierror.Append(errs, err)
 								return nil, errs
 							}
-							break
-						}
-						if copyFrom == nil {
-							err := fmt.Errorf("could not find a %s file in zipfile", checksum.Filename)
+						} else if err := checksum.Checksummer.Checksum(checksum.Expected, tmpFile); err != nil {
+							var checksumErr *ChecksumError
+							isMismatch := errors.As(err, &checksumErr)
+							err := fmt.Errorf("%w. Is the checksum file correct ? Is the binary file correct ?", err)
+							if trustedOnFirstUse {
+								err = fmt.Errorf("%s changed since its checksum was pinned on first use in %q: %w", expectedZipFilename, pinFilename(outputFolder, expectedZipFilename), err)
+							}
+							if isMismatch {
+								errs = multierror.Append(errs, &IntegrityError{Err: err})
+								log.Printf("%s, aborting", err)
+								return nil, errs
+							}
 							errs = multierror.Append(errs, err)
-							return nil, errs
+							log.Printf("%s, truncating the zipfile", err)
+							if err := tmpFile.Truncate(0); err != nil {
+								log.Printf("[TRACE] %v", err)
+							}
+							continue
 						}
 
-						outputFile, err := os.OpenFile(outputFileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-						if err != nil {
-							err := fmt.Errorf("failed to create %s: %w", outputFileName, err)
-							errs = multierror.Append(errs, err)
-							return nil, errs
+						if opts.ProvenanceVerifier != nil {
+							if err := opts.verifyProvenance(getter, zipGetOpts, tmpFile); err != nil {
+								var integrityErr *IntegrityError
+								aborting := errors.As(err, &integrityErr)
+								err := fmt.Errorf("could not verify the provenance of %s: %w", expectedZipFilename, err)
+								errs = multierror.Append(errs, err)
+								if aborting {
+									log.Printf("[TRACE] %s, aborting", err)
+									return nil, errs
+								}
+								log.Printf("[TRACE] %s, truncating the zipfile", err)
+								if err := tmpFile.Truncate(0); err != nil {
+									log.Printf("[TRACE] %v", err)
+								}
+								continue
+							}
 						}
-						defer outputFile.Close()
 
-						if _, err := io.Copy(outputFile, copyFrom); err != nil {
-							err := fmt.Errorf("extract file: %w", err)
-							errs = multierror.Append(errs, err)
-							return nil, errs
+						if opts.SigstoreVerifier != nil {
+							if err := opts.verifySigstoreBundle(getter, zipGetOpts, tmpFile); err != nil {
+								var integrityErr *IntegrityError
+								aborting := errors.As(err, &integrityErr)
+								err := fmt.Errorf("could not verify the signature of %s: %w", expectedZipFilename, err)
+								errs = multierror.Append(errs, err)
+								if aborting {
+									log.Printf("[TRACE] %s, aborting", err)
+									return nil, errs
+								}
+								log.Printf("[TRACE] %s, truncating the zipfile", err)
+								if err := tmpFile.Truncate(0); err != nil {
+									log.Printf("[TRACE] %v", err)
+								}
+								continue
+							}
 						}
 
-						if _, err := outputFile.Seek(0, 0); err != nil {
-							err := fmt.Errorf("Error seeking begining of binary file for checksumming: %w", err)
-							errs = multierror.Append(errs, err)
-							log.Printf("[WARNING] %v, ignoring", err)
+						if opts.checkOnly {
+							if _, err := opts.readZipBinaries(tmpFile, checksum, expectedBinaryFilename, binaryEntry); err != nil {
+								errs = multierror.Append(errs, err)
+								return nil, errs
+							}
+							log.Printf("[INFO] %s v%s can be installed from %s", pr.Identifier, version, expectedZipFilename)
+							served.Zip = getter
+							return &Installation{Version: "v" + version.String(), ServedBy: served}, nil
 						}
 
-						cs, err := checksum.Checksummer.Sum(outputFile)
+						otherBinaries, err := opts.installZip(tmpFile, checksum, outputFolder, namePrefix, expectedBinaryFilename, binaryEntry)
 						if err != nil {
-							err := fmt.Errorf("failed to checksum binary file: %s", err)
 							errs = multierror.Append(errs, err)
-							log.Printf("[WARNING] %v, ignoring", err)
+							return nil, errs
 						}
+						if zipKey, ok := opts.verifiedZipKey(getter, zipGetOpts, checksum); ok {
+							opts.verifiedZips[zipKey] = tmpFile.Name()
+						}
+						recordZipChecksum(outputFileName, expectedZipFilename, checksum, pin)
 
-						if err := os.WriteFile(outputFileName+checksum.Checksummer.FileExt(), []byte(hex.EncodeToString(cs)), 0644); err != nil {
-							err := fmt.Errorf("failed to write local binary checksum file: %s", err)
-							errs = multierror.Append(errs, err)
-							log.Printf("[WARNING] %v, ignoring", err)
+						if pin != "" {
+							if err := os.WriteFile(pinFilename(outputFolder, expectedZipFilename), []byte(pin), 0644); err != nil {
+								err := fmt.Errorf("failed to pin the checksum of %s: %w", expectedZipFilename, err)
+								errs = multierror.Append(errs, err)
+								return nil, errs
+							}
 						}
 
 						// Success !!
+						served.Zip = getter
 						return &Installation{
-							BinaryPath: strings.ReplaceAll(outputFileName, "\\", "/"),
-							Version:    "v" + version.String(),
+							BinaryPath:    strings.ReplaceAll(outputFileName, "\\", "/"),
+							Version:       "v" + version.String(),
+							Dependencies:  dependencies[version.String()],
+							OtherBinaries: otherBinaries,
+							SignaturePath: storeSignature(outputFileName, checksum, signed),
+							ServedBy:      served,
 						}, nil
 					}
 
@@ -810,10 +1830,25 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 			}
 
 		}
+
+		if checksum != nil {
+			foundCompatible = true
+		} else if checksumRead {
+			noCompatibleVersion.Releases = append(noCompatibleVersion.Releases, release)
+		}
+
+		// Plugins are not always released for every platform.
+		if versionIdx < len(versions)-1 {
+			log.Printf("[INFO] no %s_%s binary could be installed for %s v%s, falling back to the next version", opts.OS, opts.ARCH, pr.Identifier, version)
+		}
+	}
+
+	if !foundCompatible && len(noCompatibleVersion.Releases) > 0 {
+		errs = multierror.Append(errs, noCompatibleVersion)
 	}
 
 	if errs.Len() == 0 {
-		err := fmt.Errorf("could not find a local nor a remote checksum for plugin %q %q", pr.Identifier, pr.VersionConstraints)
+		err := fmt.Errorf("could not find a local nor a remote checksum for plugin %q %q", pr.Identifier, pr.constraintsString())
 		errs = multierror.Append(errs, err)
 	}
 
@@ -821,3 +1856,106 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 
 	return nil, errs
 }
+
+// minPublishedAt returns the date releases must have been published before
+// to be installed, following opts.MinReleaseAge. The zero time tells
+// releases are not filtered.
+func (opts InstallOptions) minPublishedAt(releases []Release) time.Time {
+	if opts.MinReleaseAge <= 0 {
+		return time.Time{}
+	}
+	for _, release := range releases {
+		if release.PublishedAt.IsZero() {
+			log.Printf("[WARNING] the publication date of %s is unknown, not filtering releases by age", release.Version)
+			return time.Time{}
+		}
+	}
+	return time.Now().Add(-opts.MinReleaseAge)
+}
+
+// orderVersions returns versions in the order they should be tried for
+// installation: highest first, unless a VersionSelector is set. When the
+// selector returns nil or a version that is not a candidate, the remaining
+// candidates are tried highest first, after the selected ones.
+func (opts InstallOptions) orderVersions(versions version.Collection) version.Collection {
+	if opts.VersionSelector == nil {
+		sort.Sort(sort.Reverse(versions))
+		return versions
+	}
+
+	remaining := append(version.Collection{}, versions...)
+	ordered := version.Collection{}
+	for len(remaining) > 0 {
+		selected := opts.VersionSelector(remaining)
+		idx := -1
+		for i, v := range remaining {
+			if selected != nil && v.Equal(selected) {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			log.Printf("[WARNING] version selector returned %v which is not a candidate, trying %s highest first", selected, remaining)
+			sort.Sort(sort.Reverse(remaining))
+			return append(ordered, remaining...)
+		}
+		ordered = append(ordered, remaining[idx])
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+	}
+	return ordered
+}
+
+// InstallLatestForPlatforms installs the latest version of pr for each of the
+// platforms, ex: to fill a plugin mirror shared by different systems. Each
+// platform replaces opts.BinaryInstallationOptions, so the highest compatible
+// version is picked per platform.
+//
+// Already installed platforms are not part of the returned list. An error
+// installing one platform does not prevent installing the others.
+//
+// With pr.SkipMissingPlatforms, the platforms no release has a zip for are
+// not an error, unless the plugin was not released for any of the platforms.
+//
+// The outcome of every platform is reported to opts.Progress and
+// opts.AuditLogPath, like InstallAll does, except for skipped platforms.
+func (pr *Requirement) InstallLatestForPlatforms(opts InstallOptions, platforms []BinaryInstallationOptions) ([]*Installation, error) {
+	var installs []*Installation
+	var errs, skipped *multierror.Error
+	source := pr.Identifier.String()
+	for _, platform := range platforms {
+		platformOpts := opts
+		platformOpts.BinaryInstallationOptions = platform
+
+		start := time.Now()
+		install, err := pr.InstallLatest(platformOpts)
+		duration := time.Since(start)
+		if err != nil {
+			err = fmt.Errorf("%s_%s: %w", platform.OS, platform.ARCH, err)
+			if pr.SkipMissingPlatforms && missingPlatform(err) {
+				log.Printf("[WARN] skipping %s, not released for this platform: %s", pr.Identifier, err)
+				skipped = multierror.Append(skipped, err)
+				continue
+			}
+			errs = multierror.Append(errs, err)
+			opts.observeInstall(InstallResult{Source: source, Reason: InstallReasonFailed, Err: err, Duration: duration})
+			continue
+		}
+		if install == nil {
+			opts.observeInstall(InstallResult{Source: source, Reason: InstallReasonUpToDate, Duration: duration})
+			continue
+		}
+		installs = append(installs, install)
+		opts.observeInstall(InstallResult{Source: source, Reason: InstallReasonInstalled, Installation: install, Duration: duration})
+	}
+	if skipped != nil && len(skipped.Errors) == len(platforms) {
+		return installs, skipped
+	}
+	return installs, errs.ErrorOrNil()
+}
+
+// InstallLatestForPlatformsContext is InstallLatestForPlatforms, with its
+// waits for opts.RateLimiter interrupted when ctx is done.
+func (pr *Requirement) InstallLatestForPlatformsContext(ctx context.Context, opts InstallOptions, platforms []BinaryInstallationOptions) ([]*Installation, error) {
+	opts.ctx = ctx
+	return pr.InstallLatestForPlatforms(opts, platforms)
+}
@@ -7,16 +7,20 @@ import (
 	"archive/zip"
 	"bytes"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/packer/hcl2template/addrs"
 )
@@ -29,6 +33,10 @@ var (
 	pluginFolderThree = filepath.Join("testdata", "plugins_3")
 
 	pluginFolderWrongChecksums = filepath.Join("testdata", "wrong_checksums")
+
+	// ignoreServedBy ignores which getters served an installation, for
+	// tests that are not about it.
+	ignoreServedBy = cmpopts.IgnoreFields(Installation{}, "ServedBy")
 )
 
 func TestChecksumFileEntry_init(t *testing.T) {
@@ -46,7 +54,7 @@ func TestChecksumFileEntry_init(t *testing.T) {
 		Checksum: "0f5969b069b9c0a58f2d5786c422341c70dfe17bd68f896fcbd46677e8c913f1",
 	}
 
-	err := checkSum.init(req)
+	err := checkSum.init(req, false)
 
 	if err != nil {
 		t.Fatalf("ChecksumFileEntry.init failure: %v", err)
@@ -57,6 +65,302 @@ func TestChecksumFileEntry_init(t *testing.T) {
 	}
 }
 
+func TestChecksumFileEntry_init_caseInsensitive(t *testing.T) {
+	req := &Requirement{
+		Identifier: &addrs.Plugin{
+			Hostname:  "github.com",
+			Namespace: "ddelnano",
+			Type:      "xenserver",
+		},
+	}
+	const filename = "PACKER-PLUGIN-XENSERVER_V0.3.0_X5.0_Darwin_AMD64.ZIP"
+
+	entry := &ChecksumFileEntry{Filename: filename}
+	if err := entry.init(req, true); err != nil {
+		t.Fatalf("ChecksumFileEntry.init failure: %v", err)
+	}
+	got := []string{entry.binVersion, entry.protVersion, entry.os, entry.arch, entry.zipName, entry.binaryName}
+	// the zip is downloaded as listed.
+	want := []string{"V0.3.0", "x5.0", "darwin", "amd64", filename, "packer-plugin-xenserver_V0.3.0_x5.0_darwin_amd64"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected entry: %s", diff)
+	}
+	if err := entry.validate("v0.3.0", BinaryInstallationOptions{OS: "darwin", ARCH: "amd64", APIVersionMajor: "5", APIVersionMinor: "0"}); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+	if entry.binaryName != "packer-plugin-xenserver_v0.3.0_x5.0_darwin_amd64" {
+		t.Errorf("expected the binary to be named like releases, got %s", entry.binaryName)
+	}
+
+	// the case of pre-releases is kept.
+	const preRelease = "Packer-Plugin-Xenserver_v0.3.0-RC1_x5.0_Darwin_amd64.zip"
+	entry = &ChecksumFileEntry{Filename: preRelease}
+	if err := entry.init(req, true); err != nil {
+		t.Fatalf("ChecksumFileEntry.init failure: %v", err)
+	}
+	if entry.binVersion != "v0.3.0-RC1" || entry.zipName != preRelease || entry.binaryName != "packer-plugin-xenserver_v0.3.0-RC1_x5.0_darwin_amd64" {
+		t.Errorf("unexpected pre-release entry: %s, %s, %s", entry.binVersion, entry.zipName, entry.binaryName)
+	}
+
+	entry = &ChecksumFileEntry{Filename: filename}
+	if err := entry.init(req, false); err == nil && entry.binVersion == "v0.3.0" {
+		t.Errorf("expected a case-sensitive init of %s not to find its version", filename)
+	}
+}
+
+func TestChecksumFileEntry_init_versionSuffix(t *testing.T) {
+	req := &Requirement{
+		Identifier: &addrs.Plugin{
+			Hostname:  "github.com",
+			Namespace: "ddelnano",
+			Type:      "xenserver",
+		},
+	}
+
+	tests := []struct {
+		filename        string
+		wantVersion     string
+		wantVersionCore string
+	}{
+		{"packer-plugin-xenserver_v0.3.0_x5.0_darwin_amd64.zip", "v0.3.0", "v0.3.0"},
+		{"packer-plugin-xenserver_v0.3.0-abcdef1_x5.0_darwin_amd64.zip", "v0.3.0-abcdef1", "v0.3.0"},
+		{"packer-plugin-xenserver_v0.3.0+build.12_x5.0_darwin_amd64.zip", "v0.3.0+build.12", "v0.3.0"},
+		{"packer-plugin-xenserver_v0.3.0-abcdef1+build.12_x5.0_darwin_amd64.zip", "v0.3.0-abcdef1+build.12", "v0.3.0"},
+		// pre-releases are other versions.
+		{"packer-plugin-xenserver_v0.3.0-rc.1+abcdef1_x5.0_darwin_amd64.zip", "v0.3.0-rc.1+abcdef1", "v0.3.0-rc.1+abcdef1"},
+		{"packer-plugin-xenserver_v0.3.0-beta_x5.0_darwin_amd64.zip", "v0.3.0-beta", "v0.3.0-beta"},
+		{"packer-plugin-xenserver_nightly-abcdef_x5.0_darwin_amd64.zip", "nightly-abcdef", "nightly-abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			entry := &ChecksumFileEntry{Filename: tt.filename}
+			if err := entry.init(req, false); err != nil {
+				t.Fatalf("ChecksumFileEntry.init failure: %v", err)
+			}
+			got := []string{entry.BinVersion(), entry.VersionCore(), entry.zipName}
+			want := []string{tt.wantVersion, tt.wantVersionCore, tt.filename}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected entry: %s", diff)
+			}
+		})
+	}
+}
+
+func TestChecksumFileEntry_validate_versionSuffix(t *testing.T) {
+	req := &Requirement{
+		Identifier: &addrs.Plugin{
+			Hostname:  "github.com",
+			Namespace: "ddelnano",
+			Type:      "xenserver",
+		},
+	}
+	opts := BinaryInstallationOptions{
+		APIVersionMajor: "5", APIVersionMinor: "0",
+		OS: "darwin", ARCH: "amd64",
+	}
+
+	tests := []struct {
+		name           string
+		filename       string
+		version        string
+		wantBinaryName string
+		wantErr        bool
+	}{
+		{
+			name:           "suffixed",
+			filename:       "packer-plugin-xenserver_v0.3.0-abcdef1_x5.0_darwin_amd64.zip",
+			version:        "v0.3.0",
+			wantBinaryName: "packer-plugin-xenserver_v0.3.0_x5.0_darwin_amd64",
+		},
+		{
+			name:           "suffixed-release",
+			filename:       "packer-plugin-xenserver_v0.3.0-abcdef1_x5.0_darwin_amd64.zip",
+			version:        "v0.3.0-abcdef1",
+			wantBinaryName: "packer-plugin-xenserver_v0.3.0-abcdef1_x5.0_darwin_amd64",
+		},
+		{
+			name:     "other-version",
+			filename: "packer-plugin-xenserver_v0.3.1-abcdef1_x5.0_darwin_amd64.zip",
+			version:  "v0.3.0",
+			wantErr:  true,
+		},
+		{
+			name:     "pre-release",
+			filename: "packer-plugin-xenserver_v0.3.0-rc.1+abcdef1_x5.0_darwin_amd64.zip",
+			version:  "v0.3.0",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &ChecksumFileEntry{Filename: tt.filename}
+			if err := entry.init(req, false); err != nil {
+				t.Fatalf("ChecksumFileEntry.init failure: %v", err)
+			}
+			err := entry.validate(tt.version, opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected %s not to be valid for %s", tt.filename, tt.version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+			if entry.zipName != tt.filename {
+				t.Errorf("expected the zip to stay named %s, got %s", tt.filename, entry.zipName)
+			}
+			if entry.binaryName != tt.wantBinaryName {
+				t.Errorf("expected binary %s, got %s", tt.wantBinaryName, entry.binaryName)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_versionSuffix(t *testing.T) {
+	const (
+		binary  = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+		zipName = "packer-plugin-amazon_v1.0.0-abcdef1_x5.0_linux_amd64.zip"
+	)
+	zip, checksum := zipFileWithChecksum(map[string]string{
+		binary: elfHeader + "amazon",
+	})
+	getter := &mockPluginGetter{
+		Releases: []Release{{Version: "v1.0.0"}},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"1.0.0": {{Filename: zipName, Checksum: checksum}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/" + zipName: zip,
+		},
+	}
+
+	pluginDir := t.TempDir()
+	install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	wantPath := filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+	if install == nil || install.BinaryPath != wantPath || install.Version != "v1.0.0" {
+		t.Fatalf("expected v1.0.0 to be installed at %s, got %#v", wantPath, install)
+	}
+}
+
+func TestRequirement_InstallLatest_versionSuffixPrefersExact(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	const (
+		preRelease = "packer-plugin-amazon_v1.0.0-rc.1+abcdef1_x5.0_linux_amd64.zip"
+		suffixed   = "packer-plugin-amazon_v1.0.0-abcdef1_x5.0_linux_amd64.zip"
+		exact      = binary + ".zip"
+	)
+	zips := map[string]io.ReadCloser{}
+	var entries []ChecksumFileEntry
+	for _, zipName := range []string{preRelease, suffixed, exact} {
+		zip, checksum := zipFileWithChecksum(map[string]string{
+			binary: elfHeader + zipName,
+		})
+		zips["github.com/hashicorp/packer-plugin-amazon/"+zipName] = zip
+		entries = append(entries, ChecksumFileEntry{Filename: zipName, Checksum: checksum})
+	}
+	getter := &mockPluginGetter{
+		Releases:            []Release{{Version: "v1.0.0"}},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{"1.0.0": entries},
+		Zips:                zips,
+	}
+
+	pluginDir := t.TempDir()
+	install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	content, err := os.ReadFile(install.BinaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != elfHeader+exact {
+		t.Errorf("expected the binary of %s to be installed, got %q", exact, content)
+	}
+}
+
+func TestRequirement_InstallLatest_checksumFilenameCase(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name          string
+		entries       func(checksum string) []ChecksumFileEntry
+		caseSensitive bool
+		wantErr       string
+	}{
+		{
+			name: "mismatched-case",
+			entries: func(checksum string) []ChecksumFileEntry {
+				return []ChecksumFileEntry{{Filename: "Packer-Plugin-Amazon_v1.0.0_x5.0_Linux_AMD64.zip", Checksum: checksum}}
+			},
+		},
+		{
+			name: "mismatched-case-sensitive",
+			entries: func(checksum string) []ChecksumFileEntry {
+				return []ChecksumFileEntry{{Filename: "Packer-Plugin-Amazon_v1.0.0_x5.0_Linux_AMD64.zip", Checksum: checksum}}
+			},
+			caseSensitive: true,
+			wantErr:       "wrong version",
+		},
+		{
+			name: "digest-stays-exact",
+			entries: func(checksum string) []ChecksumFileEntry {
+				return []ChecksumFileEntry{{Filename: "PACKER-PLUGIN-AMAZON_v1.0.0_x5.0_linux_amd64.zip", Checksum: strings.Repeat("0", len(checksum))}}
+			},
+			wantErr: "did not match",
+		},
+		{
+			name: "conflicting-entries",
+			entries: func(checksum string) []ChecksumFileEntry {
+				return []ChecksumFileEntry{
+					{Filename: "PACKER-PLUGIN-AMAZON_v1.0.0_x5.0_linux_amd64.zip", Checksum: strings.Repeat("0", len(checksum))},
+					{Filename: binary + ".zip", Checksum: checksum},
+				}
+			},
+			wantErr: "listed several times with different checksums",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zip, checksum := zipFileWithChecksum(map[string]string{
+				binary: elfHeader + "amazon",
+			})
+			getter := &mockPluginGetter{
+				Releases: []Release{{Version: "v1.0.0"}},
+				ChecksumFileEntries: map[string][]ChecksumFileEntry{
+					"1.0.0": tt.entries(checksum),
+				},
+				Zips: map[string]io.ReadCloser{},
+			}
+			// the asset is named like the sums file says.
+			for _, entry := range getter.ChecksumFileEntries["1.0.0"] {
+				getter.Zips["github.com/hashicorp/packer-plugin-amazon/"+entry.Filename] = zip
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.CaseSensitiveChecksumFilenames = tt.caseSensitive
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			wantPath := filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if install == nil || install.BinaryPath != wantPath {
+				t.Fatalf("expected %s to be installed, got %#v", wantPath, install)
+			}
+		})
+	}
+}
+
 func TestRequirement_InstallLatest(t *testing.T) {
 	type fields struct {
 		Identifier         string
@@ -75,7 +379,7 @@ func TestRequirement_InstallLatest(t *testing.T) {
 		{"already-installed-same-api-version",
 			fields{"amazon", "v1.2.3"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v1.2.3"},
@@ -92,9 +396,8 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						},
 					},
 				},
-				pluginFolderOne,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderOne,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "5", APIVersionMinor: "0",
 					OS: "darwin", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -112,7 +415,7 @@ func TestRequirement_InstallLatest(t *testing.T) {
 			// with the 5.0 one of an already installed plugin.
 			fields{"amazon", "v1.2.3"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v1.2.3"},
@@ -125,9 +428,8 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						},
 					},
 				},
-				pluginFolderOne,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderOne,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "5", APIVersionMinor: "1",
 					OS: "darwin", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -147,7 +449,7 @@ func TestRequirement_InstallLatest(t *testing.T) {
 			// ignored.
 			fields{"amazon", ">= v1"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v1.2.3"},
@@ -167,9 +469,8 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						},
 					},
 				},
-				pluginFolderOne,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderOne,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "5", APIVersionMinor: "0",
 					OS: "darwin", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -188,7 +489,7 @@ func TestRequirement_InstallLatest(t *testing.T) {
 			// version than the one we support.
 			fields{"amazon", ">= v2"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v1.2.3"},
@@ -201,19 +502,18 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						ChecksumFileEntries: map[string][]ChecksumFileEntry{
 							"2.10.0": {{
 								Filename: "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip",
-								Checksum: "43156b1900dc09b026b54610c4a152edd277366a7f71ff3812583e4a35dd0d4a",
+								Checksum: "e1bc0e507c00eb0c5c71e43841690957932de8ea9e099f8b29e828ccd520e25d",
 							}},
 						},
 						Zips: map[string]io.ReadCloser{
 							"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip": zipFile(map[string]string{
-								"packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64": "v2.10.0_x6.0_darwin_amd64",
+								"packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64": machoHeader + "v2.10.0_x6.0_darwin_amd64",
 							}),
 						},
 					},
 				},
-				pluginFolderTwo,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderTwo,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "6", APIVersionMinor: "1",
 					OS: "darwin", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -234,7 +534,7 @@ func TestRequirement_InstallLatest(t *testing.T) {
 			// be installed.
 			fields{"amazon", ">= v2"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v1.2.3"},
@@ -248,19 +548,18 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						ChecksumFileEntries: map[string][]ChecksumFileEntry{
 							"2.10.1": {{
 								Filename: "packer-plugin-amazon_v2.10.1_x6.1_darwin_amd64.zip",
-								Checksum: "90ca5b0f13a90238b62581bbf30bacd7e2c9af6592c7f4849627bddbcb039dec",
+								Checksum: "f55bc28db8459067e2c382e3827052c94ae13422b949d27b98a66e831dd8ed9d",
 							}},
 						},
 						Zips: map[string]io.ReadCloser{
 							"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.1_x6.1_darwin_amd64.zip": zipFile(map[string]string{
-								"packer-plugin-amazon_v2.10.1_x6.1_darwin_amd64": "v2.10.1_x6.1_darwin_amd64",
+								"packer-plugin-amazon_v2.10.1_x6.1_darwin_amd64": machoHeader + "v2.10.1_x6.1_darwin_amd64",
 							}),
 						},
 					},
 				},
-				pluginFolderTwo,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderTwo,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "6", APIVersionMinor: "1",
 					OS: "darwin", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -281,7 +580,7 @@ func TestRequirement_InstallLatest(t *testing.T) {
 			// be installed.
 			fields{"amazon", ">= v2"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v1.2.3"},
@@ -295,19 +594,18 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						ChecksumFileEntries: map[string][]ChecksumFileEntry{
 							"2.10.0": {{
 								Filename: "packer-plugin-amazon_v2.10.0_x6.1_linux_amd64.zip",
-								Checksum: "825fc931ae0cb151df0c56be41a17a9136c4d1f1ee73ddb8ed6baa17cef31afa",
+								Checksum: "fc58315c7d6967de88a2f9d29eaae874a9c7e25a031df55a0e2ac59bd3d110dd",
 							}},
 						},
 						Zips: map[string]io.ReadCloser{
 							"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.1_linux_amd64.zip": zipFile(map[string]string{
-								"packer-plugin-amazon_v2.10.0_x6.1_linux_amd64": "v2.10.0_x6.1_linux_amd64",
+								"packer-plugin-amazon_v2.10.0_x6.1_linux_amd64": elfHeader + "v2.10.0_x6.1_linux_amd64",
 							}),
 						},
 					},
 				},
-				pluginFolderTwo,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderTwo,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "6", APIVersionMinor: "1",
 					OS: "linux", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -323,12 +621,61 @@ func TestRequirement_InstallLatest(t *testing.T) {
 				Version:    "v2.10.0",
 			}, false},
 
+		{"upgrade-capped-by-max-version",
+			// here newer versions exist remotely but the constraint caps the
+			// version that can be installed, like the install command does
+			// with --max-version.
+			fields{"amazon", ">= v2, <= v2.1.0"},
+			args{InstallOptions{
+				Getters: []Getter{
+					&mockPluginGetter{
+						Releases: []Release{
+							{Version: "v1.2.5"},
+							{Version: "v2.0.0"},
+							{Version: "v2.1.0"},
+							{Version: "v2.10.0"},
+							{Version: "v2.10.1"},
+						},
+						ChecksumFileEntries: map[string][]ChecksumFileEntry{
+							"2.1.0": {{
+								Filename: "packer-plugin-amazon_v2.1.0_x6.1_darwin_amd64.zip",
+								Checksum: "71cefb314378b58ea104dadf1b3ab5b65f729e9cfa10654b9c254f539a8bf1bb",
+							}},
+							"2.10.1": {{
+								Filename: "packer-plugin-amazon_v2.10.1_x6.1_darwin_amd64.zip",
+								Checksum: "f55bc28db8459067e2c382e3827052c94ae13422b949d27b98a66e831dd8ed9d",
+							}},
+						},
+						Zips: map[string]io.ReadCloser{
+							"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.1.0_x6.1_darwin_amd64.zip": zipFile(map[string]string{
+								"packer-plugin-amazon_v2.1.0_x6.1_darwin_amd64": machoHeader + "v2.1.0_x6.1_darwin_amd64",
+							}),
+						},
+					},
+				},
+				PluginDirectory: pluginFolderTwo,
+				BinaryInstallationOptions: BinaryInstallationOptions{
+					APIVersionMajor: "6", APIVersionMinor: "1",
+					OS: "darwin", ARCH: "amd64",
+					Checksummers: []Checksummer{
+						{
+							Type: "sha256",
+							Hash: sha256.New(),
+						},
+					},
+				},
+			}},
+			&Installation{
+				BinaryPath: "testdata/plugins_2/github.com/hashicorp/amazon/packer-plugin-amazon_v2.1.0_x6.1_darwin_amd64",
+				Version:    "v2.1.0",
+			}, false},
+
 		{"wrong-zip-checksum",
 			// here we have something locally and test that a newer version with
 			// a wrong checksum will not be installed and error.
 			fields{"amazon", ">= v2"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v2.10.0"},
@@ -346,9 +693,8 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						},
 					},
 				},
-				pluginFolderTwo,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderTwo,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "6", APIVersionMinor: "1",
 					OS: "darwin", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -368,7 +714,7 @@ func TestRequirement_InstallLatest(t *testing.T) {
 			// this should totally error.
 			fields{"amazon", ">= v1"},
 			args{InstallOptions{
-				[]Getter{
+				Getters: []Getter{
 					&mockPluginGetter{
 						Releases: []Release{
 							{Version: "v2.10.0"},
@@ -386,9 +732,8 @@ func TestRequirement_InstallLatest(t *testing.T) {
 						},
 					},
 				},
-				pluginFolderTwo,
-				false,
-				BinaryInstallationOptions{
+				PluginDirectory: pluginFolderTwo,
+				BinaryInstallationOptions: BinaryInstallationOptions{
 					APIVersionMajor: "6", APIVersionMinor: "1",
 					OS: "darwin", ARCH: "amd64",
 					Checksummers: []Checksummer{
@@ -423,12 +768,12 @@ func TestRequirement_InstallLatest(t *testing.T) {
 				t.Errorf("Requirement.InstallLatest() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if diff := cmp.Diff(got, tt.want); diff != "" {
+			if diff := cmp.Diff(got, tt.want, ignoreServedBy); diff != "" {
 				t.Errorf("Requirement.InstallLatest() %s", diff)
 			}
 			if tt.want != nil && tt.want.BinaryPath != "" {
 				// Cleanup.
-				// These two files should be here by now and os.Remove will fail if
+				// These three files should be here by now and os.Remove will fail if
 				// they aren't.
 				if err := os.Remove(filepath.Clean(tt.want.BinaryPath)); err != nil {
 					t.Fatal(err)
@@ -436,6 +781,713 @@ func TestRequirement_InstallLatest(t *testing.T) {
 				if err := os.Remove(filepath.Clean(tt.want.BinaryPath + "_SHA256SUM")); err != nil {
 					t.Fatal(err)
 				}
+				if err := os.Remove(filepath.Clean(tt.want.BinaryPath + ZipChecksumFileExt)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_notAnExecutable(t *testing.T) {
+	tests := []struct {
+		name          string
+		binaryContent string
+	}{
+		{"text-file", "Please read me before installing the plugin."},
+		{"wrong-os-binary", elfHeader + "v2.10.0_x6.0_linux_amd64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zip, checksum := zipFileWithChecksum(map[string]string{
+				"packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64": tt.binaryContent,
+			})
+			getter := &mockPluginGetter{
+				Releases: []Release{
+					{Version: "v2.10.0"},
+				},
+				ChecksumFileEntries: map[string][]ChecksumFileEntry{
+					"2.10.0": {{
+						Filename: "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip",
+						Checksum: checksum,
+					}},
+				},
+				Zips: map[string]io.ReadCloser{
+					"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip": zip,
+				},
+			}
+
+			pluginDir := t.TempDir()
+			identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+			if len(diags) != 0 {
+				t.Fatalf("ParsePluginSourceString: %v", diags)
+			}
+			pr := &Requirement{
+				Identifier: identifier,
+			}
+			_, err := pr.InstallLatest(InstallOptions{
+				Getters:         []Getter{getter},
+				PluginDirectory: pluginDir,
+				BinaryInstallationOptions: BinaryInstallationOptions{
+					APIVersionMajor: "6", APIVersionMinor: "1",
+					OS: "darwin", ARCH: "amd64",
+					Checksummers: []Checksummer{
+						{
+							Type: "sha256",
+							Hash: sha256.New(),
+						},
+					},
+				},
+			})
+			if !errors.Is(err, ErrNotAnExecutable) {
+				t.Fatalf("Requirement.InstallLatest() error = %v, expected ErrNotAnExecutable", err)
+			}
+
+			binaryPath := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64")
+			if _, err := os.Stat(binaryPath); !os.IsNotExist(err) {
+				t.Errorf("expected %q to not be written, stat returned: %v", binaryPath, err)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatestForPlatforms(t *testing.T) {
+	darwinZip, darwinChecksum := zipFileWithChecksum(map[string]string{
+		"packer-plugin-amazon_v2.10.0_x6.0_darwin_arm64": machoHeader + "v2.10.0_x6.0_darwin_arm64",
+	})
+	linuxZip, linuxChecksum := zipFileWithChecksum(map[string]string{
+		"packer-plugin-amazon_v2.10.0_x6.0_linux_arm64": elfHeader + "v2.10.0_x6.0_linux_arm64",
+	})
+	getter := &mockPluginGetter{
+		Releases: []Release{
+			{Version: "v2.10.0"},
+		},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"2.10.0": {
+				{
+					Filename: "packer-plugin-amazon_v2.10.0_x6.0_darwin_arm64.zip",
+					Checksum: darwinChecksum,
+				},
+				{
+					Filename: "packer-plugin-amazon_v2.10.0_x6.0_linux_arm64.zip",
+					Checksum: linuxChecksum,
+				},
+			},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.0_darwin_arm64.zip": darwinZip,
+			"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.0_linux_arm64.zip":  linuxZip,
+		},
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &Requirement{
+		Identifier: identifier,
+	}
+
+	platform := func(os, arch string) BinaryInstallationOptions {
+		return BinaryInstallationOptions{
+			APIVersionMajor: "6", APIVersionMinor: "0",
+			OS: os, ARCH: arch,
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		}
+	}
+
+	pluginDir := t.TempDir()
+	installs, err := pr.InstallLatestForPlatforms(InstallOptions{
+		Getters:         []Getter{getter},
+		PluginDirectory: pluginDir,
+	}, []BinaryInstallationOptions{
+		platform("darwin", "arm64"),
+		platform("linux", "arm64"),
+	})
+	if err != nil {
+		t.Fatalf("InstallLatestForPlatforms: %v", err)
+	}
+	if len(installs) != 2 {
+		t.Fatalf("expected 2 installations, got %v", installs)
+	}
+
+	for _, binary := range []string{
+		"packer-plugin-amazon_v2.10.0_x6.0_darwin_arm64",
+		"packer-plugin-amazon_v2.10.0_x6.0_linux_arm64",
+	} {
+		binaryPath := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary)
+		if _, err := os.Stat(binaryPath); err != nil {
+			t.Errorf("expected binary to be installed: %v", err)
+		}
+		if _, err := os.Stat(binaryPath + "_SHA256SUM"); err != nil {
+			t.Errorf("expected checksum file to be installed: %v", err)
+		}
+	}
+}
+
+func TestRequirement_InstallLatestForPlatforms_skipMissingPlatforms(t *testing.T) {
+	platform := func(os, arch string) BinaryInstallationOptions {
+		return BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: os, ARCH: arch,
+			Checksummers: []Checksummer{{Type: "sha256", Hash: sha256.New()}},
+		}
+	}
+	// linux_amd64 is released, darwin_arm64 is not, and the linux_arm64 zip
+	// is listed in the checksum file but was not uploaded.
+	newGetter := func() Getter {
+		linuxZip, linuxChecksum := zipFileWithChecksum(map[string]string{
+			"packer-plugin-amazon_v1.0.0_x5.0_linux_amd64": elfHeader + "v1.0.0_x5.0_linux_amd64",
+		})
+		return &releaseFileNotFoundGetter{missingZipGetter{&mockPluginGetter{
+			Releases: []Release{{Version: "v1.0.0"}},
+			ChecksumFileEntries: map[string][]ChecksumFileEntry{
+				"1.0.0": {
+					{Filename: "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip", Checksum: linuxChecksum},
+					{Filename: "packer-plugin-amazon_v1.0.0_x5.0_linux_arm64.zip", Checksum: linuxChecksum},
+				},
+			},
+			Zips: map[string]io.ReadCloser{
+				"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip": linuxZip,
+			},
+		}}}
+	}
+
+	tests := []struct {
+		name         string
+		skip         bool
+		platforms    []BinaryInstallationOptions
+		wantInstalls []string
+		wantErr      bool
+	}{
+		{
+			name:         "skipped",
+			skip:         true,
+			platforms:    []BinaryInstallationOptions{platform("darwin", "arm64"), platform("linux", "amd64"), platform("linux", "arm64")},
+			wantInstalls: []string{"packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"},
+		},
+		{
+			name:         "not-skipped",
+			platforms:    []BinaryInstallationOptions{platform("darwin", "arm64"), platform("linux", "amd64")},
+			wantInstalls: []string{"packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"},
+			wantErr:      true,
+		},
+		{
+			name:      "no-platform-released",
+			skip:      true,
+			platforms: []BinaryInstallationOptions{platform("darwin", "arm64"), platform("linux", "arm64")},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+			pr.SkipMissingPlatforms = tt.skip
+
+			installs, err := pr.InstallLatestForPlatforms(InstallOptions{
+				Getters:         []Getter{newGetter()},
+				PluginDirectory: t.TempDir(),
+			}, tt.platforms)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InstallLatestForPlatforms() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			var got []string
+			for _, install := range installs {
+				got = append(got, filepath.Base(install.BinaryPath))
+			}
+			if diff := cmp.Diff(tt.wantInstalls, got); diff != "" {
+				t.Errorf("unexpected installs: %s", diff)
+			}
+		})
+	}
+}
+
+// releaseFileNotFoundGetter tells missing zips are not found, like the
+// github getter does.
+type releaseFileNotFoundGetter struct {
+	missingZipGetter
+}
+
+func (g *releaseFileNotFoundGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	rc, err := g.missingZipGetter.Get(what, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReleaseFileNotFound, err)
+	}
+	return rc, nil
+}
+
+// missingZipGetter answers like a release server would for zips that are
+// listed in the checksum file but were never uploaded.
+type missingZipGetter struct {
+	*mockPluginGetter
+}
+
+func (g *missingZipGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	if what == "zip" {
+		acc := options.PluginRequirement.Identifier.Hostname + "/" +
+			options.PluginRequirement.Identifier.RealRelativePath() + "/" +
+			options.ExpectedZipFilename()
+		if _, found := g.Zips[acc]; !found {
+			return nil, fmt.Errorf("404: %s not found", acc)
+		}
+	}
+	return g.mockPluginGetter.Get(what, options)
+}
+
+func TestRequirement_InstallLatest_platformFallback(t *testing.T) {
+	linuxArmZip, linuxArmChecksum := zipFileWithChecksum(map[string]string{
+		"packer-plugin-amazon_v2.10.0_x5.0_linux_arm": elfHeader + "v2.10.0_x5.0_linux_arm",
+	})
+	getter := &missingZipGetter{&mockPluginGetter{
+		Releases: []Release{
+			{Version: "v2.10.0"},
+			{Version: "v2.11.0"},
+			{Version: "v2.12.0"},
+		},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			// v2.12.0 was not released for linux/arm at all.
+			"2.12.0": {{
+				Filename: "packer-plugin-amazon_v2.12.0_x5.0_linux_amd64.zip",
+				Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			}},
+			// v2.11.0 lists a linux/arm zip, that is missing.
+			"2.11.0": {
+				{
+					Filename: "packer-plugin-amazon_v2.11.0_x5.0_linux_amd64.zip",
+					Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				},
+				{
+					Filename: "packer-plugin-amazon_v2.11.0_x5.0_linux_arm.zip",
+					Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				},
+			},
+			"2.10.0": {{
+				Filename: "packer-plugin-amazon_v2.10.0_x5.0_linux_arm.zip",
+				Checksum: linuxArmChecksum,
+			}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x5.0_linux_arm.zip": linuxArmZip,
+		},
+	}}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &Requirement{
+		Identifier: identifier,
+	}
+
+	pluginDir := t.TempDir()
+	install, err := pr.InstallLatest(InstallOptions{
+		Getters:         []Getter{getter},
+		PluginDirectory: pluginDir,
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "arm",
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	want := &Installation{
+		BinaryPath: filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_v2.10.0_x5.0_linux_arm")),
+		Version:    "v2.10.0",
+	}
+	if diff := cmp.Diff(want, install, ignoreServedBy); diff != "" {
+		t.Errorf("unexpected installation: %s", diff)
+	}
+}
+
+func TestRequirement_InstallLatest_platformFallbackLog(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	getter := &mockPluginGetter{
+		Releases: []Release{
+			{Version: "v2.11.0"},
+			{Version: "v2.12.0"},
+		},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"2.12.0": {{
+				Filename: "packer-plugin-amazon_v2.12.0_x5.0_linux_amd64.zip",
+				Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			}},
+			"2.11.0": {{
+				Filename: "packer-plugin-amazon_v2.11.0_x5.0_linux_amd64.zip",
+				Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			}},
+		},
+	}
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+	_, err := pr.InstallLatest(InstallOptions{
+		Getters:         []Getter{getter},
+		PluginDirectory: t.TempDir(),
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "arm",
+			Checksummers: []Checksummer{
+				{Type: "sha256", Hash: sha256.New()},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected no version to be installable for linux/arm")
+	}
+	// only v2.12.0 has a next version to fall back to.
+	if got := strings.Count(logs.String(), "falling back to the next version"); got != 1 {
+		t.Errorf("expected the fallback to be logged once, got %d times:\n%s", got, logs.String())
+	}
+	if strings.Contains(logs.String(), "v2.11.0, falling back") {
+		t.Errorf("expected no fallback to be logged after the last version:\n%s", logs.String())
+	}
+}
+
+func TestRequirement_InstallLatest_noCompatibleVersion(t *testing.T) {
+	getter := &mockPluginGetter{
+		Releases: []Release{
+			{Version: "v1.2.5"},
+			{Version: "v2.0.0"},
+		},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"2.0.0": {
+				{
+					Filename: "packer-plugin-amazon_v2.0.0_x6.0_darwin_amd64.zip",
+					Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				},
+				{
+					Filename: "packer-plugin-amazon_v2.0.0_x6.0_linux_amd64.zip",
+					Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				},
+			},
+			"1.2.5": {{
+				Filename: "packer-plugin-amazon_v1.2.5_x5.0_linux_amd64.zip",
+				Checksum: "1337c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			}},
+		},
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &Requirement{
+		Identifier: identifier,
+	}
+
+	_, err := pr.InstallLatest(InstallOptions{
+		Getters:         []Getter{getter},
+		PluginDirectory: t.TempDir(),
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "darwin", ARCH: "amd64",
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		},
+	})
+	if !errors.Is(err, ErrNoCompatibleVersion) {
+		t.Fatalf("InstallLatest: expected ErrNoCompatibleVersion, got %v", err)
+	}
+	var noCompatible *NoCompatibleVersionError
+	if !errors.As(err, &noCompatible) {
+		t.Fatalf("InstallLatest: expected a NoCompatibleVersionError, got %v", err)
+	}
+	want := &NoCompatibleVersionError{
+		Plugin:     identifier,
+		OS:         "darwin",
+		ARCH:       "amd64",
+		APIVersion: "x5.0",
+		Releases: []IncompatibleRelease{
+			{Version: "v2.0.0", ProtocolVersions: []string{"x6.0"}},
+			{Version: "v1.2.5"},
+		},
+	}
+	if diff := cmp.Diff(want, noCompatible); diff != "" {
+		t.Errorf("unexpected error details: %s", diff)
+	}
+}
+
+func TestRequirement_InstallLatest_versionSelector(t *testing.T) {
+	zip2_2, checksum2_2 := zipFileWithChecksum(map[string]string{
+		"packer-plugin-amazon_v2.2.0_x6.0_darwin_amd64": machoHeader + "v2.2.0_x6.0_darwin_amd64",
+	})
+	getter := &mockPluginGetter{
+		Releases: []Release{
+			{Version: "v2.0.0"},
+			{Version: "v2.1.0"},
+			{Version: "v2.2.0"},
+			{Version: "v2.10.0"},
+		},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			// v2.1.0 has no checksum file, the selector should be asked
+			// for another version.
+			"2.2.0": {{
+				Filename: "packer-plugin-amazon_v2.2.0_x6.0_darwin_amd64.zip",
+				Checksum: checksum2_2,
+			}},
+			"2.10.0": {{
+				Filename: "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip",
+				Checksum: "e1bc0e507c00eb0c5c71e43841690957932de8ea9e099f8b29e828ccd520e25d",
+			}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.2.0_x6.0_darwin_amd64.zip": zip2_2,
+		},
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &Requirement{
+		Identifier:         identifier,
+		VersionConstraints: version.MustConstraints(version.NewConstraint(">= v2.1")),
+	}
+
+	var calls [][]string
+	lowestSelector := func(candidates []*version.Version) *version.Version {
+		call := []string{}
+		lowest := candidates[0]
+		for _, v := range candidates {
+			call = append(call, v.Original())
+			if v.LessThan(lowest) {
+				lowest = v
+			}
+		}
+		calls = append(calls, call)
+		return lowest
+	}
+
+	pluginDir := t.TempDir()
+	got, err := pr.InstallLatest(InstallOptions{
+		Getters:         []Getter{getter},
+		PluginDirectory: pluginDir,
+		VersionSelector: lowestSelector,
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "6", APIVersionMinor: "0",
+			OS: "darwin", ARCH: "amd64",
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	want := &Installation{
+		BinaryPath: filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_v2.2.0_x6.0_darwin_amd64")),
+		Version:    "v2.2.0",
+	}
+	if diff := cmp.Diff(want, got, ignoreServedBy); diff != "" {
+		t.Errorf("unexpected installation: %s", diff)
+	}
+
+	// v2.0.0 is filtered out by the constraint before the selector is called.
+	wantCalls := [][]string{
+		{"v2.1.0", "v2.2.0", "v2.10.0"},
+		{"v2.2.0", "v2.10.0"},
+		{"v2.10.0"},
+	}
+	if diff := cmp.Diff(wantCalls, calls); diff != "" {
+		t.Errorf("unexpected selector calls: %s", diff)
+	}
+}
+
+func TestInstallOptions_orderVersions_unknownSelection(t *testing.T) {
+	versions := func(vs ...string) version.Collection {
+		var res version.Collection
+		for _, v := range vs {
+			res = append(res, version.Must(version.NewVersion(v)))
+		}
+		return res
+	}
+
+	tests := []struct {
+		name     string
+		selector func(candidates []*version.Version) *version.Version
+		want     []string
+	}{
+		{
+			name:     "nil",
+			selector: func([]*version.Version) *version.Version { return nil },
+			want:     []string{"v2.10.0", "v2.2.0", "v2.1.0"},
+		},
+		{
+			name: "not-a-candidate",
+			selector: func(candidates []*version.Version) *version.Version {
+				if len(candidates) == 3 {
+					return version.Must(version.NewVersion("v2.1.0"))
+				}
+				return version.Must(version.NewVersion("v3.0.0"))
+			},
+			want: []string{"v2.1.0", "v2.10.0", "v2.2.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := InstallOptions{VersionSelector: tt.selector}
+			var got []string
+			for _, v := range opts.orderVersions(versions("v2.1.0", "v2.2.0", "v2.10.0")) {
+				got = append(got, v.Original())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected order: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_anyVersion(t *testing.T) {
+	zip2_10, checksum2_10 := zipFileWithChecksum(map[string]string{
+		"packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64": machoHeader + "v2.10.0_x6.0_darwin_amd64",
+	})
+	getter := &mockPluginGetter{
+		Releases: []Release{
+			{Version: "v2.0.0"},
+			{Version: "v2.10.0"},
+			{Version: "v2.2.0"},
+			{Version: "v2.11.0"},
+		},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"2.2.0": {{
+				Filename: "packer-plugin-amazon_v2.2.0_x6.0_darwin_amd64.zip",
+				Checksum: "1337c0ff33",
+			}},
+			"2.10.0": {{
+				Filename: "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip",
+				Checksum: checksum2_10,
+			}},
+			// the newest release needs a more recent Packer
+			"2.11.0": {{
+				Filename: "packer-plugin-amazon_v2.11.0_x7.0_darwin_amd64.zip",
+				Checksum: "1337c0ff33",
+			}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip": zip2_10,
+		},
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := &Requirement{
+		Identifier: identifier,
+	}
+	if !pr.AnyVersion() {
+		t.Fatalf("a requirement without constraints should accept any version")
+	}
+
+	pluginDir := t.TempDir()
+	got, err := pr.InstallLatest(InstallOptions{
+		Getters:         []Getter{getter},
+		PluginDirectory: pluginDir,
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "6", APIVersionMinor: "0",
+			OS: "darwin", ARCH: "amd64",
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	want := &Installation{
+		BinaryPath: filepath.ToSlash(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64")),
+		Version:    "v2.10.0",
+	}
+	if diff := cmp.Diff(want, got, ignoreServedBy); diff != "" {
+		t.Errorf("unexpected installation: %s", diff)
+	}
+}
+
+func TestRequirement_AcceptsVersion(t *testing.T) {
+	tests := []struct {
+		constraints string
+		version     string
+		want        bool
+	}{
+		{"", "v0.0.1", true},
+		{"", "v12.3.4", true},
+		{">= v1.2", "v1.2.0", true},
+		{">= v1.2", "v1.1.9", false},
+		{"~> v1.2.0", "v1.3.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraints+"_"+tt.version, func(t *testing.T) {
+			pr := Requirement{}
+			if tt.constraints != "" {
+				pr.VersionConstraints = version.MustConstraints(version.NewConstraint(tt.constraints))
+			}
+			if got := pr.AcceptsVersion(version.Must(version.NewVersion(tt.version))); got != tt.want {
+				t.Errorf("AcceptsVersion(%s) = %t, want %t", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirement_ParseVersionFilter(t *testing.T) {
+	tests := []struct {
+		filter      string
+		wantPattern string
+		wantErr     bool
+		accepts     []string
+		rejects     []string
+	}{
+		{filter: "v2.10.1", accepts: []string{"v2.10.1"}, rejects: []string{"v2.10.2"}},
+		{filter: "~> v2.10.0", accepts: []string{"v2.10.9"}, rejects: []string{"v2.11.0"}},
+		{filter: "v2.10.*", wantPattern: "v2.10.*", accepts: []string{"v2.10.0", "v2.10.12"}, rejects: []string{"v2.11.0", "v12.10.0"}},
+		{filter: "2.1?.0", wantPattern: "2.1?.0", accepts: []string{"v2.10.0", "v2.11.0"}, rejects: []string{"v2.1.0", "v2.100.0"}},
+		{filter: "v2.[", wantErr: true},
+		{filter: "two", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			pr := Requirement{}
+			err := pr.ParseVersionFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersionFilter(%q) error = %v, wantErr %t", tt.filter, err, tt.wantErr)
+			}
+			if pr.VersionPattern != tt.wantPattern {
+				t.Errorf("VersionPattern = %q, want %q", pr.VersionPattern, tt.wantPattern)
+			}
+			for _, v := range tt.accepts {
+				if !pr.AcceptsVersion(version.Must(version.NewVersion(v))) {
+					t.Errorf("expected %q to accept %s", tt.filter, v)
+				}
+			}
+			for _, v := range tt.rejects {
+				if pr.AcceptsVersion(version.Must(version.NewVersion(v))) {
+					t.Errorf("expected %q to reject %s", tt.filter, v)
+				}
 			}
 		})
 	}
@@ -453,10 +1505,10 @@ func (g *mockPluginGetter) Get(what string, options GetOptions) (io.ReadCloser,
 	switch what {
 	case "releases":
 		toEncode = g.Releases
-	case "sha256":
+	case "sha256", "sha512":
 		enc, ok := g.ChecksumFileEntries[options.version.String()]
 		if !ok {
-			return nil, fmt.Errorf("No checksum available for version %q", options.version.String())
+			return nil, fmt.Errorf("%w: no checksum available for version %q", ErrReleaseFileNotFound, options.version.String())
 		}
 		toEncode = enc
 	case "zip":
@@ -506,6 +1558,23 @@ func zipFile(content map[string]string) io.ReadCloser {
 	return io.NopCloser(buff)
 }
 
+const (
+	// minimal headers for the fake binaries we put in test zips.
+	machoHeader = "\xcf\xfa\xed\xfe"
+	elfHeader   = "\x7fELF"
+)
+
+// zipFileWithChecksum works like zipFile but also returns the hex encoded
+// sha256 of the zip, for tests that need a zip passing checksum validation.
+func zipFileWithChecksum(content map[string]string) (io.ReadCloser, string) {
+	buf := &bytes.Buffer{}
+	if _, err := io.Copy(buf, zipFile(content)); err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return io.NopCloser(buf), hex.EncodeToString(sum[:])
+}
+
 var _ Getter = &mockPluginGetter{}
 
 func Test_LessInstallList(t *testing.T) {
@@ -627,3 +1696,123 @@ func Test_LessInstallList(t *testing.T) {
 		})
 	}
 }
+
+func TestInstallList_Diff(t *testing.T) {
+	install := func(plugin, version string) *Installation {
+		return &Installation{
+			BinaryPath: "plugins/github.com/hashicorp/" + plugin + "/packer-plugin-" + plugin + "_" + version + "_x5.0_linux_amd64",
+			Version:    version,
+		}
+	}
+	before := InstallList{
+		install("amazon", "v1.0.0"),
+		install("docker", "v1.0.0"),
+		install("docker", "v1.1.0"),
+		install("ansible", "v2.0.0"),
+		install("qemu", "v1.0.0"),
+	}
+	after := InstallList{
+		install("amazon", "v1.0.0"),
+		install("docker", "v1.0.0"),
+		install("docker", "v1.1.0"),
+		install("docker", "v1.2.0"),
+		// downgrades are changes too
+		install("ansible", "v1.9.0"),
+		install("azure", "v1.0.0"),
+	}
+
+	added, removed, changed := before.Diff(after)
+
+	versions := func(installs []*Installation) []string {
+		var res []string
+		for _, install := range installs {
+			res = append(res, path.Base(install.BinaryPath))
+		}
+		return res
+	}
+	if diff := cmp.Diff([]string{"packer-plugin-azure_v1.0.0_x5.0_linux_amd64"}, versions(added)); diff != "" {
+		t.Errorf("unexpected added: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"packer-plugin-qemu_v1.0.0_x5.0_linux_amd64"}, versions(removed)); diff != "" {
+		t.Errorf("unexpected removed: %s", diff)
+	}
+	if diff := cmp.Diff([]string{
+		"packer-plugin-docker_v1.2.0_x5.0_linux_amd64",
+		"packer-plugin-ansible_v1.9.0_x5.0_linux_amd64",
+	}, versions(changed)); diff != "" {
+		t.Errorf("unexpected changed: %s", diff)
+	}
+
+	added, removed, changed = after.Diff(after)
+	if len(added)+len(removed)+len(changed) != 0 {
+		t.Errorf("expected no difference with itself, got %v, %v and %v", added, removed, changed)
+	}
+}
+
+func TestRequirement_InstallLatest_minAPIVersion(t *testing.T) {
+	release := func(version, api string) (ChecksumFileEntry, string, io.ReadCloser) {
+		binary := "packer-plugin-amazon_" + version + "_" + api + "_linux_amd64"
+		zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader + version})
+		return ChecksumFileEntry{Filename: binary + ".zip", Checksum: checksum}, "github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip", zip
+	}
+
+	tests := []struct {
+		name          string
+		minAPIVersion string
+		want          string
+		wantErr       string
+	}{
+		{"no-minimum", "", "v1.2.0", ""},
+		{"minimum", "x5.1", "v1.1.0", ""},
+		{"minimum-without-x", "5.1", "v1.1.0", ""},
+		{"above-every-release", "x5.2", "", "minimum protocol version accepted is \"x5.2\""},
+		{"other-major", "x6.0", "", "minimum protocol version accepted is \"x6.0\""},
+		{"invalid", "latest", "", "Invalid minimum protocol"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &mockPluginGetter{
+				Releases:            []Release{{Version: "v1.0.0"}, {Version: "v1.1.0"}, {Version: "v1.2.0"}},
+				ChecksumFileEntries: map[string][]ChecksumFileEntry{},
+				Zips:                map[string]io.ReadCloser{},
+			}
+			for _, r := range [][2]string{{"v1.0.0", "x5.0"}, {"v1.1.0", "x5.1"}, {"v1.2.0", "x5.0"}} {
+				entry, zipPath, zip := release(r[0], r[1])
+				getter.ChecksumFileEntries[strings.TrimPrefix(r[0], "v")] = []ChecksumFileEntry{entry}
+				getter.Zips[zipPath] = zip
+			}
+
+			opts := dependenciesInstallOptions(getter, t.TempDir())
+			opts.APIVersionMinor = "1"
+			opts.MinAPIVersion = tt.minAPIVersion
+			// every version is allowed by the constraints
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", ">= 1.0.0").InstallLatest(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if install == nil || install.Version != tt.want {
+				t.Fatalf("expected %s to be installed, got %#v", tt.want, install)
+			}
+		})
+	}
+}
+
+func TestBinaryInstallationOptions_CheckProtocolVersion_minAPIVersion(t *testing.T) {
+	opts := BinaryInstallationOptions{APIVersionMajor: "5", APIVersionMinor: "2", MinAPIVersion: "x5.1"}
+	for remote, wantErr := range map[string]bool{
+		"x5.0": true,
+		"x5.1": false,
+		"x5.2": false,
+		"x5.3": true,
+	} {
+		if err := opts.CheckProtocolVersion(remote); (err != nil) != wantErr {
+			t.Errorf("CheckProtocolVersion(%q) = %v, expected an error: %t", remote, err, wantErr)
+		}
+	}
+}
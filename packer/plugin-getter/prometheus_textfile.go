@@ -0,0 +1,250 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// promMetric describes a metric written by PrometheusTextfile.
+type promMetric struct {
+	name, help, kind string
+}
+
+// sampleNames returns the names of the samples of the metric.
+func (m promMetric) sampleNames() []string {
+	if m.kind == "summary" {
+		return []string{m.name + "_sum", m.name + "_count"}
+	}
+	return []string{m.name}
+}
+
+var (
+	promInstalls = promMetric{
+		name: "packer_plugin_installs_total",
+		help: "Plugin installs, by plugin source and outcome.",
+		kind: "counter",
+	}
+	promInstallDuration = promMetric{
+		name: "packer_plugin_install_duration_seconds",
+		help: "Time spent installing plugins, by plugin source.",
+		kind: "summary",
+	}
+	promRemovals = promMetric{
+		name: "packer_plugin_removals_total",
+		help: "Plugin binary removals, by plugin source and outcome.",
+		kind: "counter",
+	}
+	// The samples of this gauge are replaced by newer ones, instead of being
+	// added to them.
+	promLastSuccess = promMetric{
+		name: "packer_plugin_last_success_timestamp_seconds",
+		help: "Unix time of the last successful install or removal, by operation and plugin source.",
+		kind: "gauge",
+	}
+
+	promMetrics = []promMetric{promInstalls, promInstallDuration, promRemovals, promLastSuccess}
+)
+
+// PrometheusTextfile is an InstallProgress counting the outcomes of plugin
+// installs, and of removals reported with ObserveRemove, to write them with
+// WriteFile to Path in the Prometheus text exposition format, ex: for the
+// textfile collector of the node exporter:
+//
+//	packer_plugin_installs_total{outcome="installed",source="github.com/hashicorp/amazon"} 1
+//	packer_plugin_install_duration_seconds_sum{source="github.com/hashicorp/amazon"} 2.5
+//	packer_plugin_install_duration_seconds_count{source="github.com/hashicorp/amazon"} 1
+//	packer_plugin_last_success_timestamp_seconds{operation="install",source="github.com/hashicorp/amazon"} 1.7040672e+09
+//
+// The samples already in Path are kept: counts are added to them, so that
+// they keep counting across Packer runs, and the last success timestamps of
+// plugins that did not succeed this time are preserved.
+type PrometheusTextfile struct {
+	Path string
+
+	mu sync.Mutex
+	// samples are the values by sample name, then labels.
+	samples map[string]map[string]float64
+}
+
+var _ InstallProgress = &PrometheusTextfile{}
+
+func (p *PrometheusTextfile) ObserveInstall(result InstallResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.add(promInstalls.name, promLabels("outcome", string(result.Reason), "source", result.Source), 1)
+	if result.Reason == InstallReasonInstalled || result.Reason == InstallReasonFailed {
+		source := promLabels("source", result.Source)
+		p.add(promInstallDuration.name+"_sum", source, result.Duration.Seconds())
+		p.add(promInstallDuration.name+"_count", source, 1)
+	}
+	if result.Reason != InstallReasonFailed {
+		p.add(promLastSuccess.name, promLabels("operation", string(AuditOperationInstall), "source", result.Source), unixNow())
+	}
+}
+
+// ObserveRemove counts the removal of a binary of the plugin source; err is
+// why it failed.
+func (p *PrometheusTextfile) ObserveRemove(source string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	outcome := AuditOutcomeRemoved
+	if err != nil {
+		outcome = string(InstallReasonFailed)
+	}
+	p.add(promRemovals.name, promLabels("outcome", outcome, "source", source), 1)
+	if err == nil {
+		p.add(promLastSuccess.name, promLabels("operation", string(AuditOperationRemove), "source", source), unixNow())
+	}
+}
+
+// unixNow returns the current Unix time, in seconds.
+func unixNow() float64 {
+	return float64(time.Now().UnixNano()) / float64(time.Second)
+}
+
+// add adds value to the sample with labels, or replaces it with the newest
+// of both for the last success timestamps.
+func (p *PrometheusTextfile) add(name, labels string, value float64) {
+	if p.samples == nil {
+		p.samples = map[string]map[string]float64{}
+	}
+	samples := p.samples[name]
+	if samples == nil {
+		samples = map[string]float64{}
+		p.samples[name] = samples
+	}
+	if name == promLastSuccess.name {
+		samples[labels] = math.Max(samples[labels], value)
+		return
+	}
+	samples[labels] += value
+}
+
+// WriteFile writes the observed samples, merged with the ones already in
+// Path, to Path. The file is replaced atomically, so that the collector
+// never reads a partial file.
+func (p *PrometheusTextfile) WriteFile() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	merged := &PrometheusTextfile{}
+	if f, err := os.Open(p.Path); err == nil {
+		err := merged.read(f)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p.Path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	for name, samples := range p.samples {
+		for labels, value := range samples {
+			merged.add(name, labels, value)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.Path), "."+filepath.Base(p.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := merged.write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	// readable by the collector, which may run as another user.
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.Path)
+}
+
+// write writes the samples in the text exposition format, sorted so that the
+// output is stable.
+func (p *PrometheusTextfile) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, metric := range promMetrics {
+		headerWritten := false
+		for _, name := range metric.sampleNames() {
+			samples := p.samples[name]
+			if len(samples) == 0 {
+				continue
+			}
+			if !headerWritten {
+				fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+				headerWritten = true
+			}
+			labels := make([]string, 0, len(samples))
+			for l := range samples {
+				labels = append(labels, l)
+			}
+			sort.Strings(labels)
+			for _, l := range labels {
+				fmt.Fprintf(bw, "%s{%s} %s\n", name, l, strconv.FormatFloat(samples[l], 'g', -1, 64))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// read reads the samples of a file written by write. The samples of other
+// metrics are ignored.
+func (p *PrometheusTextfile) read(r io.Reader) error {
+	known := map[string]bool{}
+	for _, metric := range promMetrics {
+		for _, name := range metric.sampleNames() {
+			known[name] = true
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		open := bytes.IndexByte(line, '{')
+		end := bytes.LastIndexByte(line, '}')
+		if open < 0 || end < open {
+			return fmt.Errorf("invalid sample %q", line)
+		}
+		if !known[string(line[:open])] {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(string(line[end+1:])), 64)
+		if err != nil {
+			return fmt.Errorf("invalid sample %q: %w", line, err)
+		}
+		p.add(string(line[:open]), string(line[open+1:end]), value)
+	}
+	return scanner.Err()
+}
+
+// promLabels formats name and value pairs as the labels of a sample, ex:
+// outcome="installed",source="github.com/hashicorp/amazon".
+func promLabels(pairs ...string) string {
+	var labels []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, pairs[i]+`="`+promLabelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(labels, ",")
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	promCommentRegex = regexp.MustCompile(`^# (HELP|TYPE) ([a-zA-Z_:][a-zA-Z0-9_:]*) (.+)$`)
+	promSampleRegex  = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)\{((?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\[\\"n])*",?)*)\} (\S+)$`)
+)
+
+// checkPromExposition fails t when content is not in the Prometheus text
+// exposition format: every sample must follow the TYPE line of its metric,
+// which must only be declared once, and have a valid name, labels and value.
+func checkPromExposition(t *testing.T, content string) {
+	t.Helper()
+	if !strings.HasSuffix(content, "\n") {
+		t.Errorf("expected the exposition to end with a line feed")
+	}
+	declared := map[string]bool{}
+	family, kind := "", ""
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		if m := promCommentRegex.FindStringSubmatch(line); m != nil {
+			if m[1] == "TYPE" {
+				if declared[m[2]] {
+					t.Errorf("%s is declared twice", m[2])
+				}
+				switch m[3] {
+				case "counter", "gauge", "summary", "histogram", "untyped":
+				default:
+					t.Errorf("invalid type in %q", line)
+				}
+				declared[m[2]] = true
+				family, kind = m[2], m[3]
+			}
+			continue
+		}
+		m := promSampleRegex.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("invalid sample line %q", line)
+			continue
+		}
+		if name := m[1]; name != family && !(kind == "summary" && (name == family+"_sum" || name == family+"_count")) {
+			t.Errorf("sample %q is not part of the %s %s family", line, family, kind)
+		}
+		if _, err := strconv.ParseFloat(m[3], 64); err != nil {
+			t.Errorf("invalid value in %q: %s", line, err)
+		}
+	}
+}
+
+func TestPrometheusTextfile_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packer_plugins.prom")
+	before := time.Now()
+
+	p := &PrometheusTextfile{Path: path}
+	p.ObserveInstall(InstallResult{Source: "github.com/hashicorp/amazon", Reason: InstallReasonInstalled, Duration: 2500 * time.Millisecond})
+	p.ObserveInstall(InstallResult{Source: "github.com/hashicorp/docker", Reason: InstallReasonFailed, Duration: time.Second, Err: errors.New("no matching version")})
+	p.ObserveInstall(InstallResult{Source: "github.com/hashicorp/docker", Reason: InstallReasonUpToDate})
+	p.ObserveRemove("github.com/hashicorp/amazon", nil)
+	p.ObserveRemove(`github.com/"quoted"\amazon`, errors.New("permission denied"))
+	if err := p.WriteFile(); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPromExposition(t, string(content))
+	for _, want := range []string{
+		"# TYPE packer_plugin_installs_total counter\n",
+		`packer_plugin_installs_total{outcome="installed",source="github.com/hashicorp/amazon"} 1` + "\n",
+		`packer_plugin_installs_total{outcome="failed",source="github.com/hashicorp/docker"} 1` + "\n",
+		`packer_plugin_installs_total{outcome="up_to_date",source="github.com/hashicorp/docker"} 1` + "\n",
+		"# TYPE packer_plugin_install_duration_seconds summary\n",
+		`packer_plugin_install_duration_seconds_sum{source="github.com/hashicorp/amazon"} 2.5` + "\n",
+		`packer_plugin_install_duration_seconds_count{source="github.com/hashicorp/amazon"} 1` + "\n",
+		`packer_plugin_install_duration_seconds_sum{source="github.com/hashicorp/docker"} 1` + "\n",
+		`packer_plugin_removals_total{outcome="removed",source="github.com/hashicorp/amazon"} 1` + "\n",
+		`packer_plugin_removals_total{outcome="failed",source="github.com/\"quoted\"\\amazon"} 1` + "\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected the textfile to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	timestamps := map[string]float64{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(line, "packer_plugin_last_success_timestamp_seconds{") {
+			continue
+		}
+		labels, value, _ := strings.Cut(strings.TrimPrefix(line, "packer_plugin_last_success_timestamp_seconds"), " ")
+		timestamps[labels], _ = strconv.ParseFloat(value, 64)
+	}
+	for _, labels := range []string{
+		`{operation="install",source="github.com/hashicorp/amazon"}`,
+		`{operation="install",source="github.com/hashicorp/docker"}`,
+		`{operation="remove",source="github.com/hashicorp/amazon"}`,
+	} {
+		if ts := timestamps[labels]; ts < float64(before.Unix()) || ts > float64(time.Now().Unix()+1) {
+			t.Errorf("expected a last success timestamp for %s, got %v", labels, ts)
+		}
+	}
+	if len(timestamps) != 3 {
+		t.Errorf("expected no last success timestamp for failures, got %v", timestamps)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the textfile to be left, got %v", entries)
+	}
+}
+
+func TestPrometheusTextfile_WriteFile_merges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packer_plugins.prom")
+	previous := `# HELP packer_plugin_installs_total Plugin installs, by plugin source and outcome.
+# TYPE packer_plugin_installs_total counter
+packer_plugin_installs_total{outcome="installed",source="github.com/hashicorp/amazon"} 3
+# HELP packer_plugin_last_success_timestamp_seconds Unix time of the last successful install or removal, by operation and plugin source.
+# TYPE packer_plugin_last_success_timestamp_seconds gauge
+packer_plugin_last_success_timestamp_seconds{operation="install",source="github.com/hashicorp/amazon"} 1.7e+09
+packer_plugin_last_success_timestamp_seconds{operation="install",source="github.com/hashicorp/docker"} 1.6e+09
+`
+	if err := os.WriteFile(path, []byte(previous), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &PrometheusTextfile{Path: path}
+	p.ObserveInstall(InstallResult{Source: "github.com/hashicorp/amazon", Reason: InstallReasonInstalled, Duration: time.Second})
+	p.ObserveInstall(InstallResult{Source: "github.com/hashicorp/docker", Reason: InstallReasonFailed, Duration: time.Second})
+	if err := p.WriteFile(); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPromExposition(t, string(content))
+	for _, want := range []string{
+		`packer_plugin_installs_total{outcome="installed",source="github.com/hashicorp/amazon"} 4` + "\n",
+		`packer_plugin_installs_total{outcome="failed",source="github.com/hashicorp/docker"} 1` + "\n",
+		// docker failed this time, its previous success is kept.
+		`packer_plugin_last_success_timestamp_seconds{operation="install",source="github.com/hashicorp/docker"} 1.6e+09` + "\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected the textfile to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(string(content), `source="github.com/hashicorp/amazon"} 1.7e+09`) {
+		t.Errorf("expected the last success of amazon to be updated, got:\n%s", content)
+	}
+}
+
+func TestRequirements_InstallAll_prometheusTextfile(t *testing.T) {
+	pluginDir := t.TempDir()
+	textfile := &PrometheusTextfile{Path: filepath.Join(t.TempDir(), "packer_plugins.prom")}
+	opts := dependenciesInstallOptions(singleReleaseGetter("amazon"), pluginDir)
+	opts.Progress = textfile
+
+	reqs := Requirements{mustRequirement(t, "github.com/hashicorp/amazon", ""), mustRequirement(t, "github.com/hashicorp/docker", "")}
+	if _, err := reqs.InstallAll(opts); err == nil {
+		t.Fatalf("expected docker, which has no release, to fail")
+	}
+	if err := textfile.WriteFile(); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	content, err := os.ReadFile(textfile.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPromExposition(t, string(content))
+	for _, want := range []string{
+		`packer_plugin_installs_total{outcome="installed",source="github.com/hashicorp/amazon"} 1` + "\n",
+		`packer_plugin_installs_total{outcome="failed",source="github.com/hashicorp/docker"} 1` + "\n",
+		`packer_plugin_install_duration_seconds_count{source="github.com/hashicorp/amazon"} 1` + "\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected the textfile to contain %q, got:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"io"
+)
+
+// A ProvenanceVerifier verifies a downloaded zip against the provenance
+// attestation of its release, ex: a SLSA provenance in-toto statement.
+//
+// When InstallOptions.ProvenanceVerifier is set, getters are also asked for
+// the attestation with the "provenance" phase, once the zip matched its
+// checksum.
+type ProvenanceVerifier interface {
+	VerifyProvenance(pr *Requirement, zipFilename string, zip io.Reader, provenance []byte) error
+}
+
+// verifyProvenance gets the provenance attestation of the release from getter
+// and verifies zip with opts.ProvenanceVerifier. zip is read from its start,
+// and rewound afterwards.
+func (opts *InstallOptions) verifyProvenance(getter Getter, getOpts GetOptions, zip io.ReadSeeker) error {
+	provenance, err := opts.getAll(getter, "provenance", getOpts)
+	if err != nil {
+		return fmt.Errorf("could not get the provenance attestation: %w", err)
+	}
+
+	if _, err := zip.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := opts.ProvenanceVerifier.VerifyProvenance(getOpts.PluginRequirement, getOpts.ExpectedZipFilename(), zip, provenance); err != nil {
+		return &IntegrityError{Err: err}
+	}
+	_, err = zip.Seek(0, io.SeekStart)
+	return err
+}
@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// provenancePluginGetter also serves a provenance attestation, if set.
+type provenancePluginGetter struct {
+	*mockPluginGetter
+	Provenance string
+}
+
+func (g *provenancePluginGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	if what == "provenance" {
+		if g.Provenance == "" {
+			return nil, errors.New("404: no attestation")
+		}
+		return io.NopCloser(strings.NewReader(g.Provenance)), nil
+	}
+	return g.mockPluginGetter.Get(what, options)
+}
+
+// provenanceVerifier considers "<zip filename> <sha256>" a valid attestation.
+type provenanceVerifier struct{}
+
+func (provenanceVerifier) VerifyProvenance(pr *Requirement, zipFilename string, zip io.Reader, provenance []byte) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, zip); err != nil {
+		return err
+	}
+	if want := zipFilename + " " + hex.EncodeToString(h.Sum(nil)); string(provenance) != want {
+		return fmt.Errorf("subject mismatch: %q is not %q", provenance, want)
+	}
+	return nil
+}
+
+func TestRequirement_InstallLatest_provenanceVerifier(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name       string
+		provenance func(checksum string) string
+		wantErr    string
+	}{
+		{
+			name:       "subject",
+			provenance: func(checksum string) string { return binary + ".zip " + checksum },
+		},
+		{
+			name:       "subject-mismatch",
+			provenance: func(checksum string) string { return binary + ".zip " + strings.Repeat("0", 64) },
+			wantErr:    "could not verify the provenance of " + binary + ".zip: subject mismatch",
+		},
+		{
+			name:       "no-attestation",
+			provenance: func(checksum string) string { return "" },
+			wantErr:    "could not get the provenance attestation: 404: no attestation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := singleReleaseGetter("amazon")
+			checksum := mock.ChecksumFileEntries["1.0.0"][0].Checksum
+			getter := &provenancePluginGetter{
+				mockPluginGetter: mock,
+				Provenance:       tt.provenance(checksum),
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.ProvenanceVerifier = provenanceVerifier{}
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+
+			_, statErr := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("InstallLatest: %v", err)
+				}
+				if statErr != nil {
+					t.Errorf("expected the plugin to be installed: %v", statErr)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if !os.IsNotExist(statErr) {
+				t.Errorf("expected the plugin not to be installed, stat returned %v", statErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// ZipChecksumFileExt is the suffix of the file recording, next to an
+// installed binary, the checksum of the zip it was extracted from, as a line
+// of the release checksum file, ex:
+//
+//	packer-plugin-amazon_v1.2.3_x5.0_linux_amd64_ZIP_SHA256SUM:
+//	9f86d081884c7d65...  packer-plugin-amazon_v1.2.3_x5.0_linux_amd64.zip
+const ZipChecksumFileExt = "_ZIP_SHA256SUM"
+
+// ErrNoStoredZipChecksum is returned by Requirement.CheckPublishedChecksum
+// for binaries installed without recording the checksum of their zip, ex: by
+// older versions of Packer or from a local binary.
+var ErrNoStoredZipChecksum = errors.New("the checksum of the installed zip was not recorded")
+
+// PublishedChecksumDriftError is returned by
+// Requirement.CheckPublishedChecksum when the zip an installed binary was
+// extracted from is now published with another checksum, ex: the release
+// was re-tagged.
+type PublishedChecksumDriftError struct {
+	BinaryPath string
+	Zip        string
+	Installed  string
+	Published  string
+}
+
+func (e *PublishedChecksumDriftError) Error() string {
+	return fmt.Sprintf("%s was installed from %s with checksum %s, which is now published with checksum %s", e.BinaryPath, e.Zip, e.Installed, e.Published)
+}
+
+// writeZipChecksum records, next to binaryPath, the hex sha256 checksum of
+// the zip it was extracted from.
+func writeZipChecksum(binaryPath, zipFilename, checksum string) error {
+	return os.WriteFile(LongPath(binaryPath+ZipChecksumFileExt), []byte(checksum+"  "+zipFilename+"\n"), 0644)
+}
+
+// readZipChecksum returns the zip binaryPath was extracted from and its
+// checksum, as recorded by writeZipChecksum.
+func readZipChecksum(binaryPath string) (zipFilename, checksum string, err error) {
+	content, err := os.ReadFile(LongPath(binaryPath + ZipChecksumFileExt))
+	if os.IsNotExist(err) {
+		return "", "", fmt.Errorf("%w for %s", ErrNoStoredZipChecksum, binaryPath)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.Fields(string(content))
+	if len(parts) != 2 {
+		return "", "", &MalformedChecksumError{
+			File: binaryPath + ZipChecksumFileExt,
+			Err:  errors.New("expected a checksum and a zip filename"),
+		}
+	}
+	return parts[1], strings.ToLower(parts[0]), nil
+}
+
+// CheckPublishedChecksum compares the checksum of the zip install was
+// extracted from, recorded when it was installed, with the one currently
+// published in the sha256 checksum file of its release. Only the checksum
+// file is downloaded, from the first of opts.Getters that serves it.
+//
+// A *PublishedChecksumDriftError is returned when they differ.
+func (pr *Requirement) CheckPublishedChecksum(install *Installation, opts InstallOptions) error {
+	zipFilename, installed, err := readZipChecksum(install.BinaryPath)
+	if err != nil {
+		return err
+	}
+	v, err := version.NewVersion(install.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version of %s: %w", install.BinaryPath, err)
+	}
+
+	var errs *multierror.Error
+	for _, getter := range opts.Getters {
+		checksumFile, err := opts.get(getter, "sha256", GetOptions{
+			PluginRequirement:         pr,
+			BinaryInstallationOptions: opts.BinaryInstallationOptions,
+			version:                   v,
+		})
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not get the sha256 checksum file of %s %s: %w", pr.Identifier, install.Version, err))
+			continue
+		}
+		entries, err := ParseChecksumFileEntries(checksumFile)
+		_ = checksumFile.Close()
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not parse the sha256 checksum file of %s %s: %w", pr.Identifier, install.Version, err))
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Filename != zipFilename {
+				continue
+			}
+			published := strings.ToLower(strings.TrimSpace(entry.Checksum))
+			if published != installed {
+				return &PublishedChecksumDriftError{
+					BinaryPath: install.BinaryPath,
+					Zip:        zipFilename,
+					Installed:  installed,
+					Published:  published,
+				}
+			}
+			return nil
+		}
+		errs = multierror.Append(errs, fmt.Errorf("%s is no longer listed in the sha256 checksum file of %s %s", zipFilename, pr.Identifier, install.Version))
+	}
+	if errs.Len() == 0 {
+		return errors.New("no getter to get the published checksum from")
+	}
+	return errs
+}
+
+// recordZipChecksum records the checksum of the zip binaryPath was extracted
+// from, for Requirement.CheckPublishedChecksum: the expected one, or pin for
+// zips trusted on first use. Failing to do so does not fail the install.
+func recordZipChecksum(binaryPath, zipFilename string, checksum *FileChecksum, pin string) {
+	if checksum.Checksummer.Type != "sha256" {
+		return
+	}
+	sum := pin
+	if checksum.Expected != nil {
+		sum = hex.EncodeToString(checksum.Expected)
+	}
+	if err := writeZipChecksum(binaryPath, zipFilename, sum); err != nil {
+		log.Printf("[WARNING] failed to record the checksum of %s: %s", zipFilename, err)
+	}
+}
+
+// PublishedChecksumCheck is the result of Requirement.CheckPublishedChecksum
+// for an installed binary.
+type PublishedChecksumCheck struct {
+	// Identifier of the plugin, deduced from where the binary is installed.
+	Identifier *addrs.Plugin
+
+	Installation
+
+	// Err is nil when the binary was installed from the zip currently
+	// published.
+	Err error
+}
+
+// CheckPublishedChecksums runs CheckPublishedChecksum for every binary of pr
+// installed in listOpts.PluginDirectory. Like ListCorruptInstallations,
+// binaries are never run, only the version in their filename is matched
+// against pr.VersionConstraints.
+func (pr Requirement) CheckPublishedChecksums(listOpts ListInstallationsOptions, opts InstallOptions) ([]*PublishedChecksumCheck, error) {
+	matches, err := pr.globInstallations(listOpts.PluginDirectory, listOpts)
+	if err != nil {
+		return nil, fmt.Errorf("CheckPublishedChecksums: failed to list binaries in folder: %v", err)
+	}
+
+	var res []*PublishedChecksumCheck
+	for _, path := range matches {
+		identifier, pluginVersion, _, err := parseInstallationPath(listOpts, path)
+		if err != nil {
+			log.Printf("[TRACE] %s, ignoring", err)
+			continue
+		}
+		rawVersion, _ := version.NewVersion(pluginVersion.Core().String())
+		if !pr.AcceptsVersion(rawVersion) {
+			continue
+		}
+
+		check := &PublishedChecksumCheck{
+			Identifier: identifier,
+			Installation: Installation{
+				BinaryPath: path,
+				Version:    "v" + pluginVersion.String(),
+			},
+		}
+		check.Err = (&Requirement{Identifier: identifier}).CheckPublishedChecksum(&check.Installation, opts)
+		res = append(res, check)
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequirement_CheckPublishedChecksum(t *testing.T) {
+	pluginDir := t.TempDir()
+	getter := singleReleaseGetter("amazon")
+	opts := dependenciesInstallOptions(getter, pluginDir)
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+
+	install, err := pr.InstallLatest(opts)
+	if err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	published := getter.ChecksumFileEntries["1.0.0"][0]
+
+	if err := pr.CheckPublishedChecksum(install, opts); err != nil {
+		t.Errorf("expected the installed zip to match the published one, got %v", err)
+	}
+
+	// the release was re-tagged.
+	retagged := published
+	retagged.Checksum = strings.Repeat("0", 64)
+	getter.ChecksumFileEntries["1.0.0"] = []ChecksumFileEntry{retagged}
+	err = pr.CheckPublishedChecksum(install, opts)
+	var drift *PublishedChecksumDriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("expected a drift, got %v", err)
+	}
+	if drift.Installed != published.Checksum || drift.Published != retagged.Checksum || drift.Zip != published.Filename {
+		t.Errorf("unexpected drift %#v", drift)
+	}
+
+	// the zip is no longer published.
+	getter.ChecksumFileEntries["1.0.0"] = []ChecksumFileEntry{}
+	if err := pr.CheckPublishedChecksum(install, opts); err == nil || !strings.Contains(err.Error(), "no longer listed") {
+		t.Errorf("expected the zip not to be listed anymore, got %v", err)
+	}
+
+	if err := os.Remove(install.BinaryPath + ZipChecksumFileExt); err != nil {
+		t.Fatal(err)
+	}
+	if err := pr.CheckPublishedChecksum(install, opts); !errors.Is(err, ErrNoStoredZipChecksum) {
+		t.Errorf("expected ErrNoStoredZipChecksum, got %v", err)
+	}
+}
+
+func TestRequirement_CheckPublishedChecksums(t *testing.T) {
+	pluginDir := t.TempDir()
+	getter := singleReleaseGetter("amazon")
+	opts := dependenciesInstallOptions(getter, pluginDir)
+	if _, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts); err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	retagged := getter.ChecksumFileEntries["1.0.0"][0]
+	retagged.Checksum = strings.Repeat("0", 64)
+	getter.ChecksumFileEntries["1.0.0"] = []ChecksumFileEntry{retagged}
+
+	checks, err := (Requirement{}).CheckPublishedChecksums(ListInstallationsOptions{
+		PluginDirectory:           pluginDir,
+		BinaryInstallationOptions: opts.BinaryInstallationOptions,
+	}, opts)
+	if err != nil {
+		t.Fatalf("CheckPublishedChecksums: %v", err)
+	}
+	if len(checks) != 1 {
+		t.Fatalf("expected 1 check, got %d", len(checks))
+	}
+	check := checks[0]
+	var drift *PublishedChecksumDriftError
+	if check.Identifier.String() != "github.com/hashicorp/amazon" || check.Version != "v1.0.0" || !errors.As(check.Err, &drift) {
+		t.Errorf("unexpected check %s %s: %v", check.Identifier, check.Version, check.Err)
+	}
+}
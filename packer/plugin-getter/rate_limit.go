@@ -0,0 +1,33 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"context"
+	"fmt"
+)
+
+// A RateLimiter paces the requests done to getters. A *rate.Limiter from
+// golang.org/x/time/rate is one; sharing it between the InstallOptions of a
+// process keeps the whole process under its rate.
+type RateLimiter interface {
+	// Wait blocks until a request is allowed.
+	Wait(ctx context.Context) error
+}
+
+// waitRateLimit blocks until opts.RateLimiter, if set, allows the what
+// request, or the context of the install is done.
+func (opts *InstallOptions) waitRateLimit(what string) error {
+	if opts.RateLimiter == nil {
+		return nil
+	}
+	ctx := opts.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := opts.RateLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limiter refused the %s request: %w", what, err)
+	}
+	return nil
+}
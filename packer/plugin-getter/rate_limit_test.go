@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// timestampingGetter records when every request is done.
+type timestampingGetter struct {
+	Getter
+	requests []time.Time
+}
+
+func (g *timestampingGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	g.requests = append(g.requests, time.Now())
+	return g.Getter.Get(what, opts)
+}
+
+func TestRequirements_InstallAll_rateLimiter(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	limiter := rate.NewLimiter(rate.Every(interval), 1)
+
+	// two installs sharing the limiter, as two InstallAll calls of a process
+	// would.
+	var getters []*timestampingGetter
+	for _, name := range []string{"amazon", "docker"} {
+		getter := &timestampingGetter{Getter: multiPluginGetter{
+			"github.com/hashicorp/" + name: singleReleaseGetter(name),
+		}}
+		getters = append(getters, getter)
+
+		opts := dependenciesInstallOptions(getter, t.TempDir())
+		opts.RateLimiter = limiter
+		if _, err := (Requirements{mustRequirement(t, "github.com/hashicorp/"+name, "")}).InstallAll(opts); err != nil {
+			t.Fatalf("InstallAll: %v", err)
+		}
+	}
+
+	requests := append(getters[0].requests, getters[1].requests...)
+	if len(requests) != 6 {
+		t.Fatalf("expected 6 requests, got %d", len(requests))
+	}
+	// allow for the imprecision of timers.
+	const minGap = interval - 5*time.Millisecond
+	for i := 1; i < len(requests); i++ {
+		if gap := requests[i].Sub(requests[i-1]); gap < minGap {
+			t.Errorf("request %d was done %s after the previous one, expected at least %s", i, gap, interval)
+		}
+	}
+}
+
+func TestRequirements_InstallAllContext_rateLimiterCancelled(t *testing.T) {
+	// the burst is spent: the next request waits for an hour.
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	limiter.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	getter := multiPluginGetter{"github.com/hashicorp/amazon": singleReleaseGetter("amazon")}
+	opts := dependenciesInstallOptions(getter, t.TempDir())
+	opts.RateLimiter = limiter
+	_, err := (Requirements{mustRequirement(t, "github.com/hashicorp/amazon", "")}).InstallAllContext(ctx, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the wait to be interrupted by the cancelled context, got %v", err)
+	}
+}
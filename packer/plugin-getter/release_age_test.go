@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRequirement_InstallLatest_minReleaseAge(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		minReleaseAge time.Duration
+		publishedAt   [2]time.Time
+		want          string
+	}{
+		{
+			name:        "no-minimum",
+			publishedAt: [2]time.Time{now.Add(-40 * 24 * time.Hour), now.Add(-2 * 24 * time.Hour)},
+			want:        "v1.1.0",
+		},
+		{
+			name:          "too-new",
+			minReleaseAge: 7 * 24 * time.Hour,
+			publishedAt:   [2]time.Time{now.Add(-40 * 24 * time.Hour), now.Add(-2 * 24 * time.Hour)},
+			want:          "v1.0.0",
+		},
+		{
+			name:          "old-enough",
+			minReleaseAge: 24 * time.Hour,
+			publishedAt:   [2]time.Time{now.Add(-40 * 24 * time.Hour), now.Add(-2 * 24 * time.Hour)},
+			want:          "v1.1.0",
+		},
+		{
+			name:          "undated-release-disables-the-filter",
+			minReleaseAge: 7 * 24 * time.Hour,
+			publishedAt:   [2]time.Time{{}, now.Add(-2 * 24 * time.Hour)},
+			want:          "v1.1.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &mockPluginGetter{
+				ChecksumFileEntries: map[string][]ChecksumFileEntry{},
+				Zips:                map[string]io.ReadCloser{},
+			}
+			for i, v := range []string{"v1.0.0", "v1.1.0"} {
+				binary := "packer-plugin-amazon_" + v + "_x5.0_linux_amd64"
+				zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader + v})
+				getter.Releases = append(getter.Releases, Release{Version: v, PublishedAt: tt.publishedAt[i]})
+				getter.ChecksumFileEntries[v[1:]] = []ChecksumFileEntry{{Filename: binary + ".zip", Checksum: checksum}}
+				getter.Zips["github.com/hashicorp/packer-plugin-amazon/"+binary+".zip"] = zip
+			}
+
+			opts := dependenciesInstallOptions(getter, t.TempDir())
+			opts.MinReleaseAge = tt.minReleaseAge
+			install, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if install == nil || install.Version != tt.want {
+				t.Fatalf("expected %s to be installed, got %#v", tt.want, install)
+			}
+		})
+	}
+}
+
+func TestRelease_publishedAtJSON(t *testing.T) {
+	publishedAt := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	out, err := json.Marshal([]Release{{Version: "v1.0.0"}, {Version: "v1.1.0", PublishedAt: publishedAt}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `[{"version":"v1.0.0"},{"version":"v1.1.0","published_at":"2023-04-05T06:07:08Z"}]`; string(out) != want {
+		t.Errorf("Marshal = %s, want %s", out, want)
+	}
+	releases, err := ParseReleases(io.NopCloser(bytes.NewReader(out)))
+	if err != nil {
+		t.Fatalf("ParseReleases: %v", err)
+	}
+	if !releases[0].PublishedAt.IsZero() || !releases[1].PublishedAt.Equal(publishedAt) {
+		t.Errorf("unexpected publication dates after decoding: %v", releases)
+	}
+}
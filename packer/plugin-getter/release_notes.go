@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"log"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ReleaseNotesGetter is implemented by the Getters that can tell what changed
+// in a release, like GitHub does with the body of its releases.
+type ReleaseNotesGetter interface {
+	// ReleaseNotes returns the notes of the given version of the plugin, ex:
+	// "v1.2.3". It returns an empty string when the release has none.
+	ReleaseNotes(pr *Requirement, version string) (string, error)
+}
+
+// ReleaseNotes returns the notes of the given version of pr, as told by the
+// first of getters that has some. Getters that do not implement
+// ReleaseNotesGetter are skipped and errors are only logged, since notes are
+// informative: an empty string is returned when no getter could provide any.
+func (pr *Requirement) ReleaseNotes(getters []Getter, version string) string {
+	for _, getter := range getters {
+		notesGetter, ok := getter.(ReleaseNotesGetter)
+		if !ok {
+			continue
+		}
+		notes, err := notesGetter.ReleaseNotes(pr, version)
+		if err != nil {
+			log.Printf("[TRACE] could not get the release notes of %s %s: %s", pr.Identifier, version, err)
+			continue
+		}
+		if notes = strings.TrimSpace(notes); notes != "" {
+			return notes
+		}
+	}
+	return ""
+}
+
+// VersionNotes are the release notes of a version of a plugin.
+type VersionNotes struct {
+	Version string
+	Notes   string
+}
+
+// UpgradeNotes returns the notes of the releases of pr newer than the from
+// version, up to the to version included, newest first. Releases without
+// notes are skipped. The releases are listed by the first of getters that
+// lists any, regardless of the constraints of pr; when none can, only the
+// notes of the to version are returned.
+func (pr *Requirement) UpgradeNotes(getters []Getter, from, to string) []VersionNotes {
+	fromVersion, err := version.NewVersion(from)
+	if err != nil {
+		return nil
+	}
+	toVersion, err := version.NewVersion(to)
+	if err != nil {
+		return nil
+	}
+	versions := version.Collection{toVersion}
+	anyVersion := &Requirement{Identifier: pr.Identifier}
+	if released, err := anyVersion.remoteVersions(InstallOptions{Getters: getters}); err != nil {
+		log.Printf("[TRACE] could not list the releases of %s, only getting the notes of %s: %s", pr.Identifier, to, err)
+	} else {
+		versions = released
+	}
+
+	var res []VersionNotes
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		if !v.GreaterThan(fromVersion) || v.GreaterThan(toVersion) {
+			continue
+		}
+		versionString := "v" + v.String()
+		if notes := pr.ReleaseNotes(getters, versionString); notes != "" {
+			res = append(res, VersionNotes{Version: versionString, Notes: notes})
+		}
+	}
+	return res
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// releaseNotesGetter is a mockPluginGetter that also has release notes, by
+// version.
+type releaseNotesGetter struct {
+	mockPluginGetter
+	Notes map[string]string
+}
+
+func (g *releaseNotesGetter) ReleaseNotes(pr *Requirement, version string) (string, error) {
+	notes, found := g.Notes[version]
+	if !found {
+		return "", fmt.Errorf("no release %s", version)
+	}
+	return notes, nil
+}
+
+func TestRequirement_ReleaseNotes(t *testing.T) {
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "")
+
+	withNotes := &releaseNotesGetter{Notes: map[string]string{
+		"v1.1.0": "\n## 1.1.0\n\n* Add the amazon-ami datasource.\n",
+		"v1.0.0": "",
+	}}
+	otherNotes := &releaseNotesGetter{Notes: map[string]string{
+		"v1.0.0": "## 1.0.0 from a mirror",
+	}}
+
+	tests := []struct {
+		name    string
+		getters []Getter
+		version string
+		want    string
+	}{
+		{
+			name:    "getter-with-notes",
+			getters: []Getter{withNotes},
+			version: "v1.1.0",
+			want:    "## 1.1.0\n\n* Add the amazon-ami datasource.",
+		},
+		{
+			name:    "getters-without-notes-capability-are-skipped",
+			getters: []Getter{&mockPluginGetter{}, withNotes},
+			version: "v1.1.0",
+			want:    "## 1.1.0\n\n* Add the amazon-ami datasource.",
+		},
+		{
+			name:    "empty-notes-fall-back-to-next-getter",
+			getters: []Getter{withNotes, otherNotes},
+			version: "v1.0.0",
+			want:    "## 1.0.0 from a mirror",
+		},
+		{
+			name:    "errors-fall-back-to-next-getter",
+			getters: []Getter{otherNotes, withNotes},
+			version: "v1.1.0",
+			want:    "## 1.1.0\n\n* Add the amazon-ami datasource.",
+		},
+		{
+			name:    "no-notes",
+			getters: []Getter{&mockPluginGetter{}, otherNotes},
+			version: "v1.1.0",
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pr.ReleaseNotes(tt.getters, tt.version); got != tt.want {
+				t.Errorf("ReleaseNotes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirement_UpgradeNotes(t *testing.T) {
+	// the constraints of the requirement do not hide releases in between.
+	pr := mustRequirement(t, "github.com/hashicorp/amazon", "= v1.3.0")
+	notes := map[string]string{
+		"v1.0.0": "## 1.0.0",
+		"v1.1.0": "## 1.1.0",
+		"v1.2.0": "",
+		"v1.3.0": "## 1.3.0",
+		"v1.4.0": "## 1.4.0",
+	}
+	listing := &releaseNotesGetter{
+		mockPluginGetter: mockPluginGetter{Releases: []Release{
+			{Version: "v1.0.0"}, {Version: "v1.1.0"}, {Version: "v1.2.0"}, {Version: "v1.3.0"}, {Version: "v1.4.0"},
+		}},
+		Notes: notes,
+	}
+
+	tests := []struct {
+		name    string
+		getters []Getter
+		want    []VersionNotes
+	}{
+		{
+			name:    "releases-in-between-newest-first",
+			getters: []Getter{listing},
+			want: []VersionNotes{
+				{Version: "v1.3.0", Notes: "## 1.3.0"},
+				{Version: "v1.1.0", Notes: "## 1.1.0"},
+			},
+		},
+		{
+			name:    "releases-not-listed",
+			getters: []Getter{&releaseNotesGetter{Notes: notes}},
+			want: []VersionNotes{
+				{Version: "v1.3.0", Notes: "## 1.3.0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pr.UpgradeNotes(tt.getters, "v1.0.0", "v1.3.0")
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected notes: %s", diff)
+			}
+		})
+	}
+}
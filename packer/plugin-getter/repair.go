@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// CorruptInstallation is an installed plugin binary that does not match its
+// local checksum file, or has none.
+type CorruptInstallation struct {
+	// Identifier of the plugin, deduced from where the binary is installed.
+	Identifier *addrs.Plugin
+
+	Installation
+
+	// Err tells why the binary is considered corrupt.
+	Err error
+}
+
+// ListCorruptInstallations lists the binaries of pr that ListInstallations
+// would ignore because they fail checksum verification.
+//
+// Binaries are never run, since running a corrupt binary is unsafe. Only
+// the version in their filename is matched against pr.VersionConstraints.
+func (pr Requirement) ListCorruptInstallations(opts ListInstallationsOptions) ([]*CorruptInstallation, error) {
+	matches, err := pr.globInstallations(opts.PluginDirectory, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ListCorruptInstallations: failed to list binaries in folder: %v", err)
+	}
+
+	var res []*CorruptInstallation
+	for _, path := range matches {
+		identifier, pluginVersion, _, err := parseInstallationPath(opts, path)
+		if err != nil {
+			log.Printf("[TRACE] %s, ignoring", err)
+			continue
+		}
+
+		rawVersion, _ := version.NewVersion(pluginVersion.Core().String())
+		if !pr.AcceptsVersion(rawVersion) {
+			continue
+		}
+
+		if err := verifyInstallation(path, pr.checksummers(opts.BinaryInstallationOptions)); err != nil {
+			res = append(res, &CorruptInstallation{
+				Identifier: identifier,
+				Installation: Installation{
+					BinaryPath: path,
+					Version:    "v" + pluginVersion.String(),
+				},
+				Err: err,
+			})
+		}
+	}
+	return res, nil
+}
+
+// Repair downloads the version of the corrupt installation again, replaces
+// the binary with it and verifies it.
+func (ci *CorruptInstallation) Repair(opts InstallOptions) error {
+	constraints, err := version.NewConstraint("= " + ci.Version)
+	if err != nil {
+		return err
+	}
+	pr := &Requirement{
+		Identifier:         ci.Identifier,
+		VersionConstraints: constraints,
+	}
+
+	opts.Force = true
+	install, err := pr.InstallLatest(opts)
+	if err != nil {
+		return err
+	}
+	if install == nil || filepath.ToSlash(install.BinaryPath) != filepath.ToSlash(ci.BinaryPath) {
+		return fmt.Errorf("the released binary of %s %s is not compatible with %s", ci.Identifier, ci.Version, ci.BinaryPath)
+	}
+
+	return verifyInstallation(ci.BinaryPath, opts.Checksummers)
+}
+
+// verifyInstallation returns nil if the binary in path matches one of its
+// local checksum files.
+func verifyInstallation(path string, checksummers []Checksummer) error {
+	var errs []string
+	for _, checksummer := range checksummers {
+		cs, err := checksummer.GetCacheChecksumOfFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("no %s checksum: %s", checksummer.Type, err))
+			continue
+		}
+		if err := checksummer.ChecksumFile(cs, path); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no checksummer to verify %s", path)
+	}
+	return fmt.Errorf("%s", strings.Join(errs, "; "))
+}
+
+// parseInstallationPath returns the plugin identifier, version and protocol
+// version of a binary installed in opts.PluginDirectory, ex:
+// github.com/hashicorp/amazon/packer-plugin-amazon_v1.2.3_x5.0_darwin_amd64.
+func parseInstallationPath(opts ListInstallationsOptions, path string) (*addrs.Plugin, *version.Version, string, error) {
+	rel, err := filepath.Rel(opts.PluginDirectory, filepath.Dir(path))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	identifier, diags := addrs.ParsePluginSourceString(filepath.ToSlash(rel))
+	if diags.HasErrors() {
+		return nil, nil, "", fmt.Errorf("%q is not in a plugin directory: %s", path, diags.Error())
+	}
+
+	versionsStr := trimFilename(filepath.Base(path), Requirement{Identifier: identifier}.FilenamePrefix(), opts.FilenameSuffix(), opts.FilenameCase.insensitive())
+	parts := strings.SplitN(versionsStr, "_", 2)
+	if len(parts) != 2 || pluginVersionRegex.FindStringSubmatch(parts[0]) == nil {
+		return nil, nil, "", fmt.Errorf("%q has no valid version in its name", path)
+	}
+	v, err := version.NewVersion(parts[0])
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("%q has no valid version in its name: %s", path, err)
+	}
+	return identifier, v, parts[1], nil
+}
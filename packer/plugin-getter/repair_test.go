@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+func TestCorruptInstallation_Repair(t *testing.T) {
+	binaryContent := machoHeader + "v2.10.0_x6.0_darwin"
+	zip, checksum := zipFileWithChecksum(map[string]string{
+		"packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64": binaryContent,
+	})
+	getter := &mockPluginGetter{
+		Releases: []Release{
+			{Version: "v2.10.0"},
+			{Version: "v2.10.1"},
+		},
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{
+			"2.10.0": {{
+				Filename: "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip",
+				Checksum: checksum,
+			}},
+		},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64.zip": zip,
+		},
+	}
+
+	pluginDir := t.TempDir()
+	installDir := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(installDir, "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64")
+	sum := sha256.Sum256([]byte(binaryContent))
+	if err := os.WriteFile(binaryPath+"_SHA256SUM", []byte(hex.EncodeToString(sum[:])), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binaryPath, []byte(machoHeader+"tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	binOpts := BinaryInstallationOptions{
+		APIVersionMajor: "6", APIVersionMinor: "0",
+		OS: "darwin", ARCH: "amd64",
+		Checksummers: []Checksummer{
+			{
+				Type: "sha256",
+				Hash: sha256.New(),
+			},
+		},
+	}
+	listOpts := ListInstallationsOptions{
+		PluginDirectory:           pluginDir,
+		BinaryInstallationOptions: binOpts,
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	pr := Requirement{Identifier: identifier}
+
+	corrupt, err := pr.ListCorruptInstallations(listOpts)
+	if err != nil {
+		t.Fatalf("ListCorruptInstallations: %v", err)
+	}
+	if len(corrupt) != 1 {
+		t.Fatalf("expected one corrupt installation, got %d", len(corrupt))
+	}
+	if corrupt[0].BinaryPath != binaryPath || corrupt[0].Version != "v2.10.0" || corrupt[0].Identifier.String() != identifier.String() {
+		t.Fatalf("unexpected corrupt installation %#v", corrupt[0])
+	}
+
+	err = corrupt[0].Repair(InstallOptions{
+		Getters:                   []Getter{getter},
+		PluginDirectory:           pluginDir,
+		BinaryInstallationOptions: binOpts,
+	})
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+
+	got, err := os.ReadFile(binaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != binaryContent {
+		t.Errorf("binary was not repaired, content is %q", got)
+	}
+
+	corrupt, err = pr.ListCorruptInstallations(listOpts)
+	if err != nil {
+		t.Fatalf("ListCorruptInstallations: %v", err)
+	}
+	if len(corrupt) != 0 {
+		t.Errorf("expected no corrupt installation after repair, got %v", corrupt)
+	}
+
+	entries, err := os.ReadDir(installDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected only the binary and its checksums to be left, got %v", entries)
+	}
+}
+
+func TestCorruptInstallation_Repair_noRelease(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatalf("ParsePluginSourceString: %v", diags)
+	}
+	ci := &CorruptInstallation{
+		Identifier: identifier,
+		Installation: Installation{
+			BinaryPath: filepath.Join(t.TempDir(), "packer-plugin-amazon_v2.10.0_x6.0_darwin_amd64"),
+			Version:    "v2.10.0",
+		},
+	}
+	err := ci.Repair(InstallOptions{
+		Getters: []Getter{&mockPluginGetter{
+			Releases: []Release{{Version: "v2.10.1"}},
+		}},
+		PluginDirectory: t.TempDir(),
+		BinaryInstallationOptions: BinaryInstallationOptions{
+			APIVersionMajor: "6", APIVersionMinor: "0",
+			OS: "darwin", ARCH: "amd64",
+			Checksummers: []Checksummer{
+				{
+					Type: "sha256",
+					Hash: sha256.New(),
+				},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error when the version is not released anymore")
+	}
+}
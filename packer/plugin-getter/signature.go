@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A SignatureVerifier verifies the detached signature of the checksum file of
+// a plugin release.
+//
+// When InstallOptions.SignatureVerifier is set, getters are also asked for
+// the raw checksum file with the "<type>sums" phase, ex: "sha256sums", and
+// for its signature with the "<type>sums.sig" phase.
+type SignatureVerifier interface {
+	VerifySignature(pr *Requirement, checksumFile, signature []byte) error
+}
+
+// signedChecksumFile is a raw checksum file and its verified signature.
+type signedChecksumFile struct {
+	content   []byte
+	signature []byte
+}
+
+// verifiedChecksumFileEntries gets the raw checksum file and its signature
+// from getter, the ones prefetched from the getterIdx getter when there are,
+// and verifies them with opts.SignatureVerifier. Only the entries
+// listed in the signed checksum file are returned, along with the file.
+func (opts *InstallOptions) verifiedChecksumFileEntries(getterIdx int, getter Getter, checksummer Checksummer, getOpts GetOptions, entries []ChecksumFileEntry) ([]ChecksumFileEntry, *signedChecksumFile, error) {
+	checksumFile, err := opts.getAsset(getterIdx, getter, checksummer.Type+"sums", getOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get the checksum file: %w", err)
+	}
+	signature, err := opts.getAsset(getterIdx, getter, checksummer.Type+"sums.sig", getOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get the signature: %w", err)
+	}
+
+	if err := opts.SignatureVerifier.VerifySignature(getOpts.PluginRequirement, checksumFile, signature); err != nil {
+		return nil, nil, &IntegrityError{Err: err}
+	}
+
+	signed, err := signedEntries(checksumFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res []ChecksumFileEntry
+	for _, entry := range entries {
+		if signed[strings.ToLower(entry.Checksum)+" "+entry.Filename] {
+			res = append(res, entry)
+		}
+	}
+	if len(res) == 0 {
+		return nil, nil, &IntegrityError{Err: fmt.Errorf("no checksum entry is part of the signed checksum file")}
+	}
+	return res, &signedChecksumFile{content: checksumFile, signature: signature}, nil
+}
+
+// signedEntries returns the "<lowercase checksum> <filename>" entries of a
+// raw checksum file.
+func signedEntries(checksumFile []byte) (map[string]bool, error) {
+	signed := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(checksumFile))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		signed[strings.ToLower(parts[0])+" "+parts[1]] = true
+	}
+	return signed, scanner.Err()
+}
+
+func (opts *InstallOptions) getAll(getter Getter, what string, getOpts GetOptions) ([]byte, error) {
+	rc, err := opts.get(getter, what, getOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// signedPluginGetter also serves the raw checksum file and its signature.
+type signedPluginGetter struct {
+	*mockPluginGetter
+	ChecksumFile string
+	Signature    string
+}
+
+func (g *signedPluginGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	switch what {
+	case "sha256sums":
+		return io.NopCloser(strings.NewReader(g.ChecksumFile)), nil
+	case "sha256sums.sig":
+		return io.NopCloser(strings.NewReader(g.Signature)), nil
+	}
+	return g.mockPluginGetter.Get(what, options)
+}
+
+// signatureVerifier considers "signed(<checksum file>)" a valid signature.
+type signatureVerifier struct{}
+
+func (signatureVerifier) VerifySignature(pr *Requirement, checksumFile, signature []byte) error {
+	if string(signature) != "signed("+string(checksumFile)+")" {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+func TestRequirement_InstallLatest_signatureVerifier(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name         string
+		checksumFile func(checksum string) string
+		signature    func(checksumFile string) string
+		wantErr      string
+	}{
+		{
+			name:         "signed",
+			checksumFile: func(checksum string) string { return checksum + "  " + binary + ".zip\n" },
+			signature:    func(checksumFile string) string { return "signed(" + checksumFile + ")" },
+		},
+		{
+			name:         "bad-signature",
+			checksumFile: func(checksum string) string { return checksum + "  " + binary + ".zip\n" },
+			signature:    func(checksumFile string) string { return "signed(something else)" },
+			wantErr:      "bad signature",
+		},
+		{
+			name:         "entry-not-signed",
+			checksumFile: func(checksum string) string { return strings.Repeat("0", 64) + "  " + binary + ".zip\n" },
+			signature:    func(checksumFile string) string { return "signed(" + checksumFile + ")" },
+			wantErr:      "no checksum entry is part of the signed checksum file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := singleReleaseGetter("amazon")
+			checksum := mock.ChecksumFileEntries["1.0.0"][0].Checksum
+			checksumFile := tt.checksumFile(checksum)
+			getter := &signedPluginGetter{
+				mockPluginGetter: mock,
+				ChecksumFile:     checksumFile,
+				Signature:        tt.signature(checksumFile),
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.SignatureVerifier = signatureVerifier{}
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+
+			_, statErr := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("InstallLatest: %v", err)
+				}
+				if statErr != nil {
+					t.Errorf("expected the plugin to be installed: %v", statErr)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if !os.IsNotExist(statErr) {
+				t.Errorf("expected the plugin not to be installed, stat returned %v", statErr)
+			}
+		})
+	}
+}
+
+func TestInstallation_VerifyStoredSignature(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	install := func(t *testing.T, verifier SignatureVerifier) *Installation {
+		mock := singleReleaseGetter("amazon")
+		checksumFile := mock.ChecksumFileEntries["1.0.0"][0].Checksum + "  " + binary + ".zip\n"
+		getter := &signedPluginGetter{
+			mockPluginGetter: mock,
+			ChecksumFile:     checksumFile,
+			Signature:        "signed(" + checksumFile + ")",
+		}
+		opts := dependenciesInstallOptions(getter, t.TempDir())
+		opts.SignatureVerifier = verifier
+		installation, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+		if err != nil {
+			t.Fatalf("InstallLatest: %v", err)
+		}
+		return installation
+	}
+
+	tests := []struct {
+		name          string
+		verifier      SignatureVerifier
+		tamper        func(t *testing.T, install *Installation)
+		wantIntegrity bool
+		wantErr       error
+	}{
+		{
+			name:     "signed",
+			verifier: signatureVerifier{},
+		},
+		{
+			name:     "tampered-signature",
+			verifier: signatureVerifier{},
+			tamper: func(t *testing.T, install *Installation) {
+				if err := os.WriteFile(install.SignaturePath, []byte("signed(something else)"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantIntegrity: true,
+		},
+		{
+			name:     "tampered-binary",
+			verifier: signatureVerifier{},
+			tamper: func(t *testing.T, install *Installation) {
+				if err := os.WriteFile(install.BinaryPath, []byte(elfHeader+"tampered"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantIntegrity: true,
+		},
+		{
+			name:     "other-zip",
+			verifier: signatureVerifier{},
+			tamper: func(t *testing.T, install *Installation) {
+				if err := writeZipChecksum(install.BinaryPath, binary+".zip", strings.Repeat("0", 64)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantIntegrity: true,
+		},
+		{
+			name:    "installed-without-verifier",
+			wantErr: ErrNoStoredSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := install(t, tt.verifier)
+			if tt.verifier != nil {
+				want := installation.BinaryPath + SignedChecksumFileExt + SignatureFileExt
+				if installation.SignaturePath != want {
+					t.Fatalf("SignaturePath = %q, want %q", installation.SignaturePath, want)
+				}
+			} else if installation.SignaturePath != "" {
+				t.Fatalf("expected no stored signature, got %q", installation.SignaturePath)
+			}
+			if tt.tamper != nil {
+				tt.tamper(t, installation)
+			}
+
+			err := installation.VerifyStoredSignature(mustRequirement(t, "github.com/hashicorp/amazon", ""), signatureVerifier{})
+			var integrityErr *IntegrityError
+			switch {
+			case tt.wantIntegrity:
+				if !errors.As(err, &integrityErr) {
+					t.Errorf("expected an IntegrityError, got %v", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("VerifyStoredSignature: %v", err)
+			}
+		})
+	}
+}
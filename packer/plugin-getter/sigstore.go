@@ -0,0 +1,39 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"io"
+)
+
+// A SigstoreVerifier verifies a downloaded zip against the Sigstore bundle of
+// its signature, ex: as made by `cosign sign-blob --bundle` in a keyless
+// signing workflow, with the transparency log entry of the signature.
+//
+// When InstallOptions.SigstoreVerifier is set, getters are also asked for the
+// bundle of the zip with the "sigstore" phase, once the zip matched its
+// checksum. Zips without a bundle are not installed.
+type SigstoreVerifier interface {
+	VerifySigstoreBundle(pr *Requirement, zipFilename string, zip io.Reader, bundle []byte) error
+}
+
+// verifySigstoreBundle gets the Sigstore bundle of the zip from getter and
+// verifies zip with opts.SigstoreVerifier. zip is read from its start, and
+// rewound afterwards.
+func (opts *InstallOptions) verifySigstoreBundle(getter Getter, getOpts GetOptions, zip io.ReadSeeker) error {
+	bundle, err := opts.getAll(getter, "sigstore", getOpts)
+	if err != nil {
+		return fmt.Errorf("could not get the sigstore bundle: %w", err)
+	}
+
+	if _, err := zip.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := opts.SigstoreVerifier.VerifySigstoreBundle(getOpts.PluginRequirement, getOpts.ExpectedZipFilename(), zip, bundle); err != nil {
+		return &IntegrityError{Err: err}
+	}
+	_, err = zip.Seek(0, io.SeekStart)
+	return err
+}
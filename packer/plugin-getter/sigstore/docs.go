@@ -0,0 +1,7 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package sigstore defines a verifier of plugin zips against the Sigstore
+// bundles of their keyless signatures.
+
+package sigstore
@@ -0,0 +1,412 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package sigstore
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+var (
+	// oidcIssuerV1 and oidcIssuerV2 are the extensions of Fulcio
+	// certificates holding the OIDC issuer of the signer identity, as a raw
+	// string and as a DER UTF8String respectively.
+	oidcIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidcIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// Verifier checks that plugin zips were signed by a trusted OIDC identity,
+// with a short-lived certificate issued by Fulcio, and that the signature was
+// recorded in a Rekor transparency log, as found in the Sigstore bundle
+// generated by `cosign sign-blob --bundle` for example.
+//
+// The log entry is trusted through the signed entry timestamp of the bundle,
+// which Rekor signs when the entry is added: the log is not queried, and
+// bundles without a signed entry timestamp are refused.
+type Verifier struct {
+	// Identity is the subject of the signing certificates trusted, ex: the
+	// workflow that released the plugin,
+	// https://github.com/hashicorp/packer-plugin-amazon/.github/workflows/release.yml@refs/tags/v1.0.0,
+	// or an email address.
+	Identity string
+
+	// Issuer is the OIDC issuer that authenticated Identity, ex:
+	// https://token.actions.githubusercontent.com.
+	Issuer string
+
+	// FulcioRootsFile is a PEM file of the certificates signing certificates
+	// must chain to: the self-signed ones are roots, the others
+	// intermediates.
+	FulcioRootsFile string
+
+	// RekorPublicKeyFile is the PEM file of the public key of the Rekor log.
+	RekorPublicKeyFile string
+
+	trustOnce sync.Once
+	trust     *trustRoot
+	trustErr  error
+}
+
+var _ plugingetter.SigstoreVerifier = &Verifier{}
+
+// ErrUntrustedIdentity is returned when the zip was signed by another
+// identity, or issuer, than the ones of the Verifier.
+var ErrUntrustedIdentity = errors.New("untrusted signer identity")
+
+// ErrInvalidSignature is returned when the signature of the bundle does not
+// match the zip, or its certificate is not trusted.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// ErrUntrustedLogEntry is returned when the transparency log entry of the
+// bundle was not signed by the Rekor log, or is not the one of the
+// signature.
+var ErrUntrustedLogEntry = errors.New("untrusted transparency log entry")
+
+type trustRoot struct {
+	roots, intermediates *x509.CertPool
+	rekorKey             *ecdsa.PublicKey
+	// rekorLogID is the sha256 of the DER public key of the log, as
+	// recorded in log entries.
+	rekorLogID []byte
+}
+
+// bundle is a Sigstore bundle, in the v0.1 to v0.3 formats.
+type bundle struct {
+	VerificationMaterial struct {
+		X509CertificateChain struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		Certificate struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificate"`
+		TlogEntries []tlogEntry `json:"tlogEntries"`
+	} `json:"verificationMaterial"`
+	MessageSignature struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+}
+
+type tlogEntry struct {
+	LogIndex int64 `json:"logIndex,string"`
+	LogID    struct {
+		KeyID []byte `json:"keyId"`
+	} `json:"logId"`
+	IntegratedTime   int64 `json:"integratedTime,string"`
+	InclusionPromise *struct {
+		SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+	} `json:"inclusionPromise"`
+	CanonicalizedBody []byte `json:"canonicalizedBody"`
+}
+
+// hashedRekord is the body of a "hashedrekord" log entry.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// signedEntryTimestampPayload is what Rekor signs to promise the inclusion
+// of an entry. Fields are in canonical JSON order.
+type signedEntryTimestampPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+func (v *Verifier) VerifySigstoreBundle(pr *plugingetter.Requirement, zipFilename string, zip io.Reader, rawBundle []byte) error {
+	v.trustOnce.Do(func() {
+		v.trust, v.trustErr = v.loadTrustRoot()
+	})
+	if v.trustErr != nil {
+		return v.trustErr
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, zip); err != nil {
+		return err
+	}
+	digest := h.Sum(nil)
+
+	b := &bundle{}
+	if err := json.Unmarshal(rawBundle, b); err != nil {
+		return fmt.Errorf("sigstore: invalid bundle for %s: %w", zipFilename, err)
+	}
+	cert, intermediates, err := b.certificates()
+	if err != nil {
+		return fmt.Errorf("sigstore: invalid bundle for %s: %w", zipFilename, err)
+	}
+	if len(b.MessageSignature.Signature) == 0 {
+		return fmt.Errorf("sigstore: invalid bundle for %s: no message signature", zipFilename)
+	}
+	if len(b.VerificationMaterial.TlogEntries) == 0 {
+		return fmt.Errorf("%w: the bundle of %s has no transparency log entry", ErrUntrustedLogEntry, zipFilename)
+	}
+
+	// The certificate is short-lived: it must have been valid when the
+	// signature was logged, which the log entry vouches for.
+	entry, err := v.verifyLogEntry(b, cert, digest)
+	if err != nil {
+		return fmt.Errorf("sigstore: %s: %w", zipFilename, err)
+	}
+	if err := v.verifyCertificate(cert, intermediates, time.Unix(entry.IntegratedTime, 0)); err != nil {
+		return fmt.Errorf("sigstore: %s: %w", zipFilename, err)
+	}
+	if err := v.verifyIdentity(cert); err != nil {
+		return fmt.Errorf("sigstore: %s: %w", zipFilename, err)
+	}
+
+	if d := b.MessageSignature.MessageDigest; len(d.Digest) > 0 && !bytes.Equal(d.Digest, digest) {
+		return fmt.Errorf("sigstore: %w: %s does not match the digest of the bundle", ErrInvalidSignature, zipFilename)
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("sigstore: %w: unsupported %T signing key", ErrInvalidSignature, cert.PublicKey)
+	}
+	if !ecdsa.VerifyASN1(pub, digest, b.MessageSignature.Signature) {
+		return fmt.Errorf("sigstore: %w: %s does not match its signature", ErrInvalidSignature, zipFilename)
+	}
+
+	log.Printf("[TRACE] sigstore: %s signed by %s, log index %d", zipFilename, v.Identity, entry.LogIndex)
+	return nil
+}
+
+// certificates returns the signing certificate of the bundle, and the
+// intermediates that came with it.
+func (b *bundle) certificates() (*x509.Certificate, []*x509.Certificate, error) {
+	var raws [][]byte
+	if raw := b.VerificationMaterial.Certificate.RawBytes; len(raw) > 0 {
+		raws = append(raws, raw)
+	}
+	for _, c := range b.VerificationMaterial.X509CertificateChain.Certificates {
+		raws = append(raws, c.RawBytes)
+	}
+	if len(raws) == 0 {
+		return nil, nil, errors.New("no signing certificate")
+	}
+	var certs []*x509.Certificate
+	for _, raw := range raws {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs[0], certs[1:], nil
+}
+
+// verifyLogEntry returns the first log entry of b signed by the Rekor log
+// that records the signature of digest by cert.
+func (v *Verifier) verifyLogEntry(b *bundle, cert *x509.Certificate, digest []byte) (*tlogEntry, error) {
+	var errs []error
+	for i := range b.VerificationMaterial.TlogEntries {
+		entry := &b.VerificationMaterial.TlogEntries[i]
+		if err := v.verifyEntryTimestamp(entry); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := verifyEntryBody(entry, b.MessageSignature.Signature, cert, digest); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return entry, nil
+	}
+	return nil, fmt.Errorf("%w: %w", ErrUntrustedLogEntry, errors.Join(errs...))
+}
+
+// verifyEntryTimestamp verifies the signed entry timestamp of entry with the
+// key of the Rekor log.
+func (v *Verifier) verifyEntryTimestamp(entry *tlogEntry) error {
+	if entry.InclusionPromise == nil || len(entry.InclusionPromise.SignedEntryTimestamp) == 0 {
+		return fmt.Errorf("entry %d has no signed entry timestamp", entry.LogIndex)
+	}
+	if !bytes.Equal(entry.LogID.KeyID, v.trust.rekorLogID) {
+		return fmt.Errorf("entry %d is from another log, %x", entry.LogIndex, entry.LogID.KeyID)
+	}
+	payload, err := json.Marshal(signedEntryTimestampPayload{
+		Body:           base64.StdEncoding.EncodeToString(entry.CanonicalizedBody),
+		IntegratedTime: entry.IntegratedTime,
+		LogID:          hex.EncodeToString(v.trust.rekorLogID),
+		LogIndex:       entry.LogIndex,
+	})
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(v.trust.rekorKey, sum[:], entry.InclusionPromise.SignedEntryTimestamp) {
+		return fmt.Errorf("entry %d: invalid signed entry timestamp", entry.LogIndex)
+	}
+	return nil
+}
+
+// verifyEntryBody makes sure entry records the signature of digest by cert.
+func verifyEntryBody(entry *tlogEntry, signature []byte, cert *x509.Certificate, digest []byte) error {
+	body := &hashedRekord{}
+	if err := json.Unmarshal(entry.CanonicalizedBody, body); err != nil {
+		return fmt.Errorf("entry %d: invalid body: %w", entry.LogIndex, err)
+	}
+	if body.Kind != "hashedrekord" {
+		return fmt.Errorf("entry %d: unsupported %q entry", entry.LogIndex, body.Kind)
+	}
+	if hash := body.Spec.Data.Hash; hash.Algorithm != "sha256" || hash.Value != hex.EncodeToString(digest) {
+		return fmt.Errorf("entry %d is for another artifact", entry.LogIndex)
+	}
+	if !bytes.Equal(body.Spec.Signature.Content, signature) {
+		return fmt.Errorf("entry %d is for another signature", entry.LogIndex)
+	}
+	block, _ := pem.Decode(body.Spec.Signature.PublicKey.Content)
+	if block == nil || !bytes.Equal(block.Bytes, cert.Raw) {
+		return fmt.Errorf("entry %d is for another certificate", entry.LogIndex)
+	}
+	return nil
+}
+
+// verifyCertificate makes sure cert is a code signing certificate chaining to
+// the Fulcio roots, and valid at signedAt.
+func (v *Verifier) verifyCertificate(cert *x509.Certificate, intermediates []*x509.Certificate, signedAt time.Time) error {
+	pool := v.trust.intermediates.Clone()
+	for _, intermediate := range intermediates {
+		pool.AddCert(intermediate)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         v.trust.roots,
+		Intermediates: pool,
+		CurrentTime:   signedAt,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: untrusted certificate: %w", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// verifyIdentity makes sure cert was issued to v.Identity, as authenticated
+// by v.Issuer.
+func (v *Verifier) verifyIdentity(cert *x509.Certificate) error {
+	var identities []string
+	identities = append(identities, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	found := false
+	for _, identity := range identities {
+		if identity == v.Identity {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: signed by %q, expected %q", ErrUntrustedIdentity, identities, v.Identity)
+	}
+
+	issuer, err := certificateIssuer(cert)
+	if err != nil {
+		return err
+	}
+	if issuer != v.Issuer {
+		return fmt.Errorf("%w: %q was authenticated by %q, expected %q", ErrUntrustedIdentity, v.Identity, issuer, v.Issuer)
+	}
+	return nil
+}
+
+// certificateIssuer returns the OIDC issuer recorded in a Fulcio certificate.
+func certificateIssuer(cert *x509.Certificate) (string, error) {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidcIssuerV2):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err != nil {
+				return "", fmt.Errorf("invalid OIDC issuer extension: %w", err)
+			}
+			return issuer, nil
+		case ext.Id.Equal(oidcIssuerV1):
+			return string(ext.Value), nil
+		}
+	}
+	return "", fmt.Errorf("%w: the certificate has no OIDC issuer", ErrUntrustedIdentity)
+}
+
+func (v *Verifier) loadTrustRoot() (*trustRoot, error) {
+	if v.Identity == "" || v.Issuer == "" {
+		return nil, errors.New("sigstore: the trusted identity and issuer must be set")
+	}
+	if v.FulcioRootsFile == "" || v.RekorPublicKeyFile == "" {
+		return nil, errors.New("sigstore: the Fulcio roots and Rekor public key files must be set")
+	}
+
+	trust := &trustRoot{roots: x509.NewCertPool(), intermediates: x509.NewCertPool()}
+	rootsPEM, err := os.ReadFile(v.FulcioRootsFile)
+	if err != nil {
+		return nil, fmt.Errorf("sigstore: failed to read the Fulcio roots: %w", err)
+	}
+	for block, rest := pem.Decode(rootsPEM); block != nil; block, rest = pem.Decode(rest) {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("sigstore: invalid Fulcio certificate in %q: %w", v.FulcioRootsFile, err)
+		}
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			trust.roots.AddCert(cert)
+		} else {
+			trust.intermediates.AddCert(cert)
+		}
+	}
+	if trust.roots.Equal(x509.NewCertPool()) {
+		return nil, fmt.Errorf("sigstore: no root certificate in %q", v.FulcioRootsFile)
+	}
+
+	keyPEM, err := os.ReadFile(v.RekorPublicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("sigstore: failed to read the Rekor public key: %w", err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("sigstore: no PEM public key in %q", v.RekorPublicKeyFile)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("sigstore: invalid Rekor public key in %q: %w", v.RekorPublicKeyFile, err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("sigstore: unsupported %T Rekor public key", key)
+	}
+	trust.rekorKey = ecKey
+	logID := sha256.Sum256(block.Bytes)
+	trust.rekorLogID = logID[:]
+	return trust, nil
+}
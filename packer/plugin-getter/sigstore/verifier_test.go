@@ -0,0 +1,346 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package sigstore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	zipFilename = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip"
+	zipContent  = zipFilename + " content"
+	identity    = "https://github.com/hashicorp/packer-plugin-amazon/.github/workflows/release.yml@refs/tags/v1.0.0"
+	issuer      = "https://token.actions.githubusercontent.com"
+)
+
+// mockSigstore is a Fulcio CA and a Rekor log, to make bundles.
+type mockSigstore struct {
+	caKey    *ecdsa.PrivateKey
+	ca       *x509.Certificate
+	rekorKey *ecdsa.PrivateKey
+}
+
+func newMockSigstore(t *testing.T) *mockSigstore {
+	caKey := mustKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mockSigstore{caKey: caKey, ca: ca, rekorKey: mustKey(t)}
+}
+
+func mustKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// verifier returns a Verifier trusting s, and identity authenticated by
+// issuer.
+func (s *mockSigstore) verifier(t *testing.T) *Verifier {
+	dir := t.TempDir()
+	rootsFile := filepath.Join(dir, "fulcio.pem")
+	if err := os.WriteFile(rootsFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(&s.rekorKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "rekor.pub")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyDER}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &Verifier{
+		Identity:           identity,
+		Issuer:             issuer,
+		FulcioRootsFile:    rootsFile,
+		RekorPublicKeyFile: keyFile,
+	}
+}
+
+// bundleOptions alter how mockSigstore.bundle makes a bundle.
+type bundleOptions struct {
+	identity, issuer string
+	// signed is the content signed, zipContent by default.
+	signed string
+	// loggedDigest is the digest recorded in the log, the one of signed by
+	// default.
+	loggedDigest string
+	// rekorKey signs the entry, the key of the log by default.
+	rekorKey *ecdsa.PrivateKey
+	// noPromise leaves the signed entry timestamp out.
+	noPromise bool
+}
+
+// bundle returns a bundle of the keyless signature of opts.signed.
+func (s *mockSigstore) bundle(t *testing.T, opts bundleOptions) []byte {
+	if opts.identity == "" {
+		opts.identity = identity
+	}
+	if opts.issuer == "" {
+		opts.issuer = issuer
+	}
+	if opts.signed == "" {
+		opts.signed = zipContent
+	}
+	if opts.rekorKey == nil {
+		opts.rekorKey = s.rekorKey
+	}
+	digest := sha256.Sum256([]byte(opts.signed))
+	if opts.loggedDigest == "" {
+		opts.loggedDigest = hex.EncodeToString(digest[:])
+	}
+
+	// a short-lived signing certificate, valid when the entry is logged.
+	signingKey := mustKey(t)
+	integratedTime := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+	san, err := url.Parse(opts.identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerExt, err := asn1.Marshal(opts.issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       integratedTime.Add(-time.Minute),
+		NotAfter:        integratedTime.Add(9 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:            []*url.URL{san},
+		ExtraExtensions: []pkix.Extension{{Id: oidcIssuerV2, Value: issuerExt}},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, s.ca, &signingKey.PublicKey, s.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := ecdsa.SignASN1(rand.Reader, signingKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data": map[string]interface{}{
+				"hash": map[string]string{"algorithm": "sha256", "value": opts.loggedDigest},
+			},
+			"signature": map[string]interface{}{
+				"content": signature,
+				"publicKey": map[string]interface{}{
+					"content": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(&s.rekorKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(keyDER)
+	payload, err := json.Marshal(signedEntryTimestampPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime.Unix(),
+		LogID:          hex.EncodeToString(logID[:]),
+		LogIndex:       42,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadDigest := sha256.Sum256(payload)
+	set, err := ecdsa.SignASN1(rand.Reader, opts.rekorKey, payloadDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := map[string]interface{}{
+		"logIndex":          "42",
+		"logId":             map[string]interface{}{"keyId": logID[:]},
+		"kindVersion":       map[string]string{"kind": "hashedrekord", "version": "0.0.1"},
+		"integratedTime":    strconv.FormatInt(integratedTime.Unix(), 10),
+		"canonicalizedBody": body,
+	}
+	if !opts.noPromise {
+		entry["inclusionPromise"] = map[string]interface{}{"signedEntryTimestamp": set}
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2",
+		"verificationMaterial": map[string]interface{}{
+			"x509CertificateChain": map[string]interface{}{
+				"certificates": []map[string]interface{}{{"rawBytes": certDER}},
+			},
+			"tlogEntries": []interface{}{entry},
+		},
+		"messageSignature": map[string]interface{}{
+			"messageDigest": map[string]interface{}{"algorithm": "SHA2_256", "digest": digest[:]},
+			"signature":     signature,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVerifier_VerifySigstoreBundle(t *testing.T) {
+	s := newMockSigstore(t)
+
+	tests := []struct {
+		name     string
+		opts     bundleOptions
+		verifier func(v *Verifier)
+		zip      string
+		wantErr  error
+	}{
+		{
+			name: "signed",
+		},
+		{
+			name:    "other-identity",
+			opts:    bundleOptions{identity: "https://github.com/evil/packer-plugin-amazon/.github/workflows/release.yml@refs/tags/v1.0.0"},
+			wantErr: ErrUntrustedIdentity,
+		},
+		{
+			name:    "other-issuer",
+			opts:    bundleOptions{issuer: "https://accounts.example.com"},
+			wantErr: ErrUntrustedIdentity,
+		},
+		{
+			name:    "tampered-zip",
+			zip:     "tampered",
+			wantErr: ErrUntrustedLogEntry,
+		},
+		{
+			name:    "signature-of-another-zip",
+			opts:    bundleOptions{loggedDigest: hex.EncodeToString(make([]byte, sha256.Size))},
+			wantErr: ErrUntrustedLogEntry,
+		},
+		{
+			name:    "entry-not-signed-by-rekor",
+			opts:    bundleOptions{rekorKey: mustKey(t)},
+			wantErr: ErrUntrustedLogEntry,
+		},
+		{
+			name:    "no-signed-entry-timestamp",
+			opts:    bundleOptions{noPromise: true},
+			wantErr: ErrUntrustedLogEntry,
+		},
+		{
+			name: "untrusted-fulcio",
+			verifier: func(v *Verifier) {
+				v.FulcioRootsFile = newMockSigstore(t).verifier(t).FulcioRootsFile
+			},
+			wantErr: ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := s.verifier(t)
+			if tt.verifier != nil {
+				tt.verifier(v)
+			}
+			zip := zipContent
+			if tt.zip != "" {
+				zip = tt.zip
+			}
+			err := v.VerifySigstoreBundle(nil, zipFilename, strings.NewReader(zip), s.bundle(t, tt.opts))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("VerifySigstoreBundle: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifySigstoreBundle() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifier_VerifySigstoreBundle_failsClosed(t *testing.T) {
+	s := newMockSigstore(t)
+	bundle := s.bundle(t, bundleOptions{})
+
+	tests := []struct {
+		name     string
+		verifier func(v *Verifier)
+		bundle   string
+	}{
+		{
+			name:     "no-identity",
+			verifier: func(v *Verifier) { v.Identity = "" },
+		},
+		{
+			name:     "no-rekor-key",
+			verifier: func(v *Verifier) { v.RekorPublicKeyFile = "" },
+		},
+		{
+			name:     "missing-fulcio-roots",
+			verifier: func(v *Verifier) { v.FulcioRootsFile = filepath.Join(t.TempDir(), "missing.pem") },
+		},
+		{
+			name:   "invalid-bundle",
+			bundle: "not json",
+		},
+		{
+			name:   "no-certificate",
+			bundle: `{"messageSignature": {"signature": "MEUCIQ=="}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := s.verifier(t)
+			if tt.verifier != nil {
+				tt.verifier(v)
+			}
+			b := bundle
+			if tt.bundle != "" {
+				b = []byte(tt.bundle)
+			}
+			if err := v.VerifySigstoreBundle(nil, zipFilename, strings.NewReader(zipContent), b); err == nil {
+				t.Error("expected the verification to fail")
+			}
+		})
+	}
+}
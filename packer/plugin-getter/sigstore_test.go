@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sigstorePluginGetter also serves a sigstore bundle, if set.
+type sigstorePluginGetter struct {
+	*mockPluginGetter
+	Bundle string
+}
+
+func (g *sigstorePluginGetter) Get(what string, options GetOptions) (io.ReadCloser, error) {
+	if what == "sigstore" {
+		if g.Bundle == "" {
+			return nil, errors.New("404: no bundle")
+		}
+		return io.NopCloser(strings.NewReader(g.Bundle)), nil
+	}
+	return g.mockPluginGetter.Get(what, options)
+}
+
+// sigstoreVerifier considers "<zip filename> <sha256>" a valid bundle.
+type sigstoreVerifier struct{}
+
+func (sigstoreVerifier) VerifySigstoreBundle(pr *Requirement, zipFilename string, zip io.Reader, bundle []byte) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, zip); err != nil {
+		return err
+	}
+	if want := zipFilename + " " + hex.EncodeToString(h.Sum(nil)); string(bundle) != want {
+		return fmt.Errorf("signature mismatch: %q is not %q", bundle, want)
+	}
+	return nil
+}
+
+func TestRequirement_InstallLatest_sigstoreVerifier(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name    string
+		bundle  func(checksum string) string
+		wantErr string
+	}{
+		{
+			name:   "signed",
+			bundle: func(checksum string) string { return binary + ".zip " + checksum },
+		},
+		{
+			name:    "signature-mismatch",
+			bundle:  func(checksum string) string { return binary + ".zip " + strings.Repeat("0", 64) },
+			wantErr: "could not verify the signature of " + binary + ".zip: signature mismatch",
+		},
+		{
+			name:    "no-bundle",
+			bundle:  func(checksum string) string { return "" },
+			wantErr: "could not get the sigstore bundle: 404: no bundle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := singleReleaseGetter("amazon")
+			checksum := mock.ChecksumFileEntries["1.0.0"][0].Checksum
+			getter := &sigstorePluginGetter{
+				mockPluginGetter: mock,
+				Bundle:           tt.bundle(checksum),
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.SigstoreVerifier = sigstoreVerifier{}
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+
+			_, statErr := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("InstallLatest: %v", err)
+				}
+				if statErr != nil {
+					t.Errorf("expected the plugin to be installed: %v", statErr)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if !os.IsNotExist(statErr) {
+				t.Errorf("expected the plugin not to be installed, stat returned %v", statErr)
+			}
+		})
+	}
+}
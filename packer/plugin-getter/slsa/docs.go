@@ -0,0 +1,7 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package slsa defines a verifier of plugin zips against the SLSA provenance
+// attestations of their release.
+
+package slsa
@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package slsa
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const (
+	statementTypePrefix = "https://in-toto.io/Statement/"
+	predicateTypePrefix = "https://slsa.dev/provenance/"
+	inTotoPayloadType   = "application/vnd.in-toto+json"
+	digestAlgorithm     = "sha256"
+)
+
+// Verifier checks that plugin zips are the subject of the SLSA provenance
+// statement of their release, as found in the .intoto.jsonl file generated by
+// the SLSA GitHub generator for example.
+//
+// Statements can be wrapped in DSSE envelopes, the signatures of which are not
+// verified: trusting the attestation relies on how it was downloaded, or on
+// a SignatureVerifier.
+type Verifier struct {
+	// BuilderID, when set, is the only builder trusted to produce plugin
+	// releases, ex:
+	// https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.9.0
+	BuilderID string
+}
+
+var _ plugingetter.ProvenanceVerifier = &Verifier{}
+
+// ErrNoMatchingSubject is returned when no provenance statement lists the zip
+// with its digest.
+var ErrNoMatchingSubject = errors.New("the zip is not a subject of the provenance attestation")
+
+// ErrUntrustedBuilder is returned when the zip was built by another builder
+// than Verifier.BuilderID.
+var ErrUntrustedBuilder = errors.New("untrusted builder")
+
+type envelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+type statement struct {
+	Type          string `json:"_type"`
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate struct {
+		// SLSA v0.2
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		// SLSA v1
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+func (s *statement) builderID() string {
+	if s.Predicate.RunDetails.Builder.ID != "" {
+		return s.Predicate.RunDetails.Builder.ID
+	}
+	return s.Predicate.Builder.ID
+}
+
+func (v *Verifier) VerifyProvenance(pr *plugingetter.Requirement, zipFilename string, zip io.Reader, provenance []byte) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, zip); err != nil {
+		return err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	statements, err := parseStatements(provenance)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statements {
+		for _, subject := range s.Subject {
+			if subject.Name != zipFilename || !strings.EqualFold(subject.Digest[digestAlgorithm], digest) {
+				continue
+			}
+			if v.BuilderID != "" && s.builderID() != v.BuilderID {
+				return fmt.Errorf("%w: %s was built by %q", ErrUntrustedBuilder, zipFilename, s.builderID())
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s with sha256 %s", ErrNoMatchingSubject, zipFilename, digest)
+}
+
+// parseStatements returns the SLSA provenance statements of an in-toto json
+// lines file. Each line is a statement, or a DSSE envelope of one.
+func parseStatements(provenance []byte) ([]*statement, error) {
+	var res []*statement
+	scanner := bufio.NewScanner(bytes.NewReader(provenance))
+	scanner.Buffer(nil, len(provenance)+1)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var env envelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if env.PayloadType != "" {
+			if env.PayloadType != inTotoPayloadType {
+				continue
+			}
+			payload, err := base64.StdEncoding.DecodeString(env.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid envelope payload: %w", line, err)
+			}
+			raw = payload
+		}
+
+		s := &statement{}
+		if err := json.Unmarshal(raw, s); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !strings.HasPrefix(s.Type, statementTypePrefix) || !strings.HasPrefix(s.PredicateType, predicateTypePrefix) {
+			continue
+		}
+		res = append(res, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, errors.New("no SLSA provenance statement found")
+	}
+	return res, nil
+}
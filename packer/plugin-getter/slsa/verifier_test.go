@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package slsa
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	zipFilename = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip"
+	zipContent  = zipFilename + " content"
+	builderID   = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.9.0"
+)
+
+func TestVerifier_VerifyProvenance(t *testing.T) {
+	// a DSSE envelope of a SLSA v0.2 statement, listing the linux_amd64 zip
+	// with the sha256 of zipContent.
+	provenance, err := os.ReadFile(filepath.Join("testdata", "packer-plugin-amazon_v1.0.0.intoto.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		verifier    *Verifier
+		zipFilename string
+		zip         string
+		provenance  string
+		wantErr     error
+	}{
+		{
+			name:        "subject",
+			verifier:    &Verifier{},
+			zipFilename: zipFilename,
+			zip:         zipContent,
+		},
+		{
+			name:        "trusted-builder",
+			verifier:    &Verifier{BuilderID: builderID},
+			zipFilename: zipFilename,
+			zip:         zipContent,
+		},
+		{
+			name:        "untrusted-builder",
+			verifier:    &Verifier{BuilderID: "https://example.com/builder"},
+			zipFilename: zipFilename,
+			zip:         zipContent,
+			wantErr:     ErrUntrustedBuilder,
+		},
+		{
+			name:        "digest-mismatch",
+			verifier:    &Verifier{},
+			zipFilename: zipFilename,
+			zip:         "tampered",
+			wantErr:     ErrNoMatchingSubject,
+		},
+		{
+			name:        "not-a-subject",
+			verifier:    &Verifier{},
+			zipFilename: "packer-plugin-amazon_v1.0.0_x5.0_linux_arm64.zip",
+			zip:         zipContent,
+			wantErr:     ErrNoMatchingSubject,
+		},
+		{
+			name:        "plain-statement",
+			verifier:    &Verifier{BuilderID: "https://example.com/builder"},
+			zipFilename: zipFilename,
+			zip:         zipContent,
+			provenance: `{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://slsa.dev/provenance/v1",` +
+				`"subject":[{"name":"` + zipFilename + `","digest":{"sha256":"C585D16C276B45B767131248BFD9831A6F3BB33C4E395C63375E8A08F7804C74"}}],` +
+				`"predicate":{"runDetails":{"builder":{"id":"https://example.com/builder"}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := provenance
+			if tt.provenance != "" {
+				p = []byte(tt.provenance)
+			}
+			err := tt.verifier.VerifyProvenance(nil, tt.zipFilename, strings.NewReader(tt.zip), p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyProvenance() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifier_VerifyProvenance_notSLSA(t *testing.T) {
+	provenance := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://spdx.dev/Document","subject":[]}`
+	err := (&Verifier{}).VerifyProvenance(nil, zipFilename, strings.NewReader(zipContent), []byte(provenance))
+	if err == nil || !strings.Contains(err.Error(), "no SLSA provenance statement found") {
+		t.Errorf("VerifyProvenance() = %v, want a missing statement error", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoStoredChecksum is returned by Installation.StoredChecksum when the
+// binary has no checksum file.
+var ErrNoStoredChecksum = errors.New("no stored checksum")
+
+// MalformedChecksumError is returned by Installation.StoredChecksum when the
+// checksum file of a binary does not contain a valid checksum.
+type MalformedChecksumError struct {
+	// File is the path of the checksum file.
+	File string
+	Err  error
+}
+
+func (e *MalformedChecksumError) Error() string {
+	return fmt.Sprintf("malformed checksum file %q: %s", e.File, e.Err)
+}
+
+func (e *MalformedChecksumError) Unwrap() error { return e.Err }
+
+// StoredChecksum returns the checksum recorded in the _SHA256SUM file next to
+// the binary when it was installed, prefixed with its algorithm, ex:
+// "sha256:9f86d081884c7d65...". The binary itself is not read, use a
+// Checksummer to verify it matches.
+func (i *Installation) StoredChecksum() (string, error) {
+	checksummer := Checksummer{Type: "sha256", Hash: sha256.New()}
+	checksumFile := i.BinaryPath + checksummer.FileExt()
+
+	content, err := os.ReadFile(LongPath(checksumFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w for %s: %q does not exist", ErrNoStoredChecksum, i.BinaryPath, checksumFile)
+		}
+		return "", err
+	}
+
+	checksum := strings.ToLower(strings.TrimSpace(string(content)))
+	sum, err := hex.DecodeString(checksum)
+	if err != nil {
+		return "", &MalformedChecksumError{File: checksumFile, Err: err}
+	}
+	if len(sum) != checksummer.Hash.Size() {
+		return "", &MalformedChecksumError{
+			File: checksumFile,
+			Err:  fmt.Errorf("expected a %d bytes %s checksum, got %d bytes", checksummer.Hash.Size(), checksummer.Type, len(sum)),
+		}
+	}
+	return checksummer.Type + ":" + checksum, nil
+}
+
+// normalizeChecksum returns checksum, a sha256 checksum with or without its
+// "sha256:" prefix, formatted like StoredChecksum formats them.
+func normalizeChecksum(checksum string) (string, error) {
+	hexSum := strings.ToLower(strings.TrimSpace(checksum))
+	if algorithm, sum, found := strings.Cut(hexSum, ":"); found {
+		if algorithm != "sha256" {
+			return "", fmt.Errorf("invalid checksum %q: only sha256 checksums are stored", checksum)
+		}
+		hexSum = sum
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil || len(sum) != sha256.Size {
+		return "", fmt.Errorf("invalid checksum %q: expected a hex encoded sha256 checksum", checksum)
+	}
+	return "sha256:" + hexSum, nil
+}
+
+// hasChecksum tells whether checksum, as returned by normalizeChecksum, is
+// the stored checksum of the binary or the recorded checksum of its zip.
+func (i *Installation) hasChecksum(checksum string) bool {
+	if stored, err := i.StoredChecksum(); err == nil && stored == checksum {
+		return true
+	}
+	_, zipChecksum, err := readZipChecksum(i.BinaryPath)
+	return err == nil && "sha256:"+zipChecksum == checksum
+}
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallation_StoredChecksum(t *testing.T) {
+	const checksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	tests := []struct {
+		name          string
+		checksumFile  *string
+		want          string
+		wantNotFound  bool
+		wantMalformed bool
+	}{
+		{name: "valid", checksumFile: ptr(checksum), want: "sha256:" + checksum},
+		{name: "trailing-newline", checksumFile: ptr(checksum + "\n"), want: "sha256:" + checksum},
+		{name: "upper-case", checksumFile: ptr(strings.ToUpper(checksum)), want: "sha256:" + checksum},
+		{name: "missing", wantNotFound: true},
+		{name: "empty", checksumFile: ptr(""), wantMalformed: true},
+		{name: "not-hex", checksumFile: ptr(strings.Repeat("z", 64)), wantMalformed: true},
+		{name: "truncated", checksumFile: ptr(checksum[:32]), wantMalformed: true},
+		{name: "checksums-file-line", checksumFile: ptr(checksum + "  packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip"), wantMalformed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binary := filepath.Join(t.TempDir(), "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64")
+			if err := os.WriteFile(binary, []byte("binary"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.checksumFile != nil {
+				if err := os.WriteFile(binary+"_SHA256SUM", []byte(*tt.checksumFile), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := (&Installation{BinaryPath: binary, Version: "v1.0.0"}).StoredChecksum()
+
+			if errors.Is(err, ErrNoStoredChecksum) != tt.wantNotFound {
+				t.Errorf("StoredChecksum() error = %v, want ErrNoStoredChecksum: %t", err, tt.wantNotFound)
+			}
+			var malformed *MalformedChecksumError
+			if errors.As(err, &malformed) != tt.wantMalformed {
+				t.Errorf("StoredChecksum() error = %v, want a MalformedChecksumError: %t", err, tt.wantMalformed)
+			}
+			if malformed != nil && malformed.File != binary+"_SHA256SUM" {
+				t.Errorf("MalformedChecksumError.File = %q, want %q", malformed.File, binary+"_SHA256SUM")
+			}
+			if !tt.wantNotFound && !tt.wantMalformed && err != nil {
+				t.Fatalf("StoredChecksum: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StoredChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	// SignedChecksumFileExt is the suffix of the file storing, next to a
+	// binary installed with a SignatureVerifier, the raw checksum file of
+	// the release it was verified against, ex:
+	// packer-plugin-amazon_v1.2.3_x5.0_linux_amd64_SHA256SUMS.
+	SignedChecksumFileExt = "_SHA256SUMS"
+
+	// SignatureFileExt is appended to the stored checksum file to store its
+	// signature, ex:
+	// packer-plugin-amazon_v1.2.3_x5.0_linux_amd64_SHA256SUMS.sig.
+	SignatureFileExt = ".sig"
+)
+
+// ErrNoStoredSignature is returned by Installation.VerifyStoredSignature for
+// binaries installed without a SignatureVerifier.
+var ErrNoStoredSignature = errors.New("no stored signature")
+
+// storeSignature stores the signed checksum file the binary was verified
+// against next to binaryPath, and returns the path of the stored signature.
+// Nothing is stored, and an empty path is returned, when the checksum file
+// was not signed or cannot be stored.
+func storeSignature(binaryPath string, checksum *FileChecksum, signed *signedChecksumFile) string {
+	if signed == nil || checksum.Checksummer.Type != "sha256" {
+		return ""
+	}
+	checksumFile := binaryPath + SignedChecksumFileExt
+	if err := os.WriteFile(LongPath(checksumFile), signed.content, 0644); err != nil {
+		log.Printf("[WARNING] failed to store the signed checksum file of %s: %s", binaryPath, err)
+		return ""
+	}
+	if err := os.WriteFile(LongPath(checksumFile+SignatureFileExt), signed.signature, 0644); err != nil {
+		log.Printf("[WARNING] failed to store the signature of %s: %s", binaryPath, err)
+		return ""
+	}
+	return checksumFile + SignatureFileExt
+}
+
+// signatureFiles returns the paths of the signed checksum file and signature
+// stored next to binaryPath.
+func signatureFiles(binaryPath string) []string {
+	return []string{binaryPath + SignedChecksumFileExt, binaryPath + SignedChecksumFileExt + SignatureFileExt}
+}
+
+// VerifyStoredSignature verifies, offline, that the binary was installed from
+// a zip listed in the checksum file signed for its release: the stored
+// signature of the checksum file is verified with verifier, the recorded
+// checksum of the zip must be listed in it, and the binary must match its
+// stored checksum. Verification failures are IntegrityErrors.
+func (i *Installation) VerifyStoredSignature(pr *Requirement, verifier SignatureVerifier) error {
+	checksumFile, err := os.ReadFile(LongPath(i.BinaryPath + SignedChecksumFileExt))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w for %s", ErrNoStoredSignature, i.BinaryPath)
+	}
+	if err != nil {
+		return err
+	}
+	signature, err := os.ReadFile(LongPath(i.BinaryPath + SignedChecksumFileExt + SignatureFileExt))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w for %s", ErrNoStoredSignature, i.BinaryPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := verifier.VerifySignature(pr, checksumFile, signature); err != nil {
+		return &IntegrityError{Err: err}
+	}
+
+	zipFilename, zipChecksum, err := readZipChecksum(i.BinaryPath)
+	if err != nil {
+		return err
+	}
+	signed, err := signedEntries(checksumFile)
+	if err != nil {
+		return err
+	}
+	// the zip is named like the checksum file entry, maybe in another case.
+	listed := false
+	for entry := range signed {
+		if strings.EqualFold(entry, zipChecksum+" "+zipFilename) {
+			listed = true
+			break
+		}
+	}
+	if !listed {
+		return &IntegrityError{Err: fmt.Errorf("%s was installed from %s, which is not part of the signed checksum file", i.BinaryPath, zipFilename)}
+	}
+
+	checksummer := Checksummer{Type: "sha256", Hash: sha256.New()}
+	stored, err := checksummer.GetCacheChecksumOfFile(LongPath(i.BinaryPath))
+	if err != nil {
+		return err
+	}
+	if err := checksummer.ChecksumFile(stored, LongPath(i.BinaryPath)); err != nil {
+		return &IntegrityError{Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrUnsignedTag is matched by the errors of TagSignatureVerifiers when the
+// git tag of a release is not signed.
+var ErrUnsignedTag = errors.New("unsigned git tag")
+
+// TagSignature is the signature of the git tag of a release, as exposed by
+// the API of the forge hosting the sources of the plugin.
+type TagSignature struct {
+	// Tag is the name of the tag, ex: "v1.2.3".
+	Tag string
+	// Payload is the signed content of the tag object, and Signature its
+	// armored detached signature. Signature is empty for unsigned tags,
+	// including lightweight ones.
+	Payload   []byte
+	Signature []byte
+}
+
+// A TagSignatureGetter is a Getter that can also get the signature of the git
+// tag of a release, ex: from the API of the forge hosting the plugin sources.
+type TagSignatureGetter interface {
+	Getter
+	GetTagSignature(opts GetOptions) (*TagSignature, error)
+}
+
+// A TagSignatureVerifier verifies that the git tag of a release is signed by
+// a trusted key.
+//
+// When InstallOptions.TagSignatureVerifier is set, the tag of a version is
+// verified before the version is installed, with the first getter that is a
+// TagSignatureGetter. Getters that are not TagSignatureGetters cannot expose
+// tag signatures, so the check is skipped for the files they serve.
+type TagSignatureVerifier interface {
+	VerifyTagSignature(pr *Requirement, signature *TagSignature) error
+}
+
+// verifyTagSignature gets the signature of the tag of the release of getOpts
+// from getter and verifies it with opts.TagSignatureVerifier. It returns
+// false when getter cannot expose tag signatures.
+func (opts *InstallOptions) verifyTagSignature(getter Getter, getOpts GetOptions) (bool, error) {
+	tagGetter, ok := getter.(TagSignatureGetter)
+	if !ok {
+		log.Printf("[TRACE] %s cannot expose git tag signatures, not verifying the tag of %s %s", getterName(getter), getOpts.PluginRequirement.Identifier, getOpts.Version())
+		return false, nil
+	}
+	if err := opts.waitRateLimit("tag-signature"); err != nil {
+		return true, err
+	}
+	signature, err := tagGetter.GetTagSignature(getOpts)
+	if err != nil {
+		return true, fmt.Errorf("could not get the signature of the git tag: %w", err)
+	}
+	if err := opts.TagSignatureVerifier.VerifyTagSignature(getOpts.PluginRequirement, signature); err != nil {
+		return true, &IntegrityError{Err: err}
+	}
+	return true, nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// tagSignatureGetter is a mockPluginGetter exposing the signature of the tag
+// of its release.
+type tagSignatureGetter struct {
+	*mockPluginGetter
+	signature string
+	err       error
+	requests  int
+}
+
+func (g *tagSignatureGetter) GetTagSignature(opts GetOptions) (*TagSignature, error) {
+	g.requests++
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &TagSignature{Tag: opts.Version(), Payload: []byte("tag " + opts.Version()), Signature: []byte(g.signature)}, nil
+}
+
+// trustedTagVerifier trusts the tags signed with "trusted".
+type trustedTagVerifier struct{}
+
+func (trustedTagVerifier) VerifyTagSignature(pr *Requirement, signature *TagSignature) error {
+	switch string(signature.Signature) {
+	case "":
+		return fmt.Errorf("%w %s", ErrUnsignedTag, signature.Tag)
+	case "trusted":
+		return nil
+	default:
+		return fmt.Errorf("untrusted signature of %s", signature.Tag)
+	}
+}
+
+func TestRequirement_InstallLatest_tagSignature(t *testing.T) {
+	tests := []struct {
+		name          string
+		getters       func() []Getter
+		wantInstalled bool
+		wantIntegrity bool
+	}{
+		{
+			name: "signed tag",
+			getters: func() []Getter {
+				return []Getter{&tagSignatureGetter{mockPluginGetter: singleReleaseGetter("amazon"), signature: "trusted"}}
+			},
+			wantInstalled: true,
+		},
+		{
+			name: "unsigned tag",
+			getters: func() []Getter {
+				return []Getter{&tagSignatureGetter{mockPluginGetter: singleReleaseGetter("amazon")}}
+			},
+			wantIntegrity: true,
+		},
+		{
+			name: "untrusted signature",
+			getters: func() []Getter {
+				return []Getter{&tagSignatureGetter{mockPluginGetter: singleReleaseGetter("amazon"), signature: "someone else"}}
+			},
+			wantIntegrity: true,
+		},
+		{
+			name: "getter failing to get the signature",
+			getters: func() []Getter {
+				return []Getter{
+					&tagSignatureGetter{mockPluginGetter: singleReleaseGetter("amazon"), err: ErrReleaseFileNotFound},
+					&tagSignatureGetter{mockPluginGetter: singleReleaseGetter("amazon"), signature: "trusted"},
+				}
+			},
+			wantInstalled: true,
+		},
+		{
+			name: "getter not exposing tag signatures",
+			getters: func() []Getter {
+				return []Getter{singleReleaseGetter("amazon")}
+			},
+			wantInstalled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := dependenciesInstallOptions(nil, t.TempDir())
+			opts.Getters = tt.getters()
+			opts.TagSignatureVerifier = trustedTagVerifier{}
+
+			installed, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+			if tt.wantIntegrity {
+				var integrityErr *IntegrityError
+				if !errors.As(err, &integrityErr) {
+					t.Errorf("expected an IntegrityError, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("InstallLatest: %v", err)
+			}
+			if got := installed != nil; got != tt.wantInstalled {
+				t.Errorf("expected installed to be %t, got %#v", tt.wantInstalled, installed)
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLatest_tagSignatureVerifiedOnce(t *testing.T) {
+	getter := &tagSignatureGetter{mockPluginGetter: singleReleaseGetter("amazon"), signature: "trusted"}
+	// the checksum file of the first getter cannot be read, so that the
+	// release is installed from the second one.
+	getter.ChecksumFileEntries = nil
+	second := &tagSignatureGetter{mockPluginGetter: singleReleaseGetter("amazon"), signature: "trusted"}
+
+	opts := dependenciesInstallOptions(nil, t.TempDir())
+	opts.Getters = []Getter{getter, second}
+	opts.TagSignatureVerifier = trustedTagVerifier{}
+	if _, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts); err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	if getter.requests != 1 || second.requests != 0 {
+		t.Errorf("expected the tag to only be verified with the first getter, got %d and %d requests", getter.requests, second.requests)
+	}
+}
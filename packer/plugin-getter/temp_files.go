@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// DefaultTempFilePrefix is the prefix of the temporary files binaries are
+// written to, next to where they are installed, before being moved in place.
+const DefaultTempFilePrefix = ".packer-tmp-"
+
+// tempFilePattern returns the os.CreateTemp pattern of the temporary file
+// name is written to.
+func (opts *InstallOptions) tempFilePattern(name string) string {
+	prefix := opts.TempFilePrefix
+	if prefix == "" {
+		prefix = DefaultTempFilePrefix
+	}
+	return prefix + name + ".*"
+}
+
+// RemoveStaleTempFiles removes, from pluginDir and its sub-directories, the
+// files named with prefix that were last modified more than olderThan ago,
+// ex: the temporary files of installs that were interrupted. The paths of
+// the removed files are returned, even when some could not be removed.
+func RemoveStaleTempFiles(pluginDir, prefix string, olderThan time.Duration) ([]string, error) {
+	if prefix == "" {
+		prefix = DefaultTempFilePrefix
+	}
+	staleBefore := time.Now().Add(-olderThan)
+
+	var removed []string
+	var errs *multierror.Error
+	err := filepath.WalkDir(LongPath(pluginDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == LongPath(pluginDir) && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			errs = multierror.Append(errs, err)
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.HasPrefix(d.Name(), prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return nil
+		}
+		if info.ModTime().After(staleBefore) {
+			log.Printf("[TRACE] keeping %q, it may be in use by an install", path)
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = multierror.Append(errs, err)
+			return nil
+		}
+		removed = append(removed, path)
+		return nil
+	})
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	return removed, errs.ErrorOrNil()
+}
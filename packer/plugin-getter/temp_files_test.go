@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequirement_InstallLatest_tempFilePrefix(t *testing.T) {
+	const mainBinary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	content := elfHeader + strings.Repeat("x", 40*1024)
+
+	pluginDir := t.TempDir()
+	opts := dependenciesInstallOptions(manifestPluginGetter(map[string]string{mainBinary: content}), pluginDir)
+	opts.TempFilePrefix = ".my-tool-tmp-"
+	// slow enough to see the temporary file while the binary is extracted.
+	opts.MaxExtractRate = 100 * 1024
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+		done <- err
+	}()
+
+	outputFolder := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+	var tempFiles []string
+	for tempFiles == nil {
+		select {
+		case err := <-done:
+			t.Fatalf("the install finished, err: %v, before a temporary file with the prefix was seen", err)
+		case <-time.After(5 * time.Millisecond):
+		}
+		tempFiles, _ = filepath.Glob(filepath.Join(outputFolder, ".my-tool-tmp-"+mainBinary+".*"))
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputFolder, mainBinary)); err != nil {
+		t.Errorf("expected the binary to be installed: %v", err)
+	}
+	if _, err := os.Stat(tempFiles[0]); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file %q to be removed, stat returned: %v", tempFiles[0], err)
+	}
+}
+
+func TestRemoveStaleTempFiles(t *testing.T) {
+	pluginDir := t.TempDir()
+	outputFolder := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, age time.Duration) string {
+		path := filepath.Join(outputFolder, name)
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	stale := write(DefaultTempFilePrefix+"packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.123", 2*time.Hour)
+	inFlight := write(DefaultTempFilePrefix+"packer-plugin-amazon_v1.1.0_x5.0_linux_amd64.456", time.Minute)
+	otherTool := write(".my-tool-tmp-packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.789", 2*time.Hour)
+	binary := write("packer-plugin-amazon_v1.0.0_x5.0_linux_amd64", 2*time.Hour)
+
+	removed, err := RemoveStaleTempFiles(pluginDir, "", time.Hour)
+	if err != nil {
+		t.Fatalf("RemoveStaleTempFiles: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != stale {
+		t.Errorf("expected only %q to be removed, got %v", stale, removed)
+	}
+	for _, kept := range []string{inFlight, otherTool, binary} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("expected %q to be kept: %v", kept, err)
+		}
+	}
+
+	removed, err = RemoveStaleTempFiles(pluginDir, ".my-tool-tmp-", time.Hour)
+	if err != nil {
+		t.Fatalf("RemoveStaleTempFiles: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != otherTool {
+		t.Errorf("expected only %q to be removed, got %v", otherTool, removed)
+	}
+
+	if removed, err := RemoveStaleTempFiles(filepath.Join(pluginDir, "missing"), "", time.Hour); err != nil || len(removed) != 0 {
+		t.Errorf("expected nothing to be removed from a missing directory, got %v, %v", removed, err)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import "fmt"
+
+// An InstallTracer starts a span for every phase of an install, ex: to export
+// them as OpenTelemetry spans. Packer does not depend on a tracing library,
+// callers adapt their tracer to this interface.
+type InstallTracer interface {
+	// StartSpan starts the span of phase, the file requested to a getter:
+	// "releases", a checksummer type like "sha256", "zip" or "bundle".
+	StartSpan(phase string, attrs SpanAttributes) InstallSpan
+}
+
+// An InstallSpan is a started span of an install phase.
+type InstallSpan interface {
+	// End ends the span once the requested file was read and closed, or as
+	// soon as the request failed. bytes is the number of bytes read.
+	End(bytes int64, err error)
+}
+
+// SpanAttributes describes the request of an install phase.
+type SpanAttributes struct {
+	// Identifier is the source of the plugin, ex:
+	// github.com/hashicorp/amazon.
+	Identifier string
+	// Version is the version requested, empty when listing releases.
+	Version string
+	// Getter is the type of the getter requested, ex: *github.Getter.
+	Getter string
+}
+
+func spanAttributes(getter Getter, getOpts GetOptions) SpanAttributes {
+	attrs := SpanAttributes{Getter: fmt.Sprintf("%T", getter)}
+	if getOpts.PluginRequirement != nil {
+		attrs.Identifier = getOpts.PluginRequirement.Identifier.String()
+	}
+	if getOpts.version != nil {
+		attrs.Version = getOpts.Version()
+	}
+	return attrs
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type stubSpan struct {
+	Phase string
+	Attrs SpanAttributes
+	Bytes int64
+	Err   string
+	Ended bool
+}
+
+func (s *stubSpan) End(bytes int64, err error) {
+	s.Bytes, s.Ended = bytes, true
+	if err != nil {
+		s.Err = err.Error()
+	}
+}
+
+type stubTracer struct {
+	spans []*stubSpan
+}
+
+func (t *stubTracer) StartSpan(phase string, attrs SpanAttributes) InstallSpan {
+	span := &stubSpan{Phase: phase, Attrs: attrs}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func TestRequirement_InstallLatest_tracer(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader + "v1.0.0"})
+	zipContent, err := io.ReadAll(zip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releases := []Release{{Version: "v1.0.0"}}
+	checksums := []ChecksumFileEntry{{Filename: binary + ".zip", Checksum: checksum}}
+	failing := &failingPluginGetter{Err: fmt.Errorf("mirror is down")}
+	working := &mockPluginGetter{
+		Releases:            releases,
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{"1.0.0": checksums},
+		Zips: map[string]io.ReadCloser{
+			"github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip": io.NopCloser(bytes.NewReader(zipContent)),
+		},
+	}
+
+	tracer := &stubTracer{}
+	opts := dependenciesInstallOptions(working, t.TempDir())
+	opts.Getters = []Getter{failing, working}
+	opts.Tracer = tracer
+	if _, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts); err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+
+	// the mock getter json encodes its responses, with a trailing new line.
+	encodedLen := func(v interface{}) int64 {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int64(len(b) + 1)
+	}
+	failingAttrs := SpanAttributes{Identifier: "github.com/hashicorp/amazon", Getter: "*plugingetter.failingPluginGetter"}
+	workingAttrs := SpanAttributes{Identifier: "github.com/hashicorp/amazon", Getter: "*plugingetter.mockPluginGetter"}
+	want := []*stubSpan{
+		{Phase: "releases", Attrs: failingAttrs, Err: "mirror is down", Ended: true},
+		{Phase: "releases", Attrs: workingAttrs, Bytes: encodedLen(releases), Ended: true},
+	}
+	failingAttrs.Version, workingAttrs.Version = "v1.0.0", "v1.0.0"
+	want = append(want,
+		&stubSpan{Phase: "sha256", Attrs: failingAttrs, Err: "mirror is down", Ended: true},
+		&stubSpan{Phase: "sha256", Attrs: workingAttrs, Bytes: encodedLen(checksums), Ended: true},
+		&stubSpan{Phase: "zip", Attrs: failingAttrs, Err: "mirror is down", Ended: true},
+		&stubSpan{Phase: "zip", Attrs: workingAttrs, Bytes: int64(len(zipContent)), Ended: true},
+	)
+	if diff := cmp.Diff(want, tracer.spans); diff != "" {
+		t.Errorf("unexpected spans: %s", diff)
+	}
+}
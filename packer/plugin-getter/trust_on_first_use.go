@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pinFilename is the file the checksum of a zip installed with
+// InstallOptions.TrustOnFirstUse is pinned in, ex:
+// packer-plugin-amazon_v1.2.3_x5.0_linux_amd64.zip_SHA256SUM.pin
+func pinFilename(outputFolder, zipFilename string) string {
+	return filepath.Join(outputFolder, zipFilename+"_SHA256SUM.pin")
+}
+
+// PinFile returns the file the checksum of the zip binaryPath was installed
+// from is pinned in, when it was installed with InstallOptions.TrustOnFirstUse,
+// or "" when the zip is not known. Pins are removed with the binary, so that
+// reinstalling a removed plugin pins its checksum again.
+func PinFile(binaryPath string) string {
+	zipFilename, _, err := readZipChecksum(binaryPath)
+	if err != nil {
+		return ""
+	}
+	pin := pinFilename(filepath.Dir(binaryPath), zipFilename)
+	if _, err := os.Stat(LongPath(pin)); err != nil {
+		return ""
+	}
+	return pin
+}
+
+// trustsOnFirstUse reports whether releases without a checksum file of the
+// checksummer type can be installed.
+func (opts *InstallOptions) trustsOnFirstUse(checksummer Checksummer) bool {
+	return opts.TrustOnFirstUse && opts.SignatureVerifier == nil && checksummer.Type == "sha256"
+}
+
+// trustOnFirstUseChecksumFile returns the checksum file, in the json format
+// of ChecksumFileEntry, of a release that has none: it lists the zip of the
+// platform of getOpts, with its pinned checksum if it was installed before
+// or no checksum otherwise.
+func trustOnFirstUseChecksumFile(getter Getter, getOpts GetOptions, outputFolder string) (io.ReadCloser, error) {
+	entry := ChecksumFileEntry{Filename: getOpts.RenderAssetName(assetNames(getter).Zip)}
+	pin, err := os.ReadFile(pinFilename(outputFolder, entry.Filename))
+	switch {
+	case err == nil:
+		entry.Checksum = strings.TrimSpace(string(pin))
+		if entry.Checksum == "" {
+			return nil, fmt.Errorf("the pinned checksum of %s is empty", entry.Filename)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read the pinned checksum of %s: %w", entry.Filename, err)
+	}
+
+	content, err := json.Marshal([]ChecksumFileEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequirement_InstallLatest_trustOnFirstUse(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	// unsummedGetter returns a getter for a release without checksum file,
+	// whose binary contains content.
+	unsummedGetter := func(content string) (*mockPluginGetter, string) {
+		zip, checksum := zipFileWithChecksum(map[string]string{binary: elfHeader + content})
+		return &mockPluginGetter{
+			Releases: []Release{{Version: "v1.0.0"}},
+			Zips: map[string]io.ReadCloser{
+				"github.com/hashicorp/packer-plugin-amazon/" + binary + ".zip": zip,
+			},
+		}, checksum
+	}
+
+	pluginDir := t.TempDir()
+	outputFolder := filepath.Join(pluginDir, "github.com", "hashicorp", "amazon")
+	pinFile := filepath.Join(outputFolder, binary+".zip_SHA256SUM.pin")
+	install := func(getter Getter, edit func(*InstallOptions)) error {
+		opts := dependenciesInstallOptions(getter, pluginDir)
+		opts.TrustOnFirstUse = true
+		opts.Force = true
+		if edit != nil {
+			edit(&opts)
+		}
+		_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+		return err
+	}
+
+	getter, _ := unsummedGetter("amazon")
+	if err := install(getter, func(opts *InstallOptions) { opts.TrustOnFirstUse = false }); err == nil {
+		t.Fatalf("expected releases without checksum file not to be installed by default")
+	}
+	getter, _ = unsummedGetter("amazon")
+	if err := install(getter, func(opts *InstallOptions) { opts.SignatureVerifier = signatureVerifier{} }); err == nil {
+		t.Fatalf("expected releases without checksum file not to be installed when signatures are verified")
+	}
+
+	// the checksum file exists but could not be got.
+	getter, _ = unsummedGetter("amazon")
+	if err := install(&checksumErrorGetter{getter, errors.New("502 Bad Gateway")}, nil); err == nil {
+		t.Fatalf("expected releases whose checksum file could not be got not to be installed")
+	}
+	if _, err := os.Stat(pinFile); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be pinned, stat returned %v", err)
+	}
+
+	// first install pins the checksum of the zip.
+	getter, checksum := unsummedGetter("amazon")
+	if err := install(getter, nil); err != nil {
+		t.Fatalf("InstallLatest: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputFolder, binary)); err != nil {
+		t.Fatalf("expected the plugin to be installed: %v", err)
+	}
+	pin, err := os.ReadFile(pinFile)
+	if err != nil {
+		t.Fatalf("expected the checksum to be pinned: %v", err)
+	}
+	if string(pin) != checksum {
+		t.Errorf("pinned %q, expected the zip checksum %q", pin, checksum)
+	}
+
+	// reinstalling the same zip is verified against the pin.
+	getter, _ = unsummedGetter("amazon")
+	if err := install(getter, nil); err != nil {
+		t.Fatalf("InstallLatest of the same zip: %v", err)
+	}
+
+	// the zip changed for the same version.
+	getter, _ = unsummedGetter("tampered")
+	err = install(getter, nil)
+	if err == nil || !strings.Contains(err.Error(), "pinned on first use") {
+		t.Fatalf("expected a pinned checksum mismatch, got %v", err)
+	}
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Errorf("expected an *IntegrityError, got %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputFolder, binary))
+	if err != nil || strings.Contains(string(content), "tampered") {
+		t.Errorf("expected the installed binary to be kept, got %q, %v", content, err)
+	}
+
+	// removing the binary removes its pin.
+	if err := RemoveInstallation(&Installation{BinaryPath: filepath.Join(outputFolder, binary)}, nil); err != nil {
+		t.Fatalf("RemoveInstallation: %v", err)
+	}
+	if _, err := os.Stat(pinFile); !os.IsNotExist(err) {
+		t.Errorf("expected the pin to be removed, stat returned %v", err)
+	}
+}
+
+// checksumErrorGetter fails to get checksum files with err.
+type checksumErrorGetter struct {
+	*mockPluginGetter
+	err error
+}
+
+func (g *checksumErrorGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	if what == "sha256" {
+		return nil, g.err
+	}
+	return g.mockPluginGetter.Get(what, opts)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// FindUnreferenced returns the installations of installs whose version
+// satisfies none of the requirements of reqs for their plugin, ex: the
+// versions left behind once required_plugins were bumped. They are
+// candidates for cleanup. The installations of the plugins reqs do not
+// require at all are unreferenced too.
+//
+// Installations are matched to the requirements of their plugin by binary
+// path, in the nested and FlatLayout plugin directories. Pre-releases, ex:
+// v1.2.0-dev binaries installed with --path, only satisfy constraints
+// naming a pre-release, like other versions. Installations whose version
+// cannot be parsed are not reported, as what they are is unknown.
+func FindUnreferenced(installs InstallList, reqs []*Requirement) InstallList {
+	var res InstallList
+	for _, install := range installs {
+		v, err := version.NewVersion(install.Version)
+		if err != nil {
+			log.Printf("[TRACE] ignoring %s, its version %q is invalid: %s", install.BinaryPath, install.Version, err)
+			continue
+		}
+		referenced := false
+		for _, pr := range reqs {
+			if pr.Identifier != nil && pr.installs(install) && pr.VersionConstraints.Check(v) {
+				referenced = true
+				break
+			}
+		}
+		if !referenced {
+			res = append(res, install)
+		}
+	}
+	return res
+}
+
+// installs tells whether install is a binary of the plugin of pr.
+func (pr *Requirement) installs(install *Installation) bool {
+	binaryPath := strings.ToLower(filepath.ToSlash(install.BinaryPath))
+	dir, base := path.Dir(binaryPath), path.Base(binaryPath)
+	filenamePrefix := strings.ToLower(pr.FilenamePrefix())
+
+	source := strings.ToLower(pr.Identifier.String())
+	if strings.HasSuffix("/"+dir, "/"+source) && strings.HasPrefix(base, filenamePrefix) {
+		return true
+	}
+	flatPrefix := strings.ToLower(pr.installedNamePrefix(BinaryInstallationOptions{FlatLayout: true}))
+	return strings.HasPrefix(base, flatPrefix+filenamePrefix)
+}
+
+// RemoveInstallation removes the binary of install, along with its checksum
+// files, the checksum of the zip it was installed from and its stored
+// signature, and the checksum of that zip pinned on first use. Either all of
+// them or none of them are removed.
+func RemoveInstallation(install *Installation, checksummers []Checksummer) error {
+	return removeFilesAtomically(append([]string{install.BinaryPath}, sidecarFiles(install.BinaryPath, checksummers)...))
+}
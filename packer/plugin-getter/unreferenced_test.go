@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindUnreferenced(t *testing.T) {
+	install := func(path, version string) *Installation {
+		return &Installation{BinaryPath: path, Version: version}
+	}
+	installs := InstallList{
+		install("/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.0.0_x5.0_linux_amd64", "v1.0.0"),
+		install("/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.2.0_x5.0_linux_amd64", "v1.2.0"),
+		install("/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.3.0-dev_x5.0_linux_amd64", "v1.3.0-dev"),
+		install("/plugins/github.com/hashicorp/docker/packer-plugin-docker_v1.0.0_x5.0_linux_amd64", "v1.0.0"),
+		install("/plugins/github.com/hashicorp/docker/packer-plugin-docker_v2.0.0_x5.0_linux_amd64", "v2.0.0"),
+		install("/plugins/github.com/hashicorp/ansible/packer-plugin-ansible_v1.0.0_x5.0_linux_amd64", "v1.0.0"),
+		install("/plugins/github.com_hashicorp_packer-plugin-qemu_v1.1.0_x5.0_linux_amd64", "v1.1.0"),
+		install("/plugins/github.com_hashicorp_packer-plugin-qemu_v0.9.0_x5.0_linux_amd64", "v0.9.0"),
+		install("/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_unknown", "unknown"),
+	}
+	reqs := []*Requirement{
+		mustRequirement(t, "github.com/hashicorp/amazon", ">= 1.1.0"),
+		mustRequirement(t, "github.com/hashicorp/docker", "~> 1.0"),
+		mustRequirement(t, "github.com/hashicorp/docker", ">= 2.0.0"),
+		mustRequirement(t, "github.com/hashicorp/qemu", ">= 1.0.0"),
+		// matches no installation.
+		mustRequirement(t, "github.com/hashicorp/azure", ""),
+	}
+
+	var got []string
+	for _, install := range FindUnreferenced(installs, reqs) {
+		got = append(got, install.BinaryPath)
+	}
+	want := []string{
+		// below the constraint.
+		"/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.0.0_x5.0_linux_amd64",
+		// pre-releases only match constraints naming one.
+		"/plugins/github.com/hashicorp/amazon/packer-plugin-amazon_v1.3.0-dev_x5.0_linux_amd64",
+		// not required at all.
+		"/plugins/github.com/hashicorp/ansible/packer-plugin-ansible_v1.0.0_x5.0_linux_amd64",
+		"/plugins/github.com_hashicorp_packer-plugin-qemu_v0.9.0_x5.0_linux_amd64",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindUnreferenced() = %v, want %v", got, want)
+	}
+
+	if got := FindUnreferenced(installs[:2], nil); len(got) != 2 {
+		t.Errorf("expected every installation to be unreferenced without requirements, got %v", got)
+	}
+}
+
+func TestRemoveInstallation(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64")
+	other := filepath.Join(dir, "packer-plugin-amazon_v1.2.0_x5.0_linux_amd64")
+	files := []string{binary, binary + "_SHA256SUM", binary + ZipChecksumFileExt, other, other + "_SHA256SUM"}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("content"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := RemoveInstallation(&Installation{BinaryPath: binary, Version: "v1.0.0"}, []Checksummer{{Type: "sha256"}})
+	if err != nil {
+		t.Fatalf("RemoveInstallation: %v", err)
+	}
+	for _, file := range files[:3] {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, stat returned %v", file, err)
+		}
+	}
+	for _, file := range files[3:] {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected %s to be kept: %v", file, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"archive/zip"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A ZipURLer is a Getter that can tell the URL it downloads the zip of
+// GetOptions from. During an InstallAll, a zip is downloaded and verified once
+// per URL and checksum, then installed for every requirement resolving to it,
+// ex: a plugin and its alias served by the same mirror.
+//
+// For getters that are not ZipURLers, the zip filename stands for the URL.
+type ZipURLer interface {
+	Getter
+	ZipURL(opts GetOptions) (string, error)
+}
+
+// verifiedZipKey identifies a zip by where it is downloaded from and its
+// expected checksum.
+type verifiedZipKey struct {
+	source   string
+	checksum string
+}
+
+// verifiedZipCache holds the paths of the zips downloaded and verified during
+// an InstallAll.
+type verifiedZipCache map[verifiedZipKey]string
+
+// verifiedZipKey returns the key of the zip of zipGetOpts got from getter,
+// and false when it is not to be cached: outside of InstallAll, or when the
+// zip is trusted on first use and has no expected checksum.
+func (opts *InstallOptions) verifiedZipKey(getter Getter, zipGetOpts GetOptions, checksum *FileChecksum) (verifiedZipKey, bool) {
+	if opts.verifiedZips == nil || checksum.Expected == nil {
+		return verifiedZipKey{}, false
+	}
+	source := fmt.Sprintf("%T %s", getter, zipGetOpts.ExpectedZipFilename())
+	if urler, ok := getter.(ZipURLer); ok {
+		u, err := urler.ZipURL(zipGetOpts)
+		if err != nil {
+			log.Printf("[TRACE] not reusing %s: %s", zipGetOpts.ExpectedZipFilename(), err)
+			return verifiedZipKey{}, false
+		}
+		source = u
+	}
+	return verifiedZipKey{
+		source:   source,
+		checksum: fmt.Sprintf("%s:%x", checksum.Checksummer.Type, []byte(checksum.Expected)),
+	}, true
+}
+
+// installVerifiedZip installs the binaries of the zip at zipPath, downloaded
+// and verified for another requirement.
+func (opts *InstallOptions) installVerifiedZip(zipPath string, checksum *FileChecksum, outputFolder, namePrefix, expectedBinaryFilename, binaryEntry string) ([]string, error) {
+	zipFile, err := os.Open(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen %s: %w", checksum.Filename, err)
+	}
+	defer zipFile.Close()
+	return opts.installZip(zipFile, checksum, outputFolder, namePrefix, expectedBinaryFilename, binaryEntry)
+}
+
+// installZip extracts the binaries of the verified zipFile in outputFolder,
+// their names prefixed with namePrefix, and returns the paths of the ones that
+// are not expectedBinaryFilename. binaryEntry is the name of the plugin
+// binary in the zip, see zipBinaries.
+func (opts *InstallOptions) installZip(zipFile *os.File, checksum *FileChecksum, outputFolder, namePrefix, expectedBinaryFilename, binaryEntry string) ([]string, error) {
+	binaries, err := opts.readZipBinaries(zipFile, checksum, expectedBinaryFilename, binaryEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	var otherBinaries []string
+	for _, binary := range binaries {
+		isMain := binary.Name == expectedBinaryFilename
+		if namePrefix != "" {
+			binary = renamedZipFile(binary, namePrefix+binary.Name)
+		}
+		if err := opts.extractBinary(binary, outputFolder, checksum.Checksummer); err != nil {
+			return nil, fmt.Errorf("%s: %w", checksum.Filename, err)
+		}
+		if !isMain {
+			otherBinaries = append(otherBinaries, strings.ReplaceAll(filepath.Join(outputFolder, binary.Name), "\\", "/"))
+		}
+	}
+	return otherBinaries, nil
+}
+
+// readZipBinaries returns the binaries of zipFile to install, see
+// zipBinaries, and fails when there is none.
+func (opts *InstallOptions) readZipBinaries(zipFile *os.File, checksum *FileChecksum, expectedBinaryFilename, binaryEntry string) ([]*zip.File, error) {
+	zipFileStat, err := zipFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat: %w", err)
+	}
+
+	zr, err := zip.NewReader(zipFile, zipFileStat.Size())
+	if err != nil {
+		return nil, fmt.Errorf("zip : %v", err)
+	}
+
+	binaries, err := zipBinaries(zr, expectedBinaryFilename, binaryEntry, opts.BinaryInstallationOptions)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", checksum.Filename, err)
+	}
+	if len(binaries) == 0 {
+		return nil, fmt.Errorf("could not find a %s file in zipfile", checksum.Filename)
+	}
+	return binaries, nil
+}
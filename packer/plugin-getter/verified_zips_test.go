@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mirrorGetter serves the releases of the amazon plugin for any namespace,
+// from a single URL per zip.
+type mirrorGetter struct {
+	*mockPluginGetter
+	canonical *Requirement
+	zipGets   int
+}
+
+func (g *mirrorGetter) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	if what == "zip" {
+		g.zipGets++
+	}
+	opts.PluginRequirement = g.canonical
+	return g.mockPluginGetter.Get(what, opts)
+}
+
+func (g *mirrorGetter) ZipURL(opts GetOptions) (string, error) {
+	return "https://mirror.example/amazon/" + opts.ExpectedZipFilename(), nil
+}
+
+func TestRequirements_InstallAll_sameZip(t *testing.T) {
+	getter := &mirrorGetter{
+		mockPluginGetter: singleReleaseGetter("amazon"),
+		canonical:        mustRequirement(t, "github.com/hashicorp/amazon", ""),
+	}
+	pluginDir := t.TempDir()
+
+	// the zip of the mock getter can only be read once.
+	installs, err := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+		mustRequirement(t, "github.com/fork/amazon", ""),
+	}.InstallAll(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+	if getter.zipGets != 1 {
+		t.Errorf("expected the zip to be downloaded once, got %d downloads", getter.zipGets)
+	}
+	if len(installs) != 2 {
+		t.Fatalf("expected 2 installations, got %d", len(installs))
+	}
+
+	binary := "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	for _, namespace := range []string{"hashicorp", "fork"} {
+		binaryPath := filepath.Join(pluginDir, "github.com", namespace, "amazon", binary)
+		content, err := os.ReadFile(binaryPath)
+		if err != nil {
+			t.Fatalf("expected %s to be installed: %v", binaryPath, err)
+		}
+		if string(content) != elfHeader+"amazon" {
+			t.Errorf("unexpected content of %s: %q", binaryPath, content)
+		}
+		if _, err := os.Stat(binaryPath + "_SHA256SUM"); err != nil {
+			t.Errorf("expected the checksum of %s to be installed: %v", binaryPath, err)
+		}
+	}
+}
+
+func TestRequirements_InstallAll_differentZipURLs(t *testing.T) {
+	pluginDir := t.TempDir()
+	getter := multiPluginGetter{
+		"github.com/hashicorp/amazon": singleReleaseGetter("amazon"),
+		"github.com/hashicorp/docker": singleReleaseGetter("docker"),
+	}
+
+	installs, err := Requirements{
+		mustRequirement(t, "github.com/hashicorp/amazon", ""),
+		mustRequirement(t, "github.com/hashicorp/docker", ""),
+	}.InstallAll(dependenciesInstallOptions(getter, pluginDir))
+	if err != nil {
+		t.Fatalf("InstallAll: %v", err)
+	}
+	for _, install := range installs {
+		content, err := os.ReadFile(install.BinaryPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := elfHeader + filepath.Base(filepath.Dir(install.BinaryPath)); string(content) != want {
+			t.Errorf("unexpected content of %s: %q", install.BinaryPath, content)
+		}
+	}
+}
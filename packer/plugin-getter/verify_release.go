@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// PlatformVerification is the outcome of verifying the release zip of a
+// platform.
+type PlatformVerification struct {
+	Platform Platform
+
+	// Zip is the name of the zip of Platform, empty when the checksum file
+	// lists none.
+	Zip string
+
+	// Err tells why the zip could not be verified, nil when it matches its
+	// checksum. Checksum mismatches are IntegrityErrors, zips the checksum
+	// file does not list match ErrReleaseFileNotFound.
+	Err error
+}
+
+// OK tells whether the zip of the platform matches its checksum.
+func (v PlatformVerification) OK() bool { return v.Err == nil }
+
+// VerifyRelease downloads the zip of every platform of version v of pr and
+// verifies it against the checksum file of the release, ex: for the
+// maintainers of a mirror to make sure it serves every zip unaltered. A
+// verification is returned per platform, in order; the returned error is
+// only about getting the checksum file.
+//
+// The checksum file is got from the first getter of opts that has it, the
+// zips are downloaded with the same getter.
+func (pr *Requirement) VerifyRelease(v *version.Version, platforms []Platform, opts InstallOptions) ([]PlatformVerification, error) {
+	release, err := pr.releaseChecksumFile(v, opts)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PlatformVerification, 0, len(platforms))
+	for _, platform := range platforms {
+		res = append(res, opts.verifyPlatform(release, platform))
+	}
+	return res, nil
+}
+
+// verifyPlatform downloads the zip of platform and verifies it against its
+// entry in the checksum file of release.
+func (opts *InstallOptions) verifyPlatform(release *releaseChecksumFile, platform Platform) PlatformVerification {
+	res := PlatformVerification{Platform: platform}
+	entry, platformOpts, ok := opts.platformEntry(release, platform)
+	if !ok {
+		res.Err = fmt.Errorf("%w: the checksum file lists no %s zip", ErrReleaseFileNotFound, platform)
+		return res
+	}
+	res.Zip = entry.zipName
+
+	checksummer := release.checksummer
+	expected, err := checksummer.ParseChecksum(strings.NewReader(entry.Checksum))
+	if err != nil {
+		res.Err = fmt.Errorf("could not parse the %s checksum of %s: %w", checksummer.Type, res.Zip, err)
+		return res
+	}
+	platformOpts.expectedZipChecksum = &FileChecksum{
+		Filename:    entry.Filename,
+		Expected:    expected,
+		Checksummer: checksummer,
+	}
+
+	zip, err := opts.get(release.getter, "zip", platformOpts)
+	if err != nil {
+		res.Err = fmt.Errorf("could not get %s: %w", res.Zip, err)
+		return res
+	}
+	defer zip.Close()
+	if err := checksummer.Checksum(expected, zip); err != nil {
+		var checksumErr *ChecksumError
+		if errors.As(err, &checksumErr) {
+			checksumErr.File = res.Zip
+			err = &IntegrityError{Err: err}
+		}
+		res.Err = fmt.Errorf("could not verify %s: %w", res.Zip, err)
+	}
+	return res
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestRequirement_VerifyRelease(t *testing.T) {
+	getter := &mockPluginGetter{
+		ChecksumFileEntries: map[string][]ChecksumFileEntry{"1.0.0": {}},
+		Zips:                map[string]io.ReadCloser{},
+	}
+	for _, platform := range []string{"linux_amd64", "darwin_arm64", "windows_amd64"} {
+		zipName := "packer-plugin-amazon_v1.0.0_x5.0_" + platform + ".zip"
+		zip, checksum := zipFileWithChecksum(map[string]string{"packer-plugin-amazon_v1.0.0_x5.0_" + platform: elfHeader})
+		if platform == "darwin_arm64" {
+			zip = zipFile(map[string]string{"packer-plugin-amazon_v1.0.0_x5.0_" + platform: elfHeader + "tampered"})
+		}
+		getter.ChecksumFileEntries["1.0.0"] = append(getter.ChecksumFileEntries["1.0.0"], ChecksumFileEntry{Filename: zipName, Checksum: checksum})
+		getter.Zips["github.com/hashicorp/packer-plugin-amazon/"+zipName] = zip
+	}
+
+	platforms := []Platform{
+		{OS: "linux", ARCH: "amd64"},
+		{OS: "darwin", ARCH: "arm64"},
+		{OS: "windows", ARCH: "amd64"},
+		{OS: "freebsd", ARCH: "amd64"},
+	}
+	opts := dependenciesInstallOptions(getter, t.TempDir())
+	verifications, err := mustRequirement(t, "github.com/hashicorp/amazon", "").VerifyRelease(version.Must(version.NewVersion("1.0.0")), platforms, opts)
+	if err != nil {
+		t.Fatalf("VerifyRelease: %v", err)
+	}
+	if len(verifications) != len(platforms) {
+		t.Fatalf("expected a verification per platform, got %v", verifications)
+	}
+
+	for i, want := range []struct {
+		zip          string
+		ok           bool
+		integrityErr bool
+		notFound     bool
+	}{
+		{zip: "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64.zip", ok: true},
+		{zip: "packer-plugin-amazon_v1.0.0_x5.0_darwin_arm64.zip", integrityErr: true},
+		{zip: "packer-plugin-amazon_v1.0.0_x5.0_windows_amd64.zip", ok: true},
+		{notFound: true},
+	} {
+		got := verifications[i]
+		if got.Platform != platforms[i] || got.Zip != want.zip || got.OK() != want.ok {
+			t.Errorf("%s: got %+v, want zip %q and OK %t", platforms[i], got, want.zip, want.ok)
+		}
+		var integrityErr *IntegrityError
+		if errors.As(got.Err, &integrityErr) != want.integrityErr {
+			t.Errorf("%s: expected an IntegrityError: %t, got %v", platforms[i], want.integrityErr, got.Err)
+		}
+		if errors.Is(got.Err, ErrReleaseFileNotFound) != want.notFound {
+			t.Errorf("%s: expected ErrReleaseFileNotFound: %t, got %v", platforms[i], want.notFound, got.Err)
+		}
+	}
+}
+
+func TestRequirement_VerifyRelease_noChecksumFile(t *testing.T) {
+	getter := &mockPluginGetter{ChecksumFileEntries: map[string][]ChecksumFileEntry{}}
+	opts := dependenciesInstallOptions(getter, t.TempDir())
+	_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").VerifyRelease(version.Must(version.NewVersion("1.0.0")), []Platform{{OS: "linux", ARCH: "amd64"}}, opts)
+	if err == nil {
+		t.Fatal("VerifyRelease: expected an error without a checksum file")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrUnexpectedContentType is returned when a getter serves something else
+// than a zip, ex: the HTML login page of a server that did not accept the
+// credentials of the getter.
+var ErrUnexpectedContentType = errors.New("unexpected content type")
+
+var zipMagics = [][]byte{
+	{'P', 'K', 0x03, 0x04}, // local file header
+	{'P', 'K', 0x05, 0x06}, // end of central directory, for an empty zip
+}
+
+// checkZipContent reads the first bytes of f, the downloaded zip name, to make
+// sure it is not a text page, ex: HTML, served in place of the zip before it
+// is checksummed. Other binary content is left for the checksum to reject.
+// f is rewound.
+func checkZipContent(f io.ReadSeeker, name string) error {
+	// http.DetectContentType looks at up to 512 bytes.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	head = head[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind %s: %w", name, err)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	for _, magic := range zipMagics {
+		if bytes.HasPrefix(head, magic) {
+			return nil
+		}
+	}
+	if contentType := http.DetectContentType(head); strings.HasPrefix(contentType, "text/") {
+		return UnexpectedContentTypeError(name, contentType)
+	}
+	return nil
+}
+
+// UnexpectedContentTypeError returns an ErrUnexpectedContentType for the zip
+// name, that was served as contentType, with a hint about authentication.
+func UnexpectedContentTypeError(name, contentType string) error {
+	return fmt.Errorf("%w: got %q instead of the %s zip. "+
+		"The server may have answered with a login or error page, make sure the getter is authenticated", ErrUnexpectedContentType, contentType, name)
+}
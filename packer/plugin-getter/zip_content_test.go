@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequirement_InstallLatest_htmlZip(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+	getter := singleReleaseGetter("amazon")
+	for name := range getter.Zips {
+		getter.Zips[name] = io.NopCloser(strings.NewReader(`<!DOCTYPE html><html><head><title>Sign in</title></head><body><form action="/login"></form></body></html>`))
+	}
+
+	pluginDir := t.TempDir()
+	_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(dependenciesInstallOptions(getter, pluginDir))
+	if !errors.Is(err, ErrUnexpectedContentType) {
+		t.Fatalf("expected an ErrUnexpectedContentType, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "make sure the getter is authenticated") {
+		t.Errorf("expected an authentication hint, got %v", err)
+	}
+	var integrityErr *IntegrityError
+	if errors.As(err, &integrityErr) {
+		t.Errorf("expected no checksum error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary)); !os.IsNotExist(err) {
+		t.Errorf("expected the plugin not to be installed, stat returned %v", err)
+	}
+}
+
+func TestCheckZipContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"zip", "PK\x03\x04rest of the zip", false},
+		{"empty-zip", "PK\x05\x06", false},
+		{"html", "<html><body>Sign in</body></html>", true},
+		{"text", "Unauthorized", true},
+		{"binary", "\x00\x01\x02\x03", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := strings.NewReader(tt.content)
+			err := checkZipContent(f, "plugin.zip")
+			if gotErr := errors.Is(err, ErrUnexpectedContentType); gotErr != tt.wantErr {
+				t.Fatalf("checkZipContent() = %v, want an error: %t", err, tt.wantErr)
+			}
+			if rest, _ := io.ReadAll(f); string(rest) != tt.content {
+				t.Errorf("expected the content to be rewound, read %q", rest)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const xorKey = 0x5a
+
+// xorReader "decrypts" a zip by xoring every byte with xorKey.
+type xorReader struct {
+	io.ReadCloser
+}
+
+func (r xorReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	for i := range p[:n] {
+		p[i] ^= xorKey
+	}
+	return n, err
+}
+
+func TestRequirement_InstallLatest_zipTransform(t *testing.T) {
+	const binary = "packer-plugin-amazon_v1.0.0_x5.0_linux_amd64"
+
+	tests := []struct {
+		name      string
+		transform func(io.ReadCloser) (io.ReadCloser, error)
+		wantErr   string
+	}{
+		{
+			name: "decrypt",
+			transform: func(body io.ReadCloser) (io.ReadCloser, error) {
+				return xorReader{body}, nil
+			},
+		},
+		{
+			name:    "no-transform",
+			wantErr: "did not match",
+		},
+		{
+			name: "transform-error",
+			transform: func(body io.ReadCloser) (io.ReadCloser, error) {
+				return nil, errors.New("unknown envelope")
+			},
+			wantErr: "could not transform " + binary + ".zip: unknown envelope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := singleReleaseGetter("amazon")
+			for name, zip := range getter.Zips {
+				encrypted, err := io.ReadAll(xorReader{zip})
+				if err != nil {
+					t.Fatal(err)
+				}
+				getter.Zips[name] = io.NopCloser(bytes.NewReader(encrypted))
+			}
+
+			pluginDir := t.TempDir()
+			opts := dependenciesInstallOptions(getter, pluginDir)
+			opts.ZipTransform = tt.transform
+			_, err := mustRequirement(t, "github.com/hashicorp/amazon", "").InstallLatest(opts)
+
+			_, statErr := os.Stat(filepath.Join(pluginDir, "github.com", "hashicorp", "amazon", binary))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("InstallLatest: %v", err)
+				}
+				if statErr != nil {
+					t.Errorf("expected the plugin to be installed: %v", statErr)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if !os.IsNotExist(statErr) {
+				t.Errorf("expected the plugin not to be installed, stat returned %v", statErr)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	"github.com/hashicorp/packer/hcl2template/addrs"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 )
 
@@ -35,6 +36,127 @@ type PluginConfig struct {
 	PostProcessors  PostProcessorSet
 	DataSources     DatasourceSet
 	ReleasesOnly    bool
+
+	// FromFolders are other directories plugins are listed from, after
+	// PluginDirectory, ex: the next entries of PACKER_PLUGIN_PATH.
+	FromFolders []string
+
+	// Getters configures how plugins are downloaded.
+	Getters PluginGettersConfig
+
+	// NamespaceHostnames sets the hostname of "namespace/name" plugin
+	// sources.
+	NamespaceHostnames addrs.NamespaceHostnames
+
+	// Provenance configures the verification of downloaded plugins against
+	// the provenance attestation of their release.
+	Provenance PluginProvenanceConfig
+
+	// Sigstore configures the verification of the keyless signatures of
+	// downloaded plugins.
+	Sigstore PluginSigstoreConfig
+
+	// TagSignature configures the verification of the signatures of the git
+	// tags of plugin releases.
+	TagSignature PluginTagSignatureConfig
+}
+
+// PluginGettersConfig is the "plugin_getters" section of the Packer config
+// file.
+type PluginGettersConfig struct {
+	GitHub           GitHubGetterConfig           `json:"github"`
+	ContentAddressed ContentAddressedGetterConfig `json:"content_addressed"`
+	// MaxRetries is how many times the requests of every getter that failed
+	// transiently are retried, with an exponential backoff. Zero does not
+	// retry.
+	MaxRetries int `json:"max_retries"`
+}
+
+// ContentAddressedGetterConfig configures the experimental download of
+// plugin zips from IPFS, by their sha256 checksum. Releases and checksum
+// files are still got from GitHub.
+type ContentAddressedGetterConfig struct {
+	Enabled bool `json:"enabled"`
+	// IPFSGatewayURL is the gateway zips are got from, by default the one
+	// of a local node: "http://127.0.0.1:8080/".
+	IPFSGatewayURL string `json:"ipfs_gateway_url"`
+}
+
+// PluginProvenanceConfig is the "plugin_provenance" section of the Packer
+// config file.
+type PluginProvenanceConfig struct {
+	// Enabled makes installs fail for plugins without a SLSA provenance
+	// attestation listing the downloaded zip.
+	Enabled bool `json:"enabled"`
+	// BuilderID, when set, is the only builder trusted to build plugins.
+	BuilderID string `json:"builder_id"`
+}
+
+// PluginSigstoreConfig is the "plugin_sigstore" section of the Packer config
+// file.
+type PluginSigstoreConfig struct {
+	// Enabled makes installs fail for plugins without a Sigstore bundle
+	// proving Identity signed the downloaded zip.
+	Enabled bool `json:"enabled"`
+	// Identity is the trusted signer, ex: the URI of a release workflow, and
+	// Issuer the OIDC issuer that authenticated it.
+	Identity string `json:"identity"`
+	Issuer   string `json:"issuer"`
+	// FulcioRootsFile and RekorPublicKeyFile are the PEM files of the
+	// certificate authority and transparency log that are trusted.
+	FulcioRootsFile    string `json:"fulcio_roots_file"`
+	RekorPublicKeyFile string `json:"rekor_public_key_file"`
+}
+
+// PluginTagSignatureConfig is the "plugin_tag_signature" section of the
+// Packer config file.
+type PluginTagSignatureConfig struct {
+	// Enabled makes installs fail for plugin releases whose git tag is not
+	// signed by the trusted PGP key.
+	Enabled bool `json:"enabled"`
+	// PublicKeyFile is the armored public key that is trusted. When empty,
+	// the key with Fingerprint is fetched from KeyServer.
+	PublicKeyFile string `json:"public_key_file"`
+	KeyServer     string `json:"key_server"`
+	Fingerprint   string `json:"fingerprint"`
+	// TrustedFingerprints are the fingerprints of other trusted keys, ex:
+	// during a key rotation. Tags signed by any other key are rejected.
+	TrustedFingerprints []string `json:"trusted_fingerprints"`
+}
+
+// GitHubGetterConfig configures the GitHub plugin getter. Env vars take
+// precedence over these settings.
+type GitHubGetterConfig struct {
+	Token           string `json:"token"`
+	APIBaseURL      string `json:"api_base_url"`
+	DownloadBaseURL string `json:"download_base_url"`
+	Proxy           string `json:"proxy"`
+	CACertFile      string `json:"ca_cert_file"`
+	UserAgent       string `json:"user_agent"`
+	MaxReleases     int    `json:"max_releases"`
+
+	// ZipAssetTemplate and ChecksumAssetTemplate are the templates of the
+	// names of release files, ex: "{prefix}{version}_{os}_{arch}.zip".
+	ZipAssetTemplate      string `json:"zip_asset_template"`
+	ChecksumAssetTemplate string `json:"checksum_asset_template"`
+	// BinaryAssetTemplate is the template of the name of the binary in the
+	// zips, when it is not named like installed binaries.
+	BinaryAssetTemplate string `json:"binary_asset_template"`
+
+	DisableHTTP2        bool `json:"disable_http2"`
+	MaxIdleConns        int  `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int  `json:"max_idle_conns_per_host"`
+	// IdleConnTimeout is a duration, ex: "90s".
+	IdleConnTimeout string `json:"idle_conn_timeout"`
+
+	// MetadataTimeout and DownloadTimeout are durations, ex: "30s", bounding
+	// the requests of release metadata and of zips respectively.
+	MetadataTimeout string `json:"metadata_timeout"`
+	DownloadTimeout string `json:"download_timeout"`
+
+	// TLSMinVersion is "1.2", the default, or "1.3".
+	TLSMinVersion   string   `json:"tls_min_version"`
+	TLSCipherSuites []string `json:"tls_cipher_suites"`
 }
 
 // PACKERSPACE is used to represent the spaces that separate args for a command
@@ -71,11 +193,14 @@ func (c *PluginConfig) Discover() error {
 	}
 
 	if c.PluginDirectory == "" {
-		c.PluginDirectory, _ = PluginFolder()
+		if folders, err := PluginFolders(); err == nil {
+			c.PluginDirectory, c.FromFolders = folders[0], folders[1:]
+		}
 	}
 
 	installations, err := plugingetter.Requirement{}.ListInstallations(plugingetter.ListInstallationsOptions{
 		PluginDirectory: c.PluginDirectory,
+		FromFolders:     c.FromFolders,
 		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
 			OS:              runtime.GOOS,
 			ARCH:            runtime.GOARCH,
@@ -7,21 +7,46 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/pathing"
 )
 
 // PluginFolder returns the known plugin folder based on system.
 func PluginFolder() (string, error) {
-	if packerPluginPath := os.Getenv("PACKER_PLUGIN_PATH"); packerPluginPath != "" {
-		return packerPluginPath, nil
+	folders, err := PluginFolders()
+	if err != nil {
+		return "", err
+	}
+	return folders[0], nil
+}
+
+// PluginFolders returns the known plugin folders based on system, the
+// preferred one first. PACKER_PLUGIN_PATH may list several folders,
+// separated like PATH is: by colons, or semicolons on Windows. Plugins are
+// installed in the first one.
+func PluginFolders() ([]string, error) {
+	if folders := splitPluginPath(os.Getenv("PACKER_PLUGIN_PATH"), os.PathListSeparator); len(folders) > 0 {
+		return folders, nil
 	}
 
 	cd, err := pathing.ConfigDir()
 	if err != nil {
 		log.Printf("[ERR] Error loading config directory: %v", err)
-		return "", err
+		return nil, err
 	}
 
-	return filepath.Join(cd, "plugins"), nil
+	return []string{filepath.Join(cd, "plugins")}, nil
+}
+
+// splitPluginPath splits the entries of a PACKER_PLUGIN_PATH, ignoring the
+// empty ones.
+func splitPluginPath(pluginPath string, separator rune) []string {
+	var folders []string
+	for _, folder := range strings.Split(pluginPath, string(separator)) {
+		if folder != "" {
+			folders = append(folders, folder)
+		}
+	}
+	return folders
 }
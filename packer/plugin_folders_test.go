@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitPluginPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		pluginPath string
+		separator  rune
+		want       []string
+	}{
+		{"unix", "/home/packer/plugins:/opt/packer/plugins", ':', []string{"/home/packer/plugins", "/opt/packer/plugins"}},
+		{"unix-empty-entries", ":/home/packer/plugins::/opt/packer/plugins:", ':', []string{"/home/packer/plugins", "/opt/packer/plugins"}},
+		{"windows", `C:\Users\packer\plugins;D:\packer\plugins`, ';', []string{`C:\Users\packer\plugins`, `D:\packer\plugins`}},
+		{"single", "/home/packer/plugins", ':', []string{"/home/packer/plugins"}},
+		{"empty", "", ':', nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, splitPluginPath(tt.pluginPath, tt.separator)); diff != "" {
+				t.Errorf("unexpected folders: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPluginFolders(t *testing.T) {
+	t.Setenv("PACKER_PLUGIN_PATH", "first"+string(os.PathListSeparator)+"second")
+
+	folders, err := PluginFolders()
+	if err != nil {
+		t.Fatalf("PluginFolders: %v", err)
+	}
+	if diff := cmp.Diff([]string{"first", "second"}, folders); diff != "" {
+		t.Errorf("unexpected folders: %s", diff)
+	}
+	folder, err := PluginFolder()
+	if err != nil {
+		t.Fatalf("PluginFolder: %v", err)
+	}
+	if folder != "first" {
+		t.Errorf("PluginFolder() = %q, want the first entry", folder)
+	}
+}
@@ -255,3 +255,31 @@ func (u *TimestampedUi) TrackProgress(src string, currentSize, totalSize int64,
 func (u *TimestampedUi) timestampLine(string string) string {
 	return fmt.Sprintf("%v: %v", time.Now().Format(time.RFC3339), string)
 }
+
+// QuietUi is a UI that wraps another UI implementation and drops
+// informational messages and progress bars, keeping errors
+type QuietUi struct {
+	Ui packersdk.Ui
+}
+
+var _ packersdk.Ui = new(QuietUi)
+
+func (u *QuietUi) Ask(query string) (string, error) {
+	return u.Ui.Ask(query)
+}
+
+func (u *QuietUi) Say(message string) {}
+
+func (u *QuietUi) Message(message string) {}
+
+func (u *QuietUi) Error(message string) {
+	u.Ui.Error(message)
+}
+
+func (u *QuietUi) Machine(message string, args ...string) {
+	u.Ui.Machine(message, args...)
+}
+
+func (u *QuietUi) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) (body io.ReadCloser) {
+	return stream
+}